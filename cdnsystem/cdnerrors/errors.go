@@ -17,6 +17,8 @@
 package cdnerrors
 
 import (
+	"fmt"
+
 	"github.com/pkg/errors"
 )
 
@@ -64,6 +66,24 @@ var (
 	ErrRangeNotSatisfiable = errors.New("range not satisfiable")
 )
 
+// SourceError represents the source responds with an unexpected status code.
+type SourceError struct {
+	StatusCode int
+	Status     string
+}
+
+// NewSourceError creates a SourceError with the status code and status text responded by source.
+func NewSourceError(statusCode int, status string) *SourceError {
+	return &SourceError{
+		StatusCode: statusCode,
+		Status:     status,
+	}
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("source responds unexpected status: %s", e.Status)
+}
+
 // IsSystemError checks the error is a system error or not.
 func IsSystemError(err error) bool {
 	return errors.Cause(err) == ErrSystemError
@@ -122,3 +142,16 @@ func IsRangeNotSatisfiable(err error) bool {
 func IsFileNotExist(err error) bool {
 	return errors.Cause(err) == ErrFileNotExist
 }
+
+// IsSourceError checks the error is caused by source, the source is not reachable or responds with an unexpected status.
+func IsSourceError(err error) bool {
+	return IsURLNotReachable(err) || GetSourceStatusCode(err) != 0
+}
+
+// GetSourceStatusCode returns the status code responded by source, it is 0 when the error is not a SourceError.
+func GetSourceStatusCode(err error) int {
+	if e, ok := errors.Cause(err).(*SourceError); ok {
+		return e.StatusCode
+	}
+	return 0
+}
@@ -533,3 +533,44 @@ func (s *ErrorTestSuite) TestIsURLNotReachable() {
 		})
 	}
 }
+
+func (s *ErrorTestSuite) TestIsSourceError() {
+	type args struct {
+		err error
+	}
+	tests := []struct {
+		name       string
+		args       args
+		want       bool
+		statusCode int
+	}{
+		{
+			name: "notReachable",
+			args: args{
+				err: errors.Wrapf(ErrURLNotReachable, "wrap err"),
+			},
+			want:       true,
+			statusCode: 0,
+		}, {
+			name: "status",
+			args: args{
+				err: errors.Wrapf(errors.Wrapf(NewSourceError(404, "404 Not Found"), "wrap err"), "wapp err"),
+			},
+			want:       true,
+			statusCode: 404,
+		}, {
+			name: "notEqual",
+			args: args{
+				err: errors.Wrapf(ErrInvalidValue, "invaid"),
+			},
+			want:       false,
+			statusCode: 0,
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.Equal(tt.want, IsSourceError(tt.args.err))
+			s.Equal(tt.statusCode, GetSourceStatusCode(tt.args.err))
+		})
+	}
+}
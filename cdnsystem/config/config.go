@@ -21,6 +21,8 @@ import (
 	"io/ioutil"
 	"time"
 
+	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+	"d7y.io/dragonfly/v2/pkg/piecesize"
 	"d7y.io/dragonfly/v2/pkg/unit"
 	"d7y.io/dragonfly/v2/pkg/util/net/iputils"
 	"gopkg.in/yaml.v3"
@@ -102,10 +104,14 @@ func NewDefaultBaseProperties() *BaseProperties {
 	return &BaseProperties{
 		ListenPort:              DefaultListenPort,
 		DownloadPort:            DefaultDownloadPort,
+		AdminIP:                 DefaultAdminIP,
 		SystemReservedBandwidth: DefaultSystemReservedBandwidth,
 		MaxBandwidth:            DefaultMaxBandwidth,
+		UploadLimit:             DefaultUploadLimit,
+		PerPeerUploadLimit:      DefaultPerPeerUploadLimit,
 		EnableProfiler:          DefaultEnableProfiler,
 		FailAccessInterval:      DefaultFailAccessInterval,
+		FailAccessMaxInterval:   DefaultFailAccessMaxInterval,
 		GCInitialDelay:          DefaultGCInitialDelay,
 		GCMetaInterval:          DefaultGCMetaInterval,
 		GCStorageInterval:       DefaultGCStorageInterval,
@@ -113,6 +119,7 @@ func NewDefaultBaseProperties() *BaseProperties {
 		StoragePattern:          DefaultStoragePattern,
 		Console:                 DefaultConsole,
 		AdvertiseIP:             iputils.HostIp,
+		PieceSizePolicy:         piecesize.NewDefaultPolicy(),
 	}
 }
 
@@ -127,6 +134,19 @@ type BaseProperties struct {
 	// default: 8001
 	DownloadPort int `yaml:"downloadPort"`
 
+	// AdminPort is the port of the admin api to inspect, purge and pin the cached tasks.
+	// The admin api is disabled when it is 0.
+	// default: 0
+	AdminPort int `yaml:"adminPort"`
+
+	// AdminIP is the ip the admin api listens on.
+	// AdminToken must be set when it is not a loopback address.
+	// default: 127.0.0.1
+	AdminIP string `yaml:"adminIP"`
+
+	// AdminToken is the bearer token required by the admin api, the token is not checked when it is empty.
+	AdminToken string `yaml:"adminToken"`
+
 	// SystemReservedBandwidth is the network bandwidth reserved for system software.
 	// default: 20 MB, in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will also be parsed as Byte.
 	SystemReservedBandwidth unit.Bytes `yaml:"systemReservedBandwidth"`
@@ -135,6 +155,14 @@ type BaseProperties struct {
 	// default: 200 MB, in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will also be parsed as Byte.
 	MaxBandwidth unit.Bytes `yaml:"maxBandwidth"`
 
+	// UploadLimit is the total rate limit of serving pieces to peers on DownloadPort, which is shared fairly among tasks.
+	// default: 1 GB, in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will also be parsed as Byte.
+	UploadLimit unit.Bytes `yaml:"uploadLimit"`
+
+	// PerPeerUploadLimit is the rate limit of serving pieces to a single peer on DownloadPort.
+	// default: 100 MB, in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will also be parsed as Byte.
+	PerPeerUploadLimit unit.Bytes `yaml:"perPeerUploadLimit"`
+
 	// Whether to enable profiler
 	// default: false
 	EnableProfiler bool `yaml:"enableProfiler"`
@@ -148,6 +176,12 @@ type BaseProperties struct {
 	// default: 3
 	FailAccessInterval time.Duration `yaml:"failAccessInterval"`
 
+	// FailAccessMaxInterval is the max interval time after failed to access the URL,
+	// the interval doubles from FailAccessInterval every time the source of a task fails again.
+	// Source errors are not cached when FailAccessInterval is 0.
+	// default: 30m
+	FailAccessMaxInterval time.Duration `yaml:"failAccessMaxInterval"`
+
 	// gc related
 	// GCInitialDelay is the delay time from the start to the first GC execution.
 	// default: 6s
@@ -171,4 +205,22 @@ type BaseProperties struct {
 
 	// Console shows log on console
 	Console bool `yaml:"console"`
+
+	// PieceSizePolicy decides the piece size of tasks, which is shared with the client.
+	PieceSizePolicy *piecesize.Policy `yaml:"pieceSizePolicy"`
+
+	// PieceCompression is the algorithm compressing pieces before storing, [zstd/gzip], empty means disabled.
+	// A piece is stored as plain when it can not be compressed smaller.
+	// default: ""
+	PieceCompression string `yaml:"pieceCompression"`
+
+	// ParentCDNs are the rpc addresses of the parent cdns, tasks are seeded from them instead of the origin.
+	// The origin is only accessed when no parent cdn can serve the task.
+	// default: empty, every task is downloaded from the origin
+	ParentCDNs []dfnet.NetAddr `yaml:"parentCDNs"`
+
+	// NeverExpireURLs are the regular expressions of urls whose content never changes, such as content-addressed urls.
+	// The cache of a matched url is never checked with the source for expiry.
+	// default: empty
+	NeverExpireURLs []string `yaml:"neverExpireURLs"`
 }
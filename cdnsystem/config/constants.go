@@ -39,6 +39,8 @@ const (
 	DefaultListenPort = 8003
 	// DefaultDownloadPort is the default port for download files from cdn.
 	DefaultDownloadPort = 8001
+	// DefaultAdminIP is the default ip the admin api listens on, only local callers can access it.
+	DefaultAdminIP = "127.0.0.1"
 )
 
 const (
@@ -59,6 +61,9 @@ const (
 const (
 	// DefaultFailAccessInterval is the interval time after failed to access the URL.
 	DefaultFailAccessInterval = 3 * time.Minute
+
+	// DefaultFailAccessMaxInterval is the max interval time after failed to access the URL.
+	DefaultFailAccessMaxInterval = 30 * time.Minute
 )
 
 // gc
@@ -83,4 +88,12 @@ const (
 	// DefaultMaxBandwidth is the default network bandwidth that cdn can use.
 	// unit: MB/s
 	DefaultMaxBandwidth = 1 * unit.GB
+
+	// DefaultUploadLimit is the default total rate limit of serving pieces to peers.
+	// unit: MB/s
+	DefaultUploadLimit = 1 * unit.GB
+
+	// DefaultPerPeerUploadLimit is the default rate limit of serving pieces to a single peer.
+	// unit: MB/s
+	DefaultPerPeerUploadLimit = 100 * unit.MB
 )
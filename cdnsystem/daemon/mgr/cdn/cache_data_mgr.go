@@ -52,11 +52,19 @@ func (mm *cacheDataManager) writeFileMetaDataByTask(ctx context.Context, task *t
 	metaData := &storage.FileMetaData{
 		TaskId:          task.TaskId,
 		TaskURL:         task.TaskUrl,
+		URL:             task.Url,
+		Filter:          task.Filter,
+		Digest:          task.RequestDigest,
 		PieceSize:       task.PieceSize,
 		SourceFileLen:   task.SourceFileLength,
 		AccessTime:      getCurrentTimeMillisFunc(),
 		CdnFileLength:   task.CdnFileLength,
 		TotalPieceCount: task.PieceTotal,
+		HitCount:        1,
+	}
+	// keep the task pinned when its cache is rebuilt
+	if originMetaData, err := mm.storage.ReadFileMetaData(ctx, task.TaskId); err == nil && originMetaData != nil {
+		metaData.Pinned = originMetaData.Pinned
 	}
 
 	if err := mm.storage.WriteFileMetaData(ctx, task.TaskId, metaData); err != nil {
@@ -66,7 +74,7 @@ func (mm *cacheDataManager) writeFileMetaDataByTask(ctx context.Context, task *t
 	return metaData, nil
 }
 
-// updateAccessTime update access and interval
+// updateAccessTime update access, interval and hits
 func (mm *cacheDataManager) updateAccessTime(ctx context.Context, taskId string, accessTime int64) error {
 	mm.cacheLocker.Lock(taskId, false)
 	defer mm.cacheLocker.UnLock(taskId, false)
@@ -79,11 +87,29 @@ func (mm *cacheDataManager) updateAccessTime(ctx context.Context, taskId string,
 	interval := accessTime - originMetaData.AccessTime
 	originMetaData.Interval = interval
 	if interval <= 0 {
-		logger.WithTaskID(taskId).Warnf("file hit interval:%d, accessTime:%d", interval, time.Unix(accessTime/1000, accessTime%1000))
+		logger.WithTaskID(taskId).Warnf("file hit interval:%d, accessTime:%s", interval, time.Unix(0, accessTime*int64(time.Millisecond)))
 		originMetaData.Interval = 0
 	}
 
 	originMetaData.AccessTime = accessTime
+	originMetaData.HitCount++
+
+	return mm.storage.WriteFileMetaData(ctx, taskId, originMetaData)
+}
+
+// updatePinned pins or unpins the cache of task from gc
+func (mm *cacheDataManager) updatePinned(ctx context.Context, taskId string, pinned bool) error {
+	mm.cacheLocker.Lock(taskId, false)
+	defer mm.cacheLocker.UnLock(taskId, false)
+
+	originMetaData, err := mm.readFileMetaData(ctx, taskId)
+	if err != nil {
+		return err
+	}
+	if originMetaData.Pinned == pinned {
+		return nil
+	}
+	originMetaData.Pinned = pinned
 
 	return mm.storage.WriteFileMetaData(ctx, taskId, originMetaData)
 }
@@ -125,6 +151,9 @@ func (mm *cacheDataManager) updateStatusAndResult(ctx context.Context, taskId st
 		if !stringutils.IsBlank(metaData.PieceMd5Sign) {
 			originMetaData.PieceMd5Sign = metaData.PieceMd5Sign
 		}
+		if !stringutils.IsBlank(metaData.SourceRealDigest) {
+			originMetaData.SourceRealDigest = metaData.SourceRealDigest
+		}
 	}
 	return mm.storage.WriteFileMetaData(ctx, taskId, originMetaData)
 }
@@ -24,9 +24,16 @@ import (
 	"d7y.io/dragonfly/v2/cdnsystem/source"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/structure/maputils"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"d7y.io/dragonfly/v2/pkg/util/stringutils"
 	"fmt"
 	"github.com/pkg/errors"
 	"hash"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"regexp"
 	"sort"
 )
 
@@ -34,6 +41,8 @@ import (
 type cacheDetector struct {
 	cacheDataManager *cacheDataManager
 	resourceClient   source.ResourceClient
+	// neverExpireURLs are the urls whose cache is never checked with the source for expiry
+	neverExpireURLs []*regexp.Regexp
 }
 
 // cacheResult cache result of detect
@@ -42,19 +51,29 @@ type cacheResult struct {
 	pieceMetaRecords []*storage.PieceMetaRecord // piece meta data records of task
 	fileMetaData     *storage.FileMetaData      // file meta data of task
 	fileMd5          hash.Hash                  // md5 of file content that has been downloaded
+	fileDigest       hash.Hash                  // digest of file content that has been downloaded, nil if task has no digest
 }
 
 func (s *cacheResult) String() string {
 	return fmt.Sprintf("{breakNum:%d, pieceMetaRecords:%+v, fileMetaData:%+v, "+
-		"fileMd5:%v}", s.breakPoint, s.pieceMetaRecords, s.fileMetaData, s.fileMd5)
+		"fileMd5:%v, fileDigest:%v}", s.breakPoint, s.pieceMetaRecords, s.fileMetaData, s.fileMd5, s.fileDigest)
 }
 
 // newCacheDetector create a new cache detector
-func newCacheDetector(cacheDataManager *cacheDataManager, resourceClient source.ResourceClient) *cacheDetector {
-	return &cacheDetector{
+func newCacheDetector(cacheDataManager *cacheDataManager, resourceClient source.ResourceClient,
+	neverExpireURLs []string) (*cacheDetector, error) {
+	cd := &cacheDetector{
 		cacheDataManager: cacheDataManager,
 		resourceClient:   resourceClient,
 	}
+	for _, pattern := range neverExpireURLs {
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid never expire url pattern %q", pattern)
+		}
+		cd.neverExpireURLs = append(cd.neverExpireURLs, r)
+	}
+	return cd, nil
 }
 
 func (cd *cacheDetector) detectCache(ctx context.Context, task *types.SeedTask) (*cacheResult, error) {
@@ -90,7 +109,7 @@ func (cd *cacheDetector) doDetect(ctx context.Context, task *types.SeedTask) (re
 	if err := checkSameFile(task, fileMetaData); err != nil {
 		return nil, errors.Wrapf(err, "task does not match meta information of task file")
 	}
-	expired, err := cd.resourceClient.IsExpired(task.Url, task.Header, fileMetaData.ExpireInfo)
+	expired, err := cd.isExpired(ctx, task, fileMetaData)
 	if err != nil {
 		// 如果获取失败，则认为没有过期，防止打爆源
 		logger.WithTaskID(task.TaskId).Errorf("failed to check if the task expired: %v", err)
@@ -117,6 +136,27 @@ func (cd *cacheDetector) doDetect(ctx context.Context, task *types.SeedTask) (re
 	return cd.parseByReadFile(ctx, task.TaskId, fileMetaData)
 }
 
+// isExpired checks the cache of task with the source unless its url never expires,
+// the expire info renewed by the source is stored again.
+func (cd *cacheDetector) isExpired(ctx context.Context, task *types.SeedTask, fileMetaData *storage.FileMetaData) (bool, error) {
+	for _, r := range cd.neverExpireURLs {
+		if r.MatchString(task.Url) || r.MatchString(task.TaskUrl) {
+			return false, nil
+		}
+	}
+	expireInfo := maputils.DeepCopyMap(nil, fileMetaData.ExpireInfo)
+	expired, err := cd.resourceClient.IsExpired(task.Url, task.Header, expireInfo)
+	if err != nil || expired || reflect.DeepEqual(expireInfo, maputils.DeepCopyMap(nil, fileMetaData.ExpireInfo)) {
+		return expired, err
+	}
+	if err := cd.cacheDataManager.updateExpireInfo(ctx, task.TaskId, expireInfo); err != nil {
+		logger.WithTaskID(task.TaskId).Warnf("failed to update expire info: %v", err)
+	} else {
+		fileMetaData.ExpireInfo = expireInfo
+	}
+	return false, nil
+}
+
 // parseByReadMetaFile detect cache by read meta and pieceMeta files of task
 func (cd *cacheDetector) parseByReadMetaFile(ctx context.Context, taskId string,
 	fileMetaData *storage.FileMetaData) (*cacheResult, error) {
@@ -161,19 +201,41 @@ func (cd *cacheDetector) parseByReadFile(ctx context.Context, taskId string, met
 	}
 
 	fileMd5 := md5.New()
+	var (
+		fileDigest hash.Hash
+		fileHash   io.Writer = fileMd5
+	)
+	if !stringutils.IsBlank(metaData.Digest) {
+		algorithm, _, err := digestutils.Parse(metaData.Digest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse digest of task")
+		}
+		fileDigest, _ = digestutils.NewHash(algorithm)
+		fileHash = io.MultiWriter(fileMd5, fileDigest)
+	}
 	// sort piece meta records by pieceNum
 	sort.Slice(tempRecords, func(i, j int) bool {
 		return tempRecords[i].PieceNum < tempRecords[j].PieceNum
 	})
 
 	var breakPoint uint64 = 0
+	// readOffset is the offset of reader in the data file
+	var readOffset uint64 = 0
 	pieceMetaRecords := make([]*storage.PieceMetaRecord, 0, 0)
 	for index := range tempRecords {
 		if int32(index) != tempRecords[index].PieceNum {
 			break
 		}
+		// skip the hole left by the compressed pieces
+		if gap := int64(tempRecords[index].Range.StartIndex) - int64(readOffset); gap > 0 {
+			if _, err := io.CopyN(ioutil.Discard, reader, gap); err != nil {
+				logger.WithTaskID(taskId).Errorf("skip content before pieceNum %d failed: %v", tempRecords[index].PieceNum, err)
+				break
+			}
+		}
+		readOffset = tempRecords[index].Range.StartIndex + uint64(tempRecords[index].PieceLen)
 		// read content
-		if err := checkPieceContent(reader, tempRecords[index], fileMd5); err != nil {
+		if err := checkPieceContent(reader, tempRecords[index], fileHash); err != nil {
 			logger.WithTaskID(taskId).Errorf("read content of pieceNum %d failed: %v", tempRecords[index].PieceNum, err)
 			break
 		}
@@ -200,6 +262,7 @@ func (cd *cacheDetector) parseByReadFile(ctx context.Context, taskId string, met
 		pieceMetaRecords: pieceMetaRecords,
 		fileMetaData:     metaData,
 		fileMd5:          fileMd5,
+		fileDigest:       fileDigest,
 	}, nil
 }
 
@@ -17,16 +17,17 @@
 package cdn
 
 import (
+	"bytes"
 	"crypto/md5"
 	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"d7y.io/dragonfly/v2/pkg/util/compressutils"
 	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/ifaceutils"
 	"d7y.io/dragonfly/v2/pkg/util/stringutils"
 	"encoding/binary"
 	"github.com/pkg/errors"
-	"hash"
 	"io"
 )
 
@@ -45,7 +46,17 @@ func checkSameFile(task *types.SeedTask, metaData *storage.FileMetaData) error {
 		return errors.Errorf("meta task TaskId(%s) is not equals with task TaskId(%s)", metaData.TaskId, task.TaskId)
 	}
 
-	if metaData.TaskURL != task.TaskUrl {
+	// content addressed task is shared by urls with the same digest
+	if !stringutils.IsBlank(task.RequestDigest) {
+		if metaData.Digest != task.RequestDigest {
+			return errors.Errorf("meta task digest(%s) is not equals with task request digest(%s)", metaData.Digest,
+				task.RequestDigest)
+		}
+		if !stringutils.IsBlank(metaData.SourceRealDigest) && metaData.SourceRealDigest != task.RequestDigest {
+			return errors.Errorf("meta task source digest(%s) is not equals with task request digest(%s)",
+				metaData.SourceRealDigest, task.RequestDigest)
+		}
+	} else if metaData.TaskURL != task.TaskUrl {
 		return errors.Errorf("meta task taskUrl(%s) is not equals with task taskUrl(%s)", metaData.TaskURL, task.Url)
 	}
 	if !stringutils.IsBlank(metaData.SourceRealMd5) && !stringutils.IsBlank(task.RequestMd5) &&
@@ -57,7 +68,29 @@ func checkSameFile(task *types.SeedTask, metaData *storage.FileMetaData) error {
 }
 
 //checkPieceContent read piece content from reader and check data integrity by pieceMetaRecord
-func checkPieceContent(reader io.Reader, pieceRecord *storage.PieceMetaRecord, fileMd5 hash.Hash) error {
+func checkPieceContent(reader io.Reader, pieceRecord *storage.PieceMetaRecord, fileMd5 io.Writer) error {
+	// the file md5 is calculated with the origin content, buffer the compressed content and decompress it later
+	var compressedContent *bytes.Buffer
+	if pieceRecord.PieceStyle != types.PlainUnspecified && !ifaceutils.IsNil(fileMd5) {
+		compressedContent = bytes.NewBuffer(make([]byte, 0, pieceRecord.PieceLen))
+		if err := checkPieceContent(io.TeeReader(reader, compressedContent), &storage.PieceMetaRecord{
+			PieceNum:   pieceRecord.PieceNum,
+			PieceLen:   pieceRecord.PieceLen,
+			Md5:        pieceRecord.Md5,
+			PieceStyle: types.PlainUnspecified,
+		}, nil); err != nil {
+			return err
+		}
+		r, err := compressutils.NewReader(pieceRecord.PieceStyle.ToPieceStyle(), compressedContent)
+		if err != nil {
+			return errors.Wrapf(err, "decompress piece content error")
+		}
+		defer r.Close()
+		if _, err := io.Copy(fileMd5, r); err != nil {
+			return errors.Wrapf(err, "decompress piece content error")
+		}
+		return nil
+	}
 	bufSize := int32(256 * 1024)
 	pieceLen := pieceRecord.PieceLen
 	if pieceLen >0 && pieceLen < bufSize {
@@ -21,6 +21,7 @@ import (
 	"context"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	"github.com/pkg/errors"
 	"io"
 	"sync"
@@ -44,12 +45,15 @@ type downloadMetadata struct {
 type cacheWriter struct {
 	cdnReporter      *reporter
 	cacheDataManager *cacheDataManager
+	// pieceStyle is the style compressing pieces with, plain means no compression
+	pieceStyle base.PieceStyle
 }
 
-func newCacheWriter(cdnReporter *reporter, cacheDataManager *cacheDataManager) *cacheWriter {
+func newCacheWriter(cdnReporter *reporter, cacheDataManager *cacheDataManager, pieceStyle base.PieceStyle) *cacheWriter {
 	return &cacheWriter{
 		cdnReporter:      cdnReporter,
 		cacheDataManager: cacheDataManager,
+		pieceStyle:       pieceStyle,
 	}
 }
 
@@ -17,6 +17,7 @@
 package cdn
 
 import (
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	"fmt"
 	"github.com/stretchr/testify/suite"
 	"io/ioutil"
@@ -38,7 +39,7 @@ type CacheWriterTestSuite struct {
 func (s *CacheWriterTestSuite) SetupSuite() {
 	s.workHome, _ = ioutil.TempDir("/tmp", "cdn-CacheWriterTestSuite-")
 	s.config = "baseDir: " + s.workHome
-	s.writer = newCacheWriter(nil, nil)
+	s.writer = newCacheWriter(nil, nil, base.PieceStyle_PLAIN)
 }
 
 func (s *CacheWriterTestSuite) TeardownSuite() {
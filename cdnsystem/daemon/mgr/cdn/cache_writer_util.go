@@ -24,6 +24,8 @@ import (
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/util/compressutils"
 	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
 	"encoding/binary"
@@ -61,12 +63,17 @@ func (cw *cacheWriter) writerPool(ctx context.Context, wg *sync.WaitGroup, write
 			defer wg.Done()
 			for job := range jobCh {
 				var pieceMd5 = md5.New()
-				// todo 后续压缩等特性通过waitToWriteContent 和 pieceStyle 实现
 				waitToWriteContent := job.pieceContent
 				// 要写盘数据的长度
 				originPieceLen := waitToWriteContent.Len() // 未作处理的原始数据长度
 				pieceLen := originPieceLen // 经过处理后写到存储介质的真实长度
 				pieceStyle := types.PlainUnspecified
+				if compressed := cw.compressPiece(job); compressed != nil {
+					// the compressed piece is still stored at the start of its slot, the rest of the slot is left as a hole
+					waitToWriteContent = bytes.NewBuffer(compressed)
+					pieceLen = len(compressed)
+					pieceStyle = types.PieceFormatOf(cw.pieceStyle)
+				}
 
 				if err := cw.writeToFile(ctx, job.TaskId, waitToWriteContent, int64(job.pieceNum)*int64(job.pieceSize), pieceMd5); err != nil {
 					logger.WithTaskID(job.TaskId).Errorf("failed to write file, pieceNum %d: %v", job.pieceNum, err)
@@ -112,6 +119,23 @@ func (cw *cacheWriter) writerPool(ctx context.Context, wg *sync.WaitGroup, write
 	}
 }
 
+// compressPiece compresses the piece content with the piece style of writer,
+// it returns nil when the compression is disabled, failed or not smaller than the plain content.
+func (cw *cacheWriter) compressPiece(job *protocolContent) []byte {
+	if cw.pieceStyle == base.PieceStyle_PLAIN {
+		return nil
+	}
+	compressed, err := compressutils.Compress(cw.pieceStyle, job.pieceContent.Bytes())
+	if err != nil {
+		logger.WithTaskID(job.TaskId).Warnf("failed to compress piece %d, store it as plain: %v", job.pieceNum, err)
+		return nil
+	}
+	if len(compressed) >= job.pieceContent.Len() {
+		return nil
+	}
+	return compressed
+}
+
 // writeToFile
 func (cw *cacheWriter) writeToFile(ctx context.Context, taskId string, bytesBuffer *bytes.Buffer, offset int64, pieceMd5 hash.Hash) error {
 	var resultBuf = &bytes.Buffer{}
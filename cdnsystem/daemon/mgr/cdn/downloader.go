@@ -17,6 +17,7 @@
 package cdn
 
 import (
+	"context"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
 	"d7y.io/dragonfly/v2/pkg/structure/maputils"
@@ -29,10 +30,33 @@ import (
 const RangeHeaderName = "Range"
 
 
-func (cm *Manager) download(task *types.SeedTask, detectResult *cacheResult) (io.ReadCloser, map[string]string, error) {
+func (cm *Manager) download(ctx context.Context, task *types.SeedTask, detectResult *cacheResult) (io.ReadCloser, map[string]string, error) {
+	if cm.parent != nil {
+		// the expire info of the origin is unknown, the task is checked again with the parent cdn when it is accessed
+		body, err := cm.parent.download(ctx, task, detectResult.breakPoint)
+		if err == nil {
+			logger.WithTaskID(task.TaskId).Infof("start download from parent cdn at range:%d-%d", detectResult.breakPoint,
+				task.SourceFileLength)
+			return &fallbackReader{
+				ReadCloser: body,
+				taskId:     task.TaskId,
+				offset:     detectResult.breakPoint,
+				fallback: func(offset int64) (io.ReadCloser, error) {
+					body, _, err := cm.downloadFromSource(ctx, task, offset)
+					return body, err
+				},
+			}, nil, nil
+		}
+		logger.WithTaskID(task.TaskId).Warnf("failed to download from parent cdns, back to source: %v", err)
+	}
+	return cm.downloadFromSource(ctx, task, detectResult.breakPoint)
+}
+
+// downloadFromSource downloads the content of task from the origin after breakPoint
+func (cm *Manager) downloadFromSource(ctx context.Context, task *types.SeedTask, breakPoint int64) (io.ReadCloser, map[string]string, error) {
 	headers := maputils.DeepCopyMap(nil, task.Header)
-	if detectResult.breakPoint > 0 {
-		breakRange, err := rangeutils.GetBreakRange(detectResult.breakPoint, task.SourceFileLength)
+	if breakPoint > 0 {
+		breakRange, err := rangeutils.GetBreakRange(breakPoint, task.SourceFileLength)
 		if err != nil {
 			return nil, nil, errors.Wrapf(err, "failed to calculate the breakRange")
 		}
@@ -41,7 +65,38 @@ func (cm *Manager) download(task *types.SeedTask, detectResult *cacheResult) (io
 			headers[RangeHeaderName] = fmt.Sprintf("bytes=%s", breakRange)
 		}
 	}
-	logger.WithTaskID(task.TaskId).Infof("start download url %s at range:%d-%d: with header: %+v", task.Url, detectResult.breakPoint,
+	logger.WithTaskID(task.TaskId).Infof("start download url %s at range:%d-%d: with header: %+v", task.Url, breakPoint,
 		task.SourceFileLength, task.Header)
 	return cm.resourceClient.Download(task.Url, headers)
+}
+
+// fallbackReader reads the content transferred from the parent cdn, it switches to the origin at the read offset
+// once when the transfer fails, so that a broken parent does not fail the task.
+type fallbackReader struct {
+	io.ReadCloser
+	taskId string
+	// offset is the origin offset of the next byte to read
+	offset   int64
+	fallback func(offset int64) (io.ReadCloser, error)
+}
+
+func (fr *fallbackReader) Read(p []byte) (int, error) {
+	n, err := fr.ReadCloser.Read(p)
+	fr.offset += int64(n)
+	if err == nil || err == io.EOF || fr.fallback == nil {
+		return n, err
+	}
+	logger.WithTaskID(fr.taskId).Warnf("failed to transfer from parent cdn at %d, back to source: %v", fr.offset, err)
+	fallback := fr.fallback
+	fr.fallback = nil
+	fr.ReadCloser.Close()
+	body, sourceErr := fallback(fr.offset)
+	if sourceErr != nil {
+		return n, errors.Wrapf(sourceErr, "failed to download from source after parent cdn failed: %v", err)
+	}
+	fr.ReadCloser = body
+	if n > 0 {
+		return n, nil
+	}
+	return fr.Read(p)
 }
\ No newline at end of file
@@ -19,6 +19,7 @@ package cdn
 import (
 	_ "d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage/disk"   // To register diskStorage
 	_ "d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage/hybrid" // To register hybridStorage
+	_ "d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage/s3"     // To register s3Storage
 	"d7y.io/dragonfly/v2/pkg/rpc/cdnsystem/server"
 	"d7y.io/dragonfly/v2/pkg/synclock"
 	"time"
@@ -26,6 +27,7 @@ import (
 import (
 	"context"
 	"crypto/md5"
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
 	"d7y.io/dragonfly/v2/cdnsystem/config"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage"
@@ -34,9 +36,13 @@ import (
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
 	"d7y.io/dragonfly/v2/pkg/ratelimiter/limitreader"
 	"d7y.io/dragonfly/v2/pkg/ratelimiter/ratelimiter"
+	"d7y.io/dragonfly/v2/pkg/util/compressutils"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/stringutils"
 	"fmt"
 	"github.com/pkg/errors"
+	"hash"
+	"io"
 )
 
 func init() {
@@ -57,12 +63,26 @@ type Manager struct {
 	detector         *cacheDetector
 	resourceClient   source.ResourceClient
 	writer           *cacheWriter
+	// parent downloads tasks from the parent cdns, nil means downloading from the origin only
+	parent *parentSource
 }
 
 // NewManager returns a new Manager.
 func NewManager(cfg *config.Config, cacheStore storage.Manager, progressMgr mgr.SeedProgressMgr, resourceClient source.ResourceClient) (mgr.CDNMgr, error) {
 	rateLimiter := ratelimiter.NewRateLimiter(ratelimiter.TransRate(int64(cfg.MaxBandwidth-cfg.SystemReservedBandwidth)), 2)
-	cacheDataManager := newCacheDataManager(cacheStore)
+	pieceStyle, err := compressutils.ParsePieceStyle(cfg.PieceCompression)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid piece compression")
+	}
+	parent, err := newParentSource(cfg.ParentCDNs)
+	if err != nil {
+		return nil, err
+	}
+	detector, err := newCacheDetector(newCacheDataManager(cacheStore), resourceClient, cfg.NeverExpireURLs)
+	if err != nil {
+		return nil, err
+	}
+	cacheDataManager := detector.cacheDataManager
 	cdnReporter := newReporter(progressMgr, cacheStore)
 	return &Manager{
 		cfg:              cfg,
@@ -72,9 +92,10 @@ func NewManager(cfg *config.Config, cacheStore storage.Manager, progressMgr mgr.
 		cacheDataManager: cacheDataManager,
 		cdnReporter:      cdnReporter,
 		progressMgr:      progressMgr,
-		detector:         newCacheDetector(cacheDataManager, resourceClient),
+		detector:         detector,
 		resourceClient:   resourceClient,
-		writer:           newCacheWriter(cdnReporter, cacheDataManager),
+		writer:           newCacheWriter(cdnReporter, cacheDataManager, pieceStyle),
+		parent:           parent,
 	}, nil
 }
 
@@ -96,17 +117,19 @@ func (cm *Manager) TriggerCDN(ctx context.Context, task *types.SeedTask) (seedTa
 	// full cache
 	if detectResult.breakPoint == -1 {
 		logger.WithTaskID(task.TaskId).Infof("cache full hit on local")
-		return getUpdateTaskInfo(types.TaskInfoCdnStatusSuccess, detectResult.fileMetaData.SourceRealMd5, detectResult.fileMetaData.PieceMd5Sign,
-			detectResult.fileMetaData.SourceFileLen, detectResult.fileMetaData.CdnFileLength), nil
+		return getUpdateTaskInfo(types.TaskInfoCdnStatusSuccess, detectResult.fileMetaData.SourceRealMd5,
+			detectResult.fileMetaData.SourceRealDigest, detectResult.fileMetaData.PieceMd5Sign, detectResult.fileMetaData.SourceFileLen, detectResult.fileMetaData.CdnFileLength), nil
 	}
 	server.StatSeedStart(task.TaskId, task.Url)
 	start := time.Now()
 	// third: start to download the source file
-	body, expireInfo, err := cm.download(task, detectResult)
+	body, expireInfo, err := cm.download(ctx, task, detectResult)
 	// download fail
 	if err != nil {
 		server.StatSeedFinish(task.TaskId, task.Url, false, err, start.Nanosecond(), time.Now().Nanosecond(), 0, 0)
-		return getUpdateTaskInfoWithStatusOnly(types.TaskInfoCdnStatusSourceError), err
+		updateTaskInfo := getUpdateTaskInfoWithStatusOnly(types.TaskInfoCdnStatusSourceError)
+		updateTaskInfo.SourceStatusCode = cdnerrors.GetSourceStatusCode(err)
+		return updateTaskInfo, err
 	}
 	defer body.Close()
 
@@ -116,7 +139,22 @@ func (cm *Manager) TriggerCDN(ctx context.Context, task *types.SeedTask) (seedTa
 	if detectResult.fileMd5 != nil {
 		fileMd5 = detectResult.fileMd5
 	}
-	reader := limitreader.NewLimitReaderWithLimiterAndMD5Sum(body, cm.limiter, fileMd5)
+	var (
+		src        io.Reader = body
+		algorithm  string
+		fileDigest hash.Hash
+	)
+	if !stringutils.IsBlank(task.RequestDigest) {
+		if algorithm, _, err = digestutils.Parse(task.RequestDigest); err != nil {
+			return getUpdateTaskInfoWithStatusOnly(types.TaskInfoCdnStatusFailed), errors.Wrapf(err, "failed to parse digest")
+		}
+		fileDigest = detectResult.fileDigest
+		if fileDigest == nil {
+			fileDigest, _ = digestutils.NewHash(algorithm)
+		}
+		src = io.TeeReader(body, fileDigest)
+	}
+	reader := limitreader.NewLimitReaderWithLimiterAndMD5Sum(src, cm.limiter, fileMd5)
 	// forth: write to storage
 	downloadMetadata, err := cm.writer.startWriter(ctx, reader, task, detectResult)
 	if err != nil {
@@ -128,12 +166,16 @@ func (cm *Manager) TriggerCDN(ctx context.Context, task *types.SeedTask) (seedTa
 	server.StatSeedFinish(task.TaskId, task.Url, true, nil, start.Nanosecond(), time.Now().Nanosecond(), downloadMetadata.backSourceLength,
 		downloadMetadata.realSourceFileLength)
 	sourceMD5 := reader.Md5()
+	var sourceDigest string
+	if fileDigest != nil {
+		sourceDigest = digestutils.Format(algorithm, digestutils.ToHashString(fileDigest))
+	}
 	// fifth: handle CDN result
-	success, err := cm.handleCDNResult(ctx, task, sourceMD5, downloadMetadata)
+	success, err := cm.handleCDNResult(ctx, task, sourceMD5, sourceDigest, downloadMetadata)
 	if err != nil || !success {
 		return getUpdateTaskInfoWithStatusOnly(types.TaskInfoCdnStatusFailed), err
 	}
-	return getUpdateTaskInfo(types.TaskInfoCdnStatusSuccess, sourceMD5, downloadMetadata.pieceMd5Sign,
+	return getUpdateTaskInfo(types.TaskInfoCdnStatusSuccess, sourceMD5, sourceDigest, downloadMetadata.pieceMd5Sign,
 		downloadMetadata.realSourceFileLength, downloadMetadata.realCdnFileLength), nil
 }
 
@@ -145,7 +187,86 @@ func (cm *Manager) Delete(ctx context.Context, TaskId string) error {
 	return nil
 }
 
-func (cm *Manager) handleCDNResult(ctx context.Context, task *types.SeedTask, sourceMd5 string, downloadMetadata *downloadMetadata) (bool, error) {
+func (cm *Manager) RecordHit(ctx context.Context, taskId string) error {
+	if err := cm.cacheDataManager.updateAccessTime(ctx, taskId, getCurrentTimeMillisFunc()); err != nil {
+		return errors.Wrap(err, "failed to update access time")
+	}
+	return nil
+}
+
+func (cm *Manager) SetPinned(ctx context.Context, taskId string, pinned bool) error {
+	if err := cm.cacheDataManager.updatePinned(ctx, taskId, pinned); err != nil {
+		return errors.Wrap(err, "failed to update pinned")
+	}
+	return nil
+}
+
+func (cm *Manager) RestoreTasks(ctx context.Context) ([]*types.SeedTask, error) {
+	taskIds, err := cm.cacheStore.ListTaskIds(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tasks in storage")
+	}
+	var tasks []*types.SeedTask
+	for _, taskId := range taskIds {
+		task, err := cm.restoreTask(ctx, taskId)
+		if err != nil {
+			logger.WithTaskID(taskId).Warnf("skip restoring task: %v", err)
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	logger.Infof("restore %d tasks from %d tasks in storage", len(tasks), len(taskIds))
+	return tasks, nil
+}
+
+// restoreTask checks the cache of task by its meta data only, the origin is not accessed
+func (cm *Manager) restoreTask(ctx context.Context, taskId string) (*types.SeedTask, error) {
+	cm.cdnLocker.Lock(taskId, false)
+	defer cm.cdnLocker.UnLock(taskId, false)
+	fileMetaData, err := cm.cacheDataManager.readFileMetaData(ctx, taskId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read file meta data")
+	}
+	if !fileMetaData.Finish {
+		return nil, errors.New("task is not finished")
+	}
+	detectResult, err := cm.detector.parseByReadMetaFile(ctx, taskId, fileMetaData)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check cache")
+	}
+	url := fileMetaData.URL
+	if stringutils.IsBlank(url) {
+		url = fileMetaData.TaskURL
+	}
+	task := &types.SeedTask{
+		TaskId:           taskId,
+		Url:              url,
+		TaskUrl:          fileMetaData.TaskURL,
+		Filter:           fileMetaData.Filter,
+		SourceFileLength: fileMetaData.SourceFileLen,
+		CdnFileLength:    fileMetaData.CdnFileLength,
+		PieceSize:        fileMetaData.PieceSize,
+		CdnStatus:        types.TaskInfoCdnStatusSuccess,
+		PieceTotal:       int32(len(detectResult.pieceMetaRecords)),
+		SourceRealMd5:    fileMetaData.SourceRealMd5,
+		PieceMd5Sign:     fileMetaData.PieceMd5Sign,
+		// the digests are restored, so that the task registered again with the same digest is the same task
+		RequestDigest:    fileMetaData.Digest,
+		SourceRealDigest: fileMetaData.SourceRealDigest,
+	}
+	cm.progressMgr.InitSeedProgress(ctx, taskId)
+	if err := cm.cdnReporter.reportCache(ctx, taskId, detectResult); err != nil {
+		cm.progressMgr.Clear(ctx, taskId)
+		return nil, errors.Wrapf(err, "failed to report cache")
+	}
+	if err := cm.progressMgr.PublishTask(ctx, taskId, task); err != nil {
+		cm.progressMgr.Clear(ctx, taskId)
+		return nil, errors.Wrapf(err, "failed to publish task")
+	}
+	return task, nil
+}
+
+func (cm *Manager) handleCDNResult(ctx context.Context, task *types.SeedTask, sourceMd5, sourceDigest string, downloadMetadata *downloadMetadata) (bool, error) {
 	logger.WithTaskID(task.TaskId).Debugf("handle cdn result, downloadMetaData: %+v", downloadMetadata)
 	var isSuccess = true
 	var errorMsg string
@@ -154,6 +275,11 @@ func (cm *Manager) handleCDNResult(ctx context.Context, task *types.SeedTask, so
 		errorMsg = fmt.Sprintf("file md5 not match expected:%s real:%s", task.RequestMd5, sourceMd5)
 		isSuccess = false
 	}
+	// check digest
+	if isSuccess && !stringutils.IsBlank(task.RequestDigest) && task.RequestDigest != sourceDigest {
+		errorMsg = fmt.Sprintf("file digest not match expected:%s real:%s", task.RequestDigest, sourceDigest)
+		isSuccess = false
+	}
 	// check source length
 	if isSuccess && task.SourceFileLength >= 0 && task.SourceFileLength != downloadMetadata.realSourceFileLength {
 		errorMsg = fmt.Sprintf("file length not match expected:%d real:%d", task.SourceFileLength, downloadMetadata.realSourceFileLength)
@@ -177,13 +303,14 @@ func (cm *Manager) handleCDNResult(ctx context.Context, task *types.SeedTask, so
 		cdnFileLength = 0
 	}
 	if err := cm.cacheDataManager.updateStatusAndResult(ctx, task.TaskId, &storage.FileMetaData{
-		Finish:          true,
-		Success:         isSuccess,
-		SourceRealMd5:   sourceMd5,
-		PieceMd5Sign:    pieceMd5Sign,
-		CdnFileLength:   cdnFileLength,
-		SourceFileLen:   sourceFileLen,
-		TotalPieceCount: downloadMetadata.pieceTotalCount,
+		Finish:           true,
+		Success:          isSuccess,
+		SourceRealMd5:    sourceMd5,
+		SourceRealDigest: sourceDigest,
+		PieceMd5Sign:     pieceMd5Sign,
+		CdnFileLength:    cdnFileLength,
+		SourceFileLen:    sourceFileLen,
+		TotalPieceCount:  downloadMetadata.pieceTotalCount,
 	}); err != nil {
 		return false, errors.Wrap(err, "failed to update task status and result")
 	}
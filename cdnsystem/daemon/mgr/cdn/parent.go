@@ -0,0 +1,262 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnutil"
+	"d7y.io/dragonfly/v2/cdnsystem/server/upload"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/cdnsystem"
+	cdnclient "d7y.io/dragonfly/v2/pkg/rpc/cdnsystem/client"
+	"d7y.io/dragonfly/v2/pkg/util/compressutils"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"github.com/pkg/errors"
+)
+
+// parentPieceTimeout is the timeout of downloading a piece from the parent cdn
+const parentPieceTimeout = 2 * time.Minute
+
+// parentSource downloads the content of tasks from the parent cdns instead of the origin.
+// The parent cdn is chosen by the task id, and it downloads the task from its own parents or the origin,
+// so the origin is accessed once for all the cdns under it.
+type parentSource struct {
+	addrs      []dfnet.NetAddr
+	client     cdnclient.CdnClient
+	httpClient *http.Client
+}
+
+// newParentSource returns nil when there is no parent cdn
+func newParentSource(addrs []dfnet.NetAddr) (*parentSource, error) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+	client, err := cdnclient.GetClientByAddr(addrs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create client of parent cdns")
+	}
+	return &parentSource{
+		addrs:      addrs,
+		client:     client,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// parentReader reads the content transferred from the parent cdn, closing it stops the transfer
+type parentReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (pr *parentReader) Close() error {
+	pr.cancel()
+	return pr.PipeReader.Close()
+}
+
+// download returns the origin content of task from breakPoint. It fails when no parent cdn can serve the task,
+// errors after the first piece seed is received are returned by the reader.
+func (ps *parentSource) download(ctx context.Context, task *types.SeedTask, breakPoint int64) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := ps.client.ObtainSeeds(ctx, &cdnsystem.SeedRequest{
+		TaskId: task.TaskId,
+		Url:    task.Url,
+		// the parent filters the url in the same way, so that it has the same task url
+		Filter: strings.Join(task.Filter, "&"),
+		UrlMeta: &base.UrlMeta{
+			Md5:    task.RequestMd5,
+			Header: task.Header,
+			Digest: task.RequestDigest,
+		},
+	})
+	if err != nil {
+		cancel()
+		return nil, errors.Wrapf(err, "failed to obtain seeds from parent cdns")
+	}
+	seed, err := stream.Recv()
+	if err != nil {
+		cancel()
+		return nil, errors.Wrapf(err, "failed to receive seed from parent cdns")
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		defer cancel()
+		pw.CloseWithError(ps.transfer(ctx, task, stream, seed, breakPoint, pw))
+	}()
+	return &parentReader{PipeReader: pr, cancel: cancel}, nil
+}
+
+// transfer writes the pieces to w in order, a nil error means all the pieces are written and verified
+func (ps *parentSource) transfer(ctx context.Context, task *types.SeedTask, stream *cdnclient.PieceSeedStream,
+	seed *cdnsystem.PieceSeed, breakPoint int64, w io.Writer) error {
+	var (
+		// the seeds are not in order when the parent writes pieces concurrently
+		pending      = make(map[int32]*base.PieceInfo)
+		nextPieceNum int32
+		pieceMd5s    []string
+		offset       int64
+		parent       *dfnet.NetAddr
+		dstAddr      string
+		err          error
+	)
+	for !seed.Done {
+		if seed.PieceInfo != nil && seed.PieceInfo.PieceNum >= nextPieceNum {
+			pending[seed.PieceInfo.PieceNum] = seed.PieceInfo
+		}
+		for piece, ok := pending[nextPieceNum]; ok; piece, ok = pending[nextPieceNum] {
+			if piece.PieceOffset != uint64(offset) {
+				return errors.Errorf("piece %d offset %d does not follow the previous pieces at %d", piece.PieceNum, piece.PieceOffset, offset)
+			}
+			if dstAddr == "" {
+				if parent, dstAddr, err = ps.locate(ctx, task.TaskId, piece.PieceNum); err != nil {
+					return err
+				}
+			}
+			n, err := ps.transferPiece(ctx, task.TaskId, dstAddr, piece, breakPoint, w)
+			if err != nil {
+				return errors.Wrapf(err, "failed to transfer piece %d from %s", piece.PieceNum, dstAddr)
+			}
+			delete(pending, nextPieceNum)
+			pieceMd5s = append(pieceMd5s, piece.PieceMd5)
+			offset += n
+			nextPieceNum++
+		}
+		if seed, err = stream.Recv(); err != nil {
+			return errors.Wrapf(err, "failed to receive seed from parent cdns")
+		}
+	}
+	if len(pending) > 0 {
+		return errors.Errorf("%d pieces after piece %d are not received", len(pending), nextPieceNum)
+	}
+	if seed.ContentLength >= 0 && seed.ContentLength != offset {
+		return errors.Errorf("content length not match expected:%d real:%d", seed.ContentLength, offset)
+	}
+	if parent == nil {
+		// the task is empty
+		return nil
+	}
+	packet, err := ps.client.GetPieceTasks(ctx, *parent, &base.PieceTaskRequest{
+		TaskId: task.TaskId,
+		SrcPid: cdnutil.GenCdnPeerId(task.TaskId),
+		Limit:  1,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get piece md5 sign from parent cdn %s", parent.GetEndpoint())
+	}
+	if sign := digestutils.Sha256(pieceMd5s...); packet.PieceMd5Sign != sign {
+		return errors.Errorf("piece md5 sign not match expected:%s real:%s", packet.PieceMd5Sign, sign)
+	}
+	logger.WithTaskID(task.TaskId).Infof("success to transfer %d pieces from parent cdn %s", nextPieceNum, parent.GetEndpoint())
+	return nil
+}
+
+// locate finds the parent cdn which has the piece and returns its rpc address and download address
+func (ps *parentSource) locate(ctx context.Context, taskId string, pieceNum int32) (*dfnet.NetAddr, string, error) {
+	for i := range ps.addrs {
+		packet, err := ps.client.GetPieceTasks(ctx, ps.addrs[i], &base.PieceTaskRequest{
+			TaskId:   taskId,
+			SrcPid:   cdnutil.GenCdnPeerId(taskId),
+			StartNum: pieceNum,
+			Limit:    1,
+		})
+		if err != nil {
+			logger.WithTaskID(taskId).Debugf("parent cdn %s can not serve the task: %v", ps.addrs[i].GetEndpoint(), err)
+			continue
+		}
+		if len(packet.PieceInfos) > 0 {
+			return &ps.addrs[i], packet.DstAddr, nil
+		}
+	}
+	return nil, "", errors.Errorf("no parent cdn has piece %d", pieceNum)
+}
+
+// transferPiece downloads the piece and writes its origin content after breakPoint to w,
+// it returns the origin length of the piece.
+func (ps *parentSource) transferPiece(ctx context.Context, taskId string, dstAddr string, piece *base.PieceInfo,
+	breakPoint int64, w io.Writer) (int64, error) {
+	if piece.PieceStyle == base.PieceStyle_PLAIN && int64(piece.PieceOffset)+int64(piece.RangeSize) <= breakPoint {
+		// the piece is in the local cache already
+		return int64(piece.RangeSize), nil
+	}
+	content, err := ps.downloadPiece(ctx, taskId, dstAddr, piece)
+	if err != nil {
+		return 0, err
+	}
+	if md5 := digestutils.Md5Bytes(content); md5 != piece.PieceMd5 {
+		return 0, errors.Errorf("piece md5 not match expected:%s real:%s", piece.PieceMd5, md5)
+	}
+	if piece.PieceStyle != base.PieceStyle_PLAIN {
+		r, err := compressutils.NewReader(piece.PieceStyle, bytes.NewReader(content))
+		if err != nil {
+			return 0, err
+		}
+		content, err = ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to decompress %s piece", piece.PieceStyle)
+		}
+	}
+	length := int64(len(content))
+	if skip := breakPoint - int64(piece.PieceOffset); skip > 0 {
+		if skip >= length {
+			return length, nil
+		}
+		content = content[skip:]
+	}
+	if _, err := w.Write(content); err != nil {
+		return 0, err
+	}
+	return length, nil
+}
+
+func (ps *parentSource) downloadPiece(ctx context.Context, taskId string, dstAddr string, piece *base.PieceInfo) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, parentPieceTimeout)
+	defer cancel()
+	url := fmt.Sprintf("http://%s%s%s/%s?peerId=%s", dstAddr, upload.PeerDownloadHTTPPathPrefix, taskId[:3], taskId,
+		cdnutil.GenCdnPeerId(taskId))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(RangeHeaderName, fmt.Sprintf("bytes=%d-%d", piece.RangeStart, piece.RangeStart+uint64(piece.RangeSize)-1))
+	resp, err := ps.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, errors.Errorf("unexpected http status: %s", resp.Status)
+	}
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, int64(piece.RangeSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) != int(piece.RangeSize) {
+		return nil, errors.Errorf("piece length not match expected:%d real:%d", piece.RangeSize, len(content))
+	}
+	return content, nil
+}
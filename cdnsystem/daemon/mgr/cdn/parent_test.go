@@ -0,0 +1,246 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"d7y.io/dragonfly/v2/cdnsystem/server/upload"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
+	"d7y.io/dragonfly/v2/pkg/rpc"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/cdnsystem"
+	_ "d7y.io/dragonfly/v2/pkg/rpc/cdnsystem/server"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
+	"github.com/pkg/errors"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+const testPieceSize = 4
+
+// fakeParent is a parent cdn which serves the seeds by rpc and the pieces by http
+type fakeParent struct {
+	sync.Mutex
+	content  map[string][]byte
+	requests []*cdnsystem.SeedRequest
+	// failPieceNum is the first piece that fails to be downloaded, -1 means no failure
+	failPieceNum int32
+	dstAddr      string
+}
+
+func (fp *fakeParent) pieces(taskId string) []*base.PieceInfo {
+	content := fp.content[taskId]
+	var pieces []*base.PieceInfo
+	for offset := 0; offset < len(content); offset += testPieceSize {
+		end := offset + testPieceSize
+		if end > len(content) {
+			end = len(content)
+		}
+		pieces = append(pieces, &base.PieceInfo{
+			PieceNum:    int32(offset / testPieceSize),
+			RangeStart:  uint64(offset),
+			RangeSize:   int32(end - offset),
+			PieceMd5:    digestutils.Md5Bytes(content[offset:end]),
+			PieceOffset: uint64(offset),
+			PieceStyle:  base.PieceStyle_PLAIN,
+		})
+	}
+	return pieces
+}
+
+func (fp *fakeParent) ObtainSeeds(ctx context.Context, req *cdnsystem.SeedRequest, psc chan<- *cdnsystem.PieceSeed) error {
+	fp.Lock()
+	fp.requests = append(fp.requests, req)
+	content, ok := fp.content[req.TaskId]
+	fp.Unlock()
+	if !ok {
+		return dferrors.New(dfcodes.CdnTaskNotFound, "task not found")
+	}
+	for _, piece := range fp.pieces(req.TaskId) {
+		psc <- &cdnsystem.PieceSeed{PieceInfo: piece}
+	}
+	psc <- &cdnsystem.PieceSeed{Done: true, ContentLength: int64(len(content))}
+	return nil
+}
+
+func (fp *fakeParent) GetPieceTasks(ctx context.Context, req *base.PieceTaskRequest) (*base.PiecePacket, error) {
+	pieces := fp.pieces(req.TaskId)
+	if len(pieces) == 0 {
+		return nil, dferrors.New(dfcodes.CdnTaskNotFound, "task not found")
+	}
+	var md5s []string
+	for _, piece := range pieces {
+		md5s = append(md5s, piece.PieceMd5)
+	}
+	packet := &base.PiecePacket{
+		TaskId:       req.TaskId,
+		DstAddr:      fp.dstAddr,
+		TotalPiece:   int32(len(pieces)),
+		PieceMd5Sign: digestutils.Sha256(md5s...),
+	}
+	if req.StartNum < int32(len(pieces)) {
+		packet.PieceInfos = pieces[req.StartNum : req.StartNum+1]
+	}
+	return packet, nil
+}
+
+func (fp *fakeParent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	taskId := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	content := fp.content[taskId]
+	rg, err := rangeutils.ParseRange(strings.TrimPrefix(r.Header.Get(RangeHeaderName), "bytes="))
+	if err != nil || !strings.HasPrefix(r.URL.Path, upload.PeerDownloadHTTPPathPrefix) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if fp.failPieceNum >= 0 && int32(rg.StartIndex/testPieceSize) >= fp.failPieceNum {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusPartialContent)
+	w.Write(content[rg.StartIndex : rg.EndIndex+1])
+}
+
+// fakeSource is the origin which supports range requests
+type fakeSource struct {
+	unimplementedSource
+	content []byte
+	ranges  []string
+}
+
+func (fs *fakeSource) Download(url string, headers map[string]string) (io.ReadCloser, map[string]string, error) {
+	rg := headers[RangeHeaderName]
+	fs.ranges = append(fs.ranges, rg)
+	content := fs.content
+	if rg != "" {
+		r, err := rangeutils.ParseRange(strings.TrimPrefix(rg, "bytes="))
+		if err != nil {
+			return nil, nil, err
+		}
+		content = content[r.StartIndex : r.EndIndex+1]
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil, nil
+}
+
+func TestManager_DownloadFromParent(t *testing.T) {
+	assert := testifyassert.New(t)
+	content := []byte("dragonfly downloads from the parent cdn")
+	parent := &fakeParent{
+		content:      map[string][]byte{"hit": content, "fallback": content},
+		failPieceNum: -1,
+	}
+	httpServer := httptest.NewServer(parent)
+	defer httpServer.Close()
+	parent.dstAddr = strings.TrimPrefix(httpServer.URL, "http://")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(err)
+	rpcServer := rpc.NewServer(parent)
+	go rpcServer.Serve(ln)
+	defer rpcServer.Stop()
+
+	ps, err := newParentSource([]dfnet.NetAddr{{Type: dfnet.TCP, Addr: ln.Addr().String()}})
+	assert.Nil(err)
+
+	download := func(taskId string, breakPoint int64) ([]byte, *fakeSource, error) {
+		source := &fakeSource{content: content}
+		cm := &Manager{parent: ps, resourceClient: source}
+		task := &types.SeedTask{
+			TaskId:           taskId,
+			Url:              "http://example.com/blob?token=x",
+			TaskUrl:          "http://example.com/blob",
+			Filter:           []string{"token", "expires"},
+			SourceFileLength: int64(len(content)),
+		}
+		body, _, err := cm.download(context.Background(), task, &cacheResult{breakPoint: breakPoint})
+		if err != nil {
+			return nil, source, err
+		}
+		defer body.Close()
+		data, err := ioutil.ReadAll(body)
+		return data, source, err
+	}
+
+	t.Run("hit", func(t *testing.T) {
+		assert := testifyassert.New(t)
+		data, source, err := download("hit", 0)
+		assert.Nil(err)
+		assert.Equal(content, data)
+		assert.Empty(source.ranges)
+
+		// the pieces before break point are cached already
+		data, source, err = download("hit", 10)
+		assert.Nil(err)
+		assert.Equal(content[10:], data)
+		assert.Empty(source.ranges)
+
+		parent.Lock()
+		defer parent.Unlock()
+		assert.Equal("token&expires", parent.requests[0].Filter)
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		assert := testifyassert.New(t)
+		data, source, err := download("miss", 10)
+		assert.Nil(err)
+		assert.Equal(content[10:], data)
+		assert.Equal([]string{fmt.Sprintf("bytes=10-%d", len(content)-1)}, source.ranges)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		assert := testifyassert.New(t)
+		parent.failPieceNum = 3
+		defer func() { parent.failPieceNum = -1 }()
+		data, source, err := download("fallback", 2)
+		assert.Nil(err)
+		assert.Equal(content[2:], data)
+		// the origin continues from the first byte of the failed piece
+		assert.Equal([]string{fmt.Sprintf("bytes=%d-%d", 3*testPieceSize, len(content)-1)}, source.ranges)
+	})
+}
+
+// unimplementedSource fails all the operations of source
+type unimplementedSource struct{}
+
+func (unimplementedSource) GetContentLength(url string, headers map[string]string) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (unimplementedSource) IsSupportRange(url string, headers map[string]string) (bool, error) {
+	return false, errors.New("not implemented")
+}
+
+func (unimplementedSource) IsExpired(url string, headers, expireInfo map[string]string) (bool, error) {
+	return false, errors.New("not implemented")
+}
+
+func (unimplementedSource) Download(url string, headers map[string]string) (io.ReadCloser, map[string]string, error) {
+	return nil, nil, errors.New("not implemented")
+}
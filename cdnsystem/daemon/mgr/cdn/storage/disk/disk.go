@@ -59,6 +59,11 @@ func (*diskBuilder) Build(cfg *config.Config) (storage.Manager, error) {
 	if err != nil {
 		return nil, err
 	}
+	if gcConfig := diskStore.GetGcConfig(context.TODO()); gcConfig != nil {
+		if err := storage.ValidateGcConfig(gcConfig); err != nil {
+			return nil, err
+		}
+	}
 	storageMgr := &diskStorageMgr{
 		diskStore: diskStore,
 	}
@@ -206,6 +211,17 @@ func (s *diskStorageMgr) ReadDownloadFile(ctx context.Context, taskId string) (i
 	return s.diskStore.Get(ctx, storage.GetDownloadRaw(taskId))
 }
 
+func (s *diskStorageMgr) ReadDownloadFileRange(ctx context.Context, taskId string, offset int64, length int64) (io.ReadCloser, error) {
+	raw := storage.GetDownloadRaw(taskId)
+	raw.Offset = offset
+	raw.Length = length
+	return s.diskStore.Get(ctx, raw)
+}
+
+func (s *diskStorageMgr) GetDownloadPath(taskId string) string {
+	return s.diskStore.GetPath(storage.GetDownloadRaw(taskId))
+}
+
 func (s *diskStorageMgr) StatDownloadFile(ctx context.Context, taskId string) (*storedriver.StorageInfo, error) {
 	return s.diskStore.Stat(ctx, storage.GetDownloadRaw(taskId))
 }
@@ -240,6 +256,10 @@ func (s *diskStorageMgr) DeleteTask(ctx context.Context, taskId string) error {
 	return nil
 }
 
+func (s *diskStorageMgr) ListTaskIds(ctx context.Context) ([]string, error) {
+	return storage.ListTaskIds(ctx, s.diskStore)
+}
+
 func (s *diskStorageMgr) ResetRepo(ctx context.Context, task *types.SeedTask) error {
 	return s.DeleteTask(ctx, task.TaskId)
 }
@@ -0,0 +1,144 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+	"d7y.io/dragonfly/v2/pkg/unit"
+	"github.com/pkg/errors"
+)
+
+const (
+	// GCPolicyDefault removes the tasks by the access gaps and intervals
+	GCPolicyDefault = "default"
+	// GCPolicyLRU removes the least recently used tasks first
+	GCPolicyLRU = "lru"
+	// GCPolicyLFU removes the least frequently used tasks first, the hits decay with the idle time
+	GCPolicyLFU = "lfu"
+	// GCPolicySize removes the tasks with the fewest hits per byte first
+	GCPolicySize = "size"
+
+	// defaultHitHalfLife is the default idle time after which the hits of a task count as half
+	defaultHitHalfLife = 24 * time.Hour
+)
+
+// gcCandidate is a task which is not used and can be removed by gc
+type gcCandidate struct {
+	taskId   string
+	metaData *FileMetaData
+	size     int64
+	score    float64
+}
+
+// gcPolicy scores the candidates, the candidates with lower scores are removed first
+type gcPolicy func(c *gcCandidate, now int64, halfLife time.Duration) float64
+
+var gcPolicies = map[string]gcPolicy{
+	GCPolicyLRU: func(c *gcCandidate, now int64, halfLife time.Duration) float64 {
+		return float64(c.metaData.AccessTime)
+	},
+	GCPolicyLFU: func(c *gcCandidate, now int64, halfLife time.Duration) float64 {
+		return decayedHits(c.metaData, now, halfLife)
+	},
+	GCPolicySize: func(c *gcCandidate, now int64, halfLife time.Duration) float64 {
+		size := float64(c.size) / float64(unit.MB)
+		if size < 1 {
+			size = 1
+		}
+		return decayedHits(c.metaData, now, halfLife) / size
+	},
+}
+
+// ValidateGcConfig checks the gc policy and the pinned url patterns of cfg.
+func ValidateGcConfig(cfg *storedriver.GcConfig) error {
+	if cfg.Policy != "" && cfg.Policy != GCPolicyDefault {
+		if _, ok := gcPolicies[cfg.Policy]; !ok {
+			return errors.Errorf("unknown gc policy %s", cfg.Policy)
+		}
+	}
+	if _, err := compilePatterns(cfg.Pinned); err != nil {
+		return err
+	}
+	return nil
+}
+
+// decayedHits halves the hits of task every halfLife since it was accessed last time
+func decayedHits(metaData *FileMetaData, now int64, halfLife time.Duration) float64 {
+	if halfLife <= 0 {
+		halfLife = defaultHitHalfLife
+	}
+	idle := float64(now-metaData.AccessTime) / float64(halfLife.Milliseconds())
+	if idle < 0 {
+		idle = 0
+	}
+	return float64(metaData.HitCount) * math.Pow(0.5, idle)
+}
+
+// sortCandidates sorts the candidates in the order to remove them
+func sortCandidates(candidates []*gcCandidate, policy gcPolicy, now int64, halfLife time.Duration) {
+	for _, c := range candidates {
+		c.score = policy(c, now, halfLife)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score < candidates[j].score
+		}
+		return candidates[i].metaData.AccessTime < candidates[j].metaData.AccessTime
+	})
+}
+
+// selectCandidates selects the candidates in order until their size fills the space to free
+func selectCandidates(candidates []*gcCandidate, toFree int64) []*gcCandidate {
+	var freed int64
+	for i, c := range candidates {
+		if freed >= toFree {
+			return candidates[:i]
+		}
+		freed += c.size
+	}
+	return candidates
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var regexps []*regexp.Regexp
+	for _, pattern := range patterns {
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pinned url pattern %s", pattern)
+		}
+		regexps = append(regexps, r)
+	}
+	return regexps, nil
+}
+
+// isPinned reports whether the task is pinned by the admin api or its url matches any of the pinned patterns
+func isPinned(metaData *FileMetaData, pinned []*regexp.Regexp) bool {
+	if metaData.Pinned {
+		return true
+	}
+	for _, r := range pinned {
+		if r.MatchString(metaData.URL) || r.MatchString(metaData.TaskURL) {
+			return true
+		}
+	}
+	return false
+}
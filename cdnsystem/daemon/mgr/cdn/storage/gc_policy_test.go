@@ -0,0 +1,84 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+	"d7y.io/dragonfly/v2/pkg/unit"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestGcPolicies(t *testing.T) {
+	assert := testifyassert.New(t)
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	hour := time.Hour.Milliseconds()
+	newCandidates := func() []*gcCandidate {
+		return []*gcCandidate{
+			// recent but rarely used
+			{taskId: "recent", size: int64(10 * unit.MB), metaData: &FileMetaData{AccessTime: now - hour, HitCount: 1}},
+			// popular but idle for a long time
+			{taskId: "idle", size: int64(10 * unit.MB), metaData: &FileMetaData{AccessTime: now - 100*hour, HitCount: 100}},
+			// popular and large
+			{taskId: "large", size: int64(unit.GB), metaData: &FileMetaData{AccessTime: now - 2*hour, HitCount: 50}},
+		}
+	}
+	ids := func(candidates []*gcCandidate) []string {
+		var ids []string
+		for _, c := range candidates {
+			ids = append(ids, c.taskId)
+		}
+		return ids
+	}
+
+	var cases = []struct {
+		policy   string
+		expected []string
+	}{
+		{policy: GCPolicyLRU, expected: []string{"idle", "large", "recent"}},
+		{policy: GCPolicyLFU, expected: []string{"recent", "idle", "large"}},
+		{policy: GCPolicySize, expected: []string{"large", "recent", "idle"}},
+	}
+	for _, tc := range cases {
+		candidates := newCandidates()
+		sortCandidates(candidates, gcPolicies[tc.policy], now, 24*time.Hour)
+		assert.Equal(tc.expected, ids(candidates), tc.policy)
+	}
+
+	candidates := newCandidates()
+	assert.Equal([]string{"recent", "idle"}, ids(selectCandidates(candidates, int64(15*unit.MB))))
+	assert.Empty(selectCandidates(candidates, 0))
+	assert.Len(selectCandidates(candidates, int64(10*unit.GB)), 3)
+}
+
+func TestGcPinnedAndValidate(t *testing.T) {
+	assert := testifyassert.New(t)
+	pinned, err := compilePatterns([]string{`^https://example\.com/images/`})
+	assert.Nil(err)
+	assert.True(isPinned(&FileMetaData{URL: "https://example.com/images/base.tar"}, pinned))
+	assert.False(isPinned(&FileMetaData{URL: "https://example.com/files/a.tar"}, pinned))
+	assert.False(isPinned(&FileMetaData{URL: "https://example.com/images/base.tar"}, nil))
+	assert.True(isPinned(&FileMetaData{URL: "https://example.com/files/a.tar", Pinned: true}, pinned))
+
+	assert.Nil(ValidateGcConfig(&storedriver.GcConfig{}))
+	assert.Nil(ValidateGcConfig(&storedriver.GcConfig{Policy: GCPolicyDefault}))
+	assert.Nil(ValidateGcConfig(&storedriver.GcConfig{Policy: GCPolicyLFU, Pinned: []string{"a.*"}}))
+	assert.NotNil(ValidateGcConfig(&storedriver.GcConfig{Policy: "fifo"}))
+	assert.NotNil(ValidateGcConfig(&storedriver.GcConfig{Pinned: []string{"("}}))
+}
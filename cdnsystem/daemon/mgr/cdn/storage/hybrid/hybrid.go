@@ -68,6 +68,13 @@ func (*hybridBuilder) Build(cfg *config.Config) (storage.Manager, error) {
 	if err != nil {
 		return nil, err
 	}
+	for _, store := range []*storedriver.Store{diskStore, memoryStore} {
+		if gcConfig := store.GetGcConfig(context.TODO()); gcConfig != nil {
+			if err := storage.ValidateGcConfig(gcConfig); err != nil {
+				return nil, err
+			}
+		}
+	}
 	storageMgr := &hybridStorageMgr{
 		memoryStore: memoryStore,
 		diskStore:   diskStore,
@@ -181,6 +188,13 @@ func (h *hybridStorageMgr) ReadDownloadFile(ctx context.Context, taskId string)
 	return h.diskStore.Get(ctx, storage.GetDownloadRaw(taskId))
 }
 
+func (h *hybridStorageMgr) ReadDownloadFileRange(ctx context.Context, taskId string, offset int64, length int64) (io.ReadCloser, error) {
+	raw := storage.GetDownloadRaw(taskId)
+	raw.Offset = offset
+	raw.Length = length
+	return h.diskStore.Get(ctx, raw)
+}
+
 func (h *hybridStorageMgr) ReadPieceMetaRecords(ctx context.Context, taskId string) ([]*storage.PieceMetaRecord, error) {
 	bytes, err := h.diskStore.GetBytes(ctx, storage.GetPieceMetaDataRaw(taskId))
 	if err != nil {
@@ -240,6 +254,11 @@ func (h *hybridStorageMgr) CreateUploadLink(ctx context.Context, taskId string)
 	return nil
 }
 
+func (h *hybridStorageMgr) ListTaskIds(ctx context.Context) ([]string, error) {
+	// the meta data files are always stored in disk
+	return storage.ListTaskIds(ctx, h.diskStore)
+}
+
 func (h *hybridStorageMgr) ResetRepo(ctx context.Context, task *types.SeedTask) error {
 	if err := h.deleteTaskFiles(ctx, task.TaskId, false, true); err != nil {
 		logger.WithTaskID(task.TaskId).Errorf("reset repo: failed to delete task files: %v", err)
@@ -252,8 +271,8 @@ func (h *hybridStorageMgr) ResetRepo(ctx context.Context, task *types.SeedTask)
 	return nil
 }
 
-func (h *hybridStorageMgr) GetDownloadPath(rawFunc *storedriver.Raw) string {
-	return h.diskStore.GetPath(rawFunc)
+func (h *hybridStorageMgr) GetDownloadPath(taskId string) string {
+	return h.diskStore.GetPath(storage.GetDownloadRaw(taskId))
 }
 
 func (h *hybridStorageMgr) StatDownloadFile(ctx context.Context, taskId string) (*storedriver.StorageInfo, error) {
@@ -28,6 +28,10 @@ const (
 	DownloadHome = "download"
 
 	UploadHome = "upload"
+
+	metaDataSuffix = ".meta"
+
+	pieceMetaDataSuffix = ".piece"
 )
 
 func getDownloadKey(taskId string) string {
@@ -35,11 +39,11 @@ func getDownloadKey(taskId string) string {
 }
 
 func getTaskMetaDataKey(taskId string) string {
-	return path.Join(getParentKey(taskId), taskId+".meta")
+	return path.Join(getParentKey(taskId), taskId+metaDataSuffix)
 }
 
 func getPieceMetaDataKey(TaskId string) string {
-	return path.Join(getParentKey(TaskId), TaskId+".piece")
+	return path.Join(getParentKey(TaskId), TaskId+pieceMetaDataSuffix)
 }
 
 func getParentKey(taskId string) string {
@@ -0,0 +1,252 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/gc"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+	s3driver "d7y.io/dragonfly/v2/cdnsystem/storedriver/s3"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/synclock"
+	"d7y.io/dragonfly/v2/pkg/unit"
+	"github.com/pkg/errors"
+)
+
+const name = "s3"
+
+func init() {
+	var builder *s3Builder = nil
+	var _ storage.Builder = builder
+
+	var s3Storage *s3StorageMgr = nil
+	var _ storage.Manager = s3Storage
+	var _ gc.Executor = s3Storage
+}
+
+type s3Builder struct {
+}
+
+func (*s3Builder) Build(cfg *config.Config) (storage.Manager, error) {
+	s3Store, err := storedriver.Get(s3driver.StorageDriver)
+	if err != nil {
+		return nil, err
+	}
+	if gcConfig := s3Store.GetGcConfig(context.TODO()); gcConfig != nil {
+		if err := storage.ValidateGcConfig(gcConfig); err != nil {
+			return nil, err
+		}
+	}
+	storageMgr := &s3StorageMgr{
+		s3Store: s3Store,
+	}
+	gc.Register("s3Storage", cfg.GCInitialDelay, cfg.GCStorageInterval, storageMgr)
+	return storageMgr, nil
+}
+
+func (*s3Builder) Name() string {
+	return name
+}
+
+// s3StorageMgr stores the cache of tasks in S3 compatible object storage, so no local disk is needed.
+// The download files have no local path, they are read by ReadDownloadFileRange.
+type s3StorageMgr struct {
+	s3Store        *storedriver.Store
+	s3StoreCleaner *storage.Cleaner
+	taskMgr        mgr.SeedTaskMgr
+}
+
+func (s *s3StorageMgr) getDefaultGcConfig() *storedriver.GcConfig {
+	yongGcThreshold := 200 * unit.GB
+	if totalSpace, err := s.s3Store.GetTotalSpace(context.TODO()); err == nil && totalSpace/4 < yongGcThreshold {
+		yongGcThreshold = totalSpace / 4
+	}
+	return &storedriver.GcConfig{
+		YoungGCThreshold:  yongGcThreshold,
+		FullGCThreshold:   25 * unit.GB,
+		IntervalThreshold: 2 * time.Hour,
+		CleanRatio:        1,
+	}
+}
+
+func (s *s3StorageMgr) InitializeCleaners() {
+	s3GcConfig := s.s3Store.GetGcConfig(context.TODO())
+	if s3GcConfig == nil {
+		s3GcConfig = s.getDefaultGcConfig()
+		logger.GcLogger.With("type", "s3").Warnf("s3 gc config is nil, use default gcConfig: %v", s3GcConfig)
+	}
+	s.s3StoreCleaner = &storage.Cleaner{
+		Cfg:        s3GcConfig,
+		Store:      s.s3Store,
+		StorageMgr: s,
+		TaskMgr:    s.taskMgr,
+	}
+}
+
+func (s *s3StorageMgr) AppendPieceMetaData(ctx context.Context, taskId string, pieceRecord *storage.PieceMetaRecord) error {
+	return s.s3Store.PutBytes(ctx, storage.GetAppendPieceMetaDataRaw(taskId), []byte(pieceRecord.String()+"\n"))
+}
+
+func (s *s3StorageMgr) ReadPieceMetaRecords(ctx context.Context, taskId string) ([]*storage.PieceMetaRecord, error) {
+	bytes, err := s.s3Store.GetBytes(ctx, storage.GetPieceMetaDataRaw(taskId))
+	if err != nil {
+		return nil, err
+	}
+	pieceMetaRecords := strings.Split(strings.TrimSpace(string(bytes)), "\n")
+	var result = make([]*storage.PieceMetaRecord, 0)
+	for _, pieceStr := range pieceMetaRecords {
+		record, err := storage.ParsePieceMetaRecord(pieceStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get piece meta record: %v", pieceStr)
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+func (s *s3StorageMgr) GC(ctx context.Context) error {
+	logger.GcLogger.With("type", "s3").Info("start the s3 storage gc job")
+	gcTaskIDs, err := s.s3StoreCleaner.Gc(ctx, "s3", false)
+	if err != nil {
+		logger.GcLogger.With("type", "s3").Error("failed to get gcTaskIds")
+	}
+	logger.GcLogger.With("type", "s3").Infof("at most %d tasks can be cleaned up", len(gcTaskIDs))
+	for _, taskID := range gcTaskIDs {
+		synclock.Lock(taskID, false)
+		// try to ensure the taskID is not using again
+		if _, err := s.taskMgr.Get(ctx, taskID); err == nil || !cdnerrors.IsDataNotFound(err) {
+			if err != nil {
+				logger.GcLogger.With("type", "s3").Errorf("failed to get taskID(%s): %v", taskID, err)
+			}
+			synclock.UnLock(taskID, false)
+			continue
+		}
+		if err := s.DeleteTask(ctx, taskID); err != nil {
+			logger.GcLogger.With("type", "s3").Errorf("failed to delete s3 objects with taskID(%s): %v", taskID, err)
+			synclock.UnLock(taskID, false)
+			continue
+		}
+		synclock.UnLock(taskID, false)
+	}
+	return nil
+}
+
+func (s *s3StorageMgr) SetTaskMgr(mgr mgr.SeedTaskMgr) {
+	s.taskMgr = mgr
+}
+
+func (s *s3StorageMgr) WriteDownloadFile(ctx context.Context, taskId string, offset int64, len int64, buf *bytes.Buffer) error {
+	raw := storage.GetDownloadRaw(taskId)
+	raw.Offset = offset
+	raw.Length = len
+	return s.s3Store.Put(ctx, raw, buf)
+}
+
+func (s *s3StorageMgr) ReadFileMetaData(ctx context.Context, taskId string) (*storage.FileMetaData, error) {
+	bytes, err := s.s3Store.GetBytes(ctx, storage.GetTaskMetaDataRaw(taskId))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get metadata bytes")
+	}
+
+	metaData := &storage.FileMetaData{}
+	if err := json.Unmarshal(bytes, metaData); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal metadata bytes")
+	}
+	return metaData, nil
+}
+
+func (s *s3StorageMgr) WriteFileMetaData(ctx context.Context, taskId string, metaData *storage.FileMetaData) error {
+	data, err := json.Marshal(metaData)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal metadata")
+	}
+	metaRaw := storage.GetTaskMetaDataRaw(taskId)
+	metaRaw.Trunc = true
+	return s.s3Store.PutBytes(ctx, metaRaw, data)
+}
+
+func (s *s3StorageMgr) WritePieceMetaRecords(ctx context.Context, taskId string, records []*storage.PieceMetaRecord) error {
+	recordStrs := make([]string, 0, len(records))
+	for i := range records {
+		recordStrs = append(recordStrs, records[i].String())
+	}
+	pieceRaw := storage.GetPieceMetaDataRaw(taskId)
+	pieceRaw.Trunc = true
+	pieceRaw.TruncSize = 0
+	return s.s3Store.PutBytes(ctx, pieceRaw, []byte(strings.Join(recordStrs, "\n")))
+}
+
+func (s *s3StorageMgr) ReadDownloadFile(ctx context.Context, taskId string) (io.ReadCloser, error) {
+	return s.s3Store.Get(ctx, storage.GetDownloadRaw(taskId))
+}
+
+func (s *s3StorageMgr) ReadDownloadFileRange(ctx context.Context, taskId string, offset int64, length int64) (io.ReadCloser, error) {
+	raw := storage.GetDownloadRaw(taskId)
+	raw.Offset = offset
+	raw.Length = length
+	return s.s3Store.Get(ctx, raw)
+}
+
+// GetDownloadPath returns empty, the download file is not on local file system
+func (s *s3StorageMgr) GetDownloadPath(taskId string) string {
+	return ""
+}
+
+func (s *s3StorageMgr) StatDownloadFile(ctx context.Context, taskId string) (*storedriver.StorageInfo, error) {
+	return s.s3Store.Stat(ctx, storage.GetDownloadRaw(taskId))
+}
+
+// CreateUploadLink does nothing, the download file is uploaded directly
+func (s *s3StorageMgr) CreateUploadLink(ctx context.Context, taskId string) error {
+	return nil
+}
+
+func (s *s3StorageMgr) DeleteTask(ctx context.Context, taskId string) error {
+	if err := s.s3Store.Remove(ctx, storage.GetTaskMetaDataRaw(taskId)); err != nil && !cdnerrors.IsFileNotExist(err) {
+		return err
+	}
+	if err := s.s3Store.Remove(ctx, storage.GetPieceMetaDataRaw(taskId)); err != nil && !cdnerrors.IsFileNotExist(err) {
+		return err
+	}
+	if err := s.s3Store.Remove(ctx, storage.GetDownloadRaw(taskId)); err != nil && !cdnerrors.IsFileNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *s3StorageMgr) ListTaskIds(ctx context.Context) ([]string, error) {
+	return storage.ListTaskIds(ctx, s.s3Store)
+}
+
+func (s *s3StorageMgr) ResetRepo(ctx context.Context, task *types.SeedTask) error {
+	return s.DeleteTask(ctx, task.TaskId)
+}
+
+func init() {
+	storage.Register(&s3Builder{})
+}
@@ -73,6 +73,14 @@ func (cleaner *Cleaner) Gc(ctx context.Context, storagePattern string, force boo
 
 	logger.GcLogger.With("type", storagePattern).Debugf("start to exec gc with fullGC: %t", fullGC)
 
+	pinned, err := compilePatterns(cleaner.Cfg.Pinned)
+	if err != nil {
+		return nil, err
+	}
+	// policy is nil for the default policy
+	policy := gcPolicies[cleaner.Cfg.Policy]
+	var candidates []*gcCandidate
+
 	gapTasks := treemap.NewWith(godsutils.Int64Comparator)
 	intervalTasks := treemap.NewWith(godsutils.Int64Comparator)
 
@@ -105,18 +113,24 @@ func (cleaner *Cleaner) Gc(ctx context.Context, storagePattern string, force boo
 			return nil
 		}
 
-		// add taskId to gcTaskIds slice directly when fullGC equals true.
-		if fullGC {
-			gcTaskIDs = append(gcTaskIDs, taskId)
-			return nil
-		}
-
 		metaData, err := cleaner.StorageMgr.ReadFileMetaData(ctx, taskId)
 		if err != nil || metaData == nil {
 			logger.GcLogger.With("type", storagePattern).Debugf("taskId: %s, failed to get metadata: %v", taskId, err)
 			gcTaskIDs = append(gcTaskIDs, taskId)
 			return nil
 		}
+		if isPinned(metaData, pinned) {
+			logger.GcLogger.With("type", storagePattern).Debugf("taskId: %s is pinned, url: %s", taskId, metaData.URL)
+			return nil
+		}
+		if policy != nil {
+			candidates = append(candidates, cleaner.newCandidate(ctx, metaData))
+			return nil
+		}
+		if fullGC {
+			gcTaskIDs = append(gcTaskIDs, taskId)
+			return nil
+		}
 		// put taskId into gapTasks or intervalTasks which will sort by some rules
 		if err := cleaner.sortInert(ctx, gapTasks, intervalTasks, metaData); err != nil {
 			logger.GcLogger.With("type", storagePattern).Errorf("failed to parse inert metaData(%+v): %v", metaData, err)
@@ -131,13 +145,48 @@ func (cleaner *Cleaner) Gc(ctx context.Context, storagePattern string, force boo
 		return nil, err
 	}
 
-	if !fullGC {
+	if policy != nil {
+		sortCandidates(candidates, policy, timeutils.CurrentTimeMillis(), cleaner.Cfg.HalfLife)
+		if !force {
+			// remove the tasks until the free space is back above the young gc threshold
+			candidates = selectCandidates(candidates, int64(cleaner.Cfg.YoungGCThreshold-freeSpace))
+		}
+		for _, c := range candidates {
+			gcTaskIDs = append(gcTaskIDs, c.taskId)
+		}
+	} else if !fullGC {
 		gcTaskIDs = append(gcTaskIDs, cleaner.getGCTasks(gapTasks, intervalTasks)...)
 	}
 
+	if cleaner.Cfg.DryRun {
+		cleaner.reportDryRun(storagePattern, gcTaskIDs, candidates)
+		return nil, nil
+	}
 	return gcTaskIDs, nil
 }
 
+func (cleaner *Cleaner) newCandidate(ctx context.Context, metaData *FileMetaData) *gcCandidate {
+	size := metaData.CdnFileLength
+	if info, err := cleaner.StorageMgr.StatDownloadFile(ctx, metaData.TaskId); err == nil {
+		size = info.Size
+	}
+	return &gcCandidate{
+		taskId:   metaData.TaskId,
+		metaData: metaData,
+		size:     size,
+	}
+}
+
+// reportDryRun logs the tasks which would be removed by gc, the candidates ranked by a policy are reported in detail
+func (cleaner *Cleaner) reportDryRun(storagePattern string, gcTaskIDs []string, candidates []*gcCandidate) {
+	log := logger.GcLogger.With("type", storagePattern, "dryRun", true)
+	for _, c := range candidates {
+		log.Infof("gc would remove task %s, url: %s, size: %d, hits: %d, score: %f", c.taskId, c.metaData.URL, c.size,
+			c.metaData.HitCount, c.score)
+	}
+	log.Infof("gc would remove %d tasks: %v", len(gcTaskIDs), gcTaskIDs)
+}
+
 func (cleaner *Cleaner) sortInert(ctx context.Context, gapTasks, intervalTasks *treemap.Map,
 	metaData *FileMetaData) error {
 	gap := timeutils.CurrentTimeMillis() - metaData.AccessTime
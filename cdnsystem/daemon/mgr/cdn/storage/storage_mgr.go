@@ -19,6 +19,7 @@ package storage
 import (
 	"bytes"
 	"context"
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
 	"d7y.io/dragonfly/v2/cdnsystem/config"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr"
 	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
@@ -29,6 +30,7 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"io"
+	"os"
 	"strconv"
 	"strings"
 )
@@ -64,19 +66,25 @@ type BuildOptions interface {
 
 // fileMetaData
 type FileMetaData struct {
-	TaskId          string            `json:"taskId"`
-	TaskURL         string            `json:"taskUrl"`
-	PieceSize       int32             `json:"pieceSize"`
-	SourceFileLen   int64             `json:"sourceFileLen"`
-	AccessTime      int64             `json:"accessTime"`
-	Interval        int64             `json:"interval"`
-	CdnFileLength   int64             `json:"cdnFileLength"`
-	SourceRealMd5   string            `json:"sourceRealMd5"`
-	PieceMd5Sign    string            `json:"pieceMd5Sign"`
-	ExpireInfo      map[string]string `json:"expireInfo"`
-	Finish          bool              `json:"finish"`
-	Success         bool              `json:"success"`
-	TotalPieceCount int32             `json:"totalPieceCount"`
+	TaskId           string            `json:"taskId"`
+	TaskURL          string            `json:"taskUrl"`
+	URL              string            `json:"url,omitempty"`
+	Filter           []string          `json:"filter,omitempty"`
+	PieceSize        int32             `json:"pieceSize"`
+	SourceFileLen    int64             `json:"sourceFileLen"`
+	AccessTime       int64             `json:"accessTime"`
+	Interval         int64             `json:"interval"`
+	CdnFileLength    int64             `json:"cdnFileLength"`
+	SourceRealMd5    string            `json:"sourceRealMd5"`
+	Digest           string            `json:"digest,omitempty"`
+	SourceRealDigest string            `json:"sourceRealDigest,omitempty"`
+	PieceMd5Sign     string            `json:"pieceMd5Sign"`
+	ExpireInfo       map[string]string `json:"expireInfo"`
+	Finish           bool              `json:"finish"`
+	Success          bool              `json:"success"`
+	TotalPieceCount  int32             `json:"totalPieceCount"`
+	HitCount         int64             `json:"hitCount"`
+	Pinned           bool              `json:"pinned"`
 	//PieceMetaDataSign string            `json:"pieceMetaDataSign"`
 }
 
@@ -138,6 +146,30 @@ func ParsePieceMetaRecord(value string) (record *PieceMetaRecord, err error) {
 	}, nil
 }
 
+// ListTaskIds walks the download home of store and collects the ids of the tasks which have meta data files.
+func ListTaskIds(ctx context.Context, store storedriver.Driver) ([]string, error) {
+	var taskIds []string
+	walkFn := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), metaDataSuffix) {
+			return nil
+		}
+		taskIds = append(taskIds, strings.TrimSuffix(info.Name(), metaDataSuffix))
+		return nil
+	}
+	if err := store.Walk(ctx, &storedriver.Raw{
+		Bucket: DownloadHome,
+		WalkFn: walkFn,
+	}); err != nil {
+		if cdnerrors.IsFileNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return taskIds, nil
+}
 
 func NewManager(cfg *config.Config) (Manager, error) {
 	sb := getBuilder(cfg.StoragePattern, true)
@@ -158,6 +190,12 @@ type Manager interface {
 
 	ReadDownloadFile(ctx context.Context, taskId string) (io.ReadCloser, error)
 
+	// ReadDownloadFileRange reads length bytes of the download file from offset
+	ReadDownloadFileRange(ctx context.Context, taskId string, offset int64, length int64) (io.ReadCloser, error)
+
+	// GetDownloadPath returns the local path of the download file, empty if the storage is not on local file system
+	GetDownloadPath(taskId string) string
+
 	CreateUploadLink(ctx context.Context, taskId string) error
 
 	ReadFileMetaData(ctx context.Context, taskId string) (*FileMetaData, error)
@@ -172,6 +210,9 @@ type Manager interface {
 
 	DeleteTask(ctx context.Context, taskId string) error
 
+	// ListTaskIds lists the ids of the tasks which have meta data in storage
+	ListTaskIds(ctx context.Context) ([]string, error)
+
 	SetTaskMgr(mgr.SeedTaskMgr)
 
 	InitializeCleaners()
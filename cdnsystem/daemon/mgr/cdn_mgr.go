@@ -32,4 +32,14 @@ type CDNMgr interface {
 	// Delete the cdn meta with specified taskID.
 	// The file on the disk will be deleted when the force is true.
 	Delete(ctx context.Context, taskID string) error
+
+	// RestoreTasks loads the successful tasks from the storage, validates them and publishes their pieces,
+	// it is called once at startup so that the tasks cached before restarting can be served immediately.
+	RestoreTasks(ctx context.Context) ([]*types.SeedTask, error)
+
+	// RecordHit records an access to the cache of task, which is used by the gc policies to rank tasks.
+	RecordHit(ctx context.Context, taskID string) error
+
+	// SetPinned pins or unpins the cache of task, the cache of a pinned task is never removed by gc.
+	SetPinned(ctx context.Context, taskID string, pinned bool) error
 }
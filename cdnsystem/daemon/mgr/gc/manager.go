@@ -59,11 +59,16 @@ type Manager struct {
 	cdnMgr  mgr.CDNMgr
 }
 
-func (gcm *Manager) GCTask(ctx context.Context, taskID string, full bool) {
+func (gcm *Manager) GCTask(ctx context.Context, taskID string, full bool) error {
+	var err error
 	if full {
-		gcm.cdnMgr.Delete(ctx, taskID)
+		err = gcm.cdnMgr.Delete(ctx, taskID)
 	}
-	gcm.taskMgr.Delete(ctx, taskID)
+	// the task is removed from memory even if its files are left, so the cache is checked again when it is registered
+	if e := gcm.taskMgr.Delete(ctx, taskID); err == nil {
+		err = e
+	}
+	return err
 }
 
 // NewManager returns a new Manager.
@@ -26,5 +26,6 @@ type GCMgr interface {
 
 	// GCTask is used to do the gc task job with specified taskID.
 	// The CDN file will be deleted when the full is true.
-	GCTask(ctx context.Context, taskID string, full bool)
+	// The caller should hold the lock of the task.
+	GCTask(ctx context.Context, taskID string, full bool) error
 }
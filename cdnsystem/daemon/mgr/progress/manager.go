@@ -17,7 +17,6 @@
 package progress
 
 import (
-	"container/list"
 	"context"
 	"d7y.io/dragonfly/v2/cdnsystem/config"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr"
@@ -25,10 +24,7 @@ import (
 	"d7y.io/dragonfly/v2/pkg/dferrors"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
 	"d7y.io/dragonfly/v2/pkg/structure/syncmap"
-	"d7y.io/dragonfly/v2/pkg/synclock"
 	"github.com/pkg/errors"
-	"sync"
-	"time"
 )
 
 func init() {
@@ -37,14 +33,14 @@ func init() {
 	var _ mgr.SeedProgressMgr = manager
 }
 
+// Manager keeps an append-only log of the published pieces for every task.
+// Every subscriber reads the log with its own cursor, so a slow subscriber only slows down itself
+// and never misses a piece, while publishing never blocks on subscribers.
 type Manager struct {
-	cfg                  *config.Config
-	seedSubscribers      *syncmap.SyncMap
-	taskPieceMetaRecords *syncmap.SyncMap
-	taskMgr              mgr.SeedTaskMgr
-	mu                   *synclock.LockerPool
-	timeout              time.Duration
-	buffer               int
+	cfg            *config.Config
+	seedProgresses *syncmap.SyncMap
+	taskMgr        mgr.SeedTaskMgr
+	buffer         int
 }
 
 func (pm *Manager) SetTaskMgr(taskMgr mgr.SeedTaskMgr) {
@@ -53,149 +49,109 @@ func (pm *Manager) SetTaskMgr(taskMgr mgr.SeedTaskMgr) {
 
 func NewManager(cfg *config.Config) (*Manager, error) {
 	return &Manager{
-		cfg:                  cfg,
-		seedSubscribers:      syncmap.NewSyncMap(),
-		taskPieceMetaRecords: syncmap.NewSyncMap(),
-		mu:                   synclock.NewLockerPool(),
-		timeout:              3 * time.Second,
-		buffer:               4,
+		cfg:            cfg,
+		seedProgresses: syncmap.NewSyncMap(),
+		buffer:         4,
 	}, nil
 }
 
 func (pm *Manager) InitSeedProgress(ctx context.Context, taskId string) {
-	pm.mu.Lock(taskId, true)
-	if _, ok := pm.seedSubscribers.Load(taskId); ok {
-		logger.WithTaskID(taskId).Debugf("the task seedSubscribers already exist")
-		if _, ok := pm.taskPieceMetaRecords.Load(taskId); ok {
-			logger.WithTaskID(taskId).Debugf("the task taskPieceMetaRecords already exist")
-			pm.mu.UnLock(taskId, true)
-			return
-		}
-	}
-	pm.mu.UnLock(taskId, true)
-	pm.mu.Lock(taskId, false)
-	defer pm.mu.UnLock(taskId, false)
-	if _, loaded := pm.seedSubscribers.LoadOrStore(taskId, list.New()); loaded {
-		logger.WithTaskID(taskId).Info("the task seedSubscribers already exist")
-	}
-	if _, loaded := pm.taskPieceMetaRecords.LoadOrStore(taskId, syncmap.NewSyncMap()); loaded {
-		logger.WithTaskID(taskId).Info("the task taskPieceMetaRecords already exist")
+	// the task is triggered again, the pieces of the last run may be rewritten, so its progress is finished
+	// and replaced by a new one, the cached pieces are published again when cdn detects the cache
+	if v, loaded := pm.seedProgresses.Load(taskId); loaded {
+		v.(*seedProgress).finish()
+		logger.WithTaskID(taskId).Info("the task seed progress already exist, reset it")
 	}
+	pm.seedProgresses.Store(taskId, newSeedProgress())
 }
 
-func (pm *Manager) WatchSeedProgress(ctx context.Context, taskId string) (<-chan *types.SeedPiece, error) {
-	logger.Debugf("watch seed progress begin for taskId:%s", taskId)
-	pm.mu.Lock(taskId, true)
-	defer pm.mu.UnLock(taskId, true)
-	chanList, err := pm.seedSubscribers.GetAsList(taskId)
+func (pm *Manager) WatchSeedProgress(ctx context.Context, taskId string, startPieceNum int32) (<-chan *types.SeedPiece, error) {
+	logger.Debugf("watch seed progress begin for taskId:%s, startPieceNum:%d", taskId, startPieceNum)
+	progress, err := pm.getSeedProgress(taskId)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get seed subscribers")
+		return nil, errors.Wrap(err, "failed to get seed progress")
 	}
-	pieceMetaDataRecords, err := pm.getPieceMetaRecordsByTaskId(taskId)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get piece meta records by taskId")
+	if pm.taskMgr != nil {
+		if task, err := pm.taskMgr.Get(ctx, taskId); err == nil && task.IsDone() {
+			progress.finish()
+		}
 	}
 	ch := make(chan *types.SeedPiece, pm.buffer)
-	ele := chanList.PushBack(ch)
-	go func(seedCh chan *types.SeedPiece, ele *list.Element) {
-		for _, pieceMetaRecord := range pieceMetaDataRecords {
-			logger.Debugf("seed piece meta record %+v", pieceMetaRecord)
+	go pm.deliver(ctx, taskId, progress, startPieceNum, ch)
+	return ch, nil
+}
+
+// deliver sends the pieces not less than startPieceNum to the subscriber in publishing order,
+// it blocks when the subscriber falls behind and closes the channel after the task is finished or ctx is done.
+func (pm *Manager) deliver(ctx context.Context, taskId string, progress *seedProgress, startPieceNum int32,
+	ch chan<- *types.SeedPiece) {
+	defer close(ch)
+	cursor := 0
+	for {
+		records, notify, finished := progress.read(cursor)
+		if len(records) == 0 {
+			if finished {
+				return
+			}
 			select {
-			case seedCh <- pieceMetaRecord:
-			case <-time.After(pm.timeout):
+			case <-notify:
+				continue
+			case <-ctx.Done():
+				logger.WithTaskID(taskId).Warnf("subscriber stopped at cursor %d: %v", cursor, ctx.Err())
+				return
 			}
 		}
-		if task, err := pm.taskMgr.Get(ctx, taskId); err == nil && task.IsDone() {
-			chanList.Remove(ele)
-			close(seedCh)
+		for _, record := range records {
+			cursor++
+			if record.PieceNum < startPieceNum {
+				continue
+			}
+			select {
+			case ch <- record:
+			case <-ctx.Done():
+				logger.WithTaskID(taskId).Warnf("subscriber stopped at piece %d: %v", record.PieceNum, ctx.Err())
+				return
+			}
 		}
-	}(ch, ele)
-	return ch, nil
+	}
 }
 
 // Publish publish seedPiece
 func (pm *Manager) PublishPiece(ctx context.Context, taskId string, record *types.SeedPiece) error {
 	logger.Debugf("seed piece meta record %+v", record)
-	pm.mu.Lock(taskId, false)
-	defer pm.mu.UnLock(taskId, false)
-	err := pm.setPieceMetaRecord(taskId, record)
-	if err != nil {
-		errors.Wrap(err, "failed to set piece meta record")
-	}
-	chanList, err := pm.seedSubscribers.GetAsList(taskId)
+	progress, err := pm.getSeedProgress(taskId)
 	if err != nil {
-		return errors.Wrap(err, "failed to get seed subscribers")
+		return errors.Wrap(err, "failed to get seed progress")
 	}
-	var wg sync.WaitGroup
-	for e := chanList.Front(); e != nil; e = e.Next() {
-		wg.Add(1)
-		sub := e.Value.(chan *types.SeedPiece)
-		go func(sub chan *types.SeedPiece, record *types.SeedPiece) {
-			defer wg.Done()
-			select {
-			case sub <- record:
-			case <-time.After(pm.timeout):
-			}
-
-		}(sub, record)
-	}
-	wg.Wait()
+	progress.append(record)
 	return nil
 }
 
 func (pm *Manager) PublishTask(ctx context.Context, taskId string, task *types.SeedTask) error {
 	logger.Debugf("publish task record %+v", task)
-	pm.mu.Lock(taskId, false)
-	defer pm.mu.UnLock(taskId, false)
-	chanList, err := pm.seedSubscribers.GetAsList(taskId)
+	progress, err := pm.getSeedProgress(taskId)
 	if err != nil {
-		return errors.Wrap(err, "failed to get seed subscribers")
-	}
-	// unwatch
-	for e := chanList.Front(); e != nil; e = e.Next() {
-		chanList.Remove(e)
-		sub, ok := e.Value.(chan *types.SeedPiece)
-		if !ok {
-			logger.Warnf("failed to convert chan seedPiece, e.Value:%v", e.Value)
-			continue
-		}
-		close(sub)
+		return errors.Wrap(err, "failed to get seed progress")
 	}
+	progress.finish()
 	return nil
 }
 
 func (pm *Manager) Clear(ctx context.Context, taskID string) error {
-	pm.mu.Lock(taskID, false)
-	defer pm.mu.UnLock(taskID, false)
-	chanList, err := pm.seedSubscribers.GetAsList(taskID)
-	if err != nil && errors.Cause(err) != dferrors.ErrDataNotFound {
-		return errors.Wrap(err, "failed to get seed subscribers")
+	if progress, err := pm.getSeedProgress(taskID); err == nil {
+		progress.finish()
 	}
-	if chanList != nil {
-		for e := chanList.Front(); e != nil; e = e.Next() {
-			chanList.Remove(e)
-			sub, ok := e.Value.(chan *types.SeedPiece)
-			if !ok {
-				logger.Warnf("failed to convert chan seedPiece, e.Value:%v", e.Value)
-				continue
-			}
-			close(sub)
-		}
-		chanList = nil
-	}
-	err = pm.seedSubscribers.Remove(taskID)
+	err := pm.seedProgresses.Remove(taskID)
 	if err != nil && dferrors.ErrDataNotFound != errors.Cause(err) {
-		return errors.Wrap(err, "failed to clear seed subscribes")
-	}
-	err = pm.taskPieceMetaRecords.Remove(taskID)
-	if err != nil && dferrors.ErrDataNotFound != errors.Cause(err) {
-		return errors.Wrap(err, "failed to clear piece meta records")
+		return errors.Wrap(err, "failed to clear seed progress")
 	}
 	return nil
 }
 
 func (pm *Manager) GetPieces(ctx context.Context, taskID string) (records []*types.SeedPiece, err error) {
-	pm.mu.Lock(taskID, true)
-	defer pm.mu.UnLock(taskID, true)
-	return pm.getPieceMetaRecordsByTaskId(taskID)
+	progress, err := pm.getSeedProgress(taskID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get seed progress")
+	}
+	return progress.sortedPieces(), nil
 }
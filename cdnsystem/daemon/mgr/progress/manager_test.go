@@ -0,0 +1,152 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package progress
+
+import (
+	"context"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+func TestProgressManager(t *testing.T) {
+	suite.Run(t, new(ProgressManagerTestSuite))
+}
+
+type ProgressManagerTestSuite struct {
+	pm *Manager
+	suite.Suite
+}
+
+func (s *ProgressManagerTestSuite) SetupTest() {
+	s.pm, _ = NewManager(nil)
+}
+
+func (s *ProgressManagerTestSuite) TestSlowWatcherMissesNothing() {
+	ctx := context.Background()
+	s.pm.InitSeedProgress(ctx, "task")
+	ch, err := s.pm.WatchSeedProgress(ctx, "task", 0)
+	s.Nil(err)
+
+	// publishing never blocks although nobody reads the channel
+	for i := int32(0); i < 100; i++ {
+		s.Nil(s.pm.PublishPiece(ctx, "task", &types.SeedPiece{PieceNum: i}))
+	}
+	s.Nil(s.pm.PublishTask(ctx, "task", nil))
+
+	var nums []int32
+	for piece := range ch {
+		nums = append(nums, piece.PieceNum)
+		time.Sleep(time.Millisecond)
+	}
+	s.Len(nums, 100)
+	for i, num := range nums {
+		s.Equal(int32(i), num)
+	}
+}
+
+func (s *ProgressManagerTestSuite) TestResumeFromPieceNum() {
+	ctx := context.Background()
+	s.pm.InitSeedProgress(ctx, "task")
+	for _, num := range []int32{3, 0, 1, 4, 2} {
+		s.Nil(s.pm.PublishPiece(ctx, "task", &types.SeedPiece{PieceNum: num}))
+	}
+	// duplicated pieces are published only once
+	s.Nil(s.pm.PublishPiece(ctx, "task", &types.SeedPiece{PieceNum: 3}))
+
+	ch, err := s.pm.WatchSeedProgress(ctx, "task", 2)
+	s.Nil(err)
+	s.Nil(s.pm.PublishPiece(ctx, "task", &types.SeedPiece{PieceNum: 5}))
+	s.Nil(s.pm.PublishTask(ctx, "task", nil))
+
+	var nums []int32
+	for piece := range ch {
+		nums = append(nums, piece.PieceNum)
+	}
+	s.Equal([]int32{3, 4, 2, 5}, nums)
+
+	pieces, err := s.pm.GetPieces(ctx, "task")
+	s.Nil(err)
+	s.Len(pieces, 6)
+	s.Equal(int32(0), pieces[0].PieceNum)
+}
+
+func (s *ProgressManagerTestSuite) TestRetrigger() {
+	ctx := context.Background()
+	s.pm.InitSeedProgress(ctx, "task")
+	oldCh, err := s.pm.WatchSeedProgress(ctx, "task", 0)
+	s.Nil(err)
+	s.Nil(s.pm.PublishPiece(ctx, "task", &types.SeedPiece{PieceNum: 0, PieceMd5: "old0"}))
+	s.Nil(s.pm.PublishPiece(ctx, "task", &types.SeedPiece{PieceNum: 1, PieceMd5: "old1"}))
+
+	// the task is triggered again before the last run is finished, the watchers of the last run are closed
+	s.pm.InitSeedProgress(ctx, "task")
+	select {
+	case <-drain(oldCh):
+	case <-time.After(time.Second):
+		s.Fail("watcher of the last run is not closed after the task is triggered again")
+	}
+
+	// the pieces of the last run are not served, the rewritten pieces are published again
+	ch, err := s.pm.WatchSeedProgress(ctx, "task", 0)
+	s.Nil(err)
+	s.Nil(s.pm.PublishPiece(ctx, "task", &types.SeedPiece{PieceNum: 0, PieceMd5: "new0"}))
+	s.Nil(s.pm.PublishPiece(ctx, "task", &types.SeedPiece{PieceNum: 1, PieceMd5: "new1"}))
+	s.Nil(s.pm.PublishTask(ctx, "task", nil))
+
+	var md5s []string
+	for piece := range ch {
+		md5s = append(md5s, piece.PieceMd5)
+	}
+	s.Equal([]string{"new0", "new1"}, md5s)
+
+	pieces, err := s.pm.GetPieces(ctx, "task")
+	s.Nil(err)
+	s.Len(pieces, 2)
+	s.Equal("new0", pieces[0].PieceMd5)
+}
+
+func (s *ProgressManagerTestSuite) TestWatcherCanceled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.pm.InitSeedProgress(ctx, "task")
+	ch, err := s.pm.WatchSeedProgress(ctx, "task", 0)
+	s.Nil(err)
+	s.Nil(s.pm.PublishPiece(context.Background(), "task", &types.SeedPiece{PieceNum: 0}))
+	cancel()
+
+	select {
+	case <-drain(ch):
+	case <-time.After(time.Second):
+		s.Fail("watcher is not closed after canceled")
+	}
+}
+
+func (s *ProgressManagerTestSuite) TestWatchUnknownTask() {
+	_, err := s.pm.WatchSeedProgress(context.Background(), "unknown", 0)
+	s.NotNil(err)
+}
+
+func drain(ch <-chan *types.SeedPiece) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	return done
+}
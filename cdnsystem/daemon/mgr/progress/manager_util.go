@@ -17,51 +17,88 @@
 package progress
 
 import (
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
 	"github.com/pkg/errors"
 	"sort"
-	"strconv"
+	"sync"
 )
 
-// setPieceMetaRecord
-func (pm *Manager) setPieceMetaRecord(taskID string, record *types.SeedPiece) error {
-	pieceRecords, err := pm.taskPieceMetaRecords.GetAsMap(taskID)
-	if err != nil {
-		return err
+// seedProgress is the piece log of a task
+type seedProgress struct {
+	sync.Mutex
+	// pieces in publishing order, a piece is only appended once
+	pieces    []*types.SeedPiece
+	pieceNums map[int32]struct{}
+	// notify is closed and replaced when pieces are appended or the task is finished
+	notify   chan struct{}
+	finished bool
+}
+
+func newSeedProgress() *seedProgress {
+	return &seedProgress{
+		pieceNums: make(map[int32]struct{}),
+		notify:    make(chan struct{}),
 	}
-	return pieceRecords.Add(strconv.Itoa(int(record.PieceNum)), record)
 }
 
-// getPieceMetaRecordsByTaskId
-func (pm *Manager) getPieceMetaRecordsByTaskId(taskID string) (records []*types.SeedPiece, err error) {
-	pieceRecords, err := pm.taskPieceMetaRecords.GetAsMap(taskID)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get piece meta records")
+func (sp *seedProgress) append(record *types.SeedPiece) {
+	sp.Lock()
+	defer sp.Unlock()
+	if _, ok := sp.pieceNums[record.PieceNum]; ok {
+		return
 	}
-	pieceNums := pieceRecords.ListKeyAsIntSlice()
-	sort.Ints(pieceNums)
-	for i := 0; i < len(pieceNums); i++ {
-		v, _ := pieceRecords.Get(strconv.Itoa(pieceNums[i]))
-		if value, ok := v.(*types.SeedPiece); ok {
-			records = append(records, value)
-		}
+	sp.pieceNums[record.PieceNum] = struct{}{}
+	sp.pieces = append(sp.pieces, record)
+	sp.broadcast()
+}
+
+func (sp *seedProgress) finish() {
+	sp.Lock()
+	defer sp.Unlock()
+	if sp.finished {
+		return
 	}
-	return records, nil
+	sp.finished = true
+	sp.broadcast()
 }
 
-//func (pm *Manager) unWatchSeedProgress(sub chan *types.SeedPiece, taskID string) error {
-//	chanList, err := pm.seedSubscribers.GetAsList(taskID)
-//	if err != nil {
-//		return errors.Wrap(err, "failed to get seed subscribers")
-//	}
-//	for e := chanList.Front(); e != nil; e = e.Next() {
-//		if e.Value.(chan *types.SeedPiece) == sub {
-//			chanList.Remove(e)
-//			break
-//		}
-//	}
-//	close(sub)
-//	return nil
-//}
+// read returns the pieces after cursor, the channel closed on next change and whether the task is finished
+func (sp *seedProgress) read(cursor int) ([]*types.SeedPiece, <-chan struct{}, bool) {
+	sp.Lock()
+	defer sp.Unlock()
+	var records []*types.SeedPiece
+	if cursor < len(sp.pieces) {
+		records = sp.pieces[cursor:len(sp.pieces):len(sp.pieces)]
+	}
+	return records, sp.notify, sp.finished
+}
 
+func (sp *seedProgress) sortedPieces() []*types.SeedPiece {
+	sp.Lock()
+	records := make([]*types.SeedPiece, len(sp.pieces))
+	copy(records, sp.pieces)
+	sp.Unlock()
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].PieceNum < records[j].PieceNum
+	})
+	return records
+}
+
+// broadcast must be called with the lock held
+func (sp *seedProgress) broadcast() {
+	close(sp.notify)
+	sp.notify = make(chan struct{})
+}
 
+// getSeedProgress
+func (pm *Manager) getSeedProgress(taskID string) (*seedProgress, error) {
+	v, err := pm.seedProgresses.Get(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if progress, ok := v.(*seedProgress); ok {
+		return progress, nil
+	}
+	return nil, errors.Wrapf(cdnerrors.ErrConvertFailed, "origin object: %+v", v)
+}
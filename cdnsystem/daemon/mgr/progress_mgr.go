@@ -27,8 +27,9 @@ type SeedProgressMgr interface {
 	// InitSeedProgress init task seed progress
 	InitSeedProgress(ctx context.Context, taskId string)
 
-	// WatchSeedProgress watch task seed progress
-	WatchSeedProgress(ctx context.Context, taskId string) (<-chan *types.SeedPiece, error)
+	// WatchSeedProgress watch task seed progress from startPieceNum, no piece is dropped for a slow watcher,
+	// the channel is closed when the task is finished or ctx is done
+	WatchSeedProgress(ctx context.Context, taskId string, startPieceNum int32) (<-chan *types.SeedPiece, error)
 
 	// PublishPiece publish piece seed
 	PublishPiece(ctx context.Context, taskId string, record *types.SeedPiece) error
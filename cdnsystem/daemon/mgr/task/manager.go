@@ -43,26 +43,29 @@ func init() {
 
 // Manager is an implementation of the interface of TaskMgr.
 type Manager struct {
-	cfg                     *config.Config
-	taskStore               *syncmap.SyncMap
-	accessTimeMap           *syncmap.SyncMap
-	taskURLUnReachableStore *syncmap.SyncMap
-	resourceClient          source.ResourceClient
-	cdnMgr                  mgr.CDNMgr
-	progressMgr             mgr.SeedProgressMgr
+	cfg              *config.Config
+	taskStore        *syncmap.SyncMap
+	accessTimeMap    *syncmap.SyncMap
+	sourceErrorStore *syncmap.SyncMap
+	resourceClient   source.ResourceClient
+	cdnMgr           mgr.CDNMgr
+	progressMgr      mgr.SeedProgressMgr
 }
 
 // NewManager returns a new Manager Object.
 func NewManager(cfg *config.Config, cdnMgr mgr.CDNMgr, progressMgr mgr.SeedProgressMgr,
 	resourceClient source.ResourceClient) (*Manager, error) {
+	if err := cfg.PieceSizePolicy.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid piece size policy")
+	}
 	taskMgr := &Manager{
-		cfg:                     cfg,
-		taskStore:               syncmap.NewSyncMap(),
-		accessTimeMap:           syncmap.NewSyncMap(),
-		taskURLUnReachableStore: syncmap.NewSyncMap(),
-		resourceClient:          resourceClient,
-		cdnMgr:                  cdnMgr,
-		progressMgr:             progressMgr,
+		cfg:              cfg,
+		taskStore:        syncmap.NewSyncMap(),
+		accessTimeMap:    syncmap.NewSyncMap(),
+		sourceErrorStore: syncmap.NewSyncMap(),
+		resourceClient:   resourceClient,
+		cdnMgr:           cdnMgr,
+		progressMgr:      progressMgr,
 	}
 	gc.Register("task", cfg.GCInitialDelay, cfg.GCMetaInterval, taskMgr)
 	return taskMgr, nil
@@ -81,13 +84,34 @@ func (tm *Manager) Register(ctx context.Context, req *types.TaskRegisterRequest)
 		logger.WithTaskID(task.TaskId).Warnf("failed to update accessTime: %v", err)
 	}
 
+	// the cache of a successful task is served without triggering cdn, which detects cache and records the hit
+	if task.IsSuccess() {
+		if err := tm.cdnMgr.RecordHit(ctx, task.TaskId); err != nil {
+			logger.WithTaskID(task.TaskId).Warnf("failed to record hit: %v", err)
+		}
+	}
 	// trigger CDN
 	if err := tm.triggerCdnSyncAction(ctx, task); err != nil {
 		return nil, errors.Wrapf(err, "failed to trigger cdn")
 	}
 	logger.WithTaskID(task.TaskId).Infof("successfully trigger cdn sync action")
 	// watch seed progress
-	return tm.progressMgr.WatchSeedProgress(ctx, task.TaskId)
+	return tm.progressMgr.WatchSeedProgress(ctx, task.TaskId, req.StartPieceNum)
+}
+
+// Restore adds the tasks restored by cdn manager into the task table, it should be called before serving
+func (tm *Manager) Restore(ctx context.Context) error {
+	tasks, err := tm.cdnMgr.RestoreTasks(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to restore tasks")
+	}
+	for _, task := range tasks {
+		// the restored tasks are treated as just accessed, the task gc removes them when they are not used again
+		tm.taskStore.Add(task.TaskId, task)
+		tm.accessTimeMap.Add(task.TaskId, time.Now())
+		logger.WithTaskID(task.TaskId).Infof("successfully restore task: %+v", task)
+	}
+	return nil
 }
 
 // triggerCdnSyncAction
@@ -107,10 +131,8 @@ func (tm *Manager) triggerCdnSyncAction(ctx context.Context, task *types.SeedTas
 		logger.WithTaskID(task.TaskId).Infof("reconfirm find seedTask is running or has been downloaded successfully, status:%s", task.CdnStatus)
 		return nil
 	}
-	if task.IsWait() {
-		tm.progressMgr.InitSeedProgress(ctx, task.TaskId)
-		logger.WithTaskID(task.TaskId).Infof("successfully init seed progress for task")
-	}
+	tm.progressMgr.InitSeedProgress(ctx, task.TaskId)
+	logger.WithTaskID(task.TaskId).Infof("successfully init seed progress for task")
 
 	updatedTask, err := tm.updateTask(task.TaskId, &types.SeedTask{
 		CdnStatus: types.TaskInfoCdnStatusRunning,
@@ -124,13 +146,17 @@ func (tm *Manager) triggerCdnSyncAction(ctx context.Context, task *types.SeedTas
 		if err != nil {
 			logger.WithTaskID(task.TaskId).Errorf("trigger cdn get error: %v", err)
 		}
-		go tm.progressMgr.PublishTask(ctx, task.TaskId, updateTaskInfo)
+		tm.updateSourceError(task.TaskId, updateTaskInfo, err)
 		updatedTask, err = tm.updateTask(task.TaskId, updateTaskInfo)
 		if err != nil {
 			logger.WithTaskID(task.TaskId).Errorf("failed to update task:%v", err)
 		} else {
 			logger.WithTaskID(task.TaskId).Infof("successfully update task cdn updatedTask:%+v", updatedTask)
 		}
+		// publish after the task is updated, so that watchers see the final status
+		if err := tm.progressMgr.PublishTask(ctx, task.TaskId, updateTaskInfo); err != nil {
+			logger.WithTaskID(task.TaskId).Errorf("failed to publish task:%v", err)
+		}
 	}()
 	return nil
 }
@@ -164,13 +190,22 @@ func (tm Manager) Get(ctx context.Context, taskId string) (*types.SeedTask, erro
 	return tm.getTask(taskId)
 }
 
+func (tm Manager) Exist(ctx context.Context, taskId string) (*types.SeedTask, bool) {
+	v, err := tm.taskStore.Get(taskId)
+	if err != nil {
+		return nil, false
+	}
+	task, ok := v.(*types.SeedTask)
+	return task, ok
+}
+
 func (tm Manager) GetAccessTime(ctx context.Context) (*syncmap.SyncMap, error) {
 	return tm.accessTimeMap, nil
 }
 
 func (tm Manager) Delete(ctx context.Context, taskId string) error {
 	tm.accessTimeMap.Delete(taskId)
-	tm.taskURLUnReachableStore.Delete(taskId)
+	tm.sourceErrorStore.Delete(taskId)
 	tm.taskStore.Delete(taskId)
 	tm.progressMgr.Clear(ctx, taskId)
 	return nil
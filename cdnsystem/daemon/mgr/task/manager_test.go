@@ -0,0 +1,143 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package task
+
+import (
+	"context"
+	"testing"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/progress"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
+	"github.com/pkg/errors"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+// metaStorage keeps the meta data of the finished tasks in memory, the other operations of storage are not supported
+type metaStorage struct {
+	storage.Manager
+	fileMetaData     map[string]*storage.FileMetaData
+	pieceMetaRecords map[string][]*storage.PieceMetaRecord
+}
+
+func (s *metaStorage) ListTaskIds(ctx context.Context) ([]string, error) {
+	var taskIds []string
+	for taskId := range s.fileMetaData {
+		taskIds = append(taskIds, taskId)
+	}
+	return taskIds, nil
+}
+
+func (s *metaStorage) ReadFileMetaData(ctx context.Context, taskId string) (*storage.FileMetaData, error) {
+	if metaData, ok := s.fileMetaData[taskId]; ok {
+		copied := *metaData
+		return &copied, nil
+	}
+	return nil, cdnerrors.ErrFileNotExist
+}
+
+func (s *metaStorage) WriteFileMetaData(ctx context.Context, taskId string, data *storage.FileMetaData) error {
+	s.fileMetaData[taskId] = data
+	return nil
+}
+
+func (s *metaStorage) ReadPieceMetaRecords(ctx context.Context, taskId string) ([]*storage.PieceMetaRecord, error) {
+	return s.pieceMetaRecords[taskId], nil
+}
+
+func (s *metaStorage) StatDownloadFile(ctx context.Context, taskId string) (*storedriver.StorageInfo, error) {
+	return &storedriver.StorageInfo{Size: s.fileMetaData[taskId].CdnFileLength}, nil
+}
+
+func TestManager_RegisterRestoredDigestTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctx := context.Background()
+	taskId := "restored-task"
+	url := "http://example.com/blob"
+	digest := "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	record := &storage.PieceMetaRecord{
+		PieceNum:    0,
+		PieceLen:    5,
+		Md5:         "5d41402abc4b2a76b9719d911017c592",
+		Range:       &rangeutils.Range{StartIndex: 0, EndIndex: 4},
+		OriginRange: &rangeutils.Range{StartIndex: 0, EndIndex: 4},
+	}
+	store := &metaStorage{
+		fileMetaData: map[string]*storage.FileMetaData{
+			taskId: {
+				TaskId:           taskId,
+				TaskURL:          url,
+				URL:              url,
+				PieceSize:        5,
+				SourceFileLen:    5,
+				CdnFileLength:    5,
+				SourceRealMd5:    "5d41402abc4b2a76b9719d911017c592",
+				Digest:           digest,
+				SourceRealDigest: digest,
+				PieceMd5Sign:     digestutils.Sha256(record.Md5),
+				Finish:           true,
+				Success:          true,
+				TotalPieceCount:  1,
+			},
+		},
+		pieceMetaRecords: map[string][]*storage.PieceMetaRecord{taskId: {record}},
+	}
+
+	cfg := config.New()
+	progressMgr, err := progress.NewManager(cfg)
+	assert.Nil(err)
+	cdnMgr, err := cdn.NewManager(cfg, store, progressMgr, nil)
+	assert.Nil(err)
+	tm, err := NewManager(cfg, cdnMgr, progressMgr, nil)
+	assert.Nil(err)
+	progressMgr.SetTaskMgr(tm)
+	assert.Nil(tm.Restore(ctx))
+
+	task, err := tm.Get(ctx, taskId)
+	assert.Nil(err)
+	assert.Equal(digest, task.RequestDigest)
+	assert.Equal(digest, task.SourceRealDigest)
+
+	// the restored task is served from cache when it is registered again with the same digest
+	pieceChan, err := tm.Register(ctx, &types.TaskRegisterRequest{
+		URL:    url,
+		TaskId: taskId,
+		Digest: digest,
+	})
+	if !assert.Nil(err) {
+		return
+	}
+	var pieces []*types.SeedPiece
+	for piece := range pieceChan {
+		pieces = append(pieces, piece)
+	}
+	assert.Len(pieces, 1)
+	assert.Equal(int64(1), store.fileMetaData[taskId].HitCount)
+
+	_, err = tm.Register(ctx, &types.TaskRegisterRequest{
+		URL:    url,
+		TaskId: taskId,
+		Digest: "sha256:486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7",
+	})
+	assert.True(errors.Is(err, cdnerrors.ErrTaskIdDuplicate))
+}
@@ -23,7 +23,6 @@ import (
 	"time"
 
 	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
-	"d7y.io/dragonfly/v2/cdnsystem/config"
 	"d7y.io/dragonfly/v2/cdnsystem/types"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
 	urlutils2 "d7y.io/dragonfly/v2/pkg/util/net/urlutils"
@@ -45,22 +44,18 @@ func (tm *Manager) addOrUpdateTask(ctx context.Context, request *types.TaskRegis
 	taskId := request.TaskId
 	synclock.Lock(taskId, false)
 	defer synclock.UnLock(taskId, false)
-	if key, err := tm.taskURLUnReachableStore.Get(taskId); err == nil {
-		if unReachableStartTime, ok := key.(time.Time); ok &&
-			time.Since(unReachableStartTime) < tm.cfg.FailAccessInterval {
-			return nil, errors.Wrapf(cdnerrors.ErrURLNotReachable,
-				"task hit unReachable cache and interval less than %d, url: %s", tm.cfg.FailAccessInterval, request.URL)
-		}
-		tm.taskURLUnReachableStore.Delete(taskId)
-		logger.Debugf("delete taskId:%s from url unReachable store", taskId)
+	if err := tm.checkSourceError(taskId); err != nil {
+		return nil, errors.Wrapf(err, "url: %s", request.URL)
 	}
 	var task *types.SeedTask
 	newTask := &types.SeedTask{
 		TaskId:           taskId,
 		Header:           request.Header,
 		RequestMd5:       request.Md5,
+		RequestDigest:    request.Digest,
 		Url:              request.URL,
 		TaskUrl:          taskURL,
+		Filter:           request.Filter,
 		CdnStatus:        types.TaskInfoCdnStatusWaiting,
 		SourceFileLength: IllegalSourceFileLen,
 	}
@@ -86,8 +81,8 @@ func (tm *Manager) addOrUpdateTask(ctx context.Context, request *types.TaskRegis
 	if err != nil {
 		logger.WithTaskID(task.TaskId).Errorf("failed to get url (%s) content length: %v", task.Url, err)
 
-		if cdnerrors.IsURLNotReachable(err) {
-			tm.taskURLUnReachableStore.Add(taskId, time.Now())
+		if cdnerrors.IsSourceError(err) {
+			tm.recordSourceError(taskId, err)
 			return nil, err
 		}
 	}
@@ -102,14 +97,88 @@ func (tm *Manager) addOrUpdateTask(ctx context.Context, request *types.TaskRegis
 
 	// calculate piece size and update the PieceSize and PieceTotal
 	if task.PieceSize <= 0 {
-		pieceSize := computePieceSize(task.SourceFileLength)
-		task.PieceSize = pieceSize
+		task.PieceSize = tm.cfg.PieceSizePolicy.Compute(task.TaskUrl, task.SourceFileLength, request.PeerCount)
 	}
 	tm.taskStore.Add(task.TaskId, task)
 	logger.Debugf("success add task:%+v into taskStore", task)
 	return task, nil
 }
 
+// sourceError is the negative cache of a task whose source failed, the task is not downloaded from source again until retryTime
+type sourceError struct {
+	err       error
+	failures  int
+	retryTime time.Time
+}
+
+// checkSourceError returns the cached source error of task if it should not be retried yet.
+// The cache is kept after the retry time, so that the backoff keeps growing when the source fails again.
+func (tm *Manager) checkSourceError(taskId string) error {
+	v, err := tm.sourceErrorStore.Get(taskId)
+	if err != nil {
+		return nil
+	}
+	se, ok := v.(*sourceError)
+	if !ok || !time.Now().Before(se.retryTime) {
+		return nil
+	}
+	return errors.Wrapf(se.err, "task hit source error cache %d times, retry after %s", se.failures,
+		se.retryTime.Format(time.RFC3339))
+}
+
+// recordSourceError caches the source error of task with exponential backoff,
+// the caller should hold the write lock of task.
+func (tm *Manager) recordSourceError(taskId string, err error) {
+	if tm.cfg.FailAccessInterval <= 0 {
+		return
+	}
+	se := &sourceError{}
+	if v, e := tm.sourceErrorStore.Get(taskId); e == nil {
+		if old, ok := v.(*sourceError); ok {
+			se.failures = old.failures
+		}
+	}
+	se.err = err
+	se.failures++
+	interval := backoffInterval(tm.cfg.FailAccessInterval, tm.cfg.FailAccessMaxInterval, se.failures)
+	se.retryTime = time.Now().Add(interval)
+	tm.sourceErrorStore.Add(taskId, se)
+	logger.WithTaskID(taskId).Warnf("cache source error for %s, failures: %d: %v", interval, se.failures, err)
+}
+
+// updateSourceError caches the source error of a cdn result, or clears the cache when the task succeeds.
+func (tm *Manager) updateSourceError(taskId string, updateTaskInfo *types.SeedTask, err error) {
+	if updateTaskInfo == nil {
+		return
+	}
+	synclock.Lock(taskId, false)
+	defer synclock.UnLock(taskId, false)
+	switch updateTaskInfo.CdnStatus {
+	case types.TaskInfoCdnStatusSuccess:
+		tm.sourceErrorStore.Delete(taskId)
+	case types.TaskInfoCdnStatusSourceError:
+		if err == nil {
+			err = cdnerrors.ErrURLNotReachable
+		}
+		tm.recordSourceError(taskId, err)
+	}
+}
+
+// backoffInterval doubles the interval from base for every failure after the first one, and caps it by max
+func backoffInterval(base, max time.Duration, failures int) time.Duration {
+	interval := base
+	for i := 1; i < failures; i++ {
+		if max > 0 && interval >= max {
+			break
+		}
+		interval *= 2
+	}
+	if max > 0 && interval > max {
+		interval = max
+	}
+	return interval
+}
+
 // updateTask
 func (tm *Manager) updateTask(taskId string, updateTaskInfo *types.SeedTask) (*types.SeedTask, error) {
 	if stringutils.IsBlank(taskId) {
@@ -138,6 +207,7 @@ func (tm *Manager) updateTask(taskId string, updateTaskInfo *types.SeedTask) (*t
 		// only update the task CdnStatus when the new task CDNStatus and
 		// the origin CDNStatus both not equals success
 		task.CdnStatus = updateTaskInfo.CdnStatus
+		task.SourceStatusCode = updateTaskInfo.SourceStatusCode
 		return task, nil
 	}
 
@@ -151,6 +221,10 @@ func (tm *Manager) updateTask(taskId string, updateTaskInfo *types.SeedTask) (*t
 		task.SourceRealMd5 = updateTaskInfo.SourceRealMd5
 	}
 
+	if !stringutils.IsBlank(updateTaskInfo.SourceRealDigest) {
+		task.SourceRealDigest = updateTaskInfo.SourceRealDigest
+	}
+
 	if !stringutils.IsBlank(updateTaskInfo.PieceMd5Sign) {
 		task.PieceMd5Sign = updateTaskInfo.PieceMd5Sign
 	}
@@ -163,6 +237,7 @@ func (tm *Manager) updateTask(taskId string, updateTaskInfo *types.SeedTask) (*t
 		task.PieceTotal = pieceTotal
 	}
 	task.CdnStatus = updateTaskInfo.CdnStatus
+	task.SourceStatusCode = 0
 	return task, nil
 }
 
@@ -171,6 +246,10 @@ func isSameTask(task1, task2 *types.SeedTask) bool {
 	if task1 == task2 {
 		return true
 	}
+	// content addressed tasks are the same whatever urls they come from
+	if !stringutils.IsBlank(task1.RequestDigest) || !stringutils.IsBlank(task2.RequestDigest) {
+		return task1.RequestDigest == task2.RequestDigest
+	}
 	if task1.TaskUrl != task2.TaskUrl {
 		return false
 	}
@@ -187,20 +266,3 @@ func isSameTask(task1, task2 *types.SeedTask) bool {
 
 	return true
 }
-
-// computePieceSize computes the piece size with specified fileLength.
-//
-// If the fileLength<=0, which means failed to get fileLength
-// and then use the DefaultPieceSize.
-func computePieceSize(length int64) int32 {
-	if length <= 0 || length <= 200*1024*1024 {
-		return config.DefaultPieceSize
-	}
-
-	gapCount := length / int64(100*1024*1024)
-	mpSize := (gapCount-2)*1024*1024 + config.DefaultPieceSize
-	if mpSize > config.DefaultPieceSizeLimit {
-		return config.DefaultPieceSizeLimit
-	}
-	return int32(mpSize)
-}
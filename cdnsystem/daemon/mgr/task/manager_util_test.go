@@ -0,0 +1,99 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package task
+
+import (
+	"testing"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"d7y.io/dragonfly/v2/pkg/structure/syncmap"
+	"github.com/pkg/errors"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestBackoffInterval(t *testing.T) {
+	assert := testifyassert.New(t)
+	assert.Equal(time.Minute, backoffInterval(time.Minute, 10*time.Minute, 1))
+	assert.Equal(2*time.Minute, backoffInterval(time.Minute, 10*time.Minute, 2))
+	assert.Equal(8*time.Minute, backoffInterval(time.Minute, 10*time.Minute, 4))
+	assert.Equal(10*time.Minute, backoffInterval(time.Minute, 10*time.Minute, 5))
+	assert.Equal(10*time.Minute, backoffInterval(time.Minute, 10*time.Minute, 100))
+	assert.Equal(16*time.Minute, backoffInterval(time.Minute, 0, 5))
+}
+
+func TestSourceErrorCache(t *testing.T) {
+	assert := testifyassert.New(t)
+	tm := &Manager{
+		cfg: &config.Config{BaseProperties: &config.BaseProperties{
+			FailAccessInterval:    time.Minute,
+			FailAccessMaxInterval: time.Hour,
+		}},
+		sourceErrorStore: syncmap.NewSyncMap(),
+	}
+
+	assert.Nil(tm.checkSourceError("task"))
+	tm.updateSourceError("task", &types.SeedTask{
+		CdnStatus:        types.TaskInfoCdnStatusSourceError,
+		SourceStatusCode: 404,
+	}, errors.Wrap(cdnerrors.NewSourceError(404, "404 Not Found"), "download failed"))
+	err := tm.checkSourceError("task")
+	assert.NotNil(err)
+	assert.Equal(404, cdnerrors.GetSourceStatusCode(err))
+
+	tm.recordSourceError("task", cdnerrors.ErrURLNotReachable)
+	v, _ := tm.sourceErrorStore.Get("task")
+	se := v.(*sourceError)
+	assert.Equal(2, se.failures)
+	assert.True(se.retryTime.After(time.Now().Add(time.Minute)))
+	assert.True(cdnerrors.IsURLNotReachable(tm.checkSourceError("task")))
+
+	// the backoff is kept after the retry time, and grows when the source fails again
+	se.retryTime = time.Now().Add(-time.Second)
+	assert.Nil(tm.checkSourceError("task"))
+	tm.recordSourceError("task", cdnerrors.ErrURLNotReachable)
+	v, _ = tm.sourceErrorStore.Get("task")
+	assert.Equal(3, v.(*sourceError).failures)
+
+	tm.updateSourceError("task", &types.SeedTask{CdnStatus: types.TaskInfoCdnStatusSuccess}, nil)
+	assert.Nil(tm.checkSourceError("task"))
+	_, err = tm.sourceErrorStore.Get("task")
+	assert.NotNil(err)
+
+	// source errors are not cached when the fail access interval is 0
+	tm.cfg.FailAccessInterval = 0
+	tm.recordSourceError("task", cdnerrors.ErrURLNotReachable)
+	assert.Nil(tm.checkSourceError("task"))
+}
+
+func TestIsSameTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	digest := "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	assert.True(isSameTask(&types.SeedTask{TaskUrl: "http://a/x"}, &types.SeedTask{TaskUrl: "http://a/x"}))
+	assert.False(isSameTask(&types.SeedTask{TaskUrl: "http://a/x"}, &types.SeedTask{TaskUrl: "http://b/x"}))
+	assert.False(isSameTask(&types.SeedTask{TaskUrl: "http://a/x", RequestMd5: "a"},
+		&types.SeedTask{TaskUrl: "http://a/x", RequestMd5: "b"}))
+
+	// content addressed tasks are shared across urls
+	assert.True(isSameTask(&types.SeedTask{TaskUrl: "http://a/x", RequestDigest: digest},
+		&types.SeedTask{TaskUrl: "http://b/x", RequestDigest: digest}))
+	assert.False(isSameTask(&types.SeedTask{TaskUrl: "http://a/x", RequestDigest: digest},
+		&types.SeedTask{TaskUrl: "http://a/x"}))
+}
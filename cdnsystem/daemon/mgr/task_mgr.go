@@ -33,6 +33,9 @@ type SeedTaskMgr interface {
 	// Get get task Info with specified taskId.
 	Get(ctx context.Context, taskId string) (*types.SeedTask, error)
 
+	// Exist returns the task with specified taskId without updating its access time.
+	Exist(ctx context.Context, taskId string) (*types.SeedTask, bool)
+
 	// GetAccessTime get all tasks accessTime.
 	GetAccessTime(ctx context.Context) (*syncmap.SyncMap, error)
 
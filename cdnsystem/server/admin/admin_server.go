@@ -0,0 +1,297 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/synclock"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TasksHTTPPath is the path to list the cached tasks and purge tasks by url.
+	TasksHTTPPath = "/admin/tasks"
+
+	bearerPrefix = "Bearer "
+)
+
+// TaskInfo is the summary of a cached task.
+type TaskInfo struct {
+	TaskId           string    `json:"taskId"`
+	URL              string    `json:"url"`
+	TaskURL          string    `json:"taskUrl"`
+	Status           string    `json:"status"`
+	Size             int64     `json:"size"`
+	SourceFileLength int64     `json:"sourceFileLength"`
+	PieceSize        int32     `json:"pieceSize"`
+	PieceTotal       int32     `json:"pieceTotal"`
+	AccessTime       time.Time `json:"accessTime"`
+	HitCount         int64     `json:"hitCount"`
+	Pinned           bool      `json:"pinned"`
+	// Registered is true when the task is registered in the task manager and can be seeded without checking the cache
+	Registered bool `json:"registered"`
+}
+
+// TaskDetail is a cached task with its piece meta data.
+type TaskDetail struct {
+	*TaskInfo
+	SourceRealMd5 string                     `json:"sourceRealMd5"`
+	PieceMd5Sign  string                     `json:"pieceMd5Sign"`
+	Pieces        []*storage.PieceMetaRecord `json:"pieces"`
+}
+
+// Server serves the admin api to inspect and manage the cached tasks.
+type Server struct {
+	*http.Server
+	storageMgr storage.Manager
+	taskMgr    mgr.SeedTaskMgr
+	cdnMgr     mgr.CDNMgr
+	gcMgr      mgr.GCMgr
+	token      string
+}
+
+// NewServer creates an admin server, the requests must carry the token as a bearer token when it is not empty.
+func NewServer(storageMgr storage.Manager, taskMgr mgr.SeedTaskMgr, cdnMgr mgr.CDNMgr, gcMgr mgr.GCMgr, token string) *Server {
+	s := &Server{
+		Server:     &http.Server{},
+		storageMgr: storageMgr,
+		taskMgr:    taskMgr,
+		cdnMgr:     cdnMgr,
+		gcMgr:      gcMgr,
+		token:      token,
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc(TasksHTTPPath, s.handleListTasks).Methods(http.MethodGet)
+	r.HandleFunc(TasksHTTPPath, s.handlePurgeTaskByURL).Methods(http.MethodDelete)
+	r.HandleFunc(TasksHTTPPath+"/{taskId}", s.handleGetTask).Methods(http.MethodGet)
+	r.HandleFunc(TasksHTTPPath+"/{taskId}", s.handlePurgeTask).Methods(http.MethodDelete)
+	r.HandleFunc(TasksHTTPPath+"/{taskId}/pin", s.handlePinTask(true)).Methods(http.MethodPut)
+	r.HandleFunc(TasksHTTPPath+"/{taskId}/pin", s.handlePinTask(false)).Methods(http.MethodDelete)
+	r.Use(s.authenticate)
+	s.Server.Handler = r
+	return s
+}
+
+// authenticate rejects the requests without the bearer token.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			auth := r.Header.Get("Authorization")
+			if !strings.HasPrefix(auth, bearerPrefix) ||
+				subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, bearerPrefix)), []byte(s.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Serve accepts connections on lis until Stop is called.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.Server.Serve(lis)
+}
+
+// Stop gracefully shuts down the server.
+func (s *Server) Stop() error {
+	return s.Server.Shutdown(context.Background())
+}
+
+// handleListTasks lists all the tasks in storage, sorted by access time from new to old.
+func (s *Server) handleListTasks(w http.ResponseWriter, r *http.Request) {
+	taskIds, err := s.storageMgr.ListTaskIds(r.Context())
+	if err != nil {
+		logger.Errorf("admin: list tasks failed: %v", err)
+		http.Error(w, fmt.Sprintf("list tasks error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	tasks := make([]*TaskInfo, 0, len(taskIds))
+	for _, taskId := range taskIds {
+		info, _, err := s.getTaskInfo(r.Context(), taskId)
+		if err != nil {
+			// the task may be removed by gc while listing
+			logger.WithTaskID(taskId).Warnf("admin: skip listing task: %v", err)
+			continue
+		}
+		tasks = append(tasks, info)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].AccessTime.After(tasks[j].AccessTime)
+	})
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// handleGetTask shows a task with its piece meta data.
+func (s *Server) handleGetTask(w http.ResponseWriter, r *http.Request) {
+	taskId := mux.Vars(r)["taskId"]
+	info, metaData, err := s.getTaskInfo(r.Context(), taskId)
+	if err != nil {
+		writeError(w, taskId, "get task", err)
+		return
+	}
+	pieces, err := s.storageMgr.ReadPieceMetaRecords(r.Context(), taskId)
+	if err != nil && !isNotExist(err) {
+		writeError(w, taskId, "read piece meta data", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &TaskDetail{
+		TaskInfo:      info,
+		SourceRealMd5: metaData.SourceRealMd5,
+		PieceMd5Sign:  metaData.PieceMd5Sign,
+		Pieces:        pieces,
+	})
+}
+
+// handlePurgeTask removes a task from both memory and storage.
+func (s *Server) handlePurgeTask(w http.ResponseWriter, r *http.Request) {
+	s.purge(w, r, mux.Vars(r)["taskId"])
+}
+
+// handlePurgeTaskByURL removes the task computed from the url and the query parameters
+// filter, md5, range and bizId, the same way as the task id of a download request.
+func (s *Server) handlePurgeTaskByURL(w http.ResponseWriter, r *http.Request) {
+	url := r.FormValue("url")
+	if url == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+	taskId := idgen.GenerateTaskID(url, r.FormValue("filter"), &base.UrlMeta{
+		Md5:   r.FormValue("md5"),
+		Range: r.FormValue("range"),
+	}, r.FormValue("bizId"))
+	s.purge(w, r, taskId)
+}
+
+func (s *Server) purge(w http.ResponseWriter, r *http.Request, taskId string) {
+	// hold the task lock like storage gc, so the task is not registered again while it is purged
+	synclock.Lock(taskId, false)
+	defer synclock.UnLock(taskId, false)
+	task, registered := s.taskMgr.Exist(r.Context(), taskId)
+	if registered && !task.IsDone() {
+		http.Error(w, fmt.Sprintf("task %s is %s, can not be purged", taskId, task.CdnStatus), http.StatusConflict)
+		return
+	}
+	if !registered {
+		if _, err := s.storageMgr.ReadFileMetaData(r.Context(), taskId); err != nil {
+			writeError(w, taskId, "get task", err)
+			return
+		}
+	}
+	if err := s.gcMgr.GCTask(r.Context(), taskId, true); err != nil {
+		logger.WithTaskID(taskId).Errorf("admin: purge task failed: %v", err)
+		http.Error(w, fmt.Sprintf("purge task error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	logger.WithTaskID(taskId).Infof("admin: task is purged")
+	writeJSON(w, http.StatusOK, map[string]string{"taskId": taskId})
+}
+
+// handlePinTask pins or unpins a task from gc.
+func (s *Server) handlePinTask(pinned bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		taskId := mux.Vars(r)["taskId"]
+		if err := s.cdnMgr.SetPinned(r.Context(), taskId, pinned); err != nil {
+			writeError(w, taskId, "pin task", err)
+			return
+		}
+		logger.WithTaskID(taskId).Infof("admin: task is pinned: %t", pinned)
+		info, _, err := s.getTaskInfo(r.Context(), taskId)
+		if err != nil {
+			writeError(w, taskId, "get task", err)
+			return
+		}
+		writeJSON(w, http.StatusOK, info)
+	}
+}
+
+// getTaskInfo builds the summary of task from its meta data in storage, the status of a registered task
+// is taken from the task manager without updating its access time.
+func (s *Server) getTaskInfo(ctx context.Context, taskId string) (*TaskInfo, *storage.FileMetaData, error) {
+	metaData, err := s.storageMgr.ReadFileMetaData(ctx, taskId)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := &TaskInfo{
+		TaskId:           taskId,
+		URL:              metaData.URL,
+		TaskURL:          metaData.TaskURL,
+		Size:             metaData.CdnFileLength,
+		SourceFileLength: metaData.SourceFileLen,
+		PieceSize:        metaData.PieceSize,
+		PieceTotal:       metaData.TotalPieceCount,
+		AccessTime:       time.Unix(0, metaData.AccessTime*int64(time.Millisecond)),
+		HitCount:         metaData.HitCount,
+		Pinned:           metaData.Pinned,
+	}
+	switch {
+	case metaData.Success:
+		info.Status = types.TaskInfoCdnStatusSuccess
+	case metaData.Finish:
+		info.Status = types.TaskInfoCdnStatusFailed
+	default:
+		info.Status = types.TaskInfoCdnStatusWaiting
+	}
+	if task, ok := s.taskMgr.Exist(ctx, taskId); ok {
+		info.Registered = true
+		info.Status = task.CdnStatus
+	}
+	if stat, err := s.storageMgr.StatDownloadFile(ctx, taskId); err == nil {
+		info.Size = stat.Size
+	}
+	return info, metaData, nil
+}
+
+func isNotExist(err error) bool {
+	return cdnerrors.IsFileNotExist(err) || cdnerrors.IsDataNotFound(err) || os.IsNotExist(errors.Cause(err))
+}
+
+func writeError(w http.ResponseWriter, taskId string, action string, err error) {
+	if isNotExist(err) {
+		http.Error(w, fmt.Sprintf("task %s not found", taskId), http.StatusNotFound)
+		return
+	}
+	logger.WithTaskID(taskId).Errorf("admin: %s failed: %v", action, err)
+	http.Error(w, fmt.Sprintf("%s error: %v", action, err), http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Errorf("admin: write response failed: %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/synclock"
+	"github.com/pkg/errors"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+// fakeStorage keeps the meta data of tasks in memory, the methods not used by admin server are not implemented
+type fakeStorage struct {
+	storage.Manager
+	metaData map[string]*storage.FileMetaData
+}
+
+func (fs *fakeStorage) ListTaskIds(ctx context.Context) ([]string, error) {
+	var taskIds []string
+	for taskId := range fs.metaData {
+		taskIds = append(taskIds, taskId)
+	}
+	return taskIds, nil
+}
+
+func (fs *fakeStorage) ReadFileMetaData(ctx context.Context, taskId string) (*storage.FileMetaData, error) {
+	metaData, ok := fs.metaData[taskId]
+	if !ok {
+		return nil, errors.Wrapf(cdnerrors.ErrFileNotExist, "task %s", taskId)
+	}
+	return metaData, nil
+}
+
+func (fs *fakeStorage) ReadPieceMetaRecords(ctx context.Context, taskId string) ([]*storage.PieceMetaRecord, error) {
+	return []*storage.PieceMetaRecord{{PieceNum: 0, PieceLen: 10, Md5: "md5"}}, nil
+}
+
+func (fs *fakeStorage) StatDownloadFile(ctx context.Context, taskId string) (*storedriver.StorageInfo, error) {
+	return &storedriver.StorageInfo{Size: fs.metaData[taskId].CdnFileLength}, nil
+}
+
+type fakeTaskMgr struct {
+	mgr.SeedTaskMgr
+	tasks map[string]*types.SeedTask
+}
+
+func (ftm *fakeTaskMgr) Exist(ctx context.Context, taskId string) (*types.SeedTask, bool) {
+	task, ok := ftm.tasks[taskId]
+	return task, ok
+}
+
+type fakeCDNMgr struct {
+	mgr.CDNMgr
+	storage *fakeStorage
+}
+
+func (fcm *fakeCDNMgr) SetPinned(ctx context.Context, taskId string, pinned bool) error {
+	metaData, err := fcm.storage.ReadFileMetaData(ctx, taskId)
+	if err != nil {
+		return err
+	}
+	metaData.Pinned = pinned
+	return nil
+}
+
+type fakeGCMgr struct {
+	mgr.GCMgr
+	storage *fakeStorage
+	err     error
+}
+
+func (fgm *fakeGCMgr) GCTask(ctx context.Context, taskId string, full bool) error {
+	if fgm.err != nil {
+		return fgm.err
+	}
+	delete(fgm.storage.metaData, taskId)
+	return nil
+}
+
+func newTestServer() (*Server, *fakeStorage) {
+	return newTestServerWithToken("")
+}
+
+func newTestServerWithToken(token string) (*Server, *fakeStorage) {
+	url := "http://example.com/a.tar?sign=1"
+	fs := &fakeStorage{metaData: map[string]*storage.FileMetaData{
+		"running": {TaskId: "running", URL: "http://example.com/b.tar", AccessTime: 2000},
+		"done":    {TaskId: "done", URL: "http://example.com/c.tar", AccessTime: 1000, Finish: true, Success: true, CdnFileLength: 10, HitCount: 3},
+	}}
+	byURL := idgen.GenerateTaskID(url, "sign", &base.UrlMeta{}, "")
+	fs.metaData[byURL] = &storage.FileMetaData{TaskId: byURL, URL: url, Finish: true, Success: true}
+	tm := &fakeTaskMgr{tasks: map[string]*types.SeedTask{
+		"running": {TaskId: "running", CdnStatus: types.TaskInfoCdnStatusRunning},
+	}}
+	return NewServer(fs, tm, &fakeCDNMgr{storage: fs}, &fakeGCMgr{storage: fs}, token), fs
+}
+
+func doRequest(s *Server, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.Handler.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestListAndGetTasks(t *testing.T) {
+	assert := testifyassert.New(t)
+	s, _ := newTestServer()
+
+	w := doRequest(s, http.MethodGet, TasksHTTPPath)
+	assert.Equal(http.StatusOK, w.Code)
+	var tasks []*TaskInfo
+	assert.Nil(json.Unmarshal(w.Body.Bytes(), &tasks))
+	assert.Equal(3, len(tasks))
+	assert.Equal("running", tasks[0].TaskId)
+	assert.Equal(types.TaskInfoCdnStatusRunning, tasks[0].Status)
+	assert.True(tasks[0].Registered)
+	assert.Equal("done", tasks[1].TaskId)
+	assert.Equal(types.TaskInfoCdnStatusSuccess, tasks[1].Status)
+	assert.Equal(int64(10), tasks[1].Size)
+	assert.Equal(int64(3), tasks[1].HitCount)
+
+	w = doRequest(s, http.MethodGet, TasksHTTPPath+"/done")
+	assert.Equal(http.StatusOK, w.Code)
+	var detail TaskDetail
+	assert.Nil(json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Equal("done", detail.TaskId)
+	assert.Equal(1, len(detail.Pieces))
+
+	w = doRequest(s, http.MethodGet, TasksHTTPPath+"/unknown")
+	assert.Equal(http.StatusNotFound, w.Code)
+}
+
+func TestPurgeAndPinTasks(t *testing.T) {
+	assert := testifyassert.New(t)
+	s, fs := newTestServer()
+
+	assert.Equal(http.StatusConflict, doRequest(s, http.MethodDelete, TasksHTTPPath+"/running").Code)
+	assert.Equal(http.StatusNotFound, doRequest(s, http.MethodDelete, TasksHTTPPath+"/unknown").Code)
+	assert.Equal(http.StatusOK, doRequest(s, http.MethodDelete, TasksHTTPPath+"/done").Code)
+	assert.NotContains(fs.metaData, "done")
+
+	assert.Equal(http.StatusBadRequest, doRequest(s, http.MethodDelete, TasksHTTPPath).Code)
+	assert.Equal(http.StatusOK, doRequest(s, http.MethodDelete,
+		TasksHTTPPath+"?url=http%3A%2F%2Fexample.com%2Fa.tar%3Fsign%3D2&filter=sign").Code)
+	assert.Equal(1, len(fs.metaData))
+
+	w := doRequest(s, http.MethodPut, TasksHTTPPath+"/running/pin")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.True(fs.metaData["running"].Pinned)
+	w = doRequest(s, http.MethodDelete, TasksHTTPPath+"/running/pin")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.False(fs.metaData["running"].Pinned)
+	assert.Equal(http.StatusNotFound, doRequest(s, http.MethodPut, TasksHTTPPath+"/unknown/pin").Code)
+}
+
+func TestPurgeTaskFailure(t *testing.T) {
+	assert := testifyassert.New(t)
+	s, fs := newTestServer()
+	s.gcMgr.(*fakeGCMgr).err = errors.New("disk error")
+
+	w := doRequest(s, http.MethodDelete, TasksHTTPPath+"/done")
+	assert.Equal(http.StatusInternalServerError, w.Code)
+	assert.Contains(w.Body.String(), "disk error")
+	assert.Contains(fs.metaData, "done")
+}
+
+func TestPurgeTaskWaitsForTaskLock(t *testing.T) {
+	assert := testifyassert.New(t)
+	s, fs := newTestServer()
+
+	synclock.Lock("done", false)
+	purged := make(chan int)
+	go func() {
+		purged <- doRequest(s, http.MethodDelete, TasksHTTPPath+"/done").Code
+	}()
+	select {
+	case <-purged:
+		t.Fatal("purge should wait for the task lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Contains(fs.metaData, "done")
+	synclock.UnLock("done", false)
+	assert.Equal(http.StatusOK, <-purged)
+	assert.NotContains(fs.metaData, "done")
+}
+
+func TestAuthenticate(t *testing.T) {
+	assert := testifyassert.New(t)
+	s, _ := newTestServerWithToken("secret")
+
+	assert.Equal(http.StatusUnauthorized, doRequest(s, http.MethodGet, TasksHTTPPath).Code)
+	assert.Equal(http.StatusUnauthorized, doRequest(s, http.MethodDelete, TasksHTTPPath+"/done").Code)
+
+	for _, auth := range []string{"secret", "Bearer wrong", "Basic secret"} {
+		r := httptest.NewRequest(http.MethodGet, TasksHTTPPath, nil)
+		r.Header.Set("Authorization", auth)
+		w := httptest.NewRecorder()
+		s.Handler.ServeHTTP(w, r)
+		assert.Equal(http.StatusUnauthorized, w.Code, "authorization %q should be rejected", auth)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, TasksHTTPPath, nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	s.Handler.ServeHTTP(w, r)
+	assert.Equal(http.StatusOK, w.Code)
+}
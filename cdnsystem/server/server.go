@@ -19,12 +19,16 @@ package server
 import (
 	_ "d7y.io/dragonfly/v2/cdnsystem/source/httpprotocol"
 	_ "d7y.io/dragonfly/v2/cdnsystem/source/ossprotocol"
+	_ "d7y.io/dragonfly/v2/cdnsystem/source/s3protocol"
 	_ "d7y.io/dragonfly/v2/pkg/rpc/cdnsystem/server"
 )
 
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"strconv"
 
 	"d7y.io/dragonfly/v2/cdnsystem/config"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr"
@@ -33,16 +37,21 @@ import (
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/gc"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/progress"
 	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/task"
+	"d7y.io/dragonfly/v2/cdnsystem/server/admin"
 	"d7y.io/dragonfly/v2/cdnsystem/server/service"
+	"d7y.io/dragonfly/v2/cdnsystem/server/upload"
 	"d7y.io/dragonfly/v2/cdnsystem/source"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
 	"d7y.io/dragonfly/v2/pkg/rpc"
 	"github.com/pkg/errors"
 )
 
 type Server struct {
-	Config  *config.Config
-	TaskMgr mgr.SeedTaskMgr
-	GCMgr   mgr.GCMgr
+	Config       *config.Config
+	TaskMgr      mgr.SeedTaskMgr
+	GCMgr        mgr.GCMgr
+	UploadServer *upload.Server
+	AdminServer  *admin.Server
 }
 
 // New creates a brand new server instance.
@@ -76,16 +85,37 @@ func New(cfg *config.Config) (*Server, error) {
 	storageMgr.SetTaskMgr(taskMgr)
 	storageMgr.InitializeCleaners()
 	progressMgr.SetTaskMgr(taskMgr)
+	// restore the tasks cached before restarting
+	if err := taskMgr.Restore(context.Background()); err != nil {
+		logger.Warnf("failed to restore tasks: %v", err)
+	}
 	// gc manager
 	gcMgr, err := gc.NewManager(cfg, taskMgr, cdnMgr)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to create gc manager")
 	}
 
+	// upload server
+	uploadServer, err := upload.NewServer(cfg, storageMgr, taskMgr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create upload server")
+	}
+
+	// admin server
+	var adminServer *admin.Server
+	if cfg.AdminPort > 0 {
+		if ip := net.ParseIP(cfg.AdminIP); cfg.AdminToken == "" && (ip == nil || !ip.IsLoopback()) {
+			return nil, errors.Errorf("admin token must be set when admin api listens on non loopback ip %q", cfg.AdminIP)
+		}
+		adminServer = admin.NewServer(storageMgr, taskMgr, cdnMgr, gcMgr, cfg.AdminToken)
+	}
+
 	return &Server{
-		Config:  cfg,
-		TaskMgr: taskMgr,
-		GCMgr:   gcMgr,
+		Config:       cfg,
+		TaskMgr:      taskMgr,
+		GCMgr:        gcMgr,
+		UploadServer: uploadServer,
+		AdminServer:  adminServer,
 	}, nil
 }
 
@@ -96,10 +126,32 @@ func (s *Server) Start() (err error) {
 			err = errors.New(fmt.Sprintf("%v", err))
 		}
 	}()
-	seedServer, err := service.NewCdnSeedServer(s.Config, s.TaskMgr)
+	seedServer, err := service.NewCdnSeedServer(s.Config, s.TaskMgr, s.UploadServer)
 	if err != nil {
 		return errors.Wrap(err, "create seedServer fail")
 	}
+	// start upload server
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.Config.DownloadPort))
+	if err != nil {
+		return errors.Wrap(err, "failed to listen on download port")
+	}
+	go func() {
+		if err := s.UploadServer.Serve(lis); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("upload server exited: %v", err)
+		}
+	}()
+	// start admin server
+	if s.AdminServer != nil {
+		adminLis, err := net.Listen("tcp", net.JoinHostPort(s.Config.AdminIP, strconv.Itoa(s.Config.AdminPort)))
+		if err != nil {
+			return errors.Wrap(err, "failed to listen on admin port")
+		}
+		go func() {
+			if err := s.AdminServer.Serve(adminLis); err != nil && err != http.ErrServerClosed {
+				logger.Errorf("admin server exited: %v", err)
+			}
+		}()
+	}
 	// start gc
 	s.GCMgr.StartGC(context.Background())
 	err = rpc.StartTcpServer(s.Config.ListenPort, s.Config.ListenPort, seedServer)
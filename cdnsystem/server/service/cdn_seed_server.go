@@ -34,22 +34,30 @@ import (
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	"d7y.io/dragonfly/v2/pkg/rpc/cdnsystem"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/net/iputils"
 	"d7y.io/dragonfly/v2/pkg/util/net/urlutils"
 	"d7y.io/dragonfly/v2/pkg/util/stringutils"
 )
 
+// UploadLoadReporter reports the upload capacity of the cdn node, which is sent to schedulers along with seeds.
+type UploadLoadReporter interface {
+	UploadLoad() (total int32, free int32)
+}
+
 // CdnSeedServer is used to implement cdnsystem.SeederServer.
 type CdnSeedServer struct {
-	taskMgr mgr.SeedTaskMgr
-	cfg     *config.Config
+	taskMgr    mgr.SeedTaskMgr
+	cfg        *config.Config
+	uploadLoad UploadLoadReporter
 }
 
 // NewManager returns a new Manager Object.
-func NewCdnSeedServer(cfg *config.Config, taskMgr mgr.SeedTaskMgr) (*CdnSeedServer, error) {
+func NewCdnSeedServer(cfg *config.Config, taskMgr mgr.SeedTaskMgr, uploadLoad UploadLoadReporter) (*CdnSeedServer, error) {
 	return &CdnSeedServer{
-		taskMgr: taskMgr,
-		cfg:     cfg,
+		taskMgr:    taskMgr,
+		cfg:        cfg,
+		uploadLoad: uploadLoad,
 	}, nil
 }
 
@@ -59,7 +67,15 @@ func constructRegisterRequest(req *cdnsystem.SeedRequest) (*types.TaskRegisterRe
 	}
 	meta := req.UrlMeta
 	header := make(map[string]string)
+	var digest string
 	if meta != nil {
+		if !stringutils.IsBlank(meta.Digest) {
+			algorithm, encoded, err := digestutils.Parse(meta.Digest)
+			if err != nil {
+				return nil, errors.Wrapf(err, "resource digest:%s is invalid", meta.Digest)
+			}
+			digest = digestutils.Format(algorithm, encoded)
+		}
 		if !stringutils.IsBlank(meta.Md5) {
 			header["md5"] = meta.Md5
 		}
@@ -71,11 +87,14 @@ func constructRegisterRequest(req *cdnsystem.SeedRequest) (*types.TaskRegisterRe
 		}
 	}
 	return &types.TaskRegisterRequest{
-		Header: header,
-		URL:    req.Url,
-		Md5:    header["md5"],
-		TaskId: req.TaskId,
-		Filter: strings.Split(req.Filter, "&"),
+		Header:        header,
+		URL:           req.Url,
+		Md5:           header["md5"],
+		Digest:        digest,
+		TaskId:        req.TaskId,
+		Filter:        strings.Split(req.Filter, "&"),
+		PeerCount:     req.PeerCount,
+		StartPieceNum: req.StartPieceNum,
 	}, nil
 }
 
@@ -110,6 +129,9 @@ func (css *CdnSeedServer) ObtainSeeds(ctx context.Context, req *cdnsystem.SeedRe
 	pieceChan, err := css.taskMgr.Register(ctx, registerRequest)
 
 	if err != nil {
+		if cdnerrors.IsSourceError(err) {
+			return dferrors.Newf(sourceErrorCode(cdnerrors.GetSourceStatusCode(err)), "failed to register seed task(%s):%v", req.TaskId, err)
+		}
 		return dferrors.Newf(dfcodes.CdnTaskRegistryFail, "failed to register seed task(%s):%v", req.TaskId, err)
 	}
 	task, err := css.taskMgr.Get(ctx, req.TaskId)
@@ -118,6 +140,7 @@ func (css *CdnSeedServer) ObtainSeeds(ctx context.Context, req *cdnsystem.SeedRe
 	}
 	peerId := cdnutil.GenCdnPeerId(req.TaskId)
 	for piece := range pieceChan {
+		totalLoad, freeLoad := css.getUploadLoad()
 		psc <- &cdnsystem.PieceSeed{
 			PeerId:     peerId,
 			SeederName: iputils.HostName,
@@ -127,28 +150,58 @@ func (css *CdnSeedServer) ObtainSeeds(ctx context.Context, req *cdnsystem.SeedRe
 				RangeSize:   piece.PieceLen,
 				PieceMd5:    piece.PieceMd5,
 				PieceOffset: piece.OriginRange.StartIndex,
-				PieceStyle:  base.PieceStyle(piece.PieceStyle),
+				PieceStyle:  piece.PieceStyle.ToPieceStyle(),
 			},
-			Done:          false,
-			ContentLength: task.SourceFileLength,
+			Done:            false,
+			ContentLength:   task.SourceFileLength,
+			TotalUploadLoad: totalLoad,
+			FreeUploadLoad:  freeLoad,
+			PieceSize:       task.PieceSize,
 		}
 
 	}
 	if err != nil {
 		return dferrors.Newf(dfcodes.CdnError, "failed to get task(%s): %v", req.TaskId, err)
 	}
+	if task.CdnStatus == types.TaskInfoCdnStatusSourceError {
+		return dferrors.Newf(sourceErrorCode(task.SourceStatusCode), "task(%s) status error , status: %s, source status code: %d",
+			req.TaskId, task.CdnStatus, task.SourceStatusCode)
+	}
 	if task.CdnStatus != types.TaskInfoCdnStatusSuccess {
 		return dferrors.Newf(dfcodes.CdnTaskDownloadFail, "task(%s) status error , status: %s", req.TaskId, task.CdnStatus)
 	}
+	totalLoad, freeLoad := css.getUploadLoad()
 	psc <- &cdnsystem.PieceSeed{
-		PeerId:        peerId,
-		SeederName:    iputils.HostName,
-		Done:          true,
-		ContentLength: task.SourceFileLength,
+		PeerId:          peerId,
+		SeederName:      iputils.HostName,
+		Done:            true,
+		ContentLength:   task.SourceFileLength,
+		TotalUploadLoad: totalLoad,
+		FreeUploadLoad:  freeLoad,
+		PieceSize:       task.PieceSize,
 	}
 	return nil
 }
 
+// sourceErrorCode converts the status code of source to the error code, so that clients know the status class of source
+func sourceErrorCode(statusCode int) base.Code {
+	switch {
+	case statusCode >= 400 && statusCode < 500:
+		return dfcodes.CdnSourceClientError
+	case statusCode >= 500:
+		return dfcodes.CdnSourceServerError
+	default:
+		return dfcodes.CdnSourceError
+	}
+}
+
+func (css *CdnSeedServer) getUploadLoad() (total int32, free int32) {
+	if css.uploadLoad == nil {
+		return 0, 0
+	}
+	return css.uploadLoad.UploadLoad()
+}
+
 func (css *CdnSeedServer) GetPieceTasks(ctx context.Context, req *base.PieceTaskRequest) (piecePacket *base.PiecePacket, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -169,12 +222,16 @@ func (css *CdnSeedServer) GetPieceTasks(ctx context.Context, req *base.PieceTask
 		}
 		return nil, dferrors.Newf(dfcodes.CdnError, "failed to get task(%s) from cdn: %v", req.TaskId, err)
 	}
+	if task.CdnStatus == types.TaskInfoCdnStatusSourceError {
+		return nil, dferrors.Newf(sourceErrorCode(task.SourceStatusCode), "fail to download task(%s) from source, source status code: %d",
+			task.TaskId, task.SourceStatusCode)
+	}
 	if task.IsError() {
 		return nil, dferrors.Newf(dfcodes.CdnTaskDownloadFail, "fail to download task(%s), cdnStatus: %s", task.TaskId, task.CdnStatus)
 	}
 	pieces, err := css.taskMgr.GetPieces(ctx, req.TaskId)
 	if err != nil {
-		return nil, dferrors.Newf(dfcodes.CdnError, "failed to get pieces of task(%s) from cdn: %v", err)
+		return nil, dferrors.Newf(dfcodes.CdnError, "failed to get pieces of task(%s) from cdn: %v", req.TaskId, err)
 	}
 	pieceInfos := make([]*base.PieceInfo, 0)
 	var count int32 = 0
@@ -186,7 +243,7 @@ func (css *CdnSeedServer) GetPieceTasks(ctx context.Context, req *base.PieceTask
 				RangeSize:   piece.PieceLen,
 				PieceMd5:    piece.PieceMd5,
 				PieceOffset: piece.OriginRange.StartIndex,
-				PieceStyle:  base.PieceStyle(piece.PieceStyle),
+				PieceStyle:  piece.PieceStyle.ToPieceStyle(),
 			})
 			count++
 		}
@@ -199,6 +256,7 @@ func (css *CdnSeedServer) GetPieceTasks(ctx context.Context, req *base.PieceTask
 		PieceInfos:    pieceInfos,
 		TotalPiece:    task.PieceTotal,
 		ContentLength: task.SourceFileLength,
+		PieceSize:     task.PieceSize,
 		PieceMd5Sign:  task.PieceMd5Sign,
 	}, nil
 }
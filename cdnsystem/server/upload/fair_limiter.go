@@ -0,0 +1,142 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// fairLimiter shares a rate limiter among tasks in a round-robin way,
+// so that a hot task with many peers can not starve the others.
+// Tokens are granted in quantum sized batches, one batch per task in turn.
+type fairLimiter struct {
+	limiter *rate.Limiter
+	quantum int64
+
+	lock   sync.Mutex
+	queues map[string][]*waiter
+	// active keeps the round-robin order of tasks which have waiters
+	active []string
+	next   int
+
+	notify chan struct{}
+	done   chan struct{}
+}
+
+type waiter struct {
+	ctx   context.Context
+	n     int
+	ready chan error
+}
+
+func newFairLimiter(limiter *rate.Limiter, quantum int64) *fairLimiter {
+	if quantum > int64(limiter.Burst()) {
+		quantum = int64(limiter.Burst())
+	}
+	return &fairLimiter{
+		limiter: limiter,
+		quantum: quantum,
+		queues:  make(map[string][]*waiter),
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// WaitN blocks until n tokens are granted to the task or ctx is done.
+func (fl *fairLimiter) WaitN(ctx context.Context, taskId string, n int64) error {
+	for n > 0 {
+		size := n
+		if size > fl.quantum {
+			size = fl.quantum
+		}
+		w := &waiter{
+			ctx:   ctx,
+			n:     int(size),
+			ready: make(chan error, 1),
+		}
+		fl.enqueue(taskId, w)
+		select {
+		case err := <-w.ready:
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		n -= size
+	}
+	return nil
+}
+
+func (fl *fairLimiter) enqueue(taskId string, w *waiter) {
+	fl.lock.Lock()
+	if _, ok := fl.queues[taskId]; !ok {
+		fl.active = append(fl.active, taskId)
+	}
+	fl.queues[taskId] = append(fl.queues[taskId], w)
+	fl.lock.Unlock()
+
+	select {
+	case fl.notify <- struct{}{}:
+	default:
+	}
+}
+
+// dequeue pops the head waiter of the next task in turn, it returns nil when no one is waiting.
+func (fl *fairLimiter) dequeue() *waiter {
+	fl.lock.Lock()
+	defer fl.lock.Unlock()
+	if len(fl.active) == 0 {
+		return nil
+	}
+	idx := fl.next % len(fl.active)
+	taskId := fl.active[idx]
+	queue := fl.queues[taskId]
+	w := queue[0]
+	if len(queue) == 1 {
+		delete(fl.queues, taskId)
+		fl.active = append(fl.active[:idx], fl.active[idx+1:]...)
+		fl.next = idx
+	} else {
+		fl.queues[taskId] = queue[1:]
+		fl.next = idx + 1
+	}
+	return w
+}
+
+// run grants tokens to waiters until stop is called.
+func (fl *fairLimiter) run() {
+	for {
+		w := fl.dequeue()
+		if w == nil {
+			select {
+			case <-fl.notify:
+				continue
+			case <-fl.done:
+				return
+			}
+		}
+		w.ready <- fl.limiter.WaitN(w.ctx, w.n)
+	}
+}
+
+func (fl *fairLimiter) stop() {
+	close(fl.done)
+}
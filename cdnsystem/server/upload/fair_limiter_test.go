@@ -0,0 +1,65 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"context"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestFairLimiter_Dequeue(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	fl := newFairLimiter(rate.NewLimiter(rate.Inf, 1), 1)
+	waiters := make(map[*waiter]string)
+	enqueue := func(taskId string) {
+		w := &waiter{ctx: context.Background(), n: 1, ready: make(chan error, 1)}
+		waiters[w] = taskId
+		fl.enqueue(taskId, w)
+	}
+	enqueue("hot")
+	enqueue("hot")
+	enqueue("hot")
+	enqueue("cold")
+	enqueue("warm")
+	enqueue("warm")
+
+	var order []string
+	for w := fl.dequeue(); w != nil; w = fl.dequeue() {
+		order = append(order, waiters[w])
+	}
+	assert.Equal([]string{"hot", "cold", "warm", "hot", "warm", "hot"}, order)
+	assert.Empty(fl.active)
+	assert.Empty(fl.queues)
+}
+
+func TestFairLimiter_WaitN(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	fl := newFairLimiter(rate.NewLimiter(rate.Inf, 4), 4)
+	go fl.run()
+	defer fl.stop()
+
+	assert.Nil(fl.WaitN(context.Background(), "task", 10))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NotNil(fl.WaitN(ctx, "task", 10))
+}
@@ -0,0 +1,387 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage"
+	"d7y.io/dragonfly/v2/client/clientutil"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"github.com/go-http-utils/headers"
+	"github.com/gorilla/mux"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// PeerDownloadHTTPPathPrefix is the path prefix peers download pieces from,
+	// the full path is {prefix}{taskId[:3]}/{taskId}.
+	PeerDownloadHTTPPathPrefix = "/download/"
+
+	// peerLimiterIdleTimeout is how long the limiter of a peer is kept after its last request.
+	peerLimiterIdleTimeout = 5 * time.Minute
+
+	// uploadRateInterval is the interval to sample the upload rate.
+	uploadRateInterval = time.Second
+)
+
+// Server serves the pieces of seed tasks to peers over http,
+// which makes an extra file server listening on the download port unnecessary.
+type Server struct {
+	*http.Server
+	storageMgr storage.Manager
+	taskMgr    mgr.SeedTaskMgr
+
+	// limiter limits the total upload rate of all peers and shares it fairly among tasks
+	limiter     *fairLimiter
+	uploadLimit rate.Limit
+	// perPeerLimit limits the upload rate of every single peer
+	perPeerLimit rate.Limit
+
+	// sentBytes is the bytes granted since the last sample, uploadRate is the sampled rate in bytes per second
+	sentBytes  int64
+	uploadRate int64
+
+	peerLimitersLock sync.Mutex
+	peerLimiters     map[string]*peerLimiter
+
+	done chan struct{}
+}
+
+type peerLimiter struct {
+	*rate.Limiter
+	lastAccess time.Time
+}
+
+// NewServer creates an upload server with the rate limits in cfg.
+func NewServer(cfg *config.Config, storageMgr storage.Manager, taskMgr mgr.SeedTaskMgr) (*Server, error) {
+	s := &Server{
+		Server:       &http.Server{},
+		storageMgr:   storageMgr,
+		taskMgr:      taskMgr,
+		uploadLimit:  rate.Inf,
+		perPeerLimit: rate.Inf,
+		peerLimiters: make(map[string]*peerLimiter),
+		done:         make(chan struct{}),
+	}
+	if cfg.UploadLimit > 0 {
+		s.uploadLimit = rate.Limit(cfg.UploadLimit)
+		s.limiter = newFairLimiter(rate.NewLimiter(s.uploadLimit, burstOf(s.uploadLimit)), config.DefaultPieceSize)
+	}
+	if cfg.PerPeerUploadLimit > 0 {
+		s.perPeerLimit = rate.Limit(cfg.PerPeerUploadLimit)
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc(PeerDownloadHTTPPathPrefix+"{taskPrefix}/{taskId}", s.handleUpload).Methods(http.MethodGet, http.MethodHead)
+	s.Server.Handler = r
+	return s, nil
+}
+
+// Serve accepts connections on lis until Stop is called.
+func (s *Server) Serve(lis net.Listener) error {
+	if s.limiter != nil {
+		go s.limiter.run()
+	}
+	go s.cleanPeerLimiters()
+	go s.sampleUploadRate()
+	return s.Server.Serve(lis)
+}
+
+// Stop gracefully shuts down the server.
+func (s *Server) Stop() error {
+	if s.limiter != nil {
+		s.limiter.stop()
+	}
+	close(s.done)
+	return s.Server.Shutdown(context.Background())
+}
+
+// handleUpload uses to upload a seed file when peers download pieces from cdn.
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	var (
+		taskId = mux.Vars(r)["taskId"]
+		peerId = r.FormValue("peerId")
+		// srcPeer is the peer which downloads the piece, peerId is the peer id of cdn for the task.
+		// older peers do not send it, use the remote ip instead
+		srcPeer = r.FormValue("srcPeerId")
+	)
+	if srcPeer == "" {
+		srcPeer, _, _ = net.SplitHostPort(r.RemoteAddr)
+	}
+
+	log := logger.With("taskId", taskId, "peerId", peerId, "srcPeerId", srcPeer, "component", "uploadServer")
+	log.Debugf("upload seed file to %s, request header: %#v", r.RemoteAddr, r.Header)
+	if mux.Vars(r)["taskPrefix"] != taskIdPrefix(taskId) {
+		http.Error(w, "task prefix not match", http.StatusNotFound)
+		return
+	}
+
+	// Get updates the access time of the task, so that gc will not reclaim it while it is hot
+	if _, err := s.taskMgr.Get(r.Context(), taskId); err != nil {
+		if cdnerrors.IsDataNotFound(err) {
+			http.Error(w, fmt.Sprintf("task %s not found", taskId), http.StatusNotFound)
+			return
+		}
+		log.Errorf("get task failed: %v", err)
+		http.Error(w, fmt.Sprintf("get task error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	f, size, err := s.openSeedFile(r.Context(), taskId)
+	if err != nil {
+		if os.IsNotExist(err) || cdnerrors.IsFileNotExist(err) {
+			http.Error(w, fmt.Sprintf("seed file of task %s not found", taskId), http.StatusNotFound)
+			return
+		}
+		log.Errorf("open seed file failed: %v", err)
+		http.Error(w, fmt.Sprintf("open seed file error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if f != nil {
+		defer f.Close()
+	}
+
+	// the seed file is still being written when the task is running, so the size may be smaller than the source
+	rg, err := clientutil.ParseRange(r.Header.Get(headers.Range), size)
+	if err == clientutil.ErrNoOverlap {
+		w.Header().Set(headers.ContentRange, fmt.Sprintf("bytes */%d", size))
+		http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+		return
+	} else if err != nil {
+		log.Errorf("parse range with error: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(rg) > 1 {
+		log.Errorf("multi range parsed, not support")
+		http.Error(w, "invalid range", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		start  int64
+		length = size
+		status = http.StatusOK
+	)
+	if len(rg) == 1 {
+		start, length, status = rg[0].Start, rg[0].Length, http.StatusPartialContent
+		w.Header().Set(headers.ContentRange, clientutil.GetContentRange(start, start+length-1, size))
+	}
+	// add header "Content-Length" to avoid chunked body in http client
+	w.Header().Set(headers.ContentLength, strconv.FormatInt(length, 10))
+	w.Header().Set(headers.AcceptRanges, "bytes")
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+
+	if err := s.waitN(r.Context(), taskId, srcPeer, length); err != nil {
+		log.Errorf("get limit failed: %v", err)
+		http.Error(w, fmt.Sprintf("get limit error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var reader io.Reader
+	if f != nil {
+		if _, err := f.Seek(start, io.SeekStart); err != nil {
+			log.Errorf("seek seed file failed: %v", err)
+			http.Error(w, fmt.Sprintf("seek seed file error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		reader = io.LimitReader(f, length)
+	} else {
+		rc, err := s.storageMgr.ReadDownloadFileRange(r.Context(), taskId, start, length)
+		if err != nil {
+			log.Errorf("read seed file failed: %v", err)
+			http.Error(w, fmt.Sprintf("read seed file error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		defer rc.Close()
+		reader = rc
+	}
+
+	// if w is a socket, golang will use sendfile or splice syscall for zero copy feature
+	// when start to transfer data, we could not call http.Error with header
+	w.WriteHeader(status)
+	if n, err := io.Copy(w, reader); err != nil {
+		log.Errorf("transfer data failed: %v", err)
+		return
+	} else if n != length {
+		log.Errorf("transferred data length not match request, request: %d, transferred: %d", length, n)
+		return
+	}
+	log.Debugf("upload %d bytes from offset %d to %s", length, start, r.RemoteAddr)
+}
+
+// openSeedFile opens the seed file of task on local file system, the returned file is nil
+// when the storage is not on local file system, and the seed file is read from the storage.
+func (s *Server) openSeedFile(ctx context.Context, taskId string) (*os.File, int64, error) {
+	path := s.storageMgr.GetDownloadPath(taskId)
+	if path == "" {
+		info, err := s.storageMgr.StatDownloadFile(ctx, taskId)
+		if err != nil {
+			return nil, 0, err
+		}
+		return nil, info.Size, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+// waitN blocks until both the limiter of the downloading peer and the total limiter allow n bytes to be sent.
+func (s *Server) waitN(ctx context.Context, taskId string, srcPeer string, n int64) error {
+	if s.perPeerLimit != rate.Inf && srcPeer != "" {
+		if err := waitN(ctx, s.getPeerLimiter(srcPeer), n); err != nil {
+			return err
+		}
+	}
+	if s.limiter != nil {
+		if err := s.limiter.WaitN(ctx, taskId, n); err != nil {
+			return err
+		}
+	}
+	atomic.AddInt64(&s.sentBytes, n)
+	return nil
+}
+
+// UploadLoad returns how many peers the server can serve concurrently at the per-peer rate,
+// and how many more peers it can serve with the spare bandwidth.
+// Both are zero when the upload rate is not limited.
+func (s *Server) UploadLoad() (total int32, free int32) {
+	if s.uploadLimit == rate.Inf || s.perPeerLimit == rate.Inf {
+		return 0, 0
+	}
+	total = int32(s.uploadLimit / s.perPeerLimit)
+	if total < 1 {
+		total = 1
+	}
+	spare := s.uploadLimit - rate.Limit(atomic.LoadInt64(&s.uploadRate))
+	if spare < 0 {
+		spare = 0
+	}
+	free = int32(spare / s.perPeerLimit)
+	if free > total {
+		free = total
+	}
+	return total, free
+}
+
+// sampleUploadRate smooths the upload rate over the recent intervals.
+func (s *Server) sampleUploadRate() {
+	ticker := time.NewTicker(uploadRateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.sample(uploadRateInterval)
+		}
+	}
+}
+
+// sample averages the rate of the bytes sent in the last interval into the upload rate.
+func (s *Server) sample(interval time.Duration) {
+	sent := atomic.SwapInt64(&s.sentBytes, 0)
+	current := int64(float64(sent) / interval.Seconds())
+	atomic.StoreInt64(&s.uploadRate, (atomic.LoadInt64(&s.uploadRate)+current)/2)
+}
+
+// getPeerLimiter returns the limiter of the downloading peer, which is identified by its peer id or ip.
+func (s *Server) getPeerLimiter(srcPeer string) *rate.Limiter {
+	s.peerLimitersLock.Lock()
+	defer s.peerLimitersLock.Unlock()
+	pl, ok := s.peerLimiters[srcPeer]
+	if !ok {
+		pl = &peerLimiter{Limiter: rate.NewLimiter(s.perPeerLimit, burstOf(s.perPeerLimit))}
+		s.peerLimiters[srcPeer] = pl
+	}
+	pl.lastAccess = time.Now()
+	return pl.Limiter
+}
+
+// cleanPeerLimiters drops the limiters of peers which have not downloaded for a while.
+func (s *Server) cleanPeerLimiters() {
+	ticker := time.NewTicker(peerLimiterIdleTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.peerLimitersLock.Lock()
+			for srcPeer, pl := range s.peerLimiters {
+				if time.Since(pl.lastAccess) > peerLimiterIdleTimeout {
+					delete(s.peerLimiters, srcPeer)
+				}
+			}
+			s.peerLimitersLock.Unlock()
+		}
+	}
+}
+
+// waitN waits for n tokens in batches of burst size, since a range may be larger than the burst.
+func waitN(ctx context.Context, limiter *rate.Limiter, n int64) error {
+	burst := int64(limiter.Burst())
+	for n > 0 {
+		size := n
+		if size > burst {
+			size = burst
+		}
+		if err := limiter.WaitN(ctx, int(size)); err != nil {
+			return err
+		}
+		n -= size
+	}
+	return nil
+}
+
+// burstOf makes sure a whole piece can be sent at once.
+func burstOf(limit rate.Limit) int {
+	if limit < config.DefaultPieceSizeLimit {
+		return config.DefaultPieceSizeLimit
+	}
+	return int(limit)
+}
+
+func taskIdPrefix(taskId string) string {
+	if len(taskId) < 3 {
+		return taskId
+	}
+	return taskId[:3]
+}
@@ -0,0 +1,381 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
+	"d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr"
+	"d7y.io/dragonfly/v2/cdnsystem/daemon/mgr/cdn/storage"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"d7y.io/dragonfly/v2/pkg/unit"
+	"github.com/go-http-utils/headers"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+// seedTasks knows the tasks in the map only, the other operations of task manager are not supported
+type seedTasks struct {
+	mgr.SeedTaskMgr
+	tasks map[string]*types.SeedTask
+}
+
+func (m *seedTasks) Get(ctx context.Context, taskId string) (*types.SeedTask, error) {
+	if task, ok := m.tasks[taskId]; ok {
+		return task, nil
+	}
+	return nil, cdnerrors.ErrDataNotFound
+}
+
+// seedFiles keeps the seed files under dir, they are opened by path when local is true,
+// otherwise they are read through the storage.
+type seedFiles struct {
+	storage.Manager
+	dir   string
+	local bool
+}
+
+func (s *seedFiles) path(taskId string) string {
+	return filepath.Join(s.dir, taskId)
+}
+
+func (s *seedFiles) GetDownloadPath(taskId string) string {
+	if !s.local {
+		return ""
+	}
+	return s.path(taskId)
+}
+
+func (s *seedFiles) StatDownloadFile(ctx context.Context, taskId string) (*storedriver.StorageInfo, error) {
+	info, err := os.Stat(s.path(taskId))
+	if err != nil {
+		return nil, cdnerrors.ErrFileNotExist
+	}
+	return &storedriver.StorageInfo{Path: s.path(taskId), Size: info.Size()}, nil
+}
+
+func (s *seedFiles) ReadDownloadFileRange(ctx context.Context, taskId string, offset int64, length int64) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(taskId))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, length), f}, nil
+}
+
+// cdnPeerId is the peer id of cdn for the task, which peers send as peerId when downloading from cdn
+const cdnPeerId = "cdn-task_CDN"
+
+// newPieceRequest builds the request in the same way as the piece downloader of peers
+func newPieceRequest(method, path, srcPeerId string) *http.Request {
+	url := path + "?peerId=" + cdnPeerId
+	if srcPeerId != "" {
+		url += "&srcPeerId=" + srcPeerId
+	}
+	return httptest.NewRequest(method, url, nil)
+}
+
+func TestServer_HandleUpload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "upload-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := []byte("0123456789abcdefghij")
+	// the running task has written the first pieces only
+	written := content[:8]
+	taskMgr := &seedTasks{
+		tasks: map[string]*types.SeedTask{
+			"finished": {TaskId: "finished", CdnStatus: types.TaskInfoCdnStatusSuccess, SourceFileLength: int64(len(content))},
+			"running":  {TaskId: "running", CdnStatus: types.TaskInfoCdnStatusRunning, SourceFileLength: int64(len(content))},
+			"waiting":  {TaskId: "waiting", CdnStatus: types.TaskInfoCdnStatusWaiting, SourceFileLength: -1},
+		},
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "finished"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "running"), written, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		rangeHeader   string
+		status        int
+		body          []byte
+		contentLength string
+		contentRange  string
+	}{
+		{
+			name:          "whole file",
+			method:        http.MethodGet,
+			path:          "/download/fin/finished",
+			status:        http.StatusOK,
+			body:          content,
+			contentLength: "20",
+		},
+		{
+			name:          "range",
+			method:        http.MethodGet,
+			path:          "/download/fin/finished",
+			rangeHeader:   "bytes=4-9",
+			status:        http.StatusPartialContent,
+			body:          content[4:10],
+			contentLength: "6",
+			contentRange:  "bytes 4-9/20",
+		},
+		{
+			name:          "suffix range",
+			method:        http.MethodGet,
+			path:          "/download/fin/finished",
+			rangeHeader:   "bytes=-5",
+			status:        http.StatusPartialContent,
+			body:          content[15:],
+			contentLength: "5",
+			contentRange:  "bytes 15-19/20",
+		},
+		{
+			name:          "head range",
+			method:        http.MethodHead,
+			path:          "/download/fin/finished",
+			rangeHeader:   "bytes=0-3",
+			status:        http.StatusPartialContent,
+			body:          []byte{},
+			contentLength: "4",
+			contentRange:  "bytes 0-3/20",
+		},
+		{
+			name:         "range not satisfiable",
+			method:       http.MethodGet,
+			path:         "/download/fin/finished",
+			rangeHeader:  "bytes=20-29",
+			status:       http.StatusRequestedRangeNotSatisfiable,
+			contentRange: "bytes */20",
+		},
+		{
+			name:        "multiple ranges",
+			method:      http.MethodGet,
+			path:        "/download/fin/finished",
+			rangeHeader: "bytes=0-1,4-5",
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "invalid range",
+			method:      http.MethodGet,
+			path:        "/download/fin/finished",
+			rangeHeader: "bytes=x-y",
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:   "task prefix not match",
+			method: http.MethodGet,
+			path:   "/download/abc/finished",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "task not found",
+			method: http.MethodGet,
+			path:   "/download/unk/unknown",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "seed file not created",
+			method: http.MethodGet,
+			path:   "/download/wai/waiting",
+			status: http.StatusNotFound,
+		},
+		{
+			name:          "written pieces of running task",
+			method:        http.MethodGet,
+			path:          "/download/run/running",
+			rangeHeader:   "bytes=4-7",
+			status:        http.StatusPartialContent,
+			body:          written[4:8],
+			contentLength: "4",
+			contentRange:  "bytes 4-7/8",
+		},
+		{
+			name:          "range of running task is truncated to the written size",
+			method:        http.MethodGet,
+			path:          "/download/run/running",
+			rangeHeader:   "bytes=4-15",
+			status:        http.StatusPartialContent,
+			body:          written[4:8],
+			contentLength: "4",
+			contentRange:  "bytes 4-7/8",
+		},
+		{
+			name:         "unwritten pieces of running task",
+			method:       http.MethodGet,
+			path:         "/download/run/running",
+			rangeHeader:  "bytes=8-15",
+			status:       http.StatusRequestedRangeNotSatisfiable,
+			contentRange: "bytes */8",
+		},
+	}
+
+	for _, local := range []bool{true, false} {
+		s, err := NewServer(config.New(), &seedFiles{dir: dir, local: local}, taskMgr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the limiter grants tokens only when it is running
+		go s.limiter.run()
+		for _, tc := range tests {
+			name := tc.name
+			if !local {
+				name += " from storage"
+			}
+			t.Run(name, func(t *testing.T) {
+				assert := testifyassert.New(t)
+				req := newPieceRequest(tc.method, tc.path, "peer")
+				if tc.rangeHeader != "" {
+					req.Header.Set(headers.Range, tc.rangeHeader)
+				}
+				w := httptest.NewRecorder()
+				s.Handler.ServeHTTP(w, req)
+
+				assert.Equal(tc.status, w.Code, w.Body.String())
+				assert.Equal(tc.contentRange, w.Header().Get(headers.ContentRange))
+				if tc.body != nil {
+					assert.True(bytes.Equal(tc.body, w.Body.Bytes()), w.Body.String())
+					assert.Equal(tc.contentLength, w.Header().Get(headers.ContentLength))
+					assert.Equal("bytes", w.Header().Get(headers.AcceptRanges))
+				}
+			})
+		}
+		s.limiter.stop()
+	}
+}
+
+func TestServer_UploadLoad(t *testing.T) {
+	assert := testifyassert.New(t)
+	dir, err := ioutil.TempDir("", "upload-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "task"), make([]byte, 3*unit.MB), 0644); err != nil {
+		t.Fatal(err)
+	}
+	taskMgr := &seedTasks{tasks: map[string]*types.SeedTask{"task": {TaskId: "task"}}}
+
+	cfg := config.New()
+	cfg.UploadLimit = 0
+	s, err := NewServer(cfg, &seedFiles{dir: dir, local: true}, taskMgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, free := s.UploadLoad()
+	assert.Equal(int32(0), total, "load is not reported without upload limit")
+	assert.Equal(int32(0), free)
+
+	cfg.UploadLimit = 10 * unit.MB
+	cfg.PerPeerUploadLimit = 1 * unit.MB
+	s, err = NewServer(cfg, &seedFiles{dir: dir, local: true}, taskMgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.limiter.run()
+	defer s.limiter.stop()
+
+	total, free = s.UploadLoad()
+	assert.Equal(int32(10), total)
+	assert.Equal(int32(10), free)
+
+	// three peers download a piece of 1MB each in the interval
+	for i := 0; i < 3; i++ {
+		req := newPieceRequest(http.MethodGet, "/download/tas/task", fmt.Sprintf("peer-%d", i))
+		req.Header.Set(headers.Range, fmt.Sprintf("bytes=%d-%d", i*int(unit.MB), (i+1)*int(unit.MB)-1))
+		w := httptest.NewRecorder()
+		s.Handler.ServeHTTP(w, req)
+		assert.Equal(http.StatusPartialContent, w.Code)
+	}
+	assert.Len(s.peerLimiters, 3, "every downloading peer is limited separately")
+	s.sample(time.Second)
+	total, free = s.UploadLoad()
+	assert.Equal(int32(10), total)
+	assert.Equal(int32(8), free, "1.5MB/s is in use after the first sample")
+
+	// the load is released gradually when the uploads stop
+	s.sample(time.Second)
+	_, free = s.UploadLoad()
+	assert.Equal(int32(9), free)
+	// the sampled rate halves every interval, it takes a few intervals to decay to zero
+	for i := 0; i < 30; i++ {
+		s.sample(time.Second)
+	}
+	_, free = s.UploadLoad()
+	assert.Equal(int32(10), free)
+}
+
+func TestServer_PeerLimiter(t *testing.T) {
+	assert := testifyassert.New(t)
+	dir, err := ioutil.TempDir("", "upload-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "task"), []byte("content of task"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	taskMgr := &seedTasks{tasks: map[string]*types.SeedTask{"task": {TaskId: "task"}}}
+
+	cfg := config.New()
+	cfg.UploadLimit = 0
+	cfg.PerPeerUploadLimit = 1 * unit.MB
+	s, err := NewServer(cfg, &seedFiles{dir: dir, local: true}, taskMgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// all peers send the same peer id of cdn, the limiters are keyed on the downloading peers,
+	// and on the remote ip for older peers which do not send srcPeerId
+	for _, srcPeerId := range []string{"peer-a", "peer-b", "peer-a", ""} {
+		req := newPieceRequest(http.MethodGet, "/download/tas/task", srcPeerId)
+		req.RemoteAddr = "192.168.0.2:12345"
+		w := httptest.NewRecorder()
+		s.Handler.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code, w.Body.String())
+	}
+	var keys []string
+	for key := range s.peerLimiters {
+		keys = append(keys, key)
+	}
+	assert.ElementsMatch([]string{"peer-a", "peer-b", "192.168.0.2"}, keys)
+}
@@ -0,0 +1,162 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpprotocol
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-http-utils/headers"
+)
+
+const (
+	headerAge  = "Age"
+	headerDate = "Date"
+
+	// expireInfoFreshUntil is the key of expire info to store the time in unix millis until which the cache is fresh,
+	// the cache is not revalidated with source before it.
+	expireInfoFreshUntil = "freshUntil"
+)
+
+// cacheControl is the directives of Cache-Control which decide the expiry of a shared cache, see RFC 9111
+type cacheControl struct {
+	noStore   bool
+	noCache   bool
+	private   bool
+	immutable bool
+	// maxAge and sMaxAge are -1 when they are absent
+	maxAge  int64
+	sMaxAge int64
+}
+
+func parseCacheControl(value string) *cacheControl {
+	cc := &cacheControl{
+		maxAge:  -1,
+		sMaxAge: -1,
+	}
+	for _, directive := range strings.Split(value, ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "" {
+			continue
+		}
+		name, arg := directive, ""
+		if i := strings.IndexByte(directive, '='); i >= 0 {
+			name, arg = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "no-store":
+			cc.noStore = true
+		case "no-cache":
+			cc.noCache = true
+		case "private":
+			cc.private = true
+		case "immutable":
+			cc.immutable = true
+		case "max-age":
+			cc.maxAge = parseDeltaSeconds(arg)
+		case "s-maxage":
+			cc.sMaxAge = parseDeltaSeconds(arg)
+		}
+	}
+	return cc
+}
+
+// parseDeltaSeconds parses delta-seconds, an invalid value is treated as 0 which means the response is stale
+func parseDeltaSeconds(value string) int64 {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return seconds
+}
+
+// bypassCache reports whether a shared cache must not reuse the response without downloading it again
+func (cc *cacheControl) bypassCache() bool {
+	return cc.noStore || cc.private
+}
+
+// freshUntil computes the time until which the response is fresh, the zero time is returned when the response
+// must be revalidated before reusing. As a shared cache, s-maxage takes precedence over max-age, which
+// takes precedence over Expires, and the age of response is subtracted from the freshness lifetime.
+func freshUntil(header http.Header, now time.Time) time.Time {
+	cc := parseCacheControl(header.Get(headers.CacheControl))
+	if cc.bypassCache() || cc.noCache {
+		return time.Time{}
+	}
+	var lifetime time.Duration
+	switch {
+	case cc.sMaxAge >= 0:
+		lifetime = time.Duration(cc.sMaxAge) * time.Second
+	case cc.maxAge >= 0:
+		lifetime = time.Duration(cc.maxAge) * time.Second
+	case header.Get(headers.Expires) != "":
+		// an invalid Expires represents a time in the past
+		expires, err := http.ParseTime(header.Get(headers.Expires))
+		if err != nil {
+			return time.Time{}
+		}
+		date, err := http.ParseTime(header.Get(headerDate))
+		if err != nil {
+			date = now
+		}
+		lifetime = expires.Sub(date)
+	default:
+		return time.Time{}
+	}
+	if age := parseDeltaSeconds(header.Get(headerAge)); age > 0 {
+		lifetime -= time.Duration(age) * time.Second
+	}
+	if lifetime <= 0 {
+		return time.Time{}
+	}
+	return now.Add(lifetime)
+}
+
+// newExpireInfo builds the expire info stored with the cache from the headers of the response
+func newExpireInfo(header http.Header, now time.Time) map[string]string {
+	expireInfo := map[string]string{
+		headers.LastModified: header.Get(headers.LastModified),
+		headers.ETag:         header.Get(headers.ETag),
+	}
+	updateFreshness(expireInfo, header, now)
+	return expireInfo
+}
+
+// updateFreshness updates the cache directives and the freshness of expire info with the headers of the response
+func updateFreshness(expireInfo map[string]string, header http.Header, now time.Time) {
+	if cacheControl := header.Get(headers.CacheControl); cacheControl != "" {
+		expireInfo[headers.CacheControl] = cacheControl
+	} else {
+		delete(expireInfo, headers.CacheControl)
+	}
+	if until := freshUntil(header, now); !until.IsZero() {
+		expireInfo[expireInfoFreshUntil] = strconv.FormatInt(until.UnixNano()/int64(time.Millisecond), 10)
+	} else {
+		delete(expireInfo, expireInfoFreshUntil)
+	}
+}
+
+// isFresh reports whether the cache is still fresh by the expire info
+func isFresh(expireInfo map[string]string, now time.Time) bool {
+	until, err := strconv.ParseInt(expireInfo[expireInfoFreshUntil], 10, 64)
+	if err != nil {
+		return false
+	}
+	return now.UnixNano()/int64(time.Millisecond) < until
+}
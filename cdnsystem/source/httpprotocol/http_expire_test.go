@@ -0,0 +1,117 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpprotocol
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestFreshUntil(t *testing.T) {
+	assert := testifyassert.New(t)
+	now := time.Now()
+	header := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+
+	assert.Equal(now.Add(time.Minute), freshUntil(header(headers.CacheControl, "public, max-age=60"), now))
+	assert.Equal(now.Add(2*time.Minute), freshUntil(header(headers.CacheControl, `max-age=60, s-maxage="120"`), now))
+	assert.Equal(now.Add(50*time.Second), freshUntil(header(headers.CacheControl, "max-age=60", headerAge, "10"), now))
+	assert.True(freshUntil(header(headers.CacheControl, "max-age=60", headerAge, "100"), now).IsZero())
+	assert.True(freshUntil(header(headers.CacheControl, "no-cache, max-age=60"), now).IsZero())
+	assert.True(freshUntil(header(headers.CacheControl, "no-store"), now).IsZero())
+	assert.True(freshUntil(header(headers.CacheControl, "max-age=abc"), now).IsZero())
+	assert.True(freshUntil(header(), now).IsZero())
+
+	date := now.UTC().Truncate(time.Second)
+	assert.Equal(now.Add(time.Hour), freshUntil(header(
+		headers.Expires, date.Add(time.Hour).Format(http.TimeFormat),
+		headerDate, date.Format(http.TimeFormat)), now))
+	// max-age takes precedence over Expires
+	assert.Equal(now.Add(time.Minute), freshUntil(header(
+		headers.CacheControl, "max-age=60",
+		headers.Expires, date.Add(time.Hour).Format(http.TimeFormat)), now))
+	assert.True(freshUntil(header(headers.Expires, "0"), now).IsZero())
+}
+
+func TestIsExpired(t *testing.T) {
+	assert := testifyassert.New(t)
+	var requests int32
+	cacheControl := "max-age=60"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set(headers.CacheControl, cacheControl)
+		if r.Header.Get(headers.IfNoneMatch) == "v1" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set(headers.ETag, "v2")
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+	client := NewHttpSourceClient()
+
+	// fresh cache is not revalidated
+	header := http.Header{}
+	header.Set(headers.CacheControl, "max-age=60")
+	header.Set(headers.ETag, "v1")
+	expireInfo := newExpireInfo(header, time.Now())
+	expired, err := client.IsExpired(server.URL, nil, expireInfo)
+	assert.Nil(err)
+	assert.False(expired)
+	assert.Equal(int32(0), atomic.LoadInt32(&requests))
+
+	// stale cache is revalidated and its freshness is renewed
+	expireInfo[expireInfoFreshUntil] = strconv.FormatInt(time.Now().Add(-time.Second).UnixNano()/int64(time.Millisecond), 10)
+	expired, err = client.IsExpired(server.URL, nil, expireInfo)
+	assert.Nil(err)
+	assert.False(expired)
+	assert.Equal(int32(1), atomic.LoadInt32(&requests))
+	assert.True(isFresh(expireInfo, time.Now()))
+
+	// modified content is expired
+	expired, err = client.IsExpired(server.URL, nil, map[string]string{headers.ETag: "v0"})
+	assert.Nil(err)
+	assert.True(expired)
+	assert.Equal(int32(2), atomic.LoadInt32(&requests))
+
+	// no-store is always expired and immutable never expires, both without requests
+	expired, _ = client.IsExpired(server.URL, nil, map[string]string{headers.CacheControl: "no-store", headers.ETag: "v1"})
+	assert.True(expired)
+	expired, _ = client.IsExpired(server.URL, nil, map[string]string{headers.CacheControl: "public, immutable"})
+	assert.False(expired)
+	assert.Equal(int32(2), atomic.LoadInt32(&requests))
+
+	// the expire info of download keeps the freshness
+	body, expireInfo, err := client.Download(server.URL, nil)
+	assert.Nil(err)
+	body.Close()
+	assert.Equal("v2", expireInfo[headers.ETag])
+	assert.Equal(cacheControl, expireInfo[headers.CacheControl])
+	assert.True(isFresh(expireInfo, time.Now()))
+}
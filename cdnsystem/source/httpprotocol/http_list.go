@@ -0,0 +1,90 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpprotocol
+
+import (
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
+	"d7y.io/dragonfly/v2/cdnsystem/source"
+)
+
+// hrefRegexp matches links in html directory index pages, like the ones generated by nginx autoindex or apache mod_autoindex
+var hrefRegexp = regexp.MustCompile(`(?i)<a\s+[^>]*?href\s*=\s*["']([^"']+)["']`)
+
+// List lists the entries in the html directory index page of rawURL, only the links directly under rawURL are returned
+func (client *httpSourceClient) List(rawURL string, header map[string]string) ([]*source.URLEntry, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse url %s", rawURL)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+
+	resp, err := client.requestWithHeader(http.MethodGet, base.String(), header, 0)
+	if err != nil {
+		return nil, errors.Wrapf(cdnerrors.ErrURLNotReachable, "list failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, cdnerrors.NewSourceError(resp.StatusCode, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read directory index of %s", rawURL)
+	}
+	return parseDirectoryIndex(base, body), nil
+}
+
+func parseDirectoryIndex(base *url.URL, body []byte) []*source.URLEntry {
+	var (
+		entries []*source.URLEntry
+		seen    = map[string]bool{}
+	)
+	for _, match := range hrefRegexp.FindAllSubmatch(body, -1) {
+		ref, err := url.Parse(html.UnescapeString(string(match[1])))
+		// skip sorting links and anchors
+		if err != nil || ref.RawQuery != "" || ref.Fragment != "" {
+			continue
+		}
+		u := base.ResolveReference(ref)
+		if u.Scheme != base.Scheme || u.Host != base.Host || !strings.HasPrefix(u.Path, base.Path) {
+			continue
+		}
+		name := strings.TrimPrefix(u.Path, base.Path)
+		// skip parent, current and nested links
+		trimmed := strings.TrimSuffix(name, "/")
+		if trimmed == "" || strings.Contains(trimmed, "/") || seen[name] {
+			continue
+		}
+		seen[name] = true
+		entries = append(entries, &source.URLEntry{
+			URL:   u.String(),
+			Name:  name,
+			IsDir: strings.HasSuffix(name, "/"),
+		})
+	}
+	return entries
+}
@@ -0,0 +1,72 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httpprotocol
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/cdnsystem/source"
+)
+
+func TestHttpSourceClient_List(t *testing.T) {
+	assert := testifyassert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dataset/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `<html><head><title>Index of /dataset/</title></head><body>
+<a href="?C=N;O=D">Name</a>
+<a href="../">../</a>
+<a href="train/">train/</a>
+<a HREF='a%20b.txt'>a b.txt</a>
+<a href="/dataset/c.bin">c.bin</a>
+<a href="c.bin">c.bin</a>
+<a href="train/d.bin">d.bin</a>
+<a href="http://example.com/dataset/e.bin">e.bin</a>
+<a href="#top">top</a>
+</body></html>`)
+	}))
+	defer server.Close()
+
+	client := NewHttpSourceClient().(source.ResourceLister)
+	entries, err := client.List(server.URL+"/dataset", nil)
+	assert.Nil(err)
+	assert.Equal([]*source.URLEntry{
+		{
+			URL:   server.URL + "/dataset/train/",
+			Name:  "train/",
+			IsDir: true,
+		},
+		{
+			URL:  server.URL + "/dataset/a%20b.txt",
+			Name: "a b.txt",
+		},
+		{
+			URL:  server.URL + "/dataset/c.bin",
+			Name: "c.bin",
+		},
+	}, entries)
+
+	_, err = client.List(server.URL+"/not-found/", nil)
+	assert.NotNil(err)
+}
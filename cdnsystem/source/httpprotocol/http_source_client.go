@@ -18,7 +18,6 @@ package httpprotocol
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"net"
 	"net/http"
@@ -78,10 +77,9 @@ func (client *httpSourceClient) GetContentLength(url string, header map[string]s
 		return -1, errors.Wrapf(cdnerrors.ErrURLNotReachable, "get http header meta data failed:%v", err)
 	}
 	resp.Body.Close()
-	// todo 待讨论，这里如果是其他状态码是否要加入到 ErrURLNotReachable 中,如果不加入会下载404/频繁下载403
+	// the status is kept in the error, so that the failure is cached and reported to clients with its status class
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
-		// todo 这种情况是否要和 err的情况作区分，类似于提出一种其他的错误类型用于表示这种错误是可以与url进行交互，但是状态码不符合预期
-		return -1, errors.Wrapf(cdnerrors.ErrURLNotReachable, "get http file length failed, unexpected code: %d", resp.StatusCode)
+		return -1, errors.Wrapf(cdnerrors.NewSourceError(resp.StatusCode, resp.Status), "get http file length failed")
 	}
 	return resp.ContentLength, nil
 }
@@ -101,9 +99,21 @@ func (client *httpSourceClient) IsSupportRange(url string, header map[string]str
 	return resp.StatusCode == http.StatusPartialContent, nil
 }
 
-// todo 考虑 expire，类似访问baidu网页是没有last-modified的
-// IsExpired checks if a resource received or stored is the same.
+// IsExpired checks if a resource received or stored is the same, following the expiration rules of RFC 9111.
+// The cache is not revalidated while it is fresh or immutable, and it is always expired when the response must not be stored.
+// The freshness in expireInfo is renewed when the source responds not modified.
 func (client *httpSourceClient) IsExpired(url string, header, expireInfo map[string]string) (bool, error) {
+	cc := parseCacheControl(expireInfo[headers.CacheControl])
+	if cc.bypassCache() {
+		return true, nil
+	}
+	if cc.immutable && !cc.noCache {
+		return false, nil
+	}
+	if isFresh(expireInfo, time.Now()) {
+		return false, nil
+	}
+
 	lastModified := timeutils.UnixMillis(expireInfo[headers.LastModified])
 
 	eTag := expireInfo[headers.ETag]
@@ -127,24 +137,29 @@ func (client *httpSourceClient) IsExpired(url string, header, expireInfo map[str
 		return false, err
 	}
 	resp.Body.Close()
-	return resp.StatusCode != http.StatusNotModified, nil
+	if resp.StatusCode != http.StatusNotModified {
+		return true, nil
+	}
+	// the stored cache directives are kept when the not modified response does not carry new ones
+	respHeader := resp.Header.Clone()
+	if respHeader.Get(headers.CacheControl) == "" {
+		respHeader.Set(headers.CacheControl, expireInfo[headers.CacheControl])
+	}
+	updateFreshness(expireInfo, respHeader, time.Now())
+	return false, nil
 }
 
 // Download downloads the file from the original address
 func (client *httpSourceClient) Download(url string, header map[string]string) (io.ReadCloser, map[string]string, error) {
 	resp, err := client.requestWithHeader(http.MethodGet, url, header, 0)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, errors.Wrapf(cdnerrors.ErrURLNotReachable, "download failed: %v", err)
 	}
 	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusPartialContent {
-		expireInfo := map[string]string{
-			headers.LastModified: resp.Header.Get(headers.LastModified),
-			headers.ETag:         resp.Header.Get(headers.ETag),
-		}
-		return resp.Body, expireInfo, nil
+		return resp.Body, newExpireInfo(resp.Header, time.Now()), nil
 	}
 	resp.Body.Close()
-	return nil, nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	return nil, nil, cdnerrors.NewSourceError(resp.StatusCode, resp.Status)
 }
 
 func (client *httpSourceClient) requestWithHeader(method string, url string, header map[string]string, timeout time.Duration) (*http.Response, error) {
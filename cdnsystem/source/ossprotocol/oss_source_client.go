@@ -28,6 +28,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -118,6 +119,58 @@ func (osc *ossSourceClient) Download(url string, header map[string]string) (io.R
 	return nil, nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 }
 
+// List lists the objects and common prefixes directly under the prefix of url
+func (osc *ossSourceClient) List(url string, header map[string]string) ([]*source.URLEntry, error) {
+	ossObject, err := ParseOssObject(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse oss object from url:%s", url)
+	}
+	client, err := osc.getClient(header)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get client")
+	}
+	bucket, err := client.Bucket(ossObject.bucket)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get bucket:%s", ossObject.bucket)
+	}
+	prefix := ossObject.object
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	var (
+		entries []*source.URLEntry
+		marker  string
+	)
+	for {
+		res, err := bucket.ListObjects(oss.Prefix(prefix), oss.Delimiter("/"), oss.Marker(marker), oss.MaxKeys(1000))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list oss objects with prefix:%s", prefix)
+		}
+		for _, object := range res.Objects {
+			// skip the placeholder object of the prefix itself
+			if object.Key == prefix {
+				continue
+			}
+			entries = append(entries, &source.URLEntry{
+				URL:  fmt.Sprintf("oss://%s/%s", ossObject.bucket, object.Key),
+				Name: strings.TrimPrefix(object.Key, prefix),
+			})
+		}
+		for _, commonPrefix := range res.CommonPrefixes {
+			entries = append(entries, &source.URLEntry{
+				URL:   fmt.Sprintf("oss://%s/%s", ossObject.bucket, commonPrefix),
+				Name:  strings.TrimPrefix(commonPrefix, prefix),
+				IsDir: true,
+			})
+		}
+		if !res.IsTruncated {
+			return entries, nil
+		}
+		marker = res.NextMarker
+	}
+}
+
 func (osc *ossSourceClient) getClient(header map[string]string) (*oss.Client, error) {
 	endpoint, ok := header[endpoint]
 	if !ok {
@@ -184,14 +237,14 @@ type ossObject struct {
 
 func ParseOssObject(ossUrl string) (*ossObject, error) {
 	parsedUrl, err := url.Parse(ossUrl)
-	if parsedUrl.Scheme != "oss" {
-		return nil, fmt.Errorf("url:%s is not oss object", ossUrl)
-	}
 	if err != nil {
 		return nil, err
 	}
+	if parsedUrl.Scheme != "oss" {
+		return nil, fmt.Errorf("url:%s is not oss object", ossUrl)
+	}
 	return &ossObject{
 		bucket: parsedUrl.Host,
-		object: parsedUrl.Path[1:],
+		object: strings.TrimPrefix(parsedUrl.Path, "/"),
 	}, nil
 }
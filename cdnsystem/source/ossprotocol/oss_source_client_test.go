@@ -0,0 +1,112 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ossprotocol
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/cdnsystem/source"
+)
+
+func TestOssSourceClient_List(t *testing.T) {
+	assert := testifyassert.New(t)
+	var markers []string
+	// the server returns the objects under prefix dataset/ in two pages, the keys are url encoded
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/bucket/" || query.Get("prefix") != "dataset/" || query.Get("delimiter") != "/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		marker := query.Get("marker")
+		markers = append(markers, marker)
+		w.Header().Set("Content-Type", "application/xml")
+		switch marker {
+		case "":
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult>
+  <Prefix>dataset/</Prefix>
+  <Delimiter>/</Delimiter>
+  <EncodingType>url</EncodingType>
+  <IsTruncated>true</IsTruncated>
+  <NextMarker>dataset%2Fa%20b.txt</NextMarker>
+  <Contents><Key>dataset%2F</Key><Size>0</Size></Contents>
+  <Contents><Key>dataset%2Fa%20b.txt</Key><Size>1</Size></Contents>
+  <CommonPrefixes><Prefix>dataset%2Ftrain%2F</Prefix></CommonPrefixes>
+</ListBucketResult>`)
+		case "dataset/a b.txt":
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult>
+  <Prefix>dataset/</Prefix>
+  <Delimiter>/</Delimiter>
+  <EncodingType>url</EncodingType>
+  <IsTruncated>false</IsTruncated>
+  <Contents><Key>dataset%2Fc.bin</Key><Size>1</Size></Contents>
+</ListBucketResult>`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	header := map[string]string{
+		endpoint:        server.URL,
+		accessKeyID:     "id",
+		accessKeySecret: "secret",
+	}
+	client := NewOSSSourceClient().(source.ResourceLister)
+	entries, err := client.List("oss://bucket/dataset", header)
+	assert.Nil(err)
+	assert.Equal([]string{"", "dataset/a b.txt"}, markers)
+	assert.Equal([]*source.URLEntry{
+		{
+			URL:  "oss://bucket/dataset/a b.txt",
+			Name: "a b.txt",
+		},
+		{
+			URL:   "oss://bucket/dataset/train/",
+			Name:  "train/",
+			IsDir: true,
+		},
+		{
+			URL:  "oss://bucket/dataset/c.bin",
+			Name: "c.bin",
+		},
+	}, entries)
+
+	_, err = client.List("oss://bucket/not-found/", header)
+	assert.NotNil(err)
+
+	_, err = client.List("oss://bucket/dataset/", map[string]string{endpoint: server.URL})
+	assert.NotNil(err, "access key is required")
+}
+
+func TestParseOssObject(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	object, err := ParseOssObject("oss://bucket/dataset/a.txt")
+	assert.Nil(err)
+	assert.Equal(&ossObject{bucket: "bucket", object: "dataset/a.txt"}, object)
+
+	_, err = ParseOssObject("http://bucket/dataset/a.txt")
+	assert.NotNil(err)
+}
@@ -0,0 +1,222 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3protocol
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-http-utils/headers"
+	"github.com/pkg/errors"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
+	"d7y.io/dragonfly/v2/cdnsystem/source"
+	"d7y.io/dragonfly/v2/pkg/util/s3utils"
+	"d7y.io/dragonfly/v2/pkg/util/stringutils"
+)
+
+const s3Client = "s3"
+
+// the keys in header to access the S3 compatible service, they are not sent to the service
+const (
+	endpoint        = "endpoint"
+	region          = "region"
+	accessKeyID     = "accessKeyID"
+	accessKeySecret = "accessKeySecret"
+)
+
+// defaultRegion is used to sign requests when the region is not given
+const defaultRegion = "us-east-1"
+
+func init() {
+	source.Register(s3Client, NewS3SourceClient())
+}
+
+func NewS3SourceClient() source.ResourceClient {
+	return &s3SourceClient{}
+}
+
+// s3SourceClient is an implementation of the interface of ResourceClient and ResourceLister
+// for the objects in S3 compatible services, the urls are in the form of s3://bucket/key.
+type s3SourceClient struct {
+}
+
+func (sc *s3SourceClient) GetContentLength(url string, header map[string]string) (int64, error) {
+	resHeader, err := sc.getMeta(url, header)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.ParseInt(resHeader.Get(headers.ContentLength), 10, 64)
+}
+
+func (sc *s3SourceClient) IsSupportRange(url string, header map[string]string) (bool, error) {
+	if _, err := sc.getMeta(url, header); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (sc *s3SourceClient) IsExpired(url string, header, expireInfo map[string]string) (bool, error) {
+	lastModified := expireInfo[headers.LastModified]
+	eTag := expireInfo[headers.ETag]
+	if stringutils.IsBlank(lastModified) && stringutils.IsBlank(eTag) {
+		return true, nil
+	}
+
+	resHeader, err := sc.getMeta(url, header)
+	if err != nil {
+		return false, err
+	}
+	return resHeader.Get(headers.LastModified) != lastModified || resHeader.Get(headers.ETag) != eTag, nil
+}
+
+func (sc *s3SourceClient) Download(url string, header map[string]string) (io.ReadCloser, map[string]string, error) {
+	object, err := parseS3Object(url)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse s3 object from url:%s", url)
+	}
+	client, err := getClient(object.bucket, header)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get client")
+	}
+	resp, err := client.Do(context.Background(), http.MethodGet, object.key, nil, requestHeader(header), nil)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get s3 object:%s", object.key)
+	}
+	expireInfo := map[string]string{
+		headers.LastModified: resp.Header.Get(headers.LastModified),
+		headers.ETag:         resp.Header.Get(headers.ETag),
+	}
+	return resp.Body, expireInfo, nil
+}
+
+// List lists the objects and common prefixes directly under the prefix of url
+func (sc *s3SourceClient) List(url string, header map[string]string) ([]*source.URLEntry, error) {
+	object, err := parseS3Object(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse s3 object from url:%s", url)
+	}
+	client, err := getClient(object.bucket, header)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get client")
+	}
+	prefix := object.key
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	objects, prefixes, err := client.ListObjects(context.Background(), prefix, "/")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list s3 objects with prefix:%s", prefix)
+	}
+	var entries []*source.URLEntry
+	for _, o := range objects {
+		// skip the placeholder object of the prefix itself
+		if o.Key == prefix {
+			continue
+		}
+		entries = append(entries, &source.URLEntry{
+			URL:  fmt.Sprintf("s3://%s/%s", object.bucket, o.Key),
+			Name: strings.TrimPrefix(o.Key, prefix),
+		})
+	}
+	for _, p := range prefixes {
+		entries = append(entries, &source.URLEntry{
+			URL:   fmt.Sprintf("s3://%s/%s", object.bucket, p),
+			Name:  strings.TrimPrefix(p, prefix),
+			IsDir: true,
+		})
+	}
+	return entries, nil
+}
+
+func (sc *s3SourceClient) getMeta(url string, header map[string]string) (http.Header, error) {
+	object, err := parseS3Object(url)
+	if err != nil {
+		return nil, errors.Wrapf(cdnerrors.ErrURLNotReachable, "failed to parse s3 object: %v", err)
+	}
+	client, err := getClient(object.bucket, header)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get s3 client")
+	}
+	resp, err := client.Do(context.Background(), http.MethodHead, object.key, nil, requestHeader(header), nil)
+	if err != nil {
+		return nil, errors.Wrapf(cdnerrors.ErrURLNotReachable, "failed to head s3 object:%s: %v", object.key, err)
+	}
+	resp.Body.Close()
+	return resp.Header, nil
+}
+
+// getClient creates the client of bucket with the endpoint and credentials in header
+func getClient(bucket string, header map[string]string) (*s3utils.Client, error) {
+	endpoint, ok := header[endpoint]
+	if !ok {
+		return nil, errors.Wrapf(cdnerrors.ErrInvalidValue, "endpoint is empty")
+	}
+	accessKeyID, ok := header[accessKeyID]
+	if !ok {
+		return nil, errors.Wrapf(cdnerrors.ErrInvalidValue, "accessKeyID is empty")
+	}
+	accessKeySecret, ok := header[accessKeySecret]
+	if !ok {
+		return nil, errors.Wrapf(cdnerrors.ErrInvalidValue, "accessKeySecret is empty")
+	}
+	region, ok := header[region]
+	if !ok {
+		region = defaultRegion
+	}
+	return s3utils.NewClient(endpoint, region, bucket, accessKeyID, accessKeySecret)
+}
+
+// requestHeader returns the header sent to the service, eg: Range
+func requestHeader(header map[string]string) http.Header {
+	h := http.Header{}
+	for key, value := range header {
+		if key == endpoint || key == region || key == accessKeyID || key == accessKeySecret {
+			continue
+		}
+		h.Set(key, value)
+	}
+	return h
+}
+
+type s3Object struct {
+	bucket string
+	key    string
+}
+
+func parseS3Object(s3URL string) (*s3Object, error) {
+	parsedURL, err := url.Parse(s3URL)
+	if err != nil {
+		return nil, err
+	}
+	if parsedURL.Scheme != s3Client {
+		return nil, fmt.Errorf("url:%s is not s3 object", s3URL)
+	}
+	if parsedURL.Host == "" {
+		return nil, fmt.Errorf("url:%s has no bucket", s3URL)
+	}
+	return &s3Object{
+		bucket: parsedURL.Host,
+		key:    strings.TrimPrefix(parsedURL.Path, "/"),
+	}, nil
+}
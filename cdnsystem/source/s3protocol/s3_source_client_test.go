@@ -0,0 +1,151 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3protocol
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
+	"d7y.io/dragonfly/v2/cdnsystem/source"
+)
+
+var lastModified = time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)
+
+// newS3Server serves the object dataset/a.txt of bucket, and lists the objects under prefix dataset/ in two pages
+func newS3Server(t *testing.T) (*httptest.Server, *[]string) {
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=id/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		query := r.URL.Query()
+		switch {
+		case r.URL.Path == "/bucket/dataset/a.txt":
+			w.Header().Set(headers.ETag, `"etag"`)
+			http.ServeContent(w, r, "a.txt", lastModified, strings.NewReader("content of a"))
+		case r.URL.Path == "/bucket/" && query.Get("list-type") == "2":
+			if query.Get("prefix") != "dataset/" || query.Get("delimiter") != "/" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			token := query.Get("continuation-token")
+			tokens = append(tokens, token)
+			w.Header().Set("Content-Type", "application/xml")
+			switch token {
+			case "":
+				fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult>
+  <Prefix>dataset/</Prefix>
+  <Delimiter>/</Delimiter>
+  <IsTruncated>true</IsTruncated>
+  <NextContinuationToken>next</NextContinuationToken>
+  <Contents><Key>dataset/</Key><Size>0</Size></Contents>
+  <Contents><Key>dataset/a.txt</Key><Size>12</Size></Contents>
+  <CommonPrefixes><Prefix>dataset/train/</Prefix></CommonPrefixes>
+</ListBucketResult>`)
+			case "next":
+				fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult>
+  <Prefix>dataset/</Prefix>
+  <Delimiter>/</Delimiter>
+  <IsTruncated>false</IsTruncated>
+  <Contents><Key>dataset/c.bin</Key><Size>1</Size></Contents>
+</ListBucketResult>`)
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		default:
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &tokens
+}
+
+func TestS3SourceClient_List(t *testing.T) {
+	assert := testifyassert.New(t)
+	server, tokens := newS3Server(t)
+	header := map[string]string{
+		endpoint:        server.URL,
+		accessKeyID:     "id",
+		accessKeySecret: "secret",
+	}
+	client := NewS3SourceClient().(source.ResourceLister)
+	entries, err := client.List("s3://bucket/dataset", header)
+	assert.Nil(err)
+	assert.Equal([]string{"", "next"}, *tokens)
+	assert.Equal([]*source.URLEntry{
+		{URL: "s3://bucket/dataset/a.txt", Name: "a.txt"},
+		{URL: "s3://bucket/dataset/c.bin", Name: "c.bin"},
+		{URL: "s3://bucket/dataset/train/", Name: "train/", IsDir: true},
+	}, entries)
+
+	_, err = client.List("s3://bucket/dataset", map[string]string{endpoint: server.URL})
+	assert.True(cdnerrors.IsInvalidValue(err))
+	_, err = client.List("oss://bucket/dataset", header)
+	assert.NotNil(err)
+}
+
+func TestS3SourceClient_Download(t *testing.T) {
+	assert := testifyassert.New(t)
+	server, _ := newS3Server(t)
+	header := map[string]string{
+		endpoint:        server.URL,
+		region:          "us-west-2",
+		accessKeyID:     "id",
+		accessKeySecret: "secret",
+	}
+	client := NewS3SourceClient()
+
+	length, err := client.GetContentLength("s3://bucket/dataset/a.txt", header)
+	assert.Nil(err)
+	assert.Equal(int64(12), length)
+	_, err = client.GetContentLength("s3://bucket/dataset/not-found", header)
+	assert.True(cdnerrors.IsURLNotReachable(err))
+
+	// the headers other than the credentials are sent to the service
+	rangeHeader := map[string]string{headers.Range: "bytes=8-11"}
+	for k, v := range header {
+		rangeHeader[k] = v
+	}
+	rc, expireInfo, err := client.Download("s3://bucket/dataset/a.txt", rangeHeader)
+	assert.Nil(err)
+	data, _ := ioutil.ReadAll(rc)
+	rc.Close()
+	assert.Equal("of a", string(data))
+	assert.Equal(`"etag"`, expireInfo[headers.ETag])
+
+	expired, err := client.IsExpired("s3://bucket/dataset/a.txt", header, expireInfo)
+	assert.Nil(err)
+	assert.False(expired)
+	expired, err = client.IsExpired("s3://bucket/dataset/a.txt", header, map[string]string{
+		headers.LastModified: expireInfo[headers.LastModified],
+		headers.ETag:         `"old"`,
+	})
+	assert.Nil(err)
+	assert.True(expired)
+}
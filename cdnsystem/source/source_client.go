@@ -18,8 +18,13 @@ package source
 
 import (
 	"io"
+
+	"github.com/pkg/errors"
 )
 
+// ErrListNotSupported is returned when the source client of the url does not support listing
+var ErrListNotSupported = errors.New("list is not supported")
+
 var clients = make(map[string]ResourceClient)
 
 func Register(schema string, resourceClient ResourceClient) {
@@ -41,13 +46,30 @@ type ResourceClient interface {
 	// IsSupportRange checks if source supports breakpoint continuation
 	IsSupportRange(url string, headers map[string]string) (bool, error)
 
-	// IsExpired checks if cache is expired
+	// IsExpired checks if cache is expired, expireInfo is the one returned by Download when the cache was downloaded.
+	// The client may update expireInfo in place when the source revalidates the cache, the caller should store it again.
 	IsExpired(url string, headers, expireInfo map[string]string) (bool, error)
 
 	// Download download from source
 	Download(url string, headers map[string]string) (io.ReadCloser, map[string]string, error)
 }
 
+// URLEntry is an entry listed under a directory or prefix of the source
+type URLEntry struct {
+	// URL is the url of the entry, it can be downloaded or listed again by the same client
+	URL string
+	// Name is the name of the entry relative to the listed url, directories end with "/"
+	Name string
+	// IsDir indicates the entry is a directory or prefix
+	IsDir bool
+}
+
+// ResourceLister is an optional capability of ResourceClient, which lists entries of directories or object prefixes.
+type ResourceLister interface {
+	// List lists the direct entries under url
+	List(url string, headers map[string]string) ([]*URLEntry, error)
+}
+
 type ResourceClientAdaptor struct {
 	clients map[string]ResourceClient
 }
@@ -83,3 +105,15 @@ func (s *ResourceClientAdaptor) Download(url string, headers map[string]string)
 	}
 	return sourceClient.Download(url, headers)
 }
+
+func (s *ResourceClientAdaptor) List(url string, headers map[string]string) ([]*URLEntry, error) {
+	sourceClient, err := s.getSourceClient(url)
+	if err != nil {
+		return nil, err
+	}
+	lister, ok := sourceClient.(ResourceLister)
+	if !ok {
+		return nil, errors.Wrapf(ErrListNotSupported, "url: %s", url)
+	}
+	return lister.List(url, headers)
+}
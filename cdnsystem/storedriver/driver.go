@@ -121,4 +121,12 @@ type GcConfig struct {
 	FullGCThreshold   unit.Bytes    `yaml:"fullGCThreshold"`
 	CleanRatio        int           `yaml:"cleanRatio"`
 	IntervalThreshold time.Duration `yaml:"intervalThreshold"`
+	// Policy decides which tasks are removed first, [default/lru/lfu/size], empty means default.
+	Policy string `yaml:"policy"`
+	// HalfLife is the idle time after which the hits of a task count as half in lfu and size policies.
+	HalfLife time.Duration `yaml:"halfLife"`
+	// Pinned are the regular expressions of urls whose tasks are never removed by gc.
+	Pinned []string `yaml:"pinned"`
+	// DryRun only reports the tasks which would be removed.
+	DryRun bool `yaml:"dryRun"`
 }
@@ -0,0 +1,257 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"d7y.io/dragonfly/v2/cdnsystem/cdnerrors"
+	"d7y.io/dragonfly/v2/cdnsystem/storedriver"
+	"d7y.io/dragonfly/v2/pkg/unit"
+	"d7y.io/dragonfly/v2/pkg/util/s3utils"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestS3StorageSuite(t *testing.T) {
+	suite.Run(t, new(S3StorageTestSuite))
+}
+
+type S3StorageTestSuite struct {
+	server *httptest.Server
+	bucket *fakeBucket
+	storedriver.Driver
+	suite.Suite
+}
+
+func (s *S3StorageTestSuite) SetupTest() {
+	s.bucket = &fakeBucket{name: "cdn", objects: make(map[string][]byte)}
+	s.server = httptest.NewServer(s.bucket)
+	store, err := NewStorage(map[string]interface{}{
+		"endpoint":   s.server.URL,
+		"region":     "us-east-1",
+		"bucket":     "cdn",
+		"accessKey":  "ak",
+		"secretKey":  "sk",
+		"baseDir":    "/repo/",
+		"totalSpace": "1KB",
+	})
+	s.Nil(err)
+	s.Driver = store
+}
+
+func (s *S3StorageTestSuite) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *S3StorageTestSuite) TestPutAndGet() {
+	ctx := context.Background()
+	raw := &storedriver.Raw{Bucket: "download/abc", Key: "abcd"}
+	_, err := s.Stat(ctx, raw)
+	s.True(cdnerrors.IsFileNotExist(err))
+
+	// write the pieces out of order with a hole
+	s.Nil(s.PutBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key, Offset: 6}, []byte("ghi")))
+	s.Nil(s.Put(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key, Length: 3}, strings.NewReader("abcdef")))
+	info, err := s.Stat(ctx, raw)
+	s.Nil(err)
+	s.Equal(int64(9), info.Size)
+	s.Equal("download/abc/abcd", info.Path)
+
+	data, err := s.GetBytes(ctx, raw)
+	s.Nil(err)
+	s.Equal([]byte("abc\x00\x00\x00ghi"), data)
+	data, err = s.GetBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key, Offset: 2, Length: 5})
+	s.Nil(err)
+	s.Equal([]byte("c\x00\x00\x00g"), data)
+	_, err = s.GetBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key, Offset: 10})
+	s.True(cdnerrors.IsInvalidValue(err))
+
+	// the next part hides the content of the previous part after its offset
+	s.Nil(s.PutBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key, Offset: 2}, []byte("xyzw")))
+	data, err = s.GetBytes(ctx, raw)
+	s.Nil(err)
+	s.Equal([]byte("abxyzwghi"), data)
+	s.True(strings.HasPrefix(s.GetPath(raw), "repo/download/abc/abcd"))
+}
+
+func (s *S3StorageTestSuite) TestAppendAndTrunc() {
+	ctx := context.Background()
+	raw := &storedriver.Raw{Bucket: "download/abc", Key: "abcd.piece", Append: true}
+	for _, line := range []string{"1\n", "2\n", "3\n"} {
+		s.Nil(s.PutBytes(ctx, raw, []byte(line)))
+	}
+	data, err := s.GetBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key})
+	s.Nil(err)
+	s.Equal("1\n2\n3\n", string(data))
+
+	s.Nil(s.PutBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key, Trunc: true}, []byte("4")))
+	data, err = s.GetBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key})
+	s.Nil(err)
+	s.Equal("4", string(data))
+
+	s.Nil(s.PutBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key, Offset: 3, Trunc: true, TruncSize: 2}, []byte("5")))
+	data, err = s.GetBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key})
+	s.Nil(err)
+	s.Equal("4\x00\x005", string(data))
+}
+
+func (s *S3StorageTestSuite) TestAppendWithoutList() {
+	ctx := context.Background()
+	raw := &storedriver.Raw{Bucket: "download/abc", Key: "abcd.piece", Append: true}
+	s.Nil(s.PutBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key}, []byte("0\n")))
+
+	// only the first append lists the parts of file
+	lists := s.bucket.lists
+	for _, line := range []string{"1\n", "2\n", "3\n"} {
+		s.Nil(s.PutBytes(ctx, raw, []byte(line)))
+	}
+	s.Equal(lists+1, s.bucket.lists)
+
+	// the parts are listed again after a truncate
+	s.Nil(s.PutBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key, Offset: 4, Trunc: true, TruncSize: 4}, []byte("4\n")))
+	lists = s.bucket.lists
+	s.Nil(s.PutBytes(ctx, raw, []byte("5\n")))
+	s.Nil(s.PutBytes(ctx, raw, []byte("6\n")))
+	s.Equal(lists+1, s.bucket.lists)
+	data, err := s.GetBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key})
+	s.Nil(err)
+	s.Equal("0\n1\n4\n5\n6\n", string(data))
+
+	// and after the file is removed
+	s.Nil(s.Remove(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key}))
+	s.Nil(s.PutBytes(ctx, raw, []byte("7\n")))
+	data, err = s.GetBytes(ctx, &storedriver.Raw{Bucket: raw.Bucket, Key: raw.Key})
+	s.Nil(err)
+	s.Equal("7\n", string(data))
+}
+
+func (s *S3StorageTestSuite) TestWalkAndRemove() {
+	ctx := context.Background()
+	for _, key := range []string{"a.meta", "a.piece", "a", "b.meta"} {
+		s.Nil(s.PutBytes(ctx, &storedriver.Raw{Bucket: "download/" + key[:1], Key: key}, []byte(key)))
+	}
+	var files []string
+	s.Nil(s.Walk(ctx, &storedriver.Raw{Bucket: "download", WalkFn: func(path string, info os.FileInfo, err error) error {
+		s.Nil(err)
+		if !info.IsDir() {
+			files = append(files, info.Name()+":"+strconv.FormatInt(info.Size(), 10))
+		}
+		return nil
+	}}))
+	s.Equal([]string{"a:1", "a.meta:6", "a.piece:7", "b.meta:6"}, files)
+	s.True(s.Exits(ctx, &storedriver.Raw{Bucket: "download/a"}))
+	s.True(s.Exits(ctx, &storedriver.Raw{Bucket: "download/a", Key: "a"}))
+	s.False(s.Exits(ctx, &storedriver.Raw{Bucket: "download/c"}))
+
+	total, free, err := s.GetTotalAndFreeSpace(ctx)
+	s.Nil(err)
+	s.Equal(unit.KB, total)
+	s.Equal(unit.KB-20, free)
+
+	s.Nil(s.Remove(ctx, &storedriver.Raw{Bucket: "download/a", Key: "a.meta"}))
+	s.False(s.Exits(ctx, &storedriver.Raw{Bucket: "download/a", Key: "a.meta"}))
+	s.True(s.Exits(ctx, &storedriver.Raw{Bucket: "download/a", Key: "a"}))
+	// a dir which is not empty is only removed by force
+	s.Nil(s.Remove(ctx, &storedriver.Raw{Bucket: "download/a"}))
+	s.True(s.Exits(ctx, &storedriver.Raw{Bucket: "download/a"}))
+	s.Nil(s.Remove(ctx, &storedriver.Raw{Bucket: "download/a", Trunc: true}))
+	s.False(s.Exits(ctx, &storedriver.Raw{Bucket: "download/a"}))
+	s.True(cdnerrors.IsFileNotExist(s.Remove(ctx, &storedriver.Raw{Bucket: "download/a"})))
+	s.True(s.Exits(ctx, &storedriver.Raw{Bucket: "download/b", Key: "b.meta"}))
+}
+
+func (s *S3StorageTestSuite) TestMoveFile() {
+	ctx := context.Background()
+	src := &storedriver.Raw{Bucket: "download/abc", Key: "src"}
+	dst := &storedriver.Raw{Bucket: "download/abc", Key: "dst"}
+	s.Nil(s.PutBytes(ctx, src, []byte("abc")))
+	s.Nil(s.PutBytes(ctx, &storedriver.Raw{Bucket: src.Bucket, Key: src.Key, Offset: 5}, []byte("f")))
+	s.Nil(s.MoveFile(s.GetPath(src), s.GetPath(dst)))
+	s.False(s.Exits(ctx, src))
+	data, err := s.GetBytes(ctx, dst)
+	s.Nil(err)
+	s.Equal("abc\x00\x00f", string(data))
+}
+
+func (s *S3StorageTestSuite) TestSign() {
+	s.Nil(s.PutBytes(context.Background(), &storedriver.Raw{Bucket: "download/abc", Key: "a b"}, []byte("a")))
+	s.True(strings.HasPrefix(s.bucket.lastAuth, "AWS4-HMAC-SHA256 Credential=ak/"))
+	s.Contains(s.bucket.lastAuth, "/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=")
+}
+
+// fakeBucket serves a bucket of the S3 compatible service in memory.
+type fakeBucket struct {
+	sync.Mutex
+	name     string
+	objects  map[string][]byte
+	lastAuth string
+	lists    int
+}
+
+func (b *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.Lock()
+	defer b.Unlock()
+	b.lastAuth = r.Header.Get("Authorization")
+	key := strings.TrimPrefix(r.URL.Path, "/"+b.name+"/")
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := ioutil.ReadAll(r.Body)
+		b.objects[key] = data
+	case http.MethodDelete:
+		delete(b.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		if r.URL.Query().Get("list-type") == "2" {
+			b.list(w, r.URL.Query().Get("prefix"))
+			return
+		}
+		data, ok := b.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, key, time.Time{}, strings.NewReader(string(data)))
+	}
+}
+
+func (b *fakeBucket) list(w http.ResponseWriter, prefix string) {
+	b.lists++
+	result := struct {
+		XMLName  xml.Name             `xml:"ListBucketResult"`
+		Contents []s3utils.ObjectInfo `xml:"Contents"`
+	}{}
+	for key, data := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			result.Contents = append(result.Contents, s3utils.ObjectInfo{Key: key, Size: int64(len(data)), LastModified: time.Now()})
+		}
+	}
+	sort.Slice(result.Contents, func(i, j int) bool {
+		return result.Contents[i].Key < result.Contents[j].Key
+	})
+	xml.NewEncoder(w).Encode(result)
+}
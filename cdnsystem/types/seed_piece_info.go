@@ -16,7 +16,10 @@
 
 package types
 
-import "d7y.io/dragonfly/v2/pkg/util/rangeutils"
+import (
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
+)
 
 // SeedPiece
 type SeedPiece struct {
@@ -34,4 +37,30 @@ type PieceFormat int8
 
 const (
 	PlainUnspecified PieceFormat = 1
+	ZstdCompressed   PieceFormat = 2
+	GzipCompressed   PieceFormat = 3
 )
+
+// ToPieceStyle converts the stored piece format to the piece style in rpc
+func (f PieceFormat) ToPieceStyle() base.PieceStyle {
+	switch f {
+	case ZstdCompressed:
+		return base.PieceStyle_ZSTD
+	case GzipCompressed:
+		return base.PieceStyle_GZIP
+	default:
+		return base.PieceStyle_PLAIN
+	}
+}
+
+// PieceFormatOf converts the piece style in rpc to the stored piece format
+func PieceFormatOf(style base.PieceStyle) PieceFormat {
+	switch style {
+	case base.PieceStyle_ZSTD:
+		return ZstdCompressed
+	case base.PieceStyle_GZIP:
+		return GzipCompressed
+	default:
+		return PlainUnspecified
+	}
+}
@@ -20,6 +20,7 @@ type SeedTask struct {
 	TaskId           string            `json:"taskId,omitempty"`
 	Url              string            `json:"url,omitempty"`
 	TaskUrl          string            `json:"taskUrl,omitempty"`
+	Filter           []string          `json:"filter,omitempty"`
 	SourceFileLength int64             `json:"sourceFileLength,omitempty"`
 	CdnFileLength    int64             `json:"cdnFileLength,omitempty"`
 	PieceSize        int32             `json:"pieceSize,omitempty"`
@@ -29,6 +30,12 @@ type SeedTask struct {
 	RequestMd5       string            `json:"requestMd5,omitempty"`
 	SourceRealMd5    string            `json:"sourceRealMd5,omitempty"`
 	PieceMd5Sign     string            `json:"pieceMd5Sign,omitempty"`
+	// RequestDigest is the content digest in the form of algorithm:hex, the task is content addressed when it is set
+	RequestDigest string `json:"requestDigest,omitempty"`
+	// SourceRealDigest is the digest of the content downloaded from source, it is only computed for the task with RequestDigest
+	SourceRealDigest string `json:"sourceRealDigest,omitempty"`
+	// SourceStatusCode is the status code source responds when CdnStatus is SOURCE_ERROR, 0 if source is not reachable
+	SourceStatusCode int `json:"sourceStatusCode,omitempty"`
 }
 
 // IsSuccess determines that whether the CDNStatus is success.
@@ -18,9 +18,15 @@ package types
 
 // TaskRegisterRequest
 type TaskRegisterRequest struct {
-	URL    string            `json:"rawURL,omitempty"`
-	TaskId string            `json:"taskId,omitempty"`
-	Md5    string            `json:"md5,omitempty"`
+	URL    string `json:"rawURL,omitempty"`
+	TaskId string `json:"taskId,omitempty"`
+	Md5    string `json:"md5,omitempty"`
+	// Digest is the content digest in the form of algorithm:hex
+	Digest string            `json:"digest,omitempty"`
 	Filter []string          `json:"filter,omitempty"`
 	Header map[string]string `json:"header,omitempty"`
+	// PeerCount is the number of peers downloading the task concurrently
+	PeerCount int32 `json:"peerCount,omitempty"`
+	// StartPieceNum is the piece number the watcher resumes from
+	StartPieceNum int32 `json:"startPieceNum,omitempty"`
 }
@@ -24,6 +24,8 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/time/rate"
 	"gopkg.in/yaml.v3"
+
+	"d7y.io/dragonfly/v2/pkg/unit"
 )
 
 // RateLimit is a wrapper for rate.Limit, support json and yaml unmarshal function
@@ -67,6 +69,47 @@ func (r *RateLimit) unmarshal(unmarshal func(in []byte, out interface{}) (err er
 	}
 }
 
+// Size is a wrapper for unit.Bytes, support json and yaml unmarshal function
+// yaml example 1:
+//   quota: 10737418240 # 10GiB
+// yaml example 2:
+//   quota: 10GiB
+type Size struct {
+	unit.Bytes
+}
+
+func (s *Size) UnmarshalJSON(b []byte) error {
+	return s.unmarshal(json.Unmarshal, b)
+}
+
+func (s *Size) UnmarshalYAML(node *yaml.Node) error {
+	return s.unmarshal(yaml.Unmarshal, []byte(node.Value))
+}
+
+func (s *Size) unmarshal(unmarshal func(in []byte, out interface{}) (err error), b []byte) error {
+	var v interface{}
+	if err := unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case float64:
+		s.Bytes = unit.ToBytes(int64(value))
+		return nil
+	case int:
+		s.Bytes = unit.ToBytes(int64(value))
+		return nil
+	case string:
+		size, err := units.RAMInBytes(value)
+		if err != nil {
+			return errors.WithMessage(err, "invalid size")
+		}
+		s.Bytes = unit.ToBytes(size)
+		return nil
+	default:
+		return errors.New("invalid size")
+	}
+}
+
 type Duration struct {
 	time.Duration
 }
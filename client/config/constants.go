@@ -45,6 +45,9 @@ const (
 	PatternSource = "source"
 )
 
+// OutputStdout is the output which streams the content to stdout
+const OutputStdout = "-"
+
 const (
 	DefaultPerPeerDownloadLimit = 20 * unit.MB
 	DefaultTotalDownloadLimit   = 100 * unit.MB
@@ -65,6 +68,10 @@ const (
 	DefaultDaemonAliveTime = 5 * time.Minute
 	DefaultScheduleTimeout = 5 * time.Minute
 	DefaultDownloadTimeout = 5 * time.Minute
+	DefaultParallel        = 4
+
+	DefaultStorageHighWatermark = 90
+	DefaultStorageLowWatermark  = 80
 
 	DefaultSupernodeSchema = "http"
 	DefaultSupernodeIP     = "127.0.0.1"
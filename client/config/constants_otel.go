@@ -39,6 +39,10 @@ const (
 	AttributeGetPieceCount     = attribute.Key("d7y.peer.piece.count")
 	AttributeGetPieceRetry     = attribute.Key("d7y.peer.piece.retry")
 	AttributeWritePieceSuccess = attribute.Key("d7y.peer.piece.write.success")
+	AttributeMigrateScheduler  = attribute.Key("d7y.peer.scheduler.migrate")
+	AttributeStorageCapacity   = attribute.Key("d7y.peer.storage.capacity")
+	AttributeStorageUsage      = attribute.Key("d7y.peer.storage.usage")
+	AttributeStorageReclaimed  = attribute.Key("d7y.peer.storage.reclaimed")
 
 	SpanFilePeerTask    = "file-peer-task"
 	SpanStreamPeerTask  = "stream-peer-task"
@@ -52,4 +56,5 @@ const (
 	SpanWaitPieceLimit  = "wait-limit"
 	SpanPushPieceResult = "push-result"
 	SpanPeerGC          = "peer-gc"
+	SpanPeerSpaceGC     = "peer-space-gc"
 )
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"syscall"
@@ -30,6 +31,7 @@ import (
 
 	"d7y.io/dragonfly/v2/pkg/basic"
 	"d7y.io/dragonfly/v2/pkg/dferrors"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 	"d7y.io/dragonfly/v2/pkg/util/net/urlutils"
 	"d7y.io/dragonfly/v2/pkg/util/stringutils"
 )
@@ -42,7 +44,7 @@ type ClientOption struct {
 	// Lock file location
 	LockFile string `json:"lock_file" yaml:"lock_file"`
 
-	// Output full output path.
+	// Output full output path, or OutputStdout to stream the content to stdout.
 	Output string `json:"output"`
 
 	// Timeout download timeout(second).
@@ -52,7 +54,7 @@ type ClientOption struct {
 	// Deprecated: Md5 is deprecated, use DigestMethod with DigestValue instead
 	Md5 string `json:"md5,omitempty"`
 
-	// DigestMethod indicates digest method, like md5, sha256
+	// DigestMethod indicates digest method, like md5, sha256, sha512
 	DigestMethod string `json:"digest_method,omitempty"`
 
 	// DigestValue indicates digest value
@@ -65,6 +67,7 @@ type ClientOption struct {
 	CallSystem string `json:"call_system,omitempty"`
 
 	// Pattern download pattern, must be 'p2p' or 'cdn' or 'source',
+	// 'cdn' only downloads pieces from cdn peers, 'source' downloads from the origin directly without daemon,
 	// default:`p2p`.
 	Pattern string `json:"pattern,omitempty"`
 
@@ -105,6 +108,25 @@ type ClientOption struct {
 
 	// MoreDaemonOptions indicates more options passed to daemon by command line.
 	MoreDaemonOptions string `json:"more_daemon_options,omitempty"`
+
+	// Recursive indicates to download all files under the url recursively,
+	// and recreate the tree under the Output directory.
+	Recursive bool `json:"recursive,omitempty"`
+
+	// Include only downloads the files whose relative path or name matches one of the patterns in recursive mode.
+	Include []string `json:"include,omitempty"`
+
+	// Exclude skips the files whose relative path or name matches one of the patterns in recursive mode.
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Parallel limits the count of concurrent downloading files.
+	Parallel int `json:"parallel,omitempty"`
+
+	// InputFile is the file of batch mode, which holds all files to download, see BatchEntry.
+	InputFile string `json:"input_file,omitempty"`
+
+	// JSONProgress prints the download progress as json lines to stdout instead of the progress bar.
+	JSONProgress bool `json:"json_progress,omitempty"`
 }
 
 func NewClientOption() *ClientOption {
@@ -116,14 +138,161 @@ func (cfg *ClientOption) Validate() error {
 		return errors.Wrap(dferrors.ErrInvalidArgument, "runtime config")
 	}
 
-	if !urlutils.IsValidURL(cfg.URL) {
-		return errors.Wrapf(dferrors.ErrInvalidArgument, "url: %v", cfg.URL)
+	if !stringutils.IsBlank(cfg.InputFile) {
+		if err := cfg.checkInputFile(); err != nil {
+			return errors.Wrapf(dferrors.ErrInvalidArgument, "input file: %v", err)
+		}
+	} else {
+		if !urlutils.IsValidURL(cfg.URL) {
+			return errors.Wrapf(dferrors.ErrInvalidArgument, "url: %v", cfg.URL)
+		}
+
+		if err := cfg.checkOutput(); err != nil {
+			return errors.Wrapf(dferrors.ErrInvalidArgument, "output: %v", err)
+		}
+	}
+
+	if err := cfg.checkDigest(); err != nil {
+		return errors.Wrapf(dferrors.ErrInvalidArgument, "digest: %v", err)
+	}
+
+	if err := cfg.checkPattern(); err != nil {
+		return errors.Wrapf(dferrors.ErrInvalidArgument, "pattern: %v", err)
+	}
+
+	if err := cfg.checkRecursive(); err != nil {
+		return errors.Wrapf(dferrors.ErrInvalidArgument, "recursive: %v", err)
+	}
+
+	if err := cfg.checkJSONProgress(); err != nil {
+		return errors.Wrapf(dferrors.ErrInvalidArgument, "json: %v", err)
+	}
+
+	return nil
+}
+
+// Match checks whether the file with relative path name should be downloaded in recursive mode,
+// the patterns are matched against both the relative path and the base name.
+func (cfg *ClientOption) Match(name string) bool {
+	matchAny := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+			if ok, _ := path.Match(pattern, path.Base(name)); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if len(cfg.Include) > 0 && !matchAny(cfg.Include) {
+		return false
+	}
+	return !matchAny(cfg.Exclude)
+}
+
+// RecursiveSchemes are the url schemes whose source clients can list entries in recursive mode,
+// http and https list directory index pages, oss and s3 list the objects under the prefix.
+var RecursiveSchemes = []string{"http", "https", "oss", "s3"}
+
+func isRecursiveURL(url string) bool {
+	for _, scheme := range RecursiveSchemes {
+		if strings.HasPrefix(url, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg *ClientOption) checkRecursive() error {
+	if cfg.Parallel < 1 {
+		return fmt.Errorf("parallel %d should be greater than 0", cfg.Parallel)
+	}
+	if !cfg.Recursive {
+		if len(cfg.Include) > 0 || len(cfg.Exclude) > 0 {
+			return fmt.Errorf("include and exclude patterns are only supported in recursive mode")
+		}
+		return nil
+	}
+	if !stringutils.IsBlank(cfg.Md5) || !stringutils.IsBlank(cfg.Digest()) {
+		return fmt.Errorf("md5 and digest are not supported in recursive mode")
+	}
+	if !isRecursiveURL(cfg.URL) {
+		return fmt.Errorf("url %s is not supported in recursive mode, only %s urls can be listed",
+			cfg.URL, strings.Join(RecursiveSchemes, ", "))
+	}
+	for _, pattern := range append(cfg.Include, cfg.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (cfg *ClientOption) checkPattern() error {
+	switch cfg.Pattern {
+	case "":
+		cfg.Pattern = PatternP2P
+	case PatternP2P, PatternCDN:
+	case PatternSource:
+		if cfg.NotBackSource {
+			return fmt.Errorf("source pattern is conflict with not back source")
+		}
+	default:
+		return fmt.Errorf("unknown pattern %q, must be %s, %s or %s", cfg.Pattern, PatternP2P, PatternCDN, PatternSource)
+	}
+	return nil
+}
+
+func (cfg *ClientOption) checkInputFile() error {
+	if !stringutils.IsBlank(cfg.URL) || !stringutils.IsBlank(cfg.Output) {
+		return fmt.Errorf("url and output should be set in the input file")
+	}
+	if cfg.Recursive {
+		return fmt.Errorf("recursive mode is not supported in batch mode")
+	}
+	if !stringutils.IsBlank(cfg.Md5) || !stringutils.IsBlank(cfg.Digest()) {
+		return fmt.Errorf("md5 and digest should be set in the input file")
+	}
+	if _, err := os.Stat(cfg.InputFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cfg *ClientOption) checkJSONProgress() error {
+	if !cfg.JSONProgress {
+		return nil
+	}
+	if cfg.Output == OutputStdout {
+		return fmt.Errorf("json progress is conflict with streaming the content to stdout")
+	}
+	if cfg.Recursive || !stringutils.IsBlank(cfg.InputFile) {
+		return fmt.Errorf("json progress is only supported when downloading a single file")
 	}
+	return nil
+}
 
-	if err := cfg.checkOutput(); err != nil {
-		return errors.Wrapf(dferrors.ErrInvalidArgument, "output: %v", err)
+// Digest returns the expected file digest in the form of algorithm:hex, empty if it is not set.
+func (cfg *ClientOption) Digest() string {
+	if stringutils.IsBlank(cfg.DigestMethod) || stringutils.IsBlank(cfg.DigestValue) {
+		return ""
 	}
+	return digestutils.Format(cfg.DigestMethod, cfg.DigestValue)
+}
 
+func (cfg *ClientOption) checkDigest() error {
+	if stringutils.IsBlank(cfg.DigestMethod) && stringutils.IsBlank(cfg.DigestValue) {
+		return nil
+	}
+	if stringutils.IsBlank(cfg.DigestMethod) || stringutils.IsBlank(cfg.DigestValue) {
+		return fmt.Errorf("digest method and digest value must be set together")
+	}
+	algorithm, encoded, err := digestutils.Parse(cfg.Digest())
+	if err != nil {
+		return err
+	}
+	cfg.DigestMethod, cfg.DigestValue = algorithm, encoded
 	return nil
 }
 
@@ -134,13 +303,19 @@ func (cfg *ClientOption) String() string {
 
 // This function must be called after checkURL
 func (cfg *ClientOption) checkOutput() error {
+	if cfg.Output == OutputStdout {
+		if cfg.Recursive {
+			return fmt.Errorf("stdout is not supported in recursive mode")
+		}
+		return nil
+	}
+
 	if stringutils.IsBlank(cfg.Output) {
-		url := strings.TrimRight(cfg.URL, "/")
-		idx := strings.LastIndexByte(url, '/')
-		if idx < 0 {
-			return fmt.Errorf("get output from url[%s] error", cfg.URL)
+		output, err := outputFromURL(cfg.URL)
+		if err != nil {
+			return err
 		}
-		cfg.Output = url[idx+1:]
+		cfg.Output = output
 	}
 
 	if !filepath.IsAbs(cfg.Output) {
@@ -151,8 +326,13 @@ func (cfg *ClientOption) checkOutput() error {
 		cfg.Output = absPath
 	}
 
-	if f, err := os.Stat(cfg.Output); err == nil && f.IsDir() {
-		return fmt.Errorf("path[%s] is directory but requires file path", cfg.Output)
+	if f, err := os.Stat(cfg.Output); err == nil {
+		if cfg.Recursive && !f.IsDir() {
+			return fmt.Errorf("path[%s] is file but requires directory path in recursive mode", cfg.Output)
+		}
+		if !cfg.Recursive && f.IsDir() {
+			return fmt.Errorf("path[%s] is directory but requires file path", cfg.Output)
+		}
 	}
 
 	// check permission
@@ -165,3 +345,13 @@ func (cfg *ClientOption) checkOutput() error {
 	}
 	return nil
 }
+
+// outputFromURL returns the last element of the url path as the default output.
+func outputFromURL(rawURL string) (string, error) {
+	url := strings.TrimRight(rawURL, "/")
+	idx := strings.LastIndexByte(url, '/')
+	if idx < 0 {
+		return "", fmt.Errorf("get output from url[%s] error", rawURL)
+	}
+	return url[idx+1:], nil
+}
@@ -0,0 +1,160 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"d7y.io/dragonfly/v2/pkg/util/net/urlutils"
+	"d7y.io/dragonfly/v2/pkg/util/stringutils"
+)
+
+// BatchEntry is one file to download in the input file of batch mode.
+//
+// Each line of the input file is either a json record of BatchEntry,
+// or a url followed by an optional output path separated by blanks.
+// Blank lines and lines starting with '#' are ignored.
+type BatchEntry struct {
+	// URL download URL.
+	URL string `json:"url"`
+
+	// Output full output path, it is generated from the url like --output when it is empty,
+	// outputs of all entries must be different.
+	Output string `json:"output,omitempty"`
+
+	// Digest expected file digest in the form of algorithm:hex.
+	Digest string `json:"digest,omitempty"`
+
+	// Header of http request, it overrides the same header of --header.
+	Header []string `json:"header,omitempty"`
+
+	// Filter filter some query params of url, use char '&' to separate different params,
+	// it overrides --filter.
+	Filter string `json:"filter,omitempty"`
+
+	// BizID caller business id, it overrides --callsystem.
+	BizID string `json:"biz_id,omitempty"`
+}
+
+// LoadBatchEntries reads all entries of the batch mode input file.
+func LoadBatchEntries(name string) ([]*BatchEntry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := ParseBatchEntries(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "input file %s", name)
+	}
+	return entries, nil
+}
+
+// ParseBatchEntries parses entries of the batch mode input file from reader.
+func ParseBatchEntries(reader io.Reader) ([]*BatchEntry, error) {
+	var (
+		entries []*BatchEntry
+		outputs = map[string]int{}
+		scanner = bufio.NewScanner(reader)
+		lineNo  int
+	)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry := &BatchEntry{}
+		if strings.HasPrefix(line, "{") {
+			if err := json.Unmarshal([]byte(line), entry); err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+		} else {
+			fields := strings.Fields(line)
+			if len(fields) > 2 {
+				return nil, fmt.Errorf("line %d: too many fields, expect url with an optional output path", lineNo)
+			}
+			entry.URL = fields[0]
+			if len(fields) == 2 {
+				entry.Output = fields[1]
+			}
+		}
+
+		if err := entry.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		// entries of the same output would overwrite each other when downloading in parallel
+		if prev, ok := outputs[entry.Output]; ok {
+			return nil, fmt.Errorf("line %d: output %s is the same as line %d", lineNo, entry.Output, prev)
+		}
+		outputs[entry.Output] = lineNo
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no url found")
+	}
+	return entries, nil
+}
+
+func (entry *BatchEntry) validate() error {
+	if !urlutils.IsValidURL(entry.URL) {
+		return fmt.Errorf("invalid url: %v", entry.URL)
+	}
+
+	if stringutils.IsBlank(entry.Output) {
+		output, err := outputFromURL(entry.URL)
+		if err != nil {
+			return err
+		}
+		entry.Output = output
+	}
+	output, err := filepath.Abs(entry.Output)
+	if err != nil {
+		return fmt.Errorf("get absolute path[%s] error: %v", entry.Output, err)
+	}
+	entry.Output = output
+
+	if !stringutils.IsBlank(entry.Digest) {
+		algorithm, encoded, err := digestutils.Parse(entry.Digest)
+		if err != nil {
+			return err
+		}
+		entry.Digest = digestutils.Format(algorithm, encoded)
+	}
+
+	for _, h := range entry.Header {
+		if strings.Index(h, ":") <= 0 {
+			return fmt.Errorf("invalid header: %v", h)
+		}
+	}
+	return nil
+}
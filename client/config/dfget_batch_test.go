@@ -0,0 +1,123 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestParseBatchEntries(t *testing.T) {
+	abs := func(p string) string {
+		p, _ = filepath.Abs(p)
+		return p
+	}
+
+	var cases = []struct {
+		name    string
+		text    string
+		entries []*BatchEntry
+		err     bool
+	}{
+		{
+			name: "plain lines",
+			text: `
+# comment
+http://example.com/a.tar.gz
+http://example.com/b.tar.gz  /tmp/b.tar.gz
+`,
+			entries: []*BatchEntry{
+				{URL: "http://example.com/a.tar.gz", Output: abs("a.tar.gz")},
+				{URL: "http://example.com/b.tar.gz", Output: "/tmp/b.tar.gz"},
+			},
+		},
+		{
+			name: "json records",
+			text: `{"url": "http://example.com/c", "output": "/tmp/c", "digest": "SHA256:ABABABABABABABABABABABABABABABABABABABABABABABABABABABABABABABAB", "header": ["Accept: *"], "filter": "key&sign", "biz_id": "ci"}`,
+			entries: []*BatchEntry{
+				{
+					URL:    "http://example.com/c",
+					Output: "/tmp/c",
+					Digest: "sha256:abababababababababababababababababababababababababababababababab",
+					Header: []string{"Accept: *"},
+					Filter: "key&sign",
+					BizID:  "ci",
+				},
+			},
+		},
+		{
+			name: "invalid url",
+			text: "example.com/a",
+			err:  true,
+		},
+		{
+			name: "too many fields",
+			text: "http://example.com/a /tmp/a /tmp/b",
+			err:  true,
+		},
+		{
+			name: "invalid digest",
+			text: `{"url": "http://example.com/c", "digest": "crc32:abcd"}`,
+			err:  true,
+		},
+		{
+			name: "invalid header",
+			text: `{"url": "http://example.com/c", "header": ["Accept"]}`,
+			err:  true,
+		},
+		{
+			name: "duplicate output",
+			text: `
+http://example.com/a /tmp/a
+{"url": "http://example.com/b", "output": "/tmp/../tmp/a"}
+`,
+			err: true,
+		},
+		{
+			name: "duplicate url to different outputs",
+			text: `
+http://example.com/a /tmp/a
+http://example.com/a /tmp/b
+`,
+			entries: []*BatchEntry{
+				{URL: "http://example.com/a", Output: "/tmp/a"},
+				{URL: "http://example.com/a", Output: "/tmp/b"},
+			},
+		},
+		{
+			name: "empty",
+			text: "# nothing",
+			err:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			entries, err := ParseBatchEntries(strings.NewReader(c.text))
+			if c.err {
+				assert.NotNil(err)
+				return
+			}
+			assert.Nil(err)
+			assert.Equal(c.entries, entries)
+		})
+	}
+}
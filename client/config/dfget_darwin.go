@@ -28,7 +28,7 @@ var dfgetConfig = ClientOption{
 	DigestValue:   "",
 	Identifier:    "",
 	CallSystem:    "",
-	Pattern:       "",
+	Pattern:       PatternP2P,
 	Cacerts:       nil,
 	Filter:        nil,
 	Header:        nil,
@@ -37,4 +37,5 @@ var dfgetConfig = ClientOption{
 	ShowBar:       false,
 	Console:       false,
 	Verbose:       false,
+	Parallel:      DefaultParallel,
 }
@@ -16,6 +16,134 @@
 
 package config
 
+import (
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestClientOption_CheckRecursive(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *ClientOption
+		err  bool
+	}{
+		{
+			name: "not recursive",
+			cfg:  &ClientOption{URL: "s3://bucket/dataset/", Parallel: 1},
+		},
+		{
+			name: "patterns without recursive",
+			cfg:  &ClientOption{URL: "http://example.com/dataset/", Parallel: 1, Include: []string{"*.txt"}},
+			err:  true,
+		},
+		{
+			name: "http directory",
+			cfg:  &ClientOption{URL: "http://example.com/dataset/", Parallel: 1, Recursive: true, Include: []string{"*.txt"}},
+		},
+		{
+			name: "oss prefix",
+			cfg:  &ClientOption{URL: "oss://bucket/dataset/", Parallel: 4, Recursive: true, Exclude: []string{"tmp/*"}},
+		},
+		{
+			name: "s3 prefix",
+			cfg:  &ClientOption{URL: "s3://bucket/dataset/", Parallel: 1, Recursive: true},
+		},
+		{
+			name: "hdfs directory",
+			cfg:  &ClientOption{URL: "hdfs://namenode/dataset/", Parallel: 1, Recursive: true},
+			err:  true,
+		},
+		{
+			name: "invalid parallel",
+			cfg:  &ClientOption{URL: "http://example.com/dataset/", Recursive: true},
+			err:  true,
+		},
+		{
+			name: "invalid pattern",
+			cfg:  &ClientOption{URL: "http://example.com/dataset/", Parallel: 1, Recursive: true, Include: []string{"[a-"}},
+			err:  true,
+		},
+		{
+			name: "md5",
+			cfg:  &ClientOption{URL: "http://example.com/dataset/", Parallel: 1, Recursive: true, Md5: "5d41402abc4b2a76b9719d911017c592"},
+			err:  true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			err := tc.cfg.checkRecursive()
+			assert.Equal(tc.err, err != nil, "%v", err)
+		})
+	}
+}
+
+func TestClientOption_CheckPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *ClientOption
+		pattern string
+		err     bool
+	}{
+		{
+			name:    "default",
+			cfg:     &ClientOption{},
+			pattern: PatternP2P,
+		},
+		{
+			name:    "p2p",
+			cfg:     &ClientOption{Pattern: PatternP2P},
+			pattern: PatternP2P,
+		},
+		{
+			name:    "cdn",
+			cfg:     &ClientOption{Pattern: PatternCDN, NotBackSource: true},
+			pattern: PatternCDN,
+		},
+		{
+			name:    "source",
+			cfg:     &ClientOption{Pattern: PatternSource},
+			pattern: PatternSource,
+		},
+		{
+			name: "source without back source",
+			cfg:  &ClientOption{Pattern: PatternSource, NotBackSource: true},
+			err:  true,
+		},
+		{
+			name: "unknown",
+			cfg:  &ClientOption{Pattern: "P2P"},
+			err:  true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			err := tc.cfg.checkPattern()
+			if tc.err {
+				assert.NotNil(err)
+				return
+			}
+			assert.Nil(err)
+			assert.Equal(tc.pattern, tc.cfg.Pattern)
+		})
+	}
+}
+
+func TestClientOption_Match(t *testing.T) {
+	assert := testifyassert.New(t)
+	cfg := &ClientOption{Include: []string{"*.txt", "data/*"}, Exclude: []string{"tmp.*", "data/*.bak"}}
+
+	assert.True(cfg.Match("a.txt"))
+	assert.True(cfg.Match("sub/deeper/a.txt"), "base name matches")
+	assert.True(cfg.Match("data/a.bin"), "relative path matches")
+	assert.False(cfg.Match("a.bin"))
+	assert.False(cfg.Match("sub/tmp.txt"), "excluded by base name")
+	assert.False(cfg.Match("data/a.bak"), "excluded by relative path")
+	assert.True((&ClientOption{}).Match("any/file"))
+}
+
 //
 //import (
 //	"bytes"
@@ -35,6 +35,7 @@ import (
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+	"d7y.io/dragonfly/v2/pkg/piecesize"
 	"d7y.io/dragonfly/v2/pkg/util/net/iputils"
 )
 
@@ -113,6 +114,12 @@ func (p *PeerHostOption) Validate() error {
 	if p.AliveTime.Duration > 0 && p.Scheduler.ScheduleTimeout.Duration > p.AliveTime.Duration {
 		p.Scheduler.ScheduleTimeout.Duration = p.AliveTime.Duration - time.Second
 	}
+	if err := p.Download.PieceSizePolicy.Validate(); err != nil {
+		return errors.Wrap(err, "invalid piece size policy")
+	}
+	if err := p.Storage.Validate(); err != nil {
+		return errors.Wrap(err, "invalid storage option")
+	}
 	return nil
 }
 
@@ -145,6 +152,8 @@ type DownloadOption struct {
 	DownloadGRPC     ListenOption         `json:"download_grpc" yaml:"download_grpc"`
 	PeerGRPC         ListenOption         `json:"peer_grpc" yaml:"peer_grpc"`
 	CalculateDigest  bool                 `json:"calculate_digest" yaml:"calculate_digest"`
+	// PieceSizePolicy decides the piece size when back to source, it should be the same as the cdn
+	PieceSizePolicy *piecesize.Policy `json:"piece_size_policy" yaml:"piece_size_policy"`
 }
 
 type ProxyOption struct {
@@ -380,6 +389,26 @@ type StorageOption struct {
 	// after this period cache file will be gc
 	TaskExpireTime clientutil.Duration `json:"task_expire_time" yaml:"task_expire_time"`
 	StoreStrategy  StoreStrategy       `json:"strategy" yaml:"strategy"`
+	// Quota indicates the capacity of all task data in DataPath, when it is not set, the capacity of the disk is used
+	Quota clientutil.Size `json:"quota" yaml:"quota"`
+	// HighWatermark indicates the usage percent of the capacity to trigger gc, 0 means disabled,
+	// the least recently used tasks will be gc until the usage falls below LowWatermark
+	HighWatermark int `json:"high_watermark" yaml:"high_watermark"`
+	// LowWatermark indicates the usage percent of the capacity which gc stops at
+	LowWatermark int `json:"low_watermark" yaml:"low_watermark"`
+}
+
+func (s *StorageOption) Validate() error {
+	if s.HighWatermark < 0 || s.HighWatermark > 100 {
+		return errors.Errorf("high watermark %d should be in [0, 100]", s.HighWatermark)
+	}
+	if s.LowWatermark < 0 || s.LowWatermark > s.HighWatermark {
+		return errors.Errorf("low watermark %d should be in [0, high watermark %d]", s.LowWatermark, s.HighWatermark)
+	}
+	if s.Quota.Bytes < 0 {
+		return errors.Errorf("quota %d should not be negative", s.Quota.Bytes)
+	}
+	return nil
 }
 
 type StoreStrategy string
@@ -118,5 +118,7 @@ var peerHostConfig = PeerHostOption{
 			Duration: DefaultTaskExpireTime,
 		},
 		StoreStrategy: AdvanceLocalTaskStoreStrategy,
+		HighWatermark: DefaultStorageHighWatermark,
+		LowWatermark:  DefaultStorageLowWatermark,
 	},
 }
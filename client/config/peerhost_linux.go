@@ -117,5 +117,7 @@ var peerHostConfig = PeerHostOption{
 			Duration: DefaultTaskExpireTime,
 		},
 		StoreStrategy: AdvanceLocalTaskStoreStrategy,
+		HighWatermark: DefaultStorageHighWatermark,
+		LowWatermark:  DefaultStorageLowWatermark,
 	},
 }
@@ -31,6 +31,7 @@ import (
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+	"d7y.io/dragonfly/v2/pkg/unit"
 )
 
 func Test_AllUnmarshalYAML(t *testing.T) {
@@ -92,6 +93,30 @@ timeout: 1s
 		},
 		{
 			text: `
+quota: 10GiB
+`,
+			target: &struct {
+				Quota clientutil.Size `yaml:"quota"`
+			}{
+				Quota: clientutil.Size{
+					Bytes: 10 * unit.GB,
+				},
+			},
+		},
+		{
+			text: `
+quota: 1048576
+`,
+			target: &struct {
+				Quota clientutil.Size `yaml:"quota"`
+			}{
+				Quota: clientutil.Size{
+					Bytes: unit.MB,
+				},
+			},
+		},
+		{
+			text: `
 limit: 100Mi
 `,
 			target: &struct {
@@ -367,6 +392,11 @@ func TestPeerHostOption_Load(t *testing.T) {
 				Duration: 180000000000,
 			},
 			StoreStrategy: StoreStrategy("io.d7y.storage.v2.simple"),
+			Quota: clientutil.Size{
+				Bytes: 10 * unit.GB,
+			},
+			HighWatermark: 90,
+			LowWatermark:  80,
 		},
 		Proxy: &ProxyOption{
 			ListenOption: ListenOption{
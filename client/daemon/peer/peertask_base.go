@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -37,6 +38,7 @@ import (
 	"d7y.io/dragonfly/v2/pkg/dferrors"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/base/common"
 	dfclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
 	schedulerclient "d7y.io/dragonfly/v2/pkg/rpc/scheduler/client"
@@ -47,8 +49,12 @@ const (
 	reasonReScheduleTimeout     = "wait more available peers from scheduler timeout"
 	reasonContextCanceled       = "context canceled"
 	reasonPeerGoneFromScheduler = "scheduler says client should disconnect"
+	reasonCanceledByUser        = "canceled by user"
 
 	failedCodeNotSet = 0
+
+	// maxSchedulerMigrateTimes limits how many times a peer task migrates to another scheduler
+	maxSchedulerMigrateTimes = 3
 )
 
 var errPeerPacketChanged = errors.New("peer packet changed")
@@ -77,17 +83,36 @@ type peerTask struct {
 	taskId          string
 	contentLength   int64
 	totalPiece      int32
+	pieceSize       int32
 	completedLength int64
 	usedTraffic     int64
+	// peerLength, cdnLength and sourceLength split completedLength by where the pieces come from
+	peerLength   int64
+	cdnLength    int64
+	sourceLength int64
 
 	//sizeScope   base.SizeScope
 	singlePiece *scheduler.SinglePiece
 
+	// schedulerClient is used for migrating to another scheduler when peerPacketStream is broken
+	schedulerClient schedulerclient.SchedulerClient
 	// TODO peerPacketStream
 	peerPacketStream schedulerclient.PeerPacketStream
+	// peerPacketStreamLock guards peerPacketStream, migrating, lastPieceResult and endPieceResult,
+	// sending is not goroutine safe in grpc stream
+	peerPacketStreamLock sync.Mutex
+	// migrating is true while the stream to the new scheduler is being built, piece results are not sent to the broken stream then
+	migrating bool
+	// lastPieceResult is the latest success piece result, it will be replayed after migrating scheduler,
+	// scheduler only keeps the finished count of a peer, so the former ones are not needed
+	lastPieceResult *scheduler.PieceResult
+	// endPieceResult is the end piece result reported during migrating, it is sent to the new scheduler after replaying
+	endPieceResult *scheduler.PieceResult
+	// schedulerMigrateTimes stands how many times this peer task migrated scheduler
+	schedulerMigrateTimes int
 	// peerPacket is the latest available peers from peerPacketCh
 	peerPacket *scheduler.PeerPacket
-	// peerPacketReady will receive a ready signal for peerPacket ready
+	// peerPacketReady will receive a ready signal for peerPacket ready, it is buffered to keep the signal sent before waiting
 	peerPacketReady chan bool
 	// pieceParallelCount stands the piece parallel count from peerPacket
 	pieceParallelCount int32
@@ -136,6 +161,10 @@ func (pt *peerTask) GetTaskID() string {
 	return pt.taskId
 }
 
+func (pt *peerTask) GetPieceSize() int32 {
+	return pt.pieceSize
+}
+
 func (pt *peerTask) GetContentLength() int64 {
 	return pt.contentLength
 }
@@ -156,6 +185,38 @@ func (pt *peerTask) GetTotalPieces() int32 {
 	return pt.totalPiece
 }
 
+func (pt *peerTask) GetURL() string {
+	return pt.request.Url
+}
+
+func (pt *peerTask) GetCompletedLength() int64 {
+	return atomic.LoadInt64(&pt.completedLength)
+}
+
+// Cancel stops the running peer task, the task fails with ClientContextCanceled and the reason
+func (pt *peerTask) Cancel(reason string) {
+	pt.Infof("peer task canceled: %s", reason)
+	if pt.failedCode == failedCodeNotSet {
+		pt.failedCode = dfcodes.ClientContextCanceled
+		pt.failedReason = reason
+	}
+	pt.cancel()
+}
+
+// addCompletedLength adds the length of a downloaded piece, and records where it comes from by the dst peer
+func (pt *peerTask) addCompletedLength(dstPid string, n int64) {
+	atomic.AddInt64(&pt.completedLength, n)
+	switch {
+	// pieces downloaded from source are reported with the peer itself
+	case dstPid == pt.peerId:
+		atomic.AddInt64(&pt.sourceLength, n)
+	case strings.HasSuffix(dstPid, common.CdnSuffix):
+		atomic.AddInt64(&pt.cdnLength, n)
+	default:
+		atomic.AddInt64(&pt.peerLength, n)
+	}
+}
+
 func (pt *peerTask) Context() context.Context {
 	return pt.ctx
 }
@@ -200,12 +261,16 @@ loop:
 		default:
 		}
 
-		peerPacket, err = pt.peerPacketStream.Recv()
+		peerPacket, err = pt.getPeerPacketStream().Recv()
 		if err == io.EOF {
 			pt.Debugf("peerPacketStream closed")
 			break loop
 		}
 		if err != nil {
+			// keep downloading from current peers and continue receiving from the new scheduler
+			if pt.migrateScheduler(err) {
+				continue
+			}
 			pt.failedCode = dfcodes.UnknownError
 			if de, ok := err.(*dferrors.DfError); ok {
 				pt.failedCode = de.Code
@@ -267,6 +332,83 @@ loop:
 	}
 }
 
+func (pt *peerTask) getPeerPacketStream() schedulerclient.PeerPacketStream {
+	pt.peerPacketStreamLock.Lock()
+	defer pt.peerPacketStreamLock.Unlock()
+	return pt.peerPacketStream
+}
+
+// sendPieceResult sends piece result to scheduler, the latest success piece result is recorded for replaying after migrating scheduler
+func (pt *peerTask) sendPieceResult(pr *scheduler.PieceResult) error {
+	pt.peerPacketStreamLock.Lock()
+	defer pt.peerPacketStreamLock.Unlock()
+	if pr.Success {
+		pt.lastPieceResult = pr
+	}
+	if pt.migrating {
+		// the finished count is replayed after migrating, failed piece results are dropped,
+		// the new scheduler schedules peers for this peer task again
+		if pr.PieceNum == common.EndOfPiece {
+			pt.endPieceResult = pr
+		}
+		return nil
+	}
+	return pt.peerPacketStream.Send(pr)
+}
+
+// migrateScheduler registers current peer task to another scheduler when peerPacketStream is broken,
+// then replays the finished count with the latest success piece result to the new scheduler.
+// Piece workers keep downloading from current peers during migrating, so the lock is held only to swap the stream.
+func (pt *peerTask) migrateScheduler(cause error) bool {
+	if pt.schedulerClient == nil || pt.ctx.Err() != nil {
+		return false
+	}
+	if pt.schedulerMigrateTimes >= maxSchedulerMigrateTimes {
+		pt.Warnf("peer packet stream broken: %s, but migrate scheduler times reaches limit", cause)
+		return false
+	}
+	pt.schedulerMigrateTimes++
+	pt.Warnf("peer packet stream broken: %s, try to migrate to another scheduler, times: %d", cause, pt.schedulerMigrateTimes)
+
+	// the old stream is closed by migrating, never send to it again
+	pt.peerPacketStreamLock.Lock()
+	pt.migrating = true
+	oldStream := pt.peerPacketStream
+	pt.peerPacketStreamLock.Unlock()
+
+	stream, err := pt.schedulerClient.MigratePeerTask(pt.ctx, pt.taskId, pt.request, oldStream, cause)
+	if err != nil {
+		pt.Errorf("migrate scheduler error: %s", err)
+		pt.span.RecordError(err)
+		return false
+	}
+
+	pt.peerPacketStreamLock.Lock()
+	defer pt.peerPacketStreamLock.Unlock()
+	pt.peerPacketStream = stream
+	pt.migrating = false
+	if pr := pt.lastPieceResult; pr != nil {
+		pr.FinishedCount = pt.readyPieces.Settled()
+		if err = stream.Send(pr); err != nil {
+			pt.Errorf("replay piece %d result to new scheduler error: %s", pr.PieceNum, err)
+			pt.span.RecordError(err)
+			return false
+		}
+	}
+	if pr := pt.endPieceResult; pr != nil {
+		pt.endPieceResult = nil
+		if err = stream.Send(pr); err != nil {
+			pt.Errorf("send end piece result to new scheduler error: %s", err)
+			pt.span.RecordError(err)
+			return false
+		}
+	}
+	pt.Infof("migrate scheduler success, finished count %d replayed", pt.readyPieces.Settled())
+	pt.span.AddEvent("migrate scheduler",
+		trace.WithAttributes(config.AttributeMigrateScheduler.Int(pt.schedulerMigrateTimes)))
+	return true
+}
+
 func (pt *peerTask) isExitPeerPacketCode(pp *scheduler.PeerPacket) bool {
 	switch pp.Code {
 	case dfcodes.ResourceLacked, dfcodes.BadRequest, dfcodes.PeerTaskNotFound, dfcodes.UnknownError, dfcodes.RequestTimeOut:
@@ -283,7 +425,8 @@ func (pt *peerTask) isExitPeerPacketCode(pp *scheduler.PeerPacket) bool {
 		pt.failedReason = reasonPeerGoneFromScheduler
 		pt.failedCode = dfcodes.SchedPeerGone
 		return true
-	case dfcodes.CdnError, dfcodes.CdnTaskRegistryFail, dfcodes.CdnTaskDownloadFail:
+	case dfcodes.CdnError, dfcodes.CdnTaskRegistryFail, dfcodes.CdnTaskDownloadFail,
+		dfcodes.CdnSourceError, dfcodes.CdnSourceClientError, dfcodes.CdnSourceServerError:
 		// 6xxx
 		pt.failedCode = pp.Code
 		pt.failedReason = fmt.Sprintf("receive exit peer packet with code %d", pp.Code)
@@ -312,6 +455,7 @@ func (pt *peerTask) pullSinglePiece(pti PeerTask, cleanUnfinishedFunc func()) {
 
 	request := &DownloadPieceRequest{
 		TaskID:  pt.GetTaskID(),
+		SrcPid:  pt.peerId,
 		DstPid:  pt.singlePiece.DstPid,
 		DstAddr: pt.singlePiece.DstAddr,
 		piece:   pt.singlePiece.PieceInfo,
@@ -424,6 +568,7 @@ loop:
 
 		if !initialized {
 			pt.contentLength = piecePacket.ContentLength
+			pt.pieceSize = piecePacket.PieceSize
 			if pt.contentLength > 0 {
 				pt.span.SetAttributes(config.AttributeTaskContentLength.Int64(pt.contentLength))
 			}
@@ -455,6 +600,7 @@ loop:
 			}
 			req := &DownloadPieceRequest{
 				TaskID:  pt.GetTaskID(),
+				SrcPid:  pt.peerId,
 				DstPid:  piecePacket.DstPid,
 				DstAddr: piecePacket.DstAddr,
 				piece:   piece,
@@ -627,7 +773,7 @@ retry:
 		code = de.Code
 	}
 	pt.Errorf("get piece task from peer(%s) error: %s, code: %d", peer.PeerId, err, code)
-	perr := pt.peerPacketStream.Send(&scheduler.PieceResult{
+	perr := pt.sendPieceResult(&scheduler.PieceResult{
 		TaskId:        pt.taskId,
 		SrcPid:        pt.peerId,
 		DstPid:        peer.PeerId,
@@ -669,7 +815,7 @@ func (pt *peerTask) getPieceTasks(span trace.Span, curPeerPacket *scheduler.Peer
 		// by santong: when peer return empty, retry later
 		if len(pp.PieceInfos) == 0 {
 			count++
-			er := pt.peerPacketStream.Send(&scheduler.PieceResult{
+			er := pt.sendPieceResult(&scheduler.PieceResult{
 				TaskId:        pt.taskId,
 				SrcPid:        pt.peerId,
 				DstPid:        peer.PeerId,
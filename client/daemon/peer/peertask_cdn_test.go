@@ -0,0 +1,231 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	testifyassert "github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	cdnconfig "d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/cdnsystem/server/service"
+	"d7y.io/dragonfly/v2/cdnsystem/types"
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	"d7y.io/dragonfly/v2/client/daemon/test"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/base/common"
+	"d7y.io/dragonfly/v2/pkg/rpc/cdnsystem"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	"d7y.io/dragonfly/v2/pkg/structure/syncmap"
+	"d7y.io/dragonfly/v2/pkg/util/compressutils"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"d7y.io/dragonfly/v2/pkg/util/rangeutils"
+)
+
+// testSeedTaskMgr serves a finished cdn task with the given pieces
+type testSeedTaskMgr struct {
+	task   *types.SeedTask
+	pieces []*types.SeedPiece
+}
+
+func (tm *testSeedTaskMgr) Register(ctx context.Context, req *types.TaskRegisterRequest) (<-chan *types.SeedPiece, error) {
+	return nil, nil
+}
+
+func (tm *testSeedTaskMgr) Get(ctx context.Context, taskID string) (*types.SeedTask, error) {
+	return tm.task, nil
+}
+
+func (tm *testSeedTaskMgr) Exist(ctx context.Context, taskID string) (*types.SeedTask, bool) {
+	return tm.task, true
+}
+
+func (tm *testSeedTaskMgr) GetAccessTime(ctx context.Context) (*syncmap.SyncMap, error) {
+	return syncmap.NewSyncMap(), nil
+}
+
+func (tm *testSeedTaskMgr) Delete(ctx context.Context, taskID string) error {
+	return nil
+}
+
+func (tm *testSeedTaskMgr) GetPieces(ctx context.Context, taskID string) ([]*types.SeedPiece, error) {
+	return tm.pieces, nil
+}
+
+// testSeeder exposes the cdn seed server on grpc
+type testSeeder struct {
+	cdnsystem.UnimplementedSeederServer
+	css *service.CdnSeedServer
+}
+
+func (s *testSeeder) GetPieceTasks(ctx context.Context, req *base.PieceTaskRequest) (*base.PiecePacket, error) {
+	return s.css.GetPieceTasks(ctx, req)
+}
+
+func TestPeerTaskManager_CompressedPiecesFromCdn(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	var (
+		pieceParallelCount = int32(4)
+		pieceSize          = 1024
+
+		peerID = "peer-0"
+		taskID = "task-0"
+
+		output = "../test/testdata/test.compressed.output"
+	)
+	defer os.Remove(output)
+
+	// 1. the cdn stores every piece compressed by zstd
+	var (
+		compressed []byte
+		pieces     []*types.SeedPiece
+	)
+	for start := 0; start < len(testBytes); start += pieceSize {
+		end := start + pieceSize
+		if end > len(testBytes) {
+			end = len(testBytes)
+		}
+		data, err := compressutils.Compress(base.PieceStyle_ZSTD, testBytes[start:end])
+		assert.Nil(err, "compress piece")
+		pieces = append(pieces, &types.SeedPiece{
+			PieceStyle: types.ZstdCompressed,
+			PieceNum:   int32(len(pieces)),
+			PieceMd5:   digestutils.Md5Bytes(data),
+			PieceRange: &rangeutils.Range{
+				StartIndex: uint64(len(compressed)),
+				EndIndex:   uint64(len(compressed) + len(data) - 1),
+			},
+			OriginRange: &rangeutils.Range{
+				StartIndex: uint64(start),
+				EndIndex:   uint64(end - 1),
+			},
+			PieceLen: int32(len(data)),
+		})
+		compressed = append(compressed, data...)
+	}
+
+	// 2. setup the download and rpc servers of cdn
+	upload := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(compressed))
+	}))
+	defer upload.Close()
+	host, port, _ := net.SplitHostPort(upload.Listener.Addr().String())
+	cfg := cdnconfig.New()
+	cfg.AdvertiseIP = host
+	cfg.DownloadPort, _ = strconv.Atoi(port)
+	css, _ := service.NewCdnSeedServer(cfg, &testSeedTaskMgr{
+		task: &types.SeedTask{
+			TaskId:           taskID,
+			SourceFileLength: int64(len(testBytes)),
+			PieceSize:        int32(pieceSize),
+			CdnStatus:        types.TaskInfoCdnStatusSuccess,
+			PieceTotal:       int32(len(pieces)),
+		},
+		pieces: pieces,
+	}, nil)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(err, "listen cdn rpc")
+	server := grpc.NewServer()
+	cdnsystem.RegisterSeederServer(server, &testSeeder{css: css})
+	go server.Serve(ln)
+	defer server.Stop()
+
+	// 3. the scheduler schedules the cdn as the main peer
+	sched := setupMockScheduler(ctrl, taskID, &scheduler.PeerPacket_DestPeer{
+		Ip:      "127.0.0.1",
+		RpcPort: int32(ln.Addr().(*net.TCPAddr).Port),
+		PeerId:  "cdn-" + taskID + common.CdnSuffix,
+	}, pieceParallelCount)
+	storageManager, _ := storage.NewStorageManager(
+		config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: test.DataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: -1 * time.Second,
+			},
+		}, func(request storage.CommonTaskRequest) {})
+	defer storageManager.CleanUp()
+
+	downloader, _ := NewPieceDownloader()
+	ptm := &peerTaskManager{
+		host: &scheduler.PeerHost{
+			Ip: "127.0.0.1",
+		},
+		runningPeerTasks: sync.Map{},
+		pieceManager: &pieceManager{
+			storageManager:  storageManager,
+			pieceDownloader: downloader,
+			calculateDigest: true,
+		},
+		storageManager:  storageManager,
+		schedulerClient: sched,
+		schedulerOption: config.SchedulerOption{
+			ScheduleTimeout: clientutil.Duration{Duration: 10 * time.Minute},
+		},
+	}
+	progress, _, err := ptm.StartFilePeerTask(context.Background(), &FilePeerTaskRequest{
+		PeerTaskRequest: scheduler.PeerTaskRequest{
+			Url:      "http://localhost/test/compressed",
+			BizId:    "d7y-test",
+			PeerId:   peerID,
+			PeerHost: &scheduler.PeerHost{},
+		},
+		Output: output,
+	})
+	assert.Nil(err, "start file peer task")
+
+	// without the piece size, compressed pieces are never accepted and the peer task does not finish
+	timeout := time.After(30 * time.Second)
+	for done := false; !done; {
+		select {
+		case p := <-progress:
+			assert.True(p.State.Success)
+			if p.PeerTaskDone {
+				p.DoneCallback()
+				done = true
+			}
+		case <-timeout:
+			assert.FailNow("compressed pieces from cdn are not downloaded")
+		}
+	}
+
+	// the pieces are stored decompressed
+	outputBytes, err := ioutil.ReadFile(output)
+	assert.Nil(err, "load output file")
+	assert.Equal(testBytes, outputBytes, "output and desired output must match")
+}
@@ -19,7 +19,6 @@ package peer
 import (
 	"context"
 	"sync"
-	"sync/atomic"
 
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/otel/semconv"
@@ -66,8 +65,14 @@ type FilePeerTaskProgress struct {
 	PeerID          string
 	ContentLength   int64
 	CompletedLength int64
-	PeerTaskDone    bool
-	DoneCallback    func()
+	// TotalPieces is -1 or 0 when it is unknown
+	TotalPieces int32
+	// PeerLength, CdnLength and SourceLength split CompletedLength by where the pieces come from
+	PeerLength   int64
+	CdnLength    int64
+	SourceLength int64
+	PeerTaskDone bool
+	DoneCallback func()
 }
 
 func newFilePeerTask(ctx context.Context,
@@ -161,9 +166,10 @@ func newFilePeerTask(ctx context.Context,
 			host:             host,
 			backSource:       backSource,
 			request:          request,
+			schedulerClient:  schedulerClient,
 			peerPacketStream: peerPacketStream,
 			pieceManager:     pieceManager,
-			peerPacketReady:  make(chan bool),
+			peerPacketReady:  make(chan bool, 1),
 			peerId:           request.PeerId,
 			taskId:           result.TaskId,
 			singlePiece:      singlePiece,
@@ -218,7 +224,7 @@ func (pt *filePeerTask) ReportPieceResult(piece *base.PieceInfo, pieceResult *sc
 	// retry failed piece
 	if !pieceResult.Success {
 		pieceResult.FinishedCount = pt.readyPieces.Settled()
-		_ = pt.peerPacketStream.Send(pieceResult)
+		_ = pt.sendPieceResult(pieceResult)
 		pt.failedPieceCh <- pieceResult.PieceNum
 		pt.Errorf("%d download failed, retry later", piece.PieceNum)
 		return nil
@@ -232,11 +238,11 @@ func (pt *filePeerTask) ReportPieceResult(piece *base.PieceInfo, pieceResult *sc
 	}
 	// mark piece processed
 	pt.readyPieces.Set(pieceResult.PieceNum)
-	atomic.AddInt64(&pt.completedLength, int64(piece.RangeSize))
+	pt.addCompletedLength(pieceResult.DstPid, int64(piece.RangeSize))
 	pt.lock.Unlock()
 
 	pieceResult.FinishedCount = pt.readyPieces.Settled()
-	_ = pt.peerPacketStream.Send(pieceResult)
+	_ = pt.sendPieceResult(pieceResult)
 	// send progress first to avoid close channel panic
 	p := &FilePeerTaskProgress{
 		State: &ProgressState{
@@ -248,6 +254,10 @@ func (pt *filePeerTask) ReportPieceResult(piece *base.PieceInfo, pieceResult *sc
 		PeerID:          pt.peerId,
 		ContentLength:   pt.contentLength,
 		CompletedLength: pt.completedLength,
+		TotalPieces:     pt.totalPiece,
+		PeerLength:      pt.peerLength,
+		CdnLength:       pt.cdnLength,
+		SourceLength:    pt.sourceLength,
 		PeerTaskDone:    false,
 	}
 
@@ -273,7 +283,7 @@ func (pt *filePeerTask) finish() error {
 	pt.once.Do(func() {
 		defer pt.recoverFromPanic()
 		// send EOF piece result to scheduler
-		_ = pt.peerPacketStream.Send(
+		_ = pt.sendPieceResult(
 			scheduler.NewEndPieceResult(pt.taskId, pt.peerId, pt.readyPieces.Settled()))
 		pt.Debugf("finish end piece result sent")
 
@@ -301,6 +311,10 @@ func (pt *filePeerTask) finish() error {
 			PeerID:          pt.peerId,
 			ContentLength:   pt.contentLength,
 			CompletedLength: pt.completedLength,
+			TotalPieces:     pt.totalPiece,
+			PeerLength:      pt.peerLength,
+			CdnLength:       pt.cdnLength,
+			SourceLength:    pt.sourceLength,
 			PeerTaskDone:    true,
 			DoneCallback: func() {
 				pt.peerTaskDone = true
@@ -342,7 +356,7 @@ func (pt *filePeerTask) cleanUnfinished() {
 	pt.once.Do(func() {
 		defer pt.recoverFromPanic()
 		// send EOF piece result to scheduler
-		_ = pt.peerPacketStream.Send(
+		_ = pt.sendPieceResult(
 			scheduler.NewEndPieceResult(pt.taskId, pt.peerId, pt.readyPieces.Settled()))
 		pt.Debugf("clean up end piece result sent")
 
@@ -356,6 +370,10 @@ func (pt *filePeerTask) cleanUnfinished() {
 			PeerID:          pt.peerId,
 			ContentLength:   pt.contentLength,
 			CompletedLength: pt.completedLength,
+			TotalPieces:     pt.totalPiece,
+			PeerLength:      pt.peerLength,
+			CdnLength:       pt.cdnLength,
+			SourceLength:    pt.sourceLength,
 			PeerTaskDone:    true,
 			DoneCallback: func() {
 				pt.peerTaskDone = true
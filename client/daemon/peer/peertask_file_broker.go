@@ -0,0 +1,276 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+)
+
+// filePeerTaskBroker fans out the progress of a running file peer task to all requests of the same task,
+// the requests attached later store the task data to their own output when the peer task is done.
+// The peer task runs with the context of the broker, which is canceled when all requests are gone.
+type filePeerTaskBroker struct {
+	*logger.SugaredLoggerOnWith
+	ptm    *peerTaskManager
+	ctx    context.Context
+	cancel context.CancelFunc
+	taskID string
+
+	lock        sync.Mutex
+	done        bool
+	subscribers []*filePeerTaskSubscriber
+	// finished is closed when the broker is done
+	finished chan struct{}
+}
+
+type filePeerTaskSubscriber struct {
+	ctx context.Context
+	req *FilePeerTaskRequest
+	// owner is the request which starts the peer task, its output is stored by the peer task callback
+	owner    bool
+	progress chan *FilePeerTaskProgress
+}
+
+// attachOrNewFilePeerTaskBroker attaches req to the running peer task of the same task when attach is true,
+// otherwise it creates a broker for the peer task started by req and registers it for taskID.
+// It returns the broker, the progress channel of req and whether req is attached.
+func (ptm *peerTaskManager) attachOrNewFilePeerTaskBroker(ctx context.Context, taskID string, req *FilePeerTaskRequest,
+	attach bool) (*filePeerTaskBroker, chan *FilePeerTaskProgress, bool) {
+	ptm.brokerLock.Lock()
+	defer ptm.brokerLock.Unlock()
+	if ptm.fileTaskBrokers == nil {
+		ptm.fileTaskBrokers = map[string]*filePeerTaskBroker{}
+	}
+	if b, ok := ptm.fileTaskBrokers[taskID]; ok && attach {
+		if progress, ok := b.subscribe(ctx, req, false); ok {
+			b.Infof("peer %s attached to the running peer task", req.PeerId)
+			return b, progress, true
+		}
+	}
+
+	// the peer task is detached from the request, it keeps running for the attached requests after req is gone
+	taskCtx, cancel := context.WithCancel(trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx)))
+	b := &filePeerTaskBroker{
+		ptm:      ptm,
+		ctx:      taskCtx,
+		cancel:   cancel,
+		taskID:   taskID,
+		finished: make(chan struct{}),
+
+		SugaredLoggerOnWith: logger.With("peer", req.PeerId, "task", taskID, "component", "filePeerTaskBroker"),
+	}
+	progress, _ := b.subscribe(ctx, req, true)
+	// a cdn only peer task may run concurrently, keep the first one for attaching
+	if _, ok := ptm.fileTaskBrokers[taskID]; !ok {
+		ptm.fileTaskBrokers[taskID] = b
+	}
+	return b, progress, false
+}
+
+func (b *filePeerTaskBroker) subscribe(ctx context.Context, req *FilePeerTaskRequest, owner bool) (chan *FilePeerTaskProgress, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.done {
+		return nil, false
+	}
+	sub := &filePeerTaskSubscriber{
+		ctx:      ctx,
+		req:      req,
+		owner:    owner,
+		progress: make(chan *FilePeerTaskProgress, 1),
+	}
+	b.subscribers = append(b.subscribers, sub)
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.unsubscribe(sub)
+		case <-b.finished:
+		}
+	}()
+	return sub.progress, true
+}
+
+// unsubscribe removes the subscriber whose request is gone, the peer task is canceled when no subscriber is left
+func (b *filePeerTaskBroker) unsubscribe(sub *filePeerTaskSubscriber) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.done {
+		return
+	}
+	// the subscribers may be being iterated without the lock, so they are copied instead of modified in place
+	var subscribers []*filePeerTaskSubscriber
+	for _, s := range b.subscribers {
+		if s != sub {
+			subscribers = append(subscribers, s)
+		}
+	}
+	b.subscribers = subscribers
+	if len(b.subscribers) == 0 {
+		b.Infof("all requests are gone, cancel the peer task")
+		b.cancel()
+	}
+}
+
+// start broadcasts the progress of the started peer task
+func (b *filePeerTaskBroker) start(src chan *FilePeerTaskProgress) {
+	go b.run(src)
+}
+
+// fail sends the done progress to the attached requests when the peer task fails to start
+func (b *filePeerTaskBroker) fail(err error) {
+	b.finish(&FilePeerTaskProgress{
+		State: &ProgressState{
+			Success: false,
+			Code:    dfcodes.ClientError,
+			Msg:     "start peer task error: " + err.Error(),
+		},
+		TaskId:       b.taskID,
+		PeerTaskDone: true,
+	})
+}
+
+// finishTiny writes the tiny content to the outputs of the attached requests
+func (b *filePeerTaskBroker) finishTiny(tiny *TinyData) {
+	b.finishWith(&FilePeerTaskProgress{
+		State: &ProgressState{
+			Success: true,
+			Code:    dfcodes.Success,
+			Msg:     "Success",
+		},
+		TaskId:          tiny.TaskId,
+		PeerID:          tiny.PeerID,
+		ContentLength:   int64(len(tiny.Content)),
+		CompletedLength: int64(len(tiny.Content)),
+		TotalPieces:     1,
+		PeerTaskDone:    true,
+	}, func(sub *filePeerTaskSubscriber) error {
+		return writeTinyData(sub.req.Output, tiny.Content)
+	})
+}
+
+func (b *filePeerTaskBroker) run(src chan *FilePeerTaskProgress) {
+	for {
+		select {
+		case p := <-src:
+			if !p.PeerTaskDone {
+				b.broadcast(p)
+				continue
+			}
+			b.finish(p)
+			return
+		case <-b.ctx.Done():
+			// the peer task stops with the context of its owner, the done progress may not be sent
+			select {
+			case p := <-src:
+				if p.PeerTaskDone {
+					b.finish(p)
+					return
+				}
+			default:
+			}
+			b.finish(&FilePeerTaskProgress{
+				State: &ProgressState{
+					Success: false,
+					Code:    dfcodes.ClientContextCanceled,
+					Msg:     "peer task canceled: " + b.ctx.Err().Error(),
+				},
+				TaskId:       b.taskID,
+				PeerTaskDone: true,
+			})
+			return
+		}
+	}
+}
+
+// broadcast sends the downloading progress to subscribers which are ready to receive it,
+// the progress is accumulated, so it is fine to skip some of them.
+func (b *filePeerTaskBroker) broadcast(p *FilePeerTaskProgress) {
+	b.lock.Lock()
+	subscribers := b.subscribers
+	b.lock.Unlock()
+	for _, sub := range subscribers {
+		select {
+		case sub.progress <- p:
+		default:
+		}
+	}
+}
+
+// finish stores the task data for the attached requests and sends the done progress to all subscribers
+func (b *filePeerTaskBroker) finish(p *FilePeerTaskProgress) {
+	b.finishWith(p, func(sub *filePeerTaskSubscriber) error {
+		return b.ptm.storageManager.Store(sub.ctx,
+			&storage.StoreRequest{
+				CommonTaskRequest: storage.CommonTaskRequest{
+					PeerID:      p.PeerID,
+					TaskID:      p.TaskId,
+					Destination: sub.req.Output,
+				},
+			})
+	})
+}
+
+// finishWith stores the task data by store for the attached requests when the peer task succeeds,
+// and sends the done progress to all subscribers
+func (b *filePeerTaskBroker) finishWith(p *FilePeerTaskProgress, store func(sub *filePeerTaskSubscriber) error) {
+	b.lock.Lock()
+	if b.done {
+		b.lock.Unlock()
+		return
+	}
+	b.done = true
+	close(b.finished)
+	subscribers := b.subscribers
+	b.lock.Unlock()
+
+	b.ptm.brokerLock.Lock()
+	if b.ptm.fileTaskBrokers[b.taskID] == b {
+		delete(b.ptm.fileTaskBrokers, b.taskID)
+	}
+	b.ptm.brokerLock.Unlock()
+
+	for _, sub := range subscribers {
+		pg := *p
+		pg.DoneCallback = func() {}
+		if pg.State.Success && !sub.owner {
+			if err := store(sub); err != nil {
+				b.Errorf("store task data for peer %s error: %s", sub.req.PeerId, err)
+				pg.State = &ProgressState{
+					Success: false,
+					Code:    dfcodes.ClientError,
+					Msg:     err.Error(),
+				}
+			}
+		}
+		select {
+		case sub.progress <- &pg:
+		case <-sub.ctx.Done():
+			b.Warnf("send done progress to peer %s failed, context done", sub.req.PeerId)
+		}
+	}
+	b.Infof("done progress sent to %d subscriber(s)", len(subscribers))
+	if p.DoneCallback != nil {
+		p.DoneCallback()
+	}
+}
@@ -46,8 +46,10 @@ func (p *filePeerTaskCallback) Init(pt PeerTask) error {
 				TaskID:      pt.GetTaskID(),
 				Destination: p.req.Output,
 			},
+			URL:           p.req.Url,
 			ContentLength: pt.GetContentLength(),
 			TotalPieces:   pt.GetTotalPieces(),
+			PieceSize:     pt.GetPieceSize(),
 		})
 	if err != nil {
 		pt.Log().Errorf("register task to storage manager failed: %s", err)
@@ -65,6 +67,7 @@ func (p *filePeerTaskCallback) Update(pt PeerTask) error {
 			},
 			ContentLength: pt.GetContentLength(),
 			TotalPieces:   pt.GetTotalPieces(),
+			PieceSize:     pt.GetPieceSize(),
 		})
 	if err != nil {
 		pt.Log().Errorf("update task to storage manager failed: %s", err)
@@ -85,6 +88,7 @@ func (p *filePeerTaskCallback) Done(pt PeerTask) error {
 			},
 			MetadataOnly: false,
 			TotalPieces:  pt.GetTotalPieces(),
+			Digest:       p.req.UrlMata.GetDigest(),
 		})
 	if e != nil {
 		return e
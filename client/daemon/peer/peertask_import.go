@@ -0,0 +1,217 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/piecesize"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+)
+
+// SeedGCName is the name of the gc task which registers the imported tasks to scheduler as seeds again
+const SeedGCName = "SeedKeeper"
+
+// ErrTaskExists is returned when importing a file whose task is already completed in local storage
+var ErrTaskExists = errors.New("task already exists")
+
+// ImportFileRequest imports a local file as a completed peer task, the task id is generated
+// with the url, filter, url meta and biz id of PeerTaskRequest like downloading
+type ImportFileRequest struct {
+	scheduler.PeerTaskRequest
+	// Path is the absolute path of the local file
+	Path string
+}
+
+// ImportFile writes the local file into storage as pieces, then registers the peer as a seed to scheduler,
+// so other peers can download the file with the url of the request without origin.
+func (ptm *peerTaskManager) ImportFile(ctx context.Context, req *ImportFileRequest) (*storage.ReusePeerTask, error) {
+	taskID := idgen.GenerateTaskID(req.Url, req.Filter, req.UrlMata, req.BizId)
+	log := logger.With("peer", req.PeerId, "task", taskID, "component", "importFile")
+	if reuse := ptm.storageManager.FindCompletedTask(taskID); reuse != nil {
+		return nil, errors.Wrapf(ErrTaskExists, "completed by peer %s", reuse.PeerID)
+	}
+
+	file, err := os.Open(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !stat.Mode().IsRegular() || stat.Size() == 0 {
+		return nil, fmt.Errorf("%s is not a regular file or empty", req.Path)
+	}
+
+	var (
+		contentLength = stat.Size()
+		pieceSize     = piecesize.Compute(contentLength)
+		totalPieces   = int32((contentLength + int64(pieceSize) - 1) / int64(pieceSize))
+		meta          = storage.PeerTaskMetaData{
+			PeerID: req.PeerId,
+			TaskID: taskID,
+		}
+	)
+	err = ptm.storageManager.RegisterTask(ctx, storage.RegisterTaskRequest{
+		CommonTaskRequest: storage.CommonTaskRequest{
+			PeerID: req.PeerId,
+			TaskID: taskID,
+		},
+		URL:           req.Url,
+		ContentLength: contentLength,
+		TotalPieces:   totalPieces,
+		PieceSize:     pieceSize,
+		Seed: &storage.SeedRequest{
+			URL:     req.Url,
+			Filter:  req.Filter,
+			BizID:   req.BizId,
+			URLMeta: req.UrlMata,
+		},
+	})
+	if err != nil {
+		log.Errorf("register task to storage error: %s", err)
+		return nil, err
+	}
+	if err = ptm.importPieces(ctx, meta, file, contentLength, pieceSize, totalPieces); err == nil {
+		err = ptm.storageManager.Store(ctx, &storage.StoreRequest{
+			CommonTaskRequest: storage.CommonTaskRequest{
+				PeerID: req.PeerId,
+				TaskID: taskID,
+			},
+			MetadataOnly: true,
+			TotalPieces:  totalPieces,
+			Digest:       req.UrlMata.GetDigest(),
+		})
+	}
+	if err != nil {
+		log.Errorf("import file %s error: %s", req.Path, err)
+		if er := ptm.storageManager.UnregisterTask(ctx, storage.CommonTaskRequest{
+			PeerID: req.PeerId,
+			TaskID: taskID,
+		}); er != nil {
+			log.Warnf("clean imported data error: %s", er)
+		}
+		return nil, err
+	}
+	log.Infof("imported file %s, content length: %d, total pieces: %d", req.Path, contentLength, totalPieces)
+
+	reuse := &storage.ReusePeerTask{
+		PeerTaskMetaData: meta,
+		ContentLength:    contentLength,
+		TotalPieces:      totalPieces,
+	}
+	// tell scheduler the peer is a seed of the task, the imported data is kept even if it fails,
+	// so the task is still available in local and registered again by the seed keeper
+	req.SeedInfo = &scheduler.SeedInfo{
+		ContentLength: contentLength,
+		PieceSize:     pieceSize,
+		TotalPiece:    totalPieces,
+	}
+	if _, err = ptm.schedulerClient.RegisterPeerTask(ctx, &req.PeerTaskRequest); err != nil {
+		log.Errorf("register seed peer to scheduler error: %s", err)
+		return reuse, errors.Wrap(err, "register seed peer to scheduler")
+	}
+	return reuse, nil
+}
+
+// RegisterSeeds registers the completed imported tasks to scheduler as seeds again, the seed peers are lost
+// when the daemon restarts or scheduler reclaims them, so it runs when the daemon starts and in every gc
+func (ptm *peerTaskManager) RegisterSeeds(ctx context.Context) error {
+	var (
+		seeds  = ptm.storageManager.ListSeedTasks()
+		failed int
+		err    error
+	)
+	for _, seed := range seeds {
+		req := &scheduler.PeerTaskRequest{
+			Url:      seed.URL,
+			Filter:   seed.Filter,
+			BizId:    seed.BizID,
+			UrlMata:  seed.URLMeta,
+			PeerId:   seed.PeerID,
+			PeerHost: ptm.host,
+			SeedInfo: &scheduler.SeedInfo{
+				ContentLength: seed.ContentLength,
+				PieceSize:     seed.PieceSize,
+				TotalPiece:    seed.TotalPieces,
+			},
+		}
+		if _, er := ptm.schedulerClient.RegisterPeerTask(ctx, req); er != nil {
+			logger.With("peer", seed.PeerID, "task", seed.TaskID, "component", "seedKeeper").
+				Errorf("register seed peer to scheduler error: %s", er)
+			failed++
+			err = er
+		}
+	}
+	if failed > 0 {
+		return errors.Wrapf(err, "register %d of %d seed peer(s)", failed, len(seeds))
+	}
+	logger.Debugf("registered %d seed peer(s) to scheduler", len(seeds))
+	return nil
+}
+
+// seedKeeper registers the imported tasks to scheduler in every gc of the daemon
+type seedKeeper struct {
+	ptm *peerTaskManager
+}
+
+func (k *seedKeeper) TryGC() (bool, error) {
+	return true, k.ptm.RegisterSeeds(context.Background())
+}
+
+// importPieces writes all pieces of the file to storage, the md5 of every piece is calculated when writing
+func (ptm *peerTaskManager) importPieces(ctx context.Context, meta storage.PeerTaskMetaData,
+	file io.Reader, contentLength int64, pieceSize int32, totalPieces int32) error {
+	for pieceNum := int32(0); pieceNum < totalPieces; pieceNum++ {
+		offset := int64(pieceNum) * int64(pieceSize)
+		size := int64(pieceSize)
+		if offset+size > contentLength {
+			size = contentLength - offset
+		}
+		n, err := ptm.storageManager.WritePiece(ctx, &storage.WritePieceRequest{
+			PeerTaskMetaData: meta,
+			PieceMetaData: storage.PieceMetaData{
+				Num:    pieceNum,
+				Offset: uint64(offset),
+				Range: clientutil.Range{
+					Start:  offset,
+					Length: size,
+				},
+			},
+			Reader: digestutils.NewDigestReader(io.LimitReader(file, size)),
+		})
+		if err != nil {
+			return err
+		}
+		if n != size {
+			return storage.ErrShortRead
+		}
+	}
+	return nil
+}
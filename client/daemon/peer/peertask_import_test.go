@@ -0,0 +1,144 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	testifyassert "github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	"d7y.io/dragonfly/v2/client/daemon/test"
+	mock_scheduler "d7y.io/dragonfly/v2/client/daemon/test/mock/scheduler"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+func TestPeerTaskManager_ImportFile(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	var registered []*scheduler.PeerTaskRequest
+	sched := mock_scheduler.NewMockSchedulerClient(ctrl)
+	sched.EXPECT().RegisterPeerTask(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, ptr *scheduler.PeerTaskRequest, opts ...grpc.CallOption) (*scheduler.RegisterResult, error) {
+			registered = append(registered, ptr)
+			return &scheduler.RegisterResult{}, nil
+		}).Times(2)
+	storageManager, err := storage.NewStorageManager(
+		config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: test.DataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Hour,
+			},
+		}, func(request storage.CommonTaskRequest) {})
+	assert.Nil(err)
+	defer storageManager.CleanUp()
+	host := &scheduler.PeerHost{
+		Uuid: "host-0",
+	}
+	ptm := &peerTaskManager{
+		host:            host,
+		storageManager:  storageManager,
+		schedulerClient: sched,
+	}
+
+	req := &ImportFileRequest{
+		PeerTaskRequest: scheduler.PeerTaskRequest{
+			Url:    "d7y://cache/test/data",
+			Filter: "token",
+			BizId:  "biz",
+			UrlMata: &base.UrlMeta{
+				Header: map[string]string{"X-Dragonfly-Test": "import"},
+			},
+			PeerId:   "peer-0",
+			PeerHost: host,
+		},
+		Path: test.File,
+	}
+	reuse, err := ptm.ImportFile(context.Background(), req)
+	assert.Nil(err)
+	assert.Equal(int64(len(testBytes)), reuse.ContentLength)
+	assert.Len(registered, 1)
+	seed := registered[0].SeedInfo
+	assert.NotNil(seed)
+	assert.Equal(reuse.TotalPieces, seed.TotalPiece)
+	assert.Equal(reuse.ContentLength, seed.ContentLength)
+
+	// the imported task is registered as a seed again with the same task
+	assert.Nil(ptm.RegisterSeeds(context.Background()))
+	assert.Len(registered, 2)
+	again := registered[1]
+	assert.Equal(req.Url, again.Url)
+	assert.Equal(req.Filter, again.Filter)
+	assert.Equal(req.BizId, again.BizId)
+	assert.Equal(req.UrlMata.Header, again.UrlMata.GetHeader())
+	assert.Equal("peer-0", again.PeerId)
+	assert.Equal(host, again.PeerHost)
+	assert.Equal(seed.ContentLength, again.SeedInfo.ContentLength)
+	assert.Equal(seed.PieceSize, again.SeedInfo.PieceSize)
+	assert.Equal(seed.TotalPiece, again.SeedInfo.TotalPiece)
+
+	// the imported file is a completed task
+	completed := storageManager.FindCompletedTask(reuse.TaskID)
+	assert.NotNil(completed)
+	assert.Equal("peer-0", completed.PeerID)
+	reader, err := storageManager.ReadAllPieces(context.Background(), &completed.PeerTaskMetaData)
+	assert.Nil(err)
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	assert.Nil(err)
+	assert.Equal(testBytes, data)
+	pieces, err := storageManager.GetPieces(context.Background(), &base.PieceTaskRequest{
+		TaskId: reuse.TaskID,
+		DstPid: "peer-0",
+		Limit:  reuse.TotalPieces,
+	})
+	assert.Nil(err)
+	assert.Len(pieces.PieceInfos, int(reuse.TotalPieces))
+	for _, piece := range pieces.PieceInfos {
+		assert.NotEmpty(piece.PieceMd5)
+	}
+
+	// import again
+	req.PeerId = "peer-1"
+	_, err = ptm.ImportFile(context.Background(), req)
+	assert.True(errors.Is(err, ErrTaskExists))
+
+	// delete the imported task
+	err = storageManager.UnregisterTask(context.Background(), storage.CommonTaskRequest{
+		PeerID: "peer-0",
+		TaskID: reuse.TaskID,
+	})
+	assert.Nil(err)
+	assert.Nil(storageManager.FindCompletedTask(reuse.TaskID))
+	assert.Nil(ptm.RegisterSeeds(context.Background()))
+	assert.Len(registered, 2, "deleted task should not be registered")
+}
@@ -31,8 +31,10 @@ import (
 	"golang.org/x/time/rate"
 
 	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/gc"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/idgen"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
 	schedulerclient "d7y.io/dragonfly/v2/pkg/rpc/scheduler/client"
@@ -50,8 +52,20 @@ type PeerTaskManager interface {
 	StartStreamPeerTask(ctx context.Context, req *scheduler.PeerTaskRequest) (
 		reader io.Reader, attribute map[string]string, err error)
 
+	// ImportFile imports a local file as a completed peer task and registers the peer as a seed to scheduler
+	ImportFile(ctx context.Context, req *ImportFileRequest) (*storage.ReusePeerTask, error)
+
+	// RegisterSeeds registers the imported tasks in storage to scheduler as seeds again
+	RegisterSeeds(ctx context.Context) error
+
 	IsPeerTaskRunning(pid string) bool
 
+	// RunningPeerTasks returns all running peer tasks
+	RunningPeerTasks() []PeerTask
+
+	// CancelPeerTask cancels the running peer task of the peer, false is returned when it is not running
+	CancelPeerTask(pid string) bool
+
 	// Stop stops the PeerTaskManager
 	Stop(ctx context.Context) error
 }
@@ -64,12 +78,19 @@ type PeerTask interface {
 	GetPeerID() string
 	GetTaskID() string
 	GetTotalPieces() int32
+	// GetPieceSize returns the piece size decided by the cdn or the source downloader, 0 means unknown
+	GetPieceSize() int32
 	GetContentLength() int64
 	// SetContentLength will called after download completed, when download from source without content length
 	SetContentLength(int64) error
 	SetCallback(PeerTaskCallback)
 	AddTraffic(int64)
 	GetTraffic() int64
+	GetURL() string
+	// GetCompletedLength returns the length of downloaded pieces
+	GetCompletedLength() int64
+	// Cancel stops the running peer task with the reason
+	Cancel(reason string)
 }
 
 // PeerTaskCallback inserts some operations for peer task download lifecycle
@@ -102,6 +123,13 @@ type peerTaskManager struct {
 
 	runningPeerTasks sync.Map
 
+	// brokerLock guards fileTaskBrokers and streamTaskBrokers
+	brokerLock sync.Mutex
+	// fileTaskBrokers holds the running file peer tasks by local task id for merging requests of the same task
+	fileTaskBrokers map[string]*filePeerTaskBroker
+	// streamTaskBrokers holds the running stream peer tasks by local task id for merging requests of the same task
+	streamTaskBrokers map[string]*streamPeerTaskBroker
+
 	perPeerRateLimit rate.Limit
 }
 
@@ -114,49 +142,53 @@ func NewPeerTaskManager(
 	perPeerRateLimit rate.Limit) (PeerTaskManager, error) {
 
 	ptm := &peerTaskManager{
-		host:             host,
-		runningPeerTasks: sync.Map{},
-		pieceManager:     pieceManager,
-		storageManager:   storageManager,
-		schedulerClient:  schedulerClient,
-		schedulerOption:  schedulerOption,
-		perPeerRateLimit: perPeerRateLimit,
+		host:              host,
+		runningPeerTasks:  sync.Map{},
+		fileTaskBrokers:   map[string]*filePeerTaskBroker{},
+		streamTaskBrokers: map[string]*streamPeerTaskBroker{},
+		pieceManager:      pieceManager,
+		storageManager:    storageManager,
+		schedulerClient:   schedulerClient,
+		schedulerOption:   schedulerOption,
+		perPeerRateLimit:  perPeerRateLimit,
 	}
+	gc.Register(SeedGCName, &seedKeeper{ptm: ptm})
 	return ptm, nil
 }
 
 func (ptm *peerTaskManager) StartFilePeerTask(ctx context.Context, req *FilePeerTaskRequest) (chan *FilePeerTaskProgress, *TinyData, error) {
 	// TODO ensure scheduler is ok first
 
+	taskID := idgen.GenerateTaskID(req.Url, req.Filter, req.UrlMata, req.BizId)
+	// local data may come from other peers, cdn only tasks always download from cdn
+	// reuse the completed task in local storage
+	if !req.CdnOnly {
+		if progress, ok := ptm.tryReuseFilePeerTask(ctx, taskID, req); ok {
+			return progress, nil, nil
+		}
+	}
+	// merge with the running peer task of the same task
+	broker, progress, attached := ptm.attachOrNewFilePeerTaskBroker(ctx, taskID, req, !req.CdnOnly)
+	if attached {
+		return progress, nil, nil
+	}
+
 	start := time.Now()
-	ctx, pt, tiny, err := newFilePeerTask(ctx, ptm.host, ptm.pieceManager,
+	ctx, pt, tiny, err := newFilePeerTask(broker.ctx, ptm.host, ptm.pieceManager,
 		&req.PeerTaskRequest, ptm.schedulerClient, ptm.schedulerOption, ptm.perPeerRateLimit)
 	if err != nil {
+		broker.fail(err)
 		return nil, nil, err
 	}
 	// tiny file content is returned by scheduler, just write to output
 	if tiny != nil {
 		// TODO enable trace for tiny peer task
 		//defer pt.Span().End()
-		_, err = os.Stat(req.Output)
-		if err == nil {
-			// remove exist file
-			logger.Infof("destination file %q exists, purge it first", req.Output)
-			os.Remove(req.Output)
-		}
-		dstFile, err := os.OpenFile(req.Output, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
-		if err != nil {
-			//pt.Span().RecordError(err)
-			logger.Errorf("open tasks destination file error: %s", err)
-			return nil, nil, err
-		}
-		defer dstFile.Close()
-		n, err := dstFile.Write(tiny.Content)
-		if err != nil {
+		broker.finishTiny(tiny)
+		if err := writeTinyData(req.Output, tiny.Content); err != nil {
 			//pt.Span().RecordError(err)
 			return nil, nil, err
 		}
-		logger.Debugf("copied tasks data %d bytes to %s", n, req.Output)
 		return nil, tiny, nil
 	}
 	pt.SetCallback(&filePeerTaskCallback{
@@ -168,21 +200,62 @@ func (ptm *peerTaskManager) StartFilePeerTask(ctx context.Context, req *FilePeer
 
 	ptm.runningPeerTasks.Store(req.PeerId, pt)
 
-	// FIXME 1. merge same task id
-	// FIXME 2. when failed due to schedulerClient error, relocate schedulerClient and retry
-	progress, err := pt.Start(ctx)
-	return progress, nil, err
+	src, err := pt.Start(ctx)
+	if err != nil {
+		broker.fail(err)
+		return src, nil, err
+	}
+	broker.start(src)
+	return progress, nil, nil
+}
+
+// writeTinyData writes the tiny file content to output
+func writeTinyData(output string, content []byte) error {
+	_, err := os.Stat(output)
+	if err == nil {
+		// remove exist file
+		logger.Infof("destination file %q exists, purge it first", output)
+		os.Remove(output)
+	}
+	dstFile, err := os.OpenFile(output, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+	if err != nil {
+		logger.Errorf("open tasks destination file error: %s", err)
+		return err
+	}
+	defer dstFile.Close()
+	n, err := dstFile.Write(content)
+	if err != nil {
+		return err
+	}
+	logger.Debugf("copied tasks data %d bytes to %s", n, output)
+	return nil
 }
 
 func (ptm *peerTaskManager) StartStreamPeerTask(ctx context.Context, req *scheduler.PeerTaskRequest) (reader io.Reader, attribute map[string]string, err error) {
+	taskID := idgen.GenerateTaskID(req.Url, req.Filter, req.UrlMata, req.BizId)
+	// local data may come from other peers, cdn only tasks always download from cdn
+	// reuse the completed task in local storage
+	if !req.CdnOnly {
+		if reader, attribute, ok := ptm.tryReuseStreamPeerTask(ctx, taskID, req); ok {
+			return reader, attribute, nil
+		}
+	}
+	// merge with the running peer task of the same task
+	broker, attached := ptm.attachOrNewStreamPeerTaskBroker(ctx, taskID, req, !req.CdnOnly)
+	if attached {
+		return broker.attach(ctx, req)
+	}
+
 	start := time.Now()
-	ctx, pt, tiny, err := newStreamPeerTask(ctx, ptm.host, ptm.pieceManager,
+	ctx, pt, tiny, err := newStreamPeerTask(broker.ctx, ptm.host, ptm.pieceManager,
 		req, ptm.schedulerClient, ptm.schedulerOption, ptm.perPeerRateLimit)
 	if err != nil {
+		broker.start(nil, nil, err)
 		return nil, nil, err
 	}
 	// tiny file content is returned by scheduler, just write to output
 	if tiny != nil {
+		broker.start(nil, tiny, nil)
 		return bytes.NewBuffer(tiny.Content), map[string]string{
 			headers.ContentLength: fmt.Sprintf("%d", len(tiny.Content)),
 		}, nil
@@ -196,9 +269,7 @@ func (ptm *peerTaskManager) StartStreamPeerTask(ctx context.Context, req *schedu
 	})
 
 	ptm.runningPeerTasks.Store(req.PeerId, pt)
-
-	// FIXME 1. merge same task id
-	// FIXME 2. when failed due to schedulerClient error, relocate schedulerClient and retry
+	broker.start(pt.(*streamPeerTask), nil, nil)
 
 	reader, attribute, err = pt.Start(ctx)
 	return reader, attribute, err
@@ -217,3 +288,21 @@ func (ptm *peerTaskManager) IsPeerTaskRunning(pid string) bool {
 	_, ok := ptm.runningPeerTasks.Load(pid)
 	return ok
 }
+
+func (ptm *peerTaskManager) RunningPeerTasks() []PeerTask {
+	var tasks []PeerTask
+	ptm.runningPeerTasks.Range(func(key, value interface{}) bool {
+		tasks = append(tasks, value.(PeerTask))
+		return true
+	})
+	return tasks
+}
+
+func (ptm *peerTaskManager) CancelPeerTask(pid string) bool {
+	pt, ok := ptm.runningPeerTasks.Load(pid)
+	if !ok {
+		return false
+	}
+	pt.(PeerTask).Cancel(reasonCanceledByUser)
+	return true
+}
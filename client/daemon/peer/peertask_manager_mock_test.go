@@ -165,6 +165,18 @@ func (mr *MockPeerTaskMockRecorder) GetTotalPieces() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalPieces", reflect.TypeOf((*MockPeerTask)(nil).GetTotalPieces))
 }
 
+// GetPieceSize mocks base method
+func (m *MockPeerTask) GetPieceSize() int32 {
+	ret := m.ctrl.Call(m, "GetPieceSize")
+	ret0, _ := ret[0].(int32)
+	return ret0
+}
+
+// GetPieceSize indicates an expected call of GetPieceSize
+func (mr *MockPeerTaskMockRecorder) GetPieceSize() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPieceSize", reflect.TypeOf((*MockPeerTask)(nil).GetPieceSize))
+}
+
 // GetContentLength mocks base method
 func (m *MockPeerTask) GetContentLength() int64 {
 	ret := m.ctrl.Call(m, "GetContentLength")
@@ -245,6 +257,40 @@ func (mr *MockPeerTaskMockRecorder) Log() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Log", reflect.TypeOf((*MockPeerTask)(nil).Log))
 }
 
+// GetURL mocks base method
+func (m *MockPeerTask) GetURL() string {
+	ret := m.ctrl.Call(m, "GetURL")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetURL indicates an expected call of GetURL
+func (mr *MockPeerTaskMockRecorder) GetURL() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURL", reflect.TypeOf((*MockPeerTask)(nil).GetURL))
+}
+
+// GetCompletedLength mocks base method
+func (m *MockPeerTask) GetCompletedLength() int64 {
+	ret := m.ctrl.Call(m, "GetCompletedLength")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// GetCompletedLength indicates an expected call of GetCompletedLength
+func (mr *MockPeerTaskMockRecorder) GetCompletedLength() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompletedLength", reflect.TypeOf((*MockPeerTask)(nil).GetCompletedLength))
+}
+
+// Cancel mocks base method
+func (m *MockPeerTask) Cancel(reason string) {
+	m.ctrl.Call(m, "Cancel", reason)
+}
+
+// Cancel indicates an expected call of Cancel
+func (mr *MockPeerTaskMockRecorder) Cancel(reason interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockPeerTask)(nil).Cancel), reason)
+}
+
 // MockPeerTaskCallback is a mock of PeerTaskCallback interface
 type MockPeerTaskCallback struct {
 	ctrl     *gomock.Controller
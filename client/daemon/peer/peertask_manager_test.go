@@ -28,6 +28,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-http-utils/headers"
 	"github.com/golang/mock/gomock"
 	"github.com/phayes/freeport"
 	testifyassert "github.com/stretchr/testify/assert"
@@ -96,6 +97,25 @@ func setupPeerTaskManagerComponents(
 	time.Sleep(100 * time.Millisecond)
 
 	// 2. setup a scheduler
+	sched := setupMockScheduler(ctrl, taskID, &scheduler.PeerPacket_DestPeer{
+		Ip:      "127.0.0.1",
+		RpcPort: port,
+		PeerId:  "peer-x",
+	}, pieceParallelCount)
+	storageManager, _ := storage.NewStorageManager(
+		config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: test.DataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: -1 * time.Second,
+			},
+		}, func(request storage.CommonTaskRequest) {})
+	return sched, storageManager
+}
+
+// setupMockScheduler returns a scheduler client which schedules the main peer to the peer task
+func setupMockScheduler(ctrl *gomock.Controller, taskID string, mainPeer *scheduler.PeerPacket_DestPeer,
+	pieceParallelCount int32) schedulerclient.SchedulerClient {
 	pps := mock_scheduler.NewMockPeerPacketStream(ctrl)
 	pps.EXPECT().Send(gomock.Any()).AnyTimes().DoAndReturn(
 		func(pr *scheduler.PieceResult) error {
@@ -111,12 +131,8 @@ func setupPeerTaskManagerComponents(
 					TaskId:        taskID,
 					SrcPid:        "127.0.0.1",
 					ParallelCount: pieceParallelCount,
-					MainPeer: &scheduler.PeerPacket_DestPeer{
-						Ip:      "127.0.0.1",
-						RpcPort: port,
-						PeerId:  "peer-x",
-					},
-					StealPeers: nil,
+					MainPeer:      mainPeer,
+					StealPeers:    nil,
 				}, nil
 			}
 			time.Sleep(time.Hour)
@@ -139,15 +155,7 @@ func setupPeerTaskManagerComponents(
 		func(ctx context.Context, pr *scheduler.PeerResult, opts ...grpc.CallOption) error {
 			return nil
 		})
-	storageManager, _ := storage.NewStorageManager(
-		config.SimpleLocalTaskStoreStrategy,
-		&config.StorageOption{
-			DataPath: test.DataDir,
-			TaskExpireTime: clientutil.Duration{
-				Duration: -1 * time.Second,
-			},
-		}, func(request storage.CommonTaskRequest) {})
-	return sched, storageManager
+	return sched
 }
 
 func TestPeerTaskManager_StartFilePeerTask(t *testing.T) {
@@ -288,3 +296,86 @@ func TestPeerTaskManager_StartStreamPeerTask(t *testing.T) {
 	assert.Nil(err, "load read data")
 	assert.Equal(testBytes, outputBytes, "output and desired output must match")
 }
+
+func TestPeerTaskManager_MergeStreamPeerTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	var (
+		pieceParallelCount = int32(4)
+		pieceSize          = 1024
+
+		mockContentLength = len(testBytes)
+		taskID            = "task-0"
+	)
+	// the scheduler expects only one registration, the merged request does not register again
+	sched, storageManager := setupPeerTaskManagerComponents(ctrl, taskID, int64(mockContentLength), int32(pieceSize), pieceParallelCount)
+	defer storageManager.CleanUp()
+
+	// the pieces except the first one are downloaded after the second request is merged
+	release := make(chan struct{})
+	downloader := NewMockPieceDownloader(ctrl)
+	downloader.EXPECT().DownloadPiece(gomock.Any()).AnyTimes().DoAndReturn(func(task *DownloadPieceRequest) (io.Reader, io.Closer, error) {
+		if task.piece.PieceNum > 0 {
+			<-release
+		}
+		rc := ioutil.NopCloser(
+			bytes.NewBuffer(
+				testBytes[task.piece.RangeStart : task.piece.RangeStart+uint64(task.piece.RangeSize)],
+			))
+		return rc, rc, nil
+	})
+
+	ptm := &peerTaskManager{
+		host: &scheduler.PeerHost{
+			Ip: "127.0.0.1",
+		},
+		runningPeerTasks: sync.Map{},
+		pieceManager: &pieceManager{
+			storageManager:  storageManager,
+			pieceDownloader: downloader,
+		},
+		storageManager:  storageManager,
+		schedulerClient: sched,
+		schedulerOption: config.SchedulerOption{
+			ScheduleTimeout: clientutil.Duration{Duration: 10 * time.Minute},
+		},
+	}
+	newRequest := func(peerID string) *scheduler.PeerTaskRequest {
+		return &scheduler.PeerTaskRequest{
+			Url:      "http://localhost/test/data",
+			BizId:    "d7y-test",
+			PeerId:   peerID,
+			PeerHost: &scheduler.PeerHost{},
+		}
+	}
+
+	r1, _, err := ptm.StartStreamPeerTask(context.Background(), newRequest("peer-0"))
+	assert.Nil(err, "start stream peer task")
+	// the second request is gone before reading, which does not affect the running peer task
+	ctx, cancel := context.WithCancel(context.Background())
+	_, _, err = ptm.StartStreamPeerTask(ctx, newRequest("peer-1"))
+	assert.Nil(err, "merge stream peer task")
+	cancel()
+	r2, attr2, err := ptm.StartStreamPeerTask(context.Background(), newRequest("peer-2"))
+	assert.Nil(err, "merge stream peer task")
+	assert.Equal("peer-0", attr2[config.HeaderDragonflyPeer])
+	assert.Equal(fmt.Sprintf("%d", mockContentLength), attr2[headers.ContentLength])
+	close(release)
+
+	var wg sync.WaitGroup
+	for _, r := range []io.Reader{r1, r2} {
+		wg.Add(1)
+		go func(r io.Reader) {
+			defer wg.Done()
+			outputBytes, err := ioutil.ReadAll(r)
+			assert.Nil(err, "load read data")
+			assert.Equal(testBytes, outputBytes, "output and desired output must match")
+		}(r)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,102 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/go-http-utils/headers"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+// tryReuseFilePeerTask stores the completed task in local storage to the output without downloading again,
+// the returned progress channel holds the done progress only.
+func (ptm *peerTaskManager) tryReuseFilePeerTask(ctx context.Context, taskID string,
+	req *FilePeerTaskRequest) (chan *FilePeerTaskProgress, bool) {
+	reuse := ptm.storageManager.FindCompletedTask(taskID)
+	if reuse == nil {
+		return nil, false
+	}
+	log := logger.With("peer", req.PeerId, "task", taskID, "component", "reuseFilePeerTask")
+	log.Infof("reuse from peer task: %s, content length: %d", reuse.PeerID, reuse.ContentLength)
+
+	err := ptm.storageManager.Store(ctx,
+		&storage.StoreRequest{
+			CommonTaskRequest: storage.CommonTaskRequest{
+				PeerID:      reuse.PeerID,
+				TaskID:      reuse.TaskID,
+				Destination: req.Output,
+			},
+			MetadataOnly: false,
+			TotalPieces:  reuse.TotalPieces,
+		})
+	if err != nil {
+		log.Errorf("store error when reuse peer task: %s", err)
+		return nil, false
+	}
+
+	progressCh := make(chan *FilePeerTaskProgress, 1)
+	progressCh <- &FilePeerTaskProgress{
+		State: &ProgressState{
+			Success: true,
+			Code:    dfcodes.Success,
+			Msg:     "Success",
+		},
+		TaskId:          reuse.TaskID,
+		PeerID:          reuse.PeerID,
+		ContentLength:   reuse.ContentLength,
+		CompletedLength: reuse.ContentLength,
+		TotalPieces:     reuse.TotalPieces,
+		PeerTaskDone:    true,
+		DoneCallback:    func() {},
+	}
+	return progressCh, true
+}
+
+// tryReuseStreamPeerTask returns a reader of the completed task in local storage, the reader should be closed
+func (ptm *peerTaskManager) tryReuseStreamPeerTask(ctx context.Context, taskID string,
+	req *scheduler.PeerTaskRequest) (io.Reader, map[string]string, bool) {
+	reuse := ptm.storageManager.FindCompletedTask(taskID)
+	if reuse == nil {
+		return nil, nil, false
+	}
+	log := logger.With("peer", req.PeerId, "task", taskID, "component", "reuseStreamPeerTask")
+	log.Infof("reuse from peer task: %s, content length: %d", reuse.PeerID, reuse.ContentLength)
+
+	rc, err := ptm.storageManager.ReadAllPieces(ctx, &reuse.PeerTaskMetaData)
+	if err != nil {
+		log.Errorf("read all pieces error when reuse peer task: %s", err)
+		return nil, nil, false
+	}
+
+	attr := map[string]string{}
+	if reuse.ContentLength >= 0 {
+		attr[headers.ContentLength] = fmt.Sprintf("%d", reuse.ContentLength)
+	} else {
+		attr[headers.TransferEncoding] = "chunked"
+	}
+	attr[config.HeaderDragonflyTask] = reuse.TaskID
+	attr[config.HeaderDragonflyPeer] = reuse.PeerID
+	return rc, attr, true
+}
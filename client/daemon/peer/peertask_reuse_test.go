@@ -0,0 +1,448 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
+	"d7y.io/dragonfly/v2/client/daemon/test"
+	"d7y.io/dragonfly/v2/pkg/idgen"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+func setupReuseStorageManager(assert *testifyassert.Assertions, peerID, taskID string, data []byte) storage.Manager {
+	storageManager, err := storage.NewStorageManager(
+		config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: test.DataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Hour,
+			},
+		}, func(request storage.CommonTaskRequest) {})
+	assert.Nil(err)
+
+	err = storageManager.RegisterTask(context.Background(),
+		storage.RegisterTaskRequest{
+			CommonTaskRequest: storage.CommonTaskRequest{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			ContentLength: int64(len(data)),
+			TotalPieces:   1,
+		})
+	assert.Nil(err)
+	_, err = storageManager.WritePiece(context.Background(),
+		&storage.WritePieceRequest{
+			PeerTaskMetaData: storage.PeerTaskMetaData{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			PieceMetaData: storage.PieceMetaData{
+				Num: 0,
+				Range: clientutil.Range{
+					Start:  0,
+					Length: int64(len(data)),
+				},
+			},
+			Reader: bytes.NewBuffer(data),
+		})
+	assert.Nil(err)
+	return storageManager
+}
+
+func TestPeerTaskManager_ReusePeerTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	var (
+		peerID = "peer-0"
+		output = "../test/testdata/test.reuse.output"
+		req    = &FilePeerTaskRequest{
+			PeerTaskRequest: scheduler.PeerTaskRequest{
+				Url:    "http://localhost/test/data",
+				PeerId: "peer-1",
+			},
+			Output: output,
+		}
+	)
+	taskID := idgen.GenerateTaskID(req.Url, req.Filter, req.UrlMata, req.BizId)
+	defer os.Remove(output)
+
+	storageManager := setupReuseStorageManager(assert, peerID, taskID, testBytes)
+	defer storageManager.CleanUp()
+	ptm := &peerTaskManager{storageManager: storageManager}
+
+	// not completed yet
+	_, ok := ptm.tryReuseFilePeerTask(context.Background(), taskID, req)
+	assert.False(ok)
+
+	err = storageManager.Store(context.Background(),
+		&storage.StoreRequest{
+			CommonTaskRequest: storage.CommonTaskRequest{
+				PeerID: peerID,
+				TaskID: taskID,
+			},
+			MetadataOnly: true,
+		})
+	assert.Nil(err)
+
+	progress, ok := ptm.tryReuseFilePeerTask(context.Background(), taskID, req)
+	assert.True(ok)
+	p := <-progress
+	assert.True(p.State.Success)
+	assert.True(p.PeerTaskDone)
+	assert.Equal(peerID, p.PeerID)
+	assert.Equal(int64(len(testBytes)), p.CompletedLength)
+	outputBytes, err := ioutil.ReadFile(output)
+	assert.Nil(err, "load output file")
+	assert.Equal(testBytes, outputBytes, "output and desired output must match")
+
+	reader, attr, ok := ptm.tryReuseStreamPeerTask(context.Background(), taskID, &req.PeerTaskRequest)
+	assert.True(ok)
+	data, err := ioutil.ReadAll(reader)
+	assert.Nil(err)
+	assert.Equal(testBytes, data)
+	assert.Equal(peerID, attr[config.HeaderDragonflyPeer])
+}
+
+func TestPeerTaskManager_AttachFilePeerTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	var (
+		peerID  = "peer-0"
+		taskID  = "task-0"
+		output1 = "../test/testdata/test.attach.output1"
+		output2 = "../test/testdata/test.attach.output2"
+	)
+	defer os.Remove(output2)
+
+	storageManager := setupReuseStorageManager(assert, peerID, taskID, testBytes)
+	defer storageManager.CleanUp()
+	ptm := &peerTaskManager{storageManager: storageManager}
+
+	src := make(chan *FilePeerTaskProgress)
+	broker, progress1, attached := ptm.attachOrNewFilePeerTaskBroker(context.Background(), taskID,
+		&FilePeerTaskRequest{PeerTaskRequest: scheduler.PeerTaskRequest{PeerId: peerID}, Output: output1}, true)
+	assert.False(attached)
+	broker.start(src)
+	_, progress2, attached := ptm.attachOrNewFilePeerTaskBroker(context.Background(), taskID,
+		&FilePeerTaskRequest{PeerTaskRequest: scheduler.PeerTaskRequest{PeerId: "peer-1"}, Output: output2}, true)
+	assert.True(attached)
+
+	src <- &FilePeerTaskProgress{
+		State:           &ProgressState{Success: true},
+		TaskId:          taskID,
+		PeerID:          peerID,
+		ContentLength:   int64(len(testBytes)),
+		CompletedLength: 1,
+	}
+	assert.Equal(int64(1), (<-progress1).CompletedLength)
+	assert.Equal(int64(1), (<-progress2).CompletedLength)
+
+	doneCallback := make(chan struct{})
+	src <- &FilePeerTaskProgress{
+		State:           &ProgressState{Success: true},
+		TaskId:          taskID,
+		PeerID:          peerID,
+		ContentLength:   int64(len(testBytes)),
+		CompletedLength: int64(len(testBytes)),
+		PeerTaskDone:    true,
+		DoneCallback: func() {
+			close(doneCallback)
+		},
+	}
+	for _, progress := range []chan *FilePeerTaskProgress{progress1, progress2} {
+		p := <-progress
+		assert.True(p.State.Success)
+		assert.True(p.PeerTaskDone)
+		p.DoneCallback()
+	}
+	select {
+	case <-doneCallback:
+	case <-time.After(time.Second):
+		assert.Fail("done callback of peer task is not called")
+	}
+
+	outputBytes, err := ioutil.ReadFile(output2)
+	assert.Nil(err, "load output file")
+	assert.Equal(testBytes, outputBytes, "output and desired output must match")
+
+	// the broker is removed after the peer task is done
+	_, _, attached = ptm.attachOrNewFilePeerTaskBroker(context.Background(), taskID,
+		&FilePeerTaskRequest{PeerTaskRequest: scheduler.PeerTaskRequest{PeerId: "peer-2"}, Output: output2}, true)
+	assert.False(attached)
+}
+
+func TestPeerTaskManager_AttachFilePeerTaskConcurrently(t *testing.T) {
+	assert := testifyassert.New(t)
+	ptm := &peerTaskManager{}
+
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		brokers  = map[*filePeerTaskBroker]struct{}{}
+		starters int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b, _, attached := ptm.attachOrNewFilePeerTaskBroker(context.Background(), "task-0",
+				&FilePeerTaskRequest{PeerTaskRequest: scheduler.PeerTaskRequest{PeerId: fmt.Sprintf("peer-%d", i)}}, true)
+			lock.Lock()
+			defer lock.Unlock()
+			brokers[b] = struct{}{}
+			if !attached {
+				starters++
+			}
+		}(i)
+	}
+	wg.Wait()
+	// only one request starts the peer task, the others are attached to it
+	assert.Equal(1, starters)
+	assert.Len(brokers, 1)
+}
+
+func TestPeerTaskManager_FilePeerTaskOutlivesOwner(t *testing.T) {
+	assert := testifyassert.New(t)
+	ptm := &peerTaskManager{}
+
+	ownerCtx, cancelOwner := context.WithCancel(context.Background())
+	broker, _, _ := ptm.attachOrNewFilePeerTaskBroker(ownerCtx, "task-0",
+		&FilePeerTaskRequest{PeerTaskRequest: scheduler.PeerTaskRequest{PeerId: "peer-0"}}, true)
+	attachedCtx, cancelAttached := context.WithCancel(context.Background())
+	_, progress, attached := ptm.attachOrNewFilePeerTaskBroker(attachedCtx, "task-0",
+		&FilePeerTaskRequest{PeerTaskRequest: scheduler.PeerTaskRequest{PeerId: "peer-1"}}, true)
+	assert.True(attached)
+	broker.start(make(chan *FilePeerTaskProgress))
+
+	// the peer task keeps running for the attached request after the owner is gone
+	cancelOwner()
+	select {
+	case <-broker.ctx.Done():
+		assert.Fail("peer task is canceled with its owner")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the peer task is canceled after all requests are gone
+	cancelAttached()
+	select {
+	case <-broker.ctx.Done():
+	case <-time.After(time.Second):
+		assert.Fail("peer task is not canceled after all requests are gone")
+	}
+	select {
+	case p := <-progress:
+		assert.Fail("progress is sent to the gone request", "%+v", p)
+	default:
+	}
+	select {
+	case <-broker.finished:
+	case <-time.After(time.Second):
+		assert.Fail("broker is not finished after the peer task is canceled")
+	}
+}
+
+func TestPeerTaskManager_CdnOnlySkipsReuseAndAttach(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	var (
+		pieceSize = 1024
+		output    = "../test/testdata/test.cdnonly.output"
+		req       = &FilePeerTaskRequest{
+			PeerTaskRequest: scheduler.PeerTaskRequest{
+				Url:      "http://localhost/test/data",
+				BizId:    "d7y-test",
+				PeerId:   "peer-1",
+				PeerHost: &scheduler.PeerHost{},
+				CdnOnly:  true,
+			},
+			Output: output,
+		}
+	)
+	taskID := idgen.GenerateTaskID(req.Url, req.Filter, req.UrlMata, req.BizId)
+	defer os.Remove(output)
+
+	// the scheduler expects exactly one register of the cdn only peer task
+	schedulerClient, componentStorageManager := setupPeerTaskManagerComponents(ctrl, taskID, int64(len(testBytes)), int32(pieceSize), 4)
+	defer componentStorageManager.CleanUp()
+
+	// the task is completed in local storage
+	storageManager := setupReuseStorageManager(assert, "peer-0", taskID, testBytes)
+	defer storageManager.CleanUp()
+	assert.Nil(storageManager.Store(context.Background(),
+		&storage.StoreRequest{
+			CommonTaskRequest: storage.CommonTaskRequest{
+				PeerID: "peer-0",
+				TaskID: taskID,
+			},
+			MetadataOnly: true,
+		}))
+
+	downloader := NewMockPieceDownloader(ctrl)
+	downloader.EXPECT().DownloadPiece(gomock.Any()).Times(int(math.Ceil(float64(len(testBytes)) / float64(pieceSize)))).DoAndReturn(
+		func(task *DownloadPieceRequest) (io.Reader, io.Closer, error) {
+			rc := ioutil.NopCloser(bytes.NewBuffer(
+				testBytes[task.piece.RangeStart : task.piece.RangeStart+uint64(task.piece.RangeSize)]))
+			return rc, rc, nil
+		})
+	ptm := &peerTaskManager{
+		host: &scheduler.PeerHost{
+			Ip: "127.0.0.1",
+		},
+		pieceManager: &pieceManager{
+			storageManager:  storageManager,
+			pieceDownloader: downloader,
+		},
+		storageManager:  storageManager,
+		schedulerClient: schedulerClient,
+		schedulerOption: config.SchedulerOption{
+			ScheduleTimeout: clientutil.Duration{Duration: 10 * time.Minute},
+		},
+	}
+
+	// another peer task of the task is running
+	running, _, _ := ptm.attachOrNewFilePeerTaskBroker(context.Background(), taskID,
+		&FilePeerTaskRequest{PeerTaskRequest: scheduler.PeerTaskRequest{PeerId: "peer-2"}}, true)
+	running.start(make(chan *FilePeerTaskProgress))
+
+	progress, _, err := ptm.StartFilePeerTask(context.Background(), req)
+	assert.Nil(err, "start file peer task")
+	var p *FilePeerTaskProgress
+	for p = range progress {
+		assert.True(p.State.Success)
+		if p.PeerTaskDone {
+			p.DoneCallback()
+			break
+		}
+	}
+	if assert.NotNil(p) {
+		assert.True(p.PeerTaskDone)
+		assert.Equal("peer-1", p.PeerID, "the cdn only request downloads by itself")
+	}
+	outputBytes, err := ioutil.ReadFile(output)
+	assert.Nil(err, "load output file")
+	assert.Equal(testBytes, outputBytes, "output and desired output must match")
+
+	// the running peer task is still the one for attaching
+	ptm.brokerLock.Lock()
+	assert.Equal(running, ptm.fileTaskBrokers[taskID])
+	ptm.brokerLock.Unlock()
+}
+
+func TestPeerTaskManager_StreamCdnOnlySkipsReuseAndAttach(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	var (
+		pieceSize = 1024
+		req       = &scheduler.PeerTaskRequest{
+			Url:      "http://localhost/test/data",
+			BizId:    "d7y-test",
+			PeerId:   "peer-1",
+			PeerHost: &scheduler.PeerHost{},
+			CdnOnly:  true,
+		}
+	)
+	taskID := idgen.GenerateTaskID(req.Url, req.Filter, req.UrlMata, req.BizId)
+
+	// the scheduler expects exactly one register of the cdn only peer task
+	schedulerClient, componentStorageManager := setupPeerTaskManagerComponents(ctrl, taskID, int64(len(testBytes)), int32(pieceSize), 4)
+	defer componentStorageManager.CleanUp()
+
+	// the task is completed in local storage
+	storageManager := setupReuseStorageManager(assert, "peer-0", taskID, testBytes)
+	defer storageManager.CleanUp()
+	assert.Nil(storageManager.Store(context.Background(),
+		&storage.StoreRequest{
+			CommonTaskRequest: storage.CommonTaskRequest{
+				PeerID: "peer-0",
+				TaskID: taskID,
+			},
+			MetadataOnly: true,
+		}))
+
+	downloader := NewMockPieceDownloader(ctrl)
+	downloader.EXPECT().DownloadPiece(gomock.Any()).Times(int(math.Ceil(float64(len(testBytes)) / float64(pieceSize)))).DoAndReturn(
+		func(task *DownloadPieceRequest) (io.Reader, io.Closer, error) {
+			rc := ioutil.NopCloser(bytes.NewBuffer(
+				testBytes[task.piece.RangeStart : task.piece.RangeStart+uint64(task.piece.RangeSize)]))
+			return rc, rc, nil
+		})
+	ptm := &peerTaskManager{
+		host: &scheduler.PeerHost{
+			Ip: "127.0.0.1",
+		},
+		pieceManager: &pieceManager{
+			storageManager:  storageManager,
+			pieceDownloader: downloader,
+		},
+		storageManager:  storageManager,
+		schedulerClient: schedulerClient,
+		schedulerOption: config.SchedulerOption{
+			ScheduleTimeout: clientutil.Duration{Duration: 10 * time.Minute},
+		},
+	}
+
+	// another stream peer task of the task is running, it never becomes ready
+	running, _ := ptm.attachOrNewStreamPeerTaskBroker(context.Background(), taskID,
+		&scheduler.PeerTaskRequest{PeerId: "peer-2"}, true)
+
+	// attaching to the running peer task waits until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	r, attribute, err := ptm.StartStreamPeerTask(ctx, req)
+	if !assert.Nil(err, "start stream peer task") {
+		return
+	}
+	assert.Equal("peer-1", attribute[config.HeaderDragonflyPeer], "the cdn only request downloads by itself")
+	outputBytes, err := ioutil.ReadAll(r)
+	assert.Nil(err, "read stream")
+	assert.Equal(testBytes, outputBytes, "output and desired output must match")
+
+	// the running peer task is still the one for attaching
+	ptm.brokerLock.Lock()
+	assert.Equal(running, ptm.streamTaskBrokers[taskID])
+	ptm.brokerLock.Unlock()
+}
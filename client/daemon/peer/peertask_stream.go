@@ -21,7 +21,6 @@ import (
 	"fmt"
 	"io"
 	"sync"
-	"sync/atomic"
 
 	"github.com/go-http-utils/headers"
 	"github.com/pkg/errors"
@@ -51,6 +50,8 @@ type StreamPeerTask interface {
 type streamPeerTask struct {
 	peerTask
 	successPieceCh chan int32
+	// pieceNotify is closed and replaced when a piece is ready, it is guarded by lock
+	pieceNotify chan struct{}
 }
 
 func newStreamPeerTask(ctx context.Context,
@@ -140,9 +141,10 @@ func newStreamPeerTask(ctx context.Context,
 			host:             host,
 			backSource:       backSource,
 			request:          request,
+			schedulerClient:  schedulerClient,
 			peerPacketStream: peerPacketStream,
 			pieceManager:     pieceManager,
-			peerPacketReady:  make(chan bool),
+			peerPacketReady:  make(chan bool, 1),
 			peerId:           request.PeerId,
 			taskId:           result.TaskId,
 			singlePiece:      singlePiece,
@@ -163,6 +165,7 @@ func newStreamPeerTask(ctx context.Context,
 			SugaredLoggerOnWith: logger.With("peer", request.PeerId, "task", result.TaskId, "component", "streamPeerTask"),
 		},
 		successPieceCh: make(chan int32, 4),
+		pieceNotify:    make(chan struct{}),
 	}, nil, nil
 }
 
@@ -170,7 +173,7 @@ func (s *streamPeerTask) ReportPieceResult(piece *base.PieceInfo, pieceResult *s
 	defer s.recoverFromPanic()
 	// retry failed piece
 	if !pieceResult.Success {
-		_ = s.peerPacketStream.Send(pieceResult)
+		_ = s.sendPieceResult(pieceResult)
 		s.failedPieceCh <- pieceResult.PieceNum
 		return nil
 	}
@@ -183,11 +186,13 @@ func (s *streamPeerTask) ReportPieceResult(piece *base.PieceInfo, pieceResult *s
 	}
 	// mark piece processed
 	s.readyPieces.Set(pieceResult.PieceNum)
-	atomic.AddInt64(&s.completedLength, int64(piece.RangeSize))
+	s.addCompletedLength(pieceResult.DstPid, int64(piece.RangeSize))
+	close(s.pieceNotify)
+	s.pieceNotify = make(chan struct{})
 	s.lock.Unlock()
 
 	pieceResult.FinishedCount = s.readyPieces.Settled()
-	_ = s.peerPacketStream.Send(pieceResult)
+	_ = s.sendPieceResult(pieceResult)
 	s.successPieceCh <- piece.PieceNum
 	s.Debugf("success piece %d sent", piece.PieceNum)
 	select {
@@ -269,10 +274,9 @@ func (s *streamPeerTask) Start(ctx context.Context) (io.Reader, map[string]strin
 	attr[config.HeaderDragonflyPeer] = s.peerId
 
 	go func(first int32) {
-		defer func() {
-			s.cancel()
-			s.span.End()
-		}()
+		// the peer task is not canceled when writing fails, other requests may be reading it,
+		// it is canceled by its broker when all the requests are gone
+		defer s.span.End()
 		var (
 			desired int32
 			cur     int32
@@ -328,6 +332,7 @@ func (s *streamPeerTask) Start(ctx context.Context) (io.Reader, map[string]strin
 					// all data is wrote to local storage, and all data is wrote to pipe write
 					if s.readyPieces.Settled() == desired {
 						pw.Close()
+						s.cancel()
 						return
 					}
 					_, span := tracer.Start(s.ctx, config.SpanWriteBackPiece)
@@ -358,11 +363,111 @@ func (s *streamPeerTask) Start(ctx context.Context) (io.Reader, map[string]strin
 	return reader, attr, nil
 }
 
+// newReader returns a reader of the running peer task for the request merged into it,
+// the reader reads the pieces from local storage in order as they are downloaded.
+func (s *streamPeerTask) newReader(ctx context.Context) (io.Reader, map[string]string, error) {
+	// wait first piece to get content length and attribute, like Start
+	for {
+		notify, ready := s.pieceReady(-1)
+		if ready {
+			break
+		}
+		select {
+		case <-notify:
+		case <-s.done:
+			if _, ready = s.pieceReady(-1); !ready {
+				return nil, nil, errors.Errorf("stream peer task early done: %s", s.failedReason)
+			}
+		case <-ctx.Done():
+			return nil, nil, errors.Errorf("wait first piece error: %s", ctx.Err())
+		}
+	}
+
+	pr, pw := io.Pipe()
+	attr := map[string]string{}
+	s.lock.Lock()
+	if s.contentLength != -1 {
+		attr[headers.ContentLength] = fmt.Sprintf("%d", s.contentLength)
+	} else {
+		attr[headers.TransferEncoding] = "chunked"
+	}
+	s.lock.Unlock()
+	attr[config.HeaderDragonflyTask] = s.taskId
+	attr[config.HeaderDragonflyPeer] = s.peerId
+
+	go func() {
+		var desired int32
+		for {
+			notify, ready := s.pieceReady(desired)
+			if ready {
+				if err := s.readPieceTo(ctx, pw, desired); err != nil {
+					s.Errorf("write piece %d to merged request error: %s", desired, err)
+					pw.CloseWithError(err)
+					return
+				}
+				desired++
+				continue
+			}
+			select {
+			case <-notify:
+			case <-s.done:
+				// write the pieces ready before done
+				if _, ready = s.pieceReady(desired); ready {
+					continue
+				}
+				s.lock.Lock()
+				completed := s.isCompleted()
+				s.lock.Unlock()
+				if !completed {
+					pw.CloseWithError(errors.Errorf("peer task failed: %s", s.failedReason))
+					return
+				}
+				pw.Close()
+				return
+			case <-ctx.Done():
+				pw.CloseWithError(ctx.Err())
+				return
+			}
+		}
+	}()
+	return pr, attr, nil
+}
+
+// pieceReady returns the channel closed when a new piece is ready and whether pieceNum is ready,
+// a negative pieceNum means any piece.
+func (s *streamPeerTask) pieceReady(pieceNum int32) (<-chan struct{}, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if pieceNum < 0 {
+		return s.pieceNotify, s.readyPieces.Settled() > 0
+	}
+	return s.pieceNotify, s.readyPieces.IsSet(pieceNum)
+}
+
+// readPieceTo writes the piece in local storage to w with ctx of the reading request
+func (s *streamPeerTask) readPieceTo(ctx context.Context, w io.Writer, pieceNum int32) error {
+	pr, pc, err := s.pieceManager.ReadPiece(ctx, &storage.ReadPieceRequest{
+		PeerTaskMetaData: storage.PeerTaskMetaData{
+			PeerID: s.peerId,
+			TaskID: s.taskId,
+		},
+		PieceMetaData: storage.PieceMetaData{
+			Num: pieceNum,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+	_, err = io.Copy(w, pr)
+	return err
+}
+
 func (s *streamPeerTask) finish() error {
 	// send last progress
 	s.once.Do(func() {
 		// send EOF piece result to scheduler
-		_ = s.peerPacketStream.Send(
+		_ = s.sendPieceResult(
 			scheduler.NewEndPieceResult(s.taskId, s.peerId, s.readyPieces.Settled()))
 		s.Debugf("end piece result sent")
 		close(s.done)
@@ -379,7 +484,7 @@ func (s *streamPeerTask) cleanUnfinished() {
 	// send last progress
 	s.once.Do(func() {
 		// send EOF piece result to scheduler
-		_ = s.peerPacketStream.Send(
+		_ = s.sendPieceResult(
 			scheduler.NewEndPieceResult(s.taskId, s.peerId, s.readyPieces.Settled()))
 		s.Debugf("end piece result sent")
 		close(s.done)
@@ -0,0 +1,173 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/go-http-utils/headers"
+	"go.opentelemetry.io/otel/trace"
+
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+// streamPeerTaskBroker shares a running stream peer task with all requests of the same task,
+// the requests merged later read the pieces from local storage as they are downloaded.
+// The peer task runs with the context of the broker, which is canceled when all requests are gone.
+type streamPeerTaskBroker struct {
+	*logger.SugaredLoggerOnWith
+	ptm    *peerTaskManager
+	ctx    context.Context
+	cancel context.CancelFunc
+	taskID string
+
+	// ready is closed after the peer task is started or failed to start
+	ready chan struct{}
+	pt    *streamPeerTask
+	tiny  *TinyData
+	err   error
+
+	lock        sync.Mutex
+	done        bool
+	subscribers int
+	// finished is closed when the broker is done
+	finished chan struct{}
+}
+
+// attachOrNewStreamPeerTaskBroker attaches req to the running peer task of the same task when attach is true,
+// otherwise it creates a broker for the peer task started by req and registers it for taskID.
+// It returns the broker and whether req is attached.
+func (ptm *peerTaskManager) attachOrNewStreamPeerTaskBroker(ctx context.Context, taskID string,
+	req *scheduler.PeerTaskRequest, attach bool) (*streamPeerTaskBroker, bool) {
+	ptm.brokerLock.Lock()
+	defer ptm.brokerLock.Unlock()
+	if ptm.streamTaskBrokers == nil {
+		ptm.streamTaskBrokers = map[string]*streamPeerTaskBroker{}
+	}
+	if b, ok := ptm.streamTaskBrokers[taskID]; ok && attach && b.subscribe(ctx) {
+		b.Infof("peer %s attached to the running peer task", req.PeerId)
+		return b, true
+	}
+
+	// the peer task is detached from the request, it keeps running for the attached requests after req is gone
+	taskCtx, cancel := context.WithCancel(trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx)))
+	b := &streamPeerTaskBroker{
+		ptm:      ptm,
+		ctx:      taskCtx,
+		cancel:   cancel,
+		taskID:   taskID,
+		ready:    make(chan struct{}),
+		finished: make(chan struct{}),
+
+		SugaredLoggerOnWith: logger.With("peer", req.PeerId, "task", taskID, "component", "streamPeerTaskBroker"),
+	}
+	b.subscribe(ctx)
+	// a cdn only peer task may run concurrently, keep the first one for attaching
+	if _, ok := ptm.streamTaskBrokers[taskID]; !ok {
+		ptm.streamTaskBrokers[taskID] = b
+	}
+	return b, false
+}
+
+func (b *streamPeerTaskBroker) subscribe(ctx context.Context) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.done {
+		return false
+	}
+	b.subscribers++
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.unsubscribe()
+		case <-b.finished:
+		}
+	}()
+	return true
+}
+
+// unsubscribe is called when a request is gone, the peer task is canceled when no request is left
+func (b *streamPeerTaskBroker) unsubscribe() {
+	b.lock.Lock()
+	b.subscribers--
+	if b.done || b.subscribers > 0 {
+		b.lock.Unlock()
+		return
+	}
+	b.lock.Unlock()
+	b.Infof("all requests are gone, cancel the peer task")
+	b.cancel()
+	b.close()
+}
+
+// start records the result of starting the peer task, the broker is closed after the peer task is done
+func (b *streamPeerTaskBroker) start(pt *streamPeerTask, tiny *TinyData, err error) {
+	b.pt, b.tiny, b.err = pt, tiny, err
+	close(b.ready)
+	if pt == nil {
+		b.close()
+		return
+	}
+	go func() {
+		select {
+		case <-pt.done:
+		case <-b.finished:
+		}
+		b.close()
+	}()
+}
+
+// close stops merging requests into the peer task
+func (b *streamPeerTaskBroker) close() {
+	b.lock.Lock()
+	if b.done {
+		b.lock.Unlock()
+		return
+	}
+	b.done = true
+	close(b.finished)
+	b.lock.Unlock()
+
+	b.ptm.brokerLock.Lock()
+	if b.ptm.streamTaskBrokers[b.taskID] == b {
+		delete(b.ptm.streamTaskBrokers, b.taskID)
+	}
+	b.ptm.brokerLock.Unlock()
+}
+
+// attach returns the reader of the running peer task for the merged request
+func (b *streamPeerTaskBroker) attach(ctx context.Context, req *scheduler.PeerTaskRequest) (io.Reader, map[string]string, error) {
+	select {
+	case <-b.ready:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	if b.err != nil {
+		return nil, nil, b.err
+	}
+	if b.tiny != nil {
+		return bytes.NewBuffer(b.tiny.Content), map[string]string{
+			headers.ContentLength: fmt.Sprintf("%d", len(b.tiny.Content)),
+		}, nil
+	}
+	return b.pt.newReader(ctx)
+}
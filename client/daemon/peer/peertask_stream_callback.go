@@ -46,8 +46,10 @@ func (p *streamPeerTaskCallback) Init(pt PeerTask) error {
 				TaskID:      pt.GetTaskID(),
 				Destination: "",
 			},
+			URL:           p.req.Url,
 			ContentLength: pt.GetContentLength(),
 			TotalPieces:   pt.GetTotalPieces(),
+			PieceSize:     pt.GetPieceSize(),
 		})
 	if err != nil {
 		pt.Log().Errorf("register task to storage manager failed: %s", err)
@@ -65,6 +67,7 @@ func (p *streamPeerTaskCallback) Update(pt PeerTask) error {
 			},
 			ContentLength: pt.GetContentLength(),
 			TotalPieces:   pt.GetTotalPieces(),
+			PieceSize:     pt.GetPieceSize(),
 		})
 	if err != nil {
 		pt.Log().Errorf("update task to storage manager failed: %s", err)
@@ -84,6 +87,7 @@ func (p *streamPeerTaskCallback) Done(pt PeerTask) error {
 			},
 			MetadataOnly: true,
 			TotalPieces:  pt.GetTotalPieces(),
+			Digest:       p.req.UrlMata.GetDigest(),
 		})
 	if e != nil {
 		return e
@@ -108,7 +108,7 @@ func TestStreamPeerTask_BackSource_WithContentLength(t *testing.T) {
 			storageManager:  storageManager,
 			pieceDownloader: downloader,
 			resourceClient:  sourceClient,
-			computePieceSize: func(contentLength int64) int32 {
+			computePieceSize: func(url string, contentLength int64) int32 {
 				return int32(pieceSize)
 			},
 		},
@@ -206,7 +206,7 @@ func TestStreamPeerTask_BackSource_WithoutContentLength(t *testing.T) {
 			storageManager:  storageManager,
 			pieceDownloader: downloader,
 			resourceClient:  sourceClient,
-			computePieceSize: func(contentLength int64) int32 {
+			computePieceSize: func(url string, contentLength int64) int32 {
 				return int32(pieceSize)
 			},
 		},
@@ -16,7 +16,27 @@
 
 package peer
 
-import "testing"
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	testifyassert "github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"d7y.io/dragonfly/v2/client/config"
+	mock_scheduler "d7y.io/dragonfly/v2/client/daemon/test/mock/scheduler"
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/rpc/base/common"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	schedulerclient "d7y.io/dragonfly/v2/pkg/rpc/scheduler/client"
+)
 
 func TestBitmap_Sets(t *testing.T) {
 	b := NewBitmap()
@@ -26,3 +46,129 @@ func TestBitmap_Sets(t *testing.T) {
 	b.Sets(2, 3, 3, 4)
 	//t.Logf("%s, %d", b.String(), b.Settled())
 }
+
+func TestPeerTask_MigrateScheduler(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		peerID = "peer-0"
+		taskID = "task-0"
+	)
+
+	// old scheduler breaks after some pieces finished
+	oldStream := mock_scheduler.NewMockPeerPacketStream(ctrl)
+	// piece results during migrating are never sent to the old stream
+	oldStream.EXPECT().Send(gomock.Any()).Times(4).Return(nil)
+	oldStream.EXPECT().Recv().Return(nil, status.Error(codes.Unavailable, "scheduler gone"))
+
+	// new scheduler receives the latest finished piece, then sends a new peer packet
+	var replayed []*scheduler.PieceResult
+	newStream := mock_scheduler.NewMockPeerPacketStream(ctrl)
+	newStream.EXPECT().Send(gomock.Any()).AnyTimes().DoAndReturn(
+		func(pr *scheduler.PieceResult) error {
+			replayed = append(replayed, pr)
+			return nil
+		})
+	gomock.InOrder(
+		newStream.EXPECT().Recv().Return(&scheduler.PeerPacket{
+			Code:          dfcodes.Success,
+			TaskId:        taskID,
+			SrcPid:        peerID,
+			ParallelCount: 1,
+			MainPeer: &scheduler.PeerPacket_DestPeer{
+				Ip:     "127.0.0.1",
+				PeerId: "peer-x",
+			},
+		}, nil),
+		newStream.EXPECT().Recv().Return(nil, io.EOF),
+	)
+
+	var (
+		pt      *peerTask
+		request = &scheduler.PeerTaskRequest{
+			PeerId: peerID,
+		}
+	)
+	sched := mock_scheduler.NewMockSchedulerClient(ctrl)
+	sched.EXPECT().MigratePeerTask(gomock.Any(), taskID, request, oldStream, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, taskId string, ptr *scheduler.PeerTaskRequest, stream schedulerclient.PeerPacketStream,
+			cause error, opts ...grpc.CallOption) (schedulerclient.PeerPacketStream, error) {
+			assert.Equal(codes.Unavailable, status.Code(cause))
+			// piece workers keep downloading and reporting while registering to the new scheduler
+			sent := make(chan struct{})
+			go func() {
+				defer close(sent)
+				pt.readyPieces.Set(4)
+				assert.Nil(pt.sendPieceResult(&scheduler.PieceResult{
+					TaskId:   taskID,
+					SrcPid:   peerID,
+					PieceNum: 4,
+					Success:  true,
+				}))
+				assert.Nil(pt.sendPieceResult(scheduler.NewEndPieceResult(taskID, peerID, pt.readyPieces.Settled())))
+			}()
+			select {
+			case <-sent:
+			case <-time.After(5 * time.Second):
+				assert.Fail("piece results are blocked by migrating")
+			}
+			return newStream, nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, span := tracer.Start(ctx, config.SpanFilePeerTask)
+	defer span.End()
+	pt = &peerTask{
+		ctx:                 ctx,
+		cancel:              cancel,
+		request:             request,
+		schedulerClient:     sched,
+		peerPacketStream:    oldStream,
+		peerPacketReady:     make(chan bool, 1),
+		peerId:              peerID,
+		taskId:              taskID,
+		done:                make(chan struct{}),
+		span:                span,
+		readyPieces:         NewBitmap(),
+		lock:                &sync.Mutex{},
+		SugaredLoggerOnWith: logger.With("peer", peerID, "task", taskID, "component", "peerTask"),
+	}
+	for i := int32(0); i < 3; i++ {
+		pt.readyPieces.Set(i)
+		assert.Nil(pt.sendPieceResult(&scheduler.PieceResult{
+			TaskId:   taskID,
+			SrcPid:   peerID,
+			PieceNum: i,
+			Success:  true,
+		}))
+	}
+	// failed piece result is not replayed
+	assert.Nil(pt.sendPieceResult(&scheduler.PieceResult{
+		TaskId:   taskID,
+		SrcPid:   peerID,
+		PieceNum: 3,
+		Success:  false,
+	}))
+
+	go pt.receivePeerPacket()
+
+	select {
+	case <-pt.peerPacketReady:
+	case <-time.After(5 * time.Second):
+		assert.Fail("wait peer packet from new scheduler timeout")
+		return
+	}
+	assert.Nil(ctx.Err(), "peer task should not be canceled")
+	assert.Equal(1, pt.schedulerMigrateTimes)
+	assert.Equal(newStream, pt.getPeerPacketStream())
+	// the latest piece result is replayed with the finished count, then the end piece result reported during migrating
+	if assert.Len(replayed, 2) {
+		assert.Equal(int32(4), replayed[0].PieceNum)
+		assert.Equal(int32(4), replayed[0].FinishedCount)
+		assert.Equal(common.EndOfPiece, replayed[1].PieceNum)
+	}
+	assert.Equal("peer-x", pt.peerPacket.MainPeer.PeerId)
+}
@@ -32,7 +32,9 @@ import (
 )
 
 type DownloadPieceRequest struct {
-	TaskID     string
+	TaskID string
+	// SrcPid is the peer which downloads the piece, the dst peer counts its upload with it
+	SrcPid     string
 	DstPid     string
 	DstAddr    string
 	CalcDigest bool
@@ -116,6 +118,10 @@ func buildDownloadPieceHTTPRequest(d *DownloadPieceRequest) *http.Request {
 	b.WriteString(d.TaskID)
 	b.Write([]byte("?peerId="))
 	b.WriteString(d.DstPid)
+	if d.SrcPid != "" {
+		b.Write([]byte("&srcPeerId="))
+		b.WriteString(d.SrcPid)
+	}
 
 	u := b.String()
 	logger.Debugf("built request url: %s", u)
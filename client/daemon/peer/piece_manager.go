@@ -18,13 +18,14 @@ package peer
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
 	"time"
 
 	"golang.org/x/time/rate"
 
-	cdnconfig "d7y.io/dragonfly/v2/cdnsystem/config"
 	"d7y.io/dragonfly/v2/cdnsystem/source"
 	_ "d7y.io/dragonfly/v2/cdnsystem/source/httpprotocol"
 	"d7y.io/dragonfly/v2/client/clientutil"
@@ -32,8 +33,10 @@ import (
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/pkg/dfcodes"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/piecesize"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	"d7y.io/dragonfly/v2/pkg/util/compressutils"
 	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
@@ -48,7 +51,7 @@ type pieceManager struct {
 	storageManager   storage.TaskStorageDriver
 	pieceDownloader  PieceDownloader
 	resourceClient   source.ResourceClient
-	computePieceSize func(contentLength int64) int32
+	computePieceSize func(url string, contentLength int64) int32
 
 	calculateDigest bool
 }
@@ -88,6 +91,15 @@ func WithCalculateDigest(enable bool) func(*pieceManager) {
 	}
 }
 
+// WithPieceSizePolicy sets the policy deciding the piece size when download from source
+func WithPieceSizePolicy(policy *piecesize.Policy) func(*pieceManager) {
+	return func(pm *pieceManager) {
+		pm.computePieceSize = func(url string, contentLength int64) int32 {
+			return policy.Compute(url, contentLength, 0)
+		}
+	}
+}
+
 // WithLimiter sets upload rate limiter, the burst size must big than piece size
 func WithLimiter(limiter *rate.Limiter) func(*pieceManager) {
 	return func(manager *pieceManager) {
@@ -134,7 +146,7 @@ func (pm *pieceManager) DownloadPiece(ctx context.Context, pt PeerTask, request
 	defer c.Close()
 
 	// 2. save to storage
-	n, err := pm.storageManager.WritePiece(ctx, &storage.WritePieceRequest{
+	writeRequest := &storage.WritePieceRequest{
 		PeerTaskMetaData: storage.PeerTaskMetaData{
 			PeerID: pt.GetPeerID(),
 			TaskID: pt.GetTaskID(),
@@ -149,7 +161,19 @@ func (pm *pieceManager) DownloadPiece(ctx context.Context, pt PeerTask, request
 			},
 		},
 		Reader: r,
-	})
+	}
+	if request.piece.PieceStyle != base.PieceStyle_PLAIN {
+		// compressed pieces are stored decompressed at the origin offset
+		dr, err := pm.decompressPiece(pt, request.piece, r, writeRequest)
+		if err != nil {
+			span.RecordError(err)
+			span.End()
+			pt.Log().Errorf("decompress piece failed, piece num: %d, error: %s", request.piece.PieceNum, err)
+			return
+		}
+		defer dr.Close()
+	}
+	n, err := pm.storageManager.WritePiece(ctx, writeRequest)
 	end = time.Now().UnixNano()
 	span.RecordError(err)
 	span.End()
@@ -159,10 +183,49 @@ func (pm *pieceManager) DownloadPiece(ctx context.Context, pt PeerTask, request
 			request.piece.PieceNum, n, err)
 		return
 	}
+	if request.piece.PieceStyle != base.PieceStyle_PLAIN {
+		// report the piece stored in local, which is plain now
+		request.piece = &base.PieceInfo{
+			PieceNum:    request.piece.PieceNum,
+			RangeStart:  request.piece.PieceOffset,
+			RangeSize:   int32(writeRequest.Range.Length),
+			PieceMd5:    writeRequest.Md5,
+			PieceOffset: request.piece.PieceOffset,
+			PieceStyle:  base.PieceStyle_PLAIN,
+		}
+	}
 	success = true
 	return
 }
 
+// decompressPiece makes writeRequest store the decompressed content of the compressed piece at its origin offset
+func (pm *pieceManager) decompressPiece(pt PeerTask, piece *base.PieceInfo, r io.Reader,
+	writeRequest *storage.WritePieceRequest) (io.Closer, error) {
+	pieceSize := int64(pt.GetPieceSize())
+	if pieceSize <= 0 {
+		return nil, fmt.Errorf("unknown piece size for %s piece", piece.PieceStyle)
+	}
+	length := pieceSize
+	contentLength := pt.GetContentLength()
+	if contentLength > 0 && int64(piece.PieceOffset)+length > contentLength {
+		length = contentLength - int64(piece.PieceOffset)
+	}
+	dr, err := compressutils.NewReader(piece.PieceStyle, r)
+	if err != nil {
+		return nil, err
+	}
+	// the md5 of piece is calculated with the compressed content, calculate the md5 of decompressed content again
+	writeRequest.Md5 = ""
+	writeRequest.Style = base.PieceStyle_PLAIN
+	writeRequest.Range = clientutil.Range{
+		Start:  int64(piece.PieceOffset),
+		Length: length,
+	}
+	writeRequest.UnknownLength = contentLength <= 0
+	writeRequest.Reader = digestutils.NewDigestReader(dr)
+	return dr, nil
+}
+
 func (pm *pieceManager) pushSuccessResult(peerTask PeerTask, dstPid string, piece *base.PieceInfo, start int64, end int64) {
 	err := peerTask.ReportPieceResult(
 		piece,
@@ -307,6 +370,7 @@ func (pm *pieceManager) DownloadSource(ctx context.Context, pt PeerTask, request
 	if err != nil {
 		log.Warnf("get content length error: %s for %s", err, request.Url)
 	}
+	pieceSize := pm.computePieceSize(request.Url, contentLength)
 	if contentLength == -1 {
 		log.Warnf("can not get content length for %s", request.Url)
 	} else {
@@ -317,6 +381,7 @@ func (pm *pieceManager) DownloadSource(ctx context.Context, pt PeerTask, request
 					TaskID: pt.GetTaskID(),
 				},
 				ContentLength: contentLength,
+				PieceSize:     pieceSize,
 			})
 		if err != nil {
 			return err
@@ -331,13 +396,17 @@ func (pm *pieceManager) DownloadSource(ctx context.Context, pt PeerTask, request
 	defer body.Close()
 	reader := body.(io.Reader)
 
-	// calc total md5
-	if pm.calculateDigest && request.UrlMata.Md5 != "" {
+	// calc total digest, the digest in algorithm:hex form is always verified
+	var verifyDigest bool
+	if digest := request.UrlMata.GetDigest(); digest != "" {
+		reader = digestutils.NewDigestReader(body, digest)
+		verifyDigest = true
+	} else if pm.calculateDigest && request.UrlMata.GetMd5() != "" {
 		reader = digestutils.NewDigestReader(body, request.UrlMata.Md5)
+		verifyDigest = true
 	}
 
 	// 2. save to storage
-	pieceSize := pm.computePieceSize(contentLength)
 	// handle resource which content length is unknown
 	if contentLength == -1 {
 		var n int64
@@ -360,6 +429,7 @@ func (pm *pieceManager) DownloadSource(ctx context.Context, pt PeerTask, request
 							TaskID: pt.GetTaskID(),
 						},
 						ContentLength: contentLength,
+						PieceSize:     pieceSize,
 					})
 				return pt.SetContentLength(contentLength)
 			}
@@ -388,24 +458,18 @@ func (pm *pieceManager) DownloadSource(ctx context.Context, pt PeerTask, request
 			return storage.ErrShortRead
 		}
 	}
+	// the digest is verified when the reader reaches EOF, drain it
+	if verifyDigest {
+		if _, err = io.Copy(ioutil.Discard, reader); err != nil {
+			log.Errorf("verify digest error: %s", err)
+			return err
+		}
+	}
 	log.Infof("download from source ok")
 	return nil
 }
 
-// TODO copy from cdnsystem/daemon/mgr/task/manager_util.go
-// computePieceSize computes the piece size with specified fileLength.
-//
-// If the fileLength<=0, which means failed to get fileLength
-// and then use the DefaultPieceSize.
-func computePieceSize(length int64) int32 {
-	if length <= 0 || length <= 200*1024*1024 {
-		return cdnconfig.DefaultPieceSize
-	}
-
-	gapCount := length / int64(100*1024*1024)
-	mpSize := (gapCount-2)*1024*1024 + cdnconfig.DefaultPieceSize
-	if mpSize > cdnconfig.DefaultPieceSizeLimit {
-		return cdnconfig.DefaultPieceSizeLimit
-	}
-	return int32(mpSize)
+// computePieceSize computes the piece size with the default policy.
+func computePieceSize(url string, length int64) int32 {
+	return piecesize.Compute(length)
 }
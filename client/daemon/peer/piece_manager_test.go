@@ -20,13 +20,16 @@ import (
 	"bytes"
 	"context"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -41,6 +44,7 @@ import (
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	_ "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/server"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
 func TestPieceManager_DownloadSource(t *testing.T) {
@@ -175,7 +179,7 @@ func TestPieceManager_DownloadSource(t *testing.T) {
 
 			pm, err := NewPieceManager(storageManager)
 			assert.Nil(err)
-			pm.(*pieceManager).computePieceSize = func(length int64) int32 {
+			pm.(*pieceManager).computePieceSize = func(url string, length int64) int32 {
 				return tc.pieceSize
 			}
 
@@ -210,3 +214,131 @@ func TestPieceManager_DownloadSource(t *testing.T) {
 		}()
 	}
 }
+
+func TestPieceManager_DownloadSource_Digest(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+
+	testBytes, err := ioutil.ReadFile(test.File)
+	assert.Nil(err, "load test file")
+
+	var (
+		peerID = "peer0"
+		taskID = "task0"
+		output = "../test/testdata/test.output"
+	)
+
+	storageManager, _ := storage.NewStorageManager(
+		config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: test.DataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: -1 * time.Second,
+			},
+		}, func(request storage.CommonTaskRequest) {})
+
+	sum := sha256.Sum256(testBytes)
+	digest := digestutils.Format(digestutils.AlgorithmSHA256, hex.EncodeToString(sum[:]))
+	badDigest := digestutils.Format(digestutils.AlgorithmSHA256, strings.Repeat("0", 64))
+
+	testCases := []struct {
+		name              string
+		withContentLength bool
+		digest            string
+		ok                bool
+	}{
+		{
+			name:              "sha256 match with content length",
+			withContentLength: true,
+			digest:            digest,
+			ok:                true,
+		},
+		{
+			name:              "sha256 match without content length",
+			withContentLength: false,
+			digest:            digest,
+			ok:                true,
+		},
+		{
+			name:              "sha256 not match with content length",
+			withContentLength: true,
+			digest:            badDigest,
+		},
+		{
+			name:              "sha256 not match without content length",
+			withContentLength: false,
+			digest:            badDigest,
+		},
+	}
+	for _, tc := range testCases {
+		func() {
+			mockPeerTask := NewMockPeerTask(ctrl)
+			mockPeerTask.EXPECT().SetContentLength(gomock.Any()).AnyTimes().Return(nil)
+			mockPeerTask.EXPECT().GetPeerID().AnyTimes().Return(peerID)
+			mockPeerTask.EXPECT().GetTaskID().AnyTimes().Return(taskID)
+			mockPeerTask.EXPECT().AddTraffic(gomock.Any()).AnyTimes()
+			mockPeerTask.EXPECT().ReportPieceResult(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+			mockPeerTask.EXPECT().GetContext().AnyTimes().Return(context.Background())
+			mockPeerTask.EXPECT().Log().AnyTimes().Return(logger.With("test case", tc.name))
+			err = storageManager.RegisterTask(context.Background(),
+				storage.RegisterTaskRequest{
+					CommonTaskRequest: storage.CommonTaskRequest{
+						PeerID:      peerID,
+						TaskID:      taskID,
+						Destination: output,
+					},
+					ContentLength: int64(len(testBytes)),
+				})
+			assert.Nil(err)
+			defer storageManager.CleanUp()
+			defer os.Remove(output)
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.withContentLength {
+					w.Header().Set("Content-Length", fmt.Sprintf("%d", len(testBytes)))
+				}
+				_, _ = io.Copy(w, bytes.NewBuffer(testBytes))
+			}))
+			defer ts.Close()
+
+			pm, err := NewPieceManager(storageManager, WithCalculateDigest(false))
+			assert.Nil(err)
+			pm.(*pieceManager).computePieceSize = func(url string, length int64) int32 {
+				return 1024
+			}
+
+			request := &scheduler.PeerTaskRequest{
+				Url:     ts.URL,
+				UrlMata: &base.UrlMeta{Digest: tc.digest},
+			}
+			err = pm.DownloadSource(context.Background(), mockPeerTask, request)
+			if !tc.ok {
+				assert.NotNil(err, tc.name)
+				return
+			}
+			assert.Nil(err, tc.name)
+
+			err = storageManager.Store(context.Background(),
+				&storage.StoreRequest{
+					CommonTaskRequest: storage.CommonTaskRequest{
+						PeerID:      peerID,
+						TaskID:      taskID,
+						Destination: output,
+					},
+					Digest: badDigest,
+				})
+			assert.True(errors.Is(err, digestutils.ErrDigestNotMatch), tc.name)
+
+			err = storageManager.Store(context.Background(),
+				&storage.StoreRequest{
+					CommonTaskRequest: storage.CommonTaskRequest{
+						PeerID:      peerID,
+						TaskID:      taskID,
+						Destination: output,
+					},
+					Digest: tc.digest,
+				})
+			assert.Nil(err, tc.name)
+		}()
+	}
+}
@@ -99,7 +99,8 @@ func NewPeerHost(host *scheduler.PeerHost, opt config.PeerHostOption) (PeerHost,
 
 	pieceManager, err := peer.NewPieceManager(storageManager,
 		peer.WithLimiter(rate.NewLimiter(opt.Download.TotalRateLimit.Limit, int(opt.Download.TotalRateLimit.Limit))),
-		peer.WithCalculateDigest(opt.Download.CalculateDigest))
+		peer.WithCalculateDigest(opt.Download.CalculateDigest),
+		peer.WithPieceSizePolicy(opt.Download.PieceSizePolicy))
 	if err != nil {
 		return nil, err
 	}
@@ -277,6 +278,13 @@ func (ph *peerHost) Serve() error {
 	}
 	ph.schedPeerHost.DownPort = int32(uploadPort)
 
+	// register the imported tasks reloaded from storage as seeds, after the ports of peer host are known
+	go func() {
+		if err := ph.PeerTaskManager.RegisterSeeds(context.Background()); err != nil {
+			logger.Errorf("failed to register seed peers: %v", err)
+		}
+	}()
+
 	g := errgroup.Group{}
 	// serve download grpc service
 	g.Go(func() error {
@@ -0,0 +1,175 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	grpcpeer "google.golang.org/grpc/peer"
+
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+)
+
+// credentials stands the uid and gid of the local process which calls the daemon over the unix socket
+type credentials struct {
+	uid uint32
+	gid uint32
+}
+
+// credListener records the credentials of unix socket callers into the remote address of accepted conns,
+// so they can be got from the grpc peer of the request context
+type credListener struct {
+	net.Listener
+}
+
+func (l *credListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return conn, nil
+	}
+	cred, err := peerCredentials(uc)
+	if err != nil {
+		logger.Warnf("get credentials of unix socket caller error: %s", err)
+		return conn, nil
+	}
+	return &credConn{
+		Conn: conn,
+		addr: &credAddr{
+			addr: conn.RemoteAddr(),
+			cred: cred,
+		},
+	}, nil
+}
+
+type credConn struct {
+	net.Conn
+	addr *credAddr
+}
+
+func (c *credConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// credAddr is the remote address with the caller credentials, the address of unix socket callers is usually empty
+type credAddr struct {
+	addr net.Addr
+	cred *credentials
+}
+
+func (a *credAddr) Network() string {
+	return "unix"
+}
+
+func (a *credAddr) String() string {
+	if a.addr == nil {
+		return ""
+	}
+	return a.addr.String()
+}
+
+// callerCredentials returns the credentials of the caller, false is returned when the caller is not from the unix socket
+func callerCredentials(ctx context.Context) (*credentials, bool) {
+	p, ok := grpcpeer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	addr, ok := p.Addr.(*credAddr)
+	if !ok {
+		return nil, false
+	}
+	return addr.cred, true
+}
+
+// readableBy resolves the path and checks whether the caller can read it with the permission bits like the kernel does,
+// all parent directories must be searchable, the resolved path is returned
+func readableBy(path string, cred *credentials) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	if cred.uid == 0 {
+		return resolved, nil
+	}
+	groups := userGroups(cred)
+	for dir := filepath.Dir(resolved); ; dir = filepath.Dir(dir) {
+		if err = checkPermission(dir, cred.uid, groups, 01); err != nil {
+			return "", err
+		}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	if err = checkPermission(resolved, cred.uid, groups, 04); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// userGroups returns the primary group and the supplementary groups of the caller
+func userGroups(cred *credentials) map[uint32]bool {
+	groups := map[uint32]bool{cred.gid: true}
+	u, err := user.LookupId(strconv.FormatUint(uint64(cred.uid), 10))
+	if err != nil {
+		return groups
+	}
+	ids, err := u.GroupIds()
+	if err != nil {
+		return groups
+	}
+	for _, id := range ids {
+		if gid, err := strconv.ParseUint(id, 10, 32); err == nil {
+			groups[uint32(gid)] = true
+		}
+	}
+	return groups
+}
+
+// checkPermission checks the owner, group or other permission bits of the file, perm is one of 04, 02 and 01
+func checkPermission(path string, uid uint32, groups map[uint32]bool, perm os.FileMode) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("unknown owner of %s", path)
+	}
+	mode := info.Mode().Perm()
+	switch {
+	case stat.Uid == uid:
+		ok = mode&(perm<<6) != 0
+	case groups[stat.Gid]:
+		ok = mode&(perm<<3) != 0
+	default:
+		ok = mode&perm != 0
+	}
+	if !ok {
+		return fmt.Errorf("%s: %w", path, os.ErrPermission)
+	}
+	return nil
+}
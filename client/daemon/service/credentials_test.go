@@ -0,0 +1,107 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
+	dfdaemongrpc "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
+)
+
+func TestReadableBy(t *testing.T) {
+	assert := testifyassert.New(t)
+	dir, err := ioutil.TempDir("", "readable-test")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	assert.Nil(err)
+	assert.Nil(os.Chmod(dir, 0755))
+
+	write := func(path string, perm os.FileMode) string {
+		assert.Nil(ioutil.WriteFile(path, []byte("test"), perm))
+		assert.Nil(os.Chmod(path, perm))
+		return path
+	}
+	public := write(filepath.Join(dir, "public"), 0644)
+	private := write(filepath.Join(dir, "private"), 0600)
+	closed := filepath.Join(dir, "closed")
+	assert.Nil(os.Mkdir(closed, 0700))
+	hidden := write(filepath.Join(closed, "hidden"), 0644)
+	link := filepath.Join(dir, "link")
+	assert.Nil(os.Symlink(private, link))
+
+	owner := uint32(os.Getuid())
+	// a user and group which own none of the test files
+	other := &credentials{uid: owner + 54321, gid: uint32(os.Getgid()) + 54321}
+
+	testCases := []struct {
+		name       string
+		path       string
+		cred       *credentials
+		resolved   string
+		permission bool
+	}{
+		{name: "other reads public file", path: public, cred: other, resolved: public},
+		{name: "other reads private file", path: private, cred: other, permission: true},
+		{name: "other reads file in closed dir", path: hidden, cred: other, permission: true},
+		{name: "other reads private file by link", path: link, cred: other, permission: true},
+		{name: "owner reads private file by link", path: link, cred: &credentials{uid: owner, gid: other.gid}, resolved: private},
+		{name: "root reads file in closed dir", path: hidden, cred: &credentials{}, resolved: hidden},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolved, err := readableBy(tc.path, tc.cred)
+			if tc.permission {
+				assert.True(errors.Is(err, os.ErrPermission), "expect permission error, got %v", err)
+				return
+			}
+			assert.Nil(err)
+			assert.Equal(tc.resolved, resolved)
+		})
+	}
+
+	_, err = readableBy(filepath.Join(dir, "missing"), other)
+	assert.NotNil(err)
+	assert.False(errors.Is(err, os.ErrPermission), "missing file is not a permission error")
+}
+
+func TestPeerServer_RejectLocalOnly(t *testing.T) {
+	assert := testifyassert.New(t)
+	p := &peerServer{manager: &manager{}}
+
+	_, err := p.ImportTask(context.Background(), &dfdaemongrpc.ImportTaskRequest{Url: "d7y://cache/test", Path: "/etc/shadow"})
+	assert.True(dferrors.CheckError(err, dfcodes.Forbidden), "import should be rejected on the peer server")
+
+	req := &dfdaemongrpc.TaskRequest{TaskId: "task-0"}
+	_, err = p.StatTask(context.Background(), req)
+	assert.True(dferrors.CheckError(err, dfcodes.Forbidden), "stat should be rejected on the peer server")
+	err = p.DeleteTask(context.Background(), req)
+	assert.True(dferrors.CheckError(err, dfcodes.Forbidden), "delete should be rejected on the peer server")
+	_, err = p.ListTasks(context.Background())
+	assert.True(dferrors.CheckError(err, dfcodes.Forbidden), "list should be rejected on the peer server")
+	err = p.CancelTask(context.Background(), req)
+	assert.True(dferrors.CheckError(err, dfcodes.Forbidden), "cancel should be rejected on the peer server")
+}
@@ -19,27 +19,43 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/go-http-utils/headers"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/peer"
 	"d7y.io/dragonfly/v2/client/daemon/storage"
 	"d7y.io/dragonfly/v2/pkg/dfcodes"
 	"d7y.io/dragonfly/v2/pkg/dferrors"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	"d7y.io/dragonfly/v2/pkg/idgen"
 	"d7y.io/dragonfly/v2/pkg/rpc"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/base/common"
 	dfdaemongrpc "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
 	dfdaemonserver "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/server"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
 )
 
+// streamChunkSize is the max data size of one chunk in StreamDownload
+const streamChunkSize = 512 * 1024
+
+// rateWindow is the window used to compute the download rate in Download
+const rateWindow = time.Second
+
 type Manager interface {
 	clientutil.KeepAlive
 	ServeDownload(listener net.Listener) error
@@ -68,12 +84,43 @@ func NewManager(peerHost *scheduler.PeerHost, peerTaskManager peer.PeerTaskManag
 		storageManager:  storageManager,
 	}
 	mgr.downloadServer = rpc.NewServer(mgr, downloadOpts...)
-	mgr.peerServer = rpc.NewServer(mgr, peerOpts...)
+	mgr.peerServer = rpc.NewServer(&peerServer{manager: mgr}, peerOpts...)
 	return mgr, nil
 }
 
+// peerServer serves the daemon rpc for other peers on the tcp port,
+// the rpcs which operate local files and tasks are only served for local callers on the unix socket
+type peerServer struct {
+	*manager
+}
+
+func (p *peerServer) ImportTask(ctx context.Context, req *dfdaemongrpc.ImportTaskRequest) (*dfdaemongrpc.TaskInfo, error) {
+	return nil, localOnlyError("ImportTask")
+}
+
+func (p *peerServer) StatTask(ctx context.Context, req *dfdaemongrpc.TaskRequest) (*dfdaemongrpc.TaskInfo, error) {
+	return nil, localOnlyError("StatTask")
+}
+
+func (p *peerServer) DeleteTask(ctx context.Context, req *dfdaemongrpc.TaskRequest) error {
+	return localOnlyError("DeleteTask")
+}
+
+func (p *peerServer) ListTasks(ctx context.Context) ([]*dfdaemongrpc.TaskInfo, error) {
+	return nil, localOnlyError("ListTasks")
+}
+
+func (p *peerServer) CancelTask(ctx context.Context, req *dfdaemongrpc.TaskRequest) error {
+	return localOnlyError("CancelTask")
+}
+
+func localOnlyError(method string) error {
+	return dferrors.New(dfcodes.Forbidden, fmt.Sprintf("%s is only served for local callers", method))
+}
+
 func (m *manager) ServeDownload(listener net.Listener) error {
-	return m.downloadServer.Serve(listener)
+	// record the credentials of local callers for the rpcs which access local files
+	return m.downloadServer.Serve(&credListener{Listener: listener})
 }
 
 func (m *manager) ServePeer(listener net.Listener) error {
@@ -144,6 +191,7 @@ func (m *manager) Download(ctx context.Context,
 			UrlMata:  req.UrlMeta,
 			PeerId:   clientutil.GenPeerID(m.peerHost),
 			PeerHost: m.peerHost,
+			CdnOnly:  req.CdnOnly,
 		},
 		Output: req.Output,
 	}
@@ -158,6 +206,8 @@ func (m *manager) Download(ctx context.Context,
 			PeerId:          tiny.PeerID,
 			CompletedLength: uint64(len(tiny.Content)),
 			Done:            true,
+			ContentLength:   int64(len(tiny.Content)),
+			TotalPiece:      1,
 		}
 		logger.Infof("tiny file, wrote to output")
 		if req.Uid != 0 && req.Gid != 0 {
@@ -169,6 +219,7 @@ func (m *manager) Download(ctx context.Context,
 
 		return nil
 	}
+	meter := newRateMeter(time.Now())
 	for {
 		select {
 		case p, ok := <-peerTaskProgress:
@@ -186,6 +237,12 @@ func (m *manager) Download(ctx context.Context,
 				PeerId:          p.PeerID,
 				CompletedLength: uint64(p.CompletedLength),
 				Done:            p.PeerTaskDone,
+				ContentLength:   p.ContentLength,
+				TotalPiece:      p.TotalPieces,
+				Rate:            meter.update(time.Now(), p.CompletedLength),
+				PeerLength:      uint64(p.PeerLength),
+				CdnLength:       uint64(p.CdnLength),
+				SourceLength:    uint64(p.SourceLength),
 			}
 			// peer task sets PeerTaskDone to true only once
 			if p.PeerTaskDone {
@@ -209,3 +266,359 @@ func (m *manager) Download(ctx context.Context,
 		}
 	}
 }
+
+// rateMeter computes the download rate of a task with the completed length in the latest rate window,
+// before the first window elapses, the average rate since the task started is used
+type rateMeter struct {
+	start  time.Time
+	length int64
+	rate   uint64
+	// windowed is true after the first rate window elapsed
+	windowed bool
+}
+
+func newRateMeter(start time.Time) *rateMeter {
+	return &rateMeter{
+		start: start,
+	}
+}
+
+// update records the completed length at now and returns the current rate in bytes per second
+func (r *rateMeter) update(now time.Time, length int64) uint64 {
+	elapsed := now.Sub(r.start)
+	if elapsed <= 0 {
+		return r.rate
+	}
+	if elapsed >= rateWindow || !r.windowed {
+		r.rate = uint64(float64(length-r.length) / elapsed.Seconds())
+	}
+	if elapsed >= rateWindow {
+		r.start, r.length, r.windowed = now, length, true
+	}
+	return r.rate
+}
+
+func (m *manager) StreamDownload(ctx context.Context,
+	req *dfdaemongrpc.DownRequest, chunks chan<- *dfdaemongrpc.DownChunk) error {
+	m.Keep()
+	reader, attr, err := m.peerTaskManager.StartStreamPeerTask(ctx, &scheduler.PeerTaskRequest{
+		Url:      req.Url,
+		Filter:   req.Filter,
+		BizId:    req.BizId,
+		UrlMata:  req.UrlMeta,
+		PeerId:   clientutil.GenPeerID(m.peerHost),
+		PeerHost: m.peerHost,
+		CdnOnly:  req.CdnOnly,
+	})
+	if err != nil {
+		return dferrors.New(dfcodes.UnknownError, fmt.Sprintf("%s", err))
+	}
+	// the reader of a reused task holds the task data file
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	first := &dfdaemongrpc.DownChunk{
+		TaskId:        attr[config.HeaderDragonflyTask],
+		PeerId:        attr[config.HeaderDragonflyPeer],
+		ContentLength: -1,
+	}
+	if length, err := strconv.ParseInt(attr[headers.ContentLength], 10, 64); err == nil {
+		first.ContentLength = length
+	}
+
+	var (
+		written int64
+		chunk   = first
+	)
+	for {
+		buf := make([]byte, streamChunkSize)
+		n, err := io.ReadFull(reader, buf)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		// always send the first chunk for the task and peer id, even the content is empty
+		if n > 0 || chunk == first {
+			chunk.Data = buf[:n]
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				logger.Infof("context done due to %s", ctx.Err())
+				return status.Error(codes.Canceled, ctx.Err().Error())
+			}
+			written += int64(n)
+		}
+		if err == io.EOF {
+			logger.Infof("task %s streamed %d bytes", first.TaskId, written)
+			return nil
+		}
+		if err != nil {
+			logger.Errorf("task %s stream error after %d bytes: %s", first.TaskId, written, err)
+			return dferrors.New(dfcodes.UnknownError, err.Error())
+		}
+		chunk = &dfdaemongrpc.DownChunk{}
+	}
+}
+
+func (m *manager) BatchDownload(ctx context.Context,
+	req *dfdaemongrpc.BatchDownRequest, results chan<- *dfdaemongrpc.BatchDownResult) error {
+	m.Keep()
+	parallel := int(req.Parallel)
+	if parallel <= 0 {
+		parallel = config.DefaultParallel
+	}
+
+	var (
+		wg     sync.WaitGroup
+		tokens = make(chan struct{}, parallel)
+	)
+	for i, r := range req.Requests {
+		select {
+		case tokens <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(index int32, r *dfdaemongrpc.DownRequest) {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+			m.downloadInBatch(ctx, index, r, results)
+		}(int32(i), r)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		logger.Infof("batch download context done due to %s", ctx.Err())
+		return status.Error(codes.Canceled, ctx.Err().Error())
+	}
+	return nil
+}
+
+// downloadInBatch downloads one request of batch download, and wraps its results with the request index
+func (m *manager) downloadInBatch(ctx context.Context, index int32,
+	req *dfdaemongrpc.DownRequest, results chan<- *dfdaemongrpc.BatchDownResult) {
+	send := func(result *dfdaemongrpc.BatchDownResult) {
+		select {
+		case results <- result:
+		case <-ctx.Done():
+		}
+	}
+
+	drc := make(chan *dfdaemongrpc.DownResult)
+	errChan := make(chan error, 1)
+	go func() {
+		defer close(drc)
+		errChan <- m.Download(ctx, req, drc)
+	}()
+	for dr := range drc {
+		send(&dfdaemongrpc.BatchDownResult{
+			Index:  index,
+			Result: dr,
+		})
+	}
+
+	err := <-errChan
+	if err == nil {
+		return
+	}
+	logger.Errorf("batch download %s error: %s", req.Url, err)
+	code, msg := dfcodes.UnknownError, err.Error()
+	if e, ok := err.(*dferrors.DfError); ok {
+		code, msg = e.Code, e.Message
+	}
+	send(&dfdaemongrpc.BatchDownResult{
+		Index: index,
+		Error: common.NewGrpcDfError(code, msg),
+	})
+}
+
+func (m *manager) ImportTask(ctx context.Context, req *dfdaemongrpc.ImportTaskRequest) (*dfdaemongrpc.TaskInfo, error) {
+	m.Keep()
+	if !filepath.IsAbs(req.Path) {
+		return nil, dferrors.New(dfcodes.BadRequest, fmt.Sprintf("path %q is not absolute", req.Path))
+	}
+	// the daemon reads the file with its own privileges, so the caller must be able to read it
+	cred, ok := callerCredentials(ctx)
+	if !ok {
+		return nil, dferrors.New(dfcodes.Forbidden, "credentials of the caller are unknown, import is only served on the unix socket")
+	}
+	path, err := readableBy(req.Path, cred)
+	if errors.Is(err, os.ErrPermission) {
+		logger.Warnf("uid %d, gid %d can not import %s: %s", cred.uid, cred.gid, req.Path, err)
+		return nil, dferrors.New(dfcodes.Forbidden, fmt.Sprintf("can not read %s: %s", req.Path, err))
+	} else if err != nil {
+		return nil, dferrors.New(dfcodes.BadRequest, err.Error())
+	}
+	reuse, err := m.peerTaskManager.ImportFile(ctx, &peer.ImportFileRequest{
+		PeerTaskRequest: scheduler.PeerTaskRequest{
+			Url:      req.Url,
+			Filter:   req.Filter,
+			BizId:    req.BizId,
+			UrlMata:  req.UrlMeta,
+			PeerId:   clientutil.GenPeerID(m.peerHost),
+			PeerHost: m.peerHost,
+		},
+		Path: path,
+	})
+	if err != nil {
+		logger.Errorf("import %s as %s error: %s", req.Path, req.Url, err)
+		code := dfcodes.ClientError
+		if errors.Is(err, peer.ErrTaskExists) {
+			code = dfcodes.BadRequest
+		}
+		return nil, dferrors.New(code, err.Error())
+	}
+	info := newTaskInfo(reuse)
+	info.Url = req.Url
+	return info, nil
+}
+
+func (m *manager) StatTask(ctx context.Context, req *dfdaemongrpc.TaskRequest) (*dfdaemongrpc.TaskInfo, error) {
+	m.Keep()
+	taskID := taskRequestID(req)
+	var found *dfdaemongrpc.TaskInfo
+	for _, info := range m.findTasks(taskID, req.PeerId) {
+		// prefer the completed one, then the running one
+		if found == nil || (info.Done && !found.Done) || (info.Running && !found.Done && !found.Running) {
+			found = info
+		}
+	}
+	if found == nil {
+		return nil, dferrors.New(dfcodes.PeerTaskNotFound, fmt.Sprintf("task %s not found", taskID))
+	}
+	return found, nil
+}
+
+func (m *manager) DeleteTask(ctx context.Context, req *dfdaemongrpc.TaskRequest) error {
+	m.Keep()
+	taskID := taskRequestID(req)
+	var deleted, running int
+	// the task may be stored by more than one peer
+	for _, info := range m.findTasks(taskID, req.PeerId) {
+		// running peer tasks are still writing the storage, they must be canceled first
+		if info.Running {
+			running++
+			continue
+		}
+		err := m.storageManager.UnregisterTask(ctx, storage.CommonTaskRequest{
+			PeerID: info.PeerId,
+			TaskID: info.TaskId,
+		})
+		if err != nil {
+			return dferrors.New(dfcodes.ClientError, err.Error())
+		}
+		deleted++
+	}
+	if deleted == 0 {
+		if running > 0 {
+			return dferrors.New(dfcodes.BadRequest, fmt.Sprintf("task %s is running, cancel it first", taskID))
+		}
+		return dferrors.New(dfcodes.PeerTaskNotFound, fmt.Sprintf("task %s not found", taskID))
+	}
+	logger.Infof("deleted %d peer task(s) of task %s", deleted, taskID)
+	return nil
+}
+
+func (m *manager) ListTasks(ctx context.Context) ([]*dfdaemongrpc.TaskInfo, error) {
+	m.Keep()
+	return m.listTasks(), nil
+}
+
+func (m *manager) CancelTask(ctx context.Context, req *dfdaemongrpc.TaskRequest) error {
+	m.Keep()
+	taskID := taskRequestID(req)
+	var canceled int
+	for _, info := range m.findTasks(taskID, req.PeerId) {
+		if info.Running && m.peerTaskManager.CancelPeerTask(info.PeerId) {
+			canceled++
+		}
+	}
+	if canceled == 0 {
+		return dferrors.New(dfcodes.PeerTaskNotFound, fmt.Sprintf("running task %s not found", taskID))
+	}
+	logger.Infof("canceled %d peer task(s) of task %s", canceled, taskID)
+	return nil
+}
+
+// listTasks merges the tasks in local storage and the running peer tasks, sorted by task id and peer id
+func (m *manager) listTasks() []*dfdaemongrpc.TaskInfo {
+	running := map[string]peer.PeerTask{}
+	for _, pt := range m.peerTaskManager.RunningPeerTasks() {
+		running[pt.GetPeerID()] = pt
+	}
+	var tasks []*dfdaemongrpc.TaskInfo
+	for _, stat := range m.storageManager.ListTasks() {
+		info := &dfdaemongrpc.TaskInfo{
+			TaskId:          stat.TaskID,
+			PeerId:          stat.PeerID,
+			ContentLength:   stat.ContentLength,
+			TotalPiece:      stat.TotalPieces,
+			Done:            stat.Done,
+			Url:             stat.URL,
+			CompletedLength: stat.CompletedLength,
+			UploadLength:    stat.UploadedLength,
+			ServedPeers:     int32(stat.ServedPeers),
+		}
+		if !stat.LastAccess.IsZero() {
+			info.LastAccess = stat.LastAccess.UnixNano()
+		}
+		if _, ok := running[stat.PeerID]; ok {
+			info.Running = true
+			delete(running, stat.PeerID)
+		}
+		tasks = append(tasks, info)
+	}
+	// the peer tasks which are not registered in storage yet, like waiting for the first peer packet
+	for _, pt := range running {
+		tasks = append(tasks, &dfdaemongrpc.TaskInfo{
+			TaskId:          pt.GetTaskID(),
+			PeerId:          pt.GetPeerID(),
+			ContentLength:   pt.GetContentLength(),
+			TotalPiece:      pt.GetTotalPieces(),
+			Url:             pt.GetURL(),
+			CompletedLength: pt.GetCompletedLength(),
+			Running:         true,
+		})
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].TaskId != tasks[j].TaskId {
+			return tasks[i].TaskId < tasks[j].TaskId
+		}
+		return tasks[i].PeerId < tasks[j].PeerId
+	})
+	return tasks
+}
+
+// findTasks returns the tasks of the task id, only the task of the peer is returned when peerID is not empty
+func (m *manager) findTasks(taskID, peerID string) []*dfdaemongrpc.TaskInfo {
+	var tasks []*dfdaemongrpc.TaskInfo
+	for _, info := range m.listTasks() {
+		if info.TaskId == taskID && (peerID == "" || info.PeerId == peerID) {
+			tasks = append(tasks, info)
+		}
+	}
+	return tasks
+}
+
+// taskRequestID returns the task id of the request, it is generated with the url when task id is not set
+func taskRequestID(req *dfdaemongrpc.TaskRequest) string {
+	if req.TaskId != "" {
+		return req.TaskId
+	}
+	return idgen.GenerateTaskID(req.Url, req.Filter, req.UrlMeta, req.BizId)
+}
+
+func newTaskInfo(reuse *storage.ReusePeerTask) *dfdaemongrpc.TaskInfo {
+	return &dfdaemongrpc.TaskInfo{
+		TaskId:          reuse.TaskID,
+		PeerId:          reuse.PeerID,
+		ContentLength:   reuse.ContentLength,
+		TotalPiece:      reuse.TotalPieces,
+		Done:            true,
+		CompletedLength: reuse.ContentLength,
+	}
+}
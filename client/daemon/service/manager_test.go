@@ -17,22 +17,33 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math/rand"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/go-http-utils/headers"
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/phayes/freeport"
 	testifyassert "github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/daemon/peer"
+	"d7y.io/dragonfly/v2/client/daemon/storage"
 	mock_peer "d7y.io/dragonfly/v2/client/daemon/test/mock/peer"
 	mock_storage "d7y.io/dragonfly/v2/client/daemon/test/mock/storage"
 	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+	"d7y.io/dragonfly/v2/pkg/idgen"
 	"d7y.io/dragonfly/v2/pkg/rpc"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	dfdaemongrpc "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
@@ -64,6 +75,8 @@ func TestDownloadManager_ServeDownload(t *testing.T) {
 						PeerID:          "",
 						ContentLength:   100,
 						CompletedLength: int64(i),
+						TotalPieces:     1,
+						CdnLength:       int64(i),
 						PeerTaskDone:    i == 100,
 						DoneCallback:    func() {},
 					}
@@ -115,6 +128,162 @@ func TestDownloadManager_ServeDownload(t *testing.T) {
 	}
 	assert.NotNil(lastResult)
 	assert.True(lastResult.Done)
+	assert.Equal(int64(100), lastResult.ContentLength)
+	assert.Equal(int32(1), lastResult.TotalPiece)
+	assert.Equal(uint64(100), lastResult.CdnLength)
+	assert.Equal(uint64(0), lastResult.PeerLength+lastResult.SourceLength)
+}
+
+func TestRateMeter(t *testing.T) {
+	assert := testifyassert.New(t)
+	start := time.Now()
+	r := newRateMeter(start)
+
+	assert.Equal(uint64(0), r.update(start, 100), "no elapsed time")
+	assert.Equal(uint64(1000), r.update(start.Add(100*time.Millisecond), 100), "average rate before the first window")
+	assert.Equal(uint64(1500), r.update(start.Add(time.Second), 1500), "rate of the first window")
+	assert.Equal(uint64(1500), r.update(start.Add(1500*time.Millisecond), 5000), "keep the rate inside a window")
+	assert.Equal(uint64(3500), r.update(start.Add(2*time.Second), 5000), "rate of the second window")
+}
+
+func TestDownloadManager_ServeStreamDownload(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	content := make([]byte, streamChunkSize*2+100)
+	rand.Read(content)
+	mockPeerTaskManager := mock_peer.NewMockPeerTaskManager(ctrl)
+	mockPeerTaskManager.EXPECT().StartStreamPeerTask(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req *scheduler.PeerTaskRequest) (io.Reader, map[string]string, error) {
+			return bytes.NewBuffer(content), map[string]string{
+				headers.ContentLength:      fmt.Sprintf("%d", len(content)),
+				config.HeaderDragonflyTask: "task-1",
+				config.HeaderDragonflyPeer: req.PeerId,
+			}, nil
+		})
+	m := &manager{
+		KeepAlive:       clientutil.NewKeepAlive("test"),
+		peerHost:        &scheduler.PeerHost{},
+		peerTaskManager: mockPeerTaskManager,
+	}
+	m.downloadServer = rpc.NewServer(m)
+	port, err := freeport.GetFreePort()
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	assert.Nil(err, "get free port should be ok")
+	go func() {
+		m.ServeDownload(ln)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := grpc.Dial(fmt.Sprintf(":%d", port), grpc.WithInsecure())
+	assert.Nil(err, "grpc dial should be ok")
+	defer conn.Close()
+	down, err := dfdaemongrpc.NewDaemonClient(conn).StreamDownload(context.Background(),
+		&dfdaemongrpc.DownRequest{Url: "http://localhost/test"})
+	assert.Nil(err, "client stream download grpc call should be ok")
+
+	var (
+		chunks []*dfdaemongrpc.DownChunk
+		data   []byte
+	)
+	for {
+		chunk, err := down.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(err)
+		if err != nil {
+			break
+		}
+		chunks = append(chunks, chunk)
+		data = append(data, chunk.Data...)
+	}
+	assert.Equal(3, len(chunks))
+	assert.Equal("task-1", chunks[0].TaskId)
+	assert.Equal(int64(len(content)), chunks[0].ContentLength)
+	assert.Equal(content, data)
+}
+
+func TestDownloadManager_ServeBatchDownload(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPeerTaskManager := mock_peer.NewMockPeerTaskManager(ctrl)
+	mockPeerTaskManager.EXPECT().StartFilePeerTask(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+		func(ctx context.Context, req *peer.FilePeerTaskRequest) (chan *peer.FilePeerTaskProgress, bool, error) {
+			if req.Url == "http://localhost/fail" {
+				return nil, false, fmt.Errorf("mock error")
+			}
+			ch := make(chan *peer.FilePeerTaskProgress)
+			go func() {
+				for i := 0; i <= 10; i++ {
+					ch <- &peer.FilePeerTaskProgress{
+						State: &peer.ProgressState{
+							Success: true,
+						},
+						ContentLength:   10,
+						CompletedLength: int64(i),
+						PeerTaskDone:    i == 10,
+						DoneCallback:    func() {},
+					}
+				}
+				close(ch)
+			}()
+			return ch, false, nil
+		})
+	m := &manager{
+		KeepAlive:       clientutil.NewKeepAlive("test"),
+		peerHost:        &scheduler.PeerHost{},
+		peerTaskManager: mockPeerTaskManager,
+	}
+	m.downloadServer = rpc.NewServer(m)
+	port, err := freeport.GetFreePort()
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	assert.Nil(err, "get free port should be ok")
+	go func() {
+		m.ServeDownload(ln)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := grpc.Dial(fmt.Sprintf(":%d", port), grpc.WithInsecure())
+	assert.Nil(err, "grpc dial should be ok")
+	defer conn.Close()
+	request := &dfdaemongrpc.BatchDownRequest{
+		Requests: []*dfdaemongrpc.DownRequest{
+			{Url: "http://localhost/test1", Output: "./testdata/file1"},
+			{Url: "http://localhost/fail", Output: "./testdata/file2"},
+			{Url: "http://localhost/test3", Output: "./testdata/file3"},
+		},
+		Parallel: 2,
+	}
+	down, err := dfdaemongrpc.NewDaemonClient(conn).BatchDownload(context.Background(), request)
+	assert.Nil(err, "client batch download grpc call should be ok")
+
+	var (
+		done   = map[int32]bool{}
+		failed = map[int32]bool{}
+	)
+	for {
+		result, err := down.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(err)
+		if err != nil {
+			break
+		}
+		if result.Error != nil {
+			failed[result.Index] = true
+			continue
+		}
+		if result.Result.Done {
+			done[result.Index] = true
+		}
+	}
+	assert.Equal(map[int32]bool{0: true, 2: true}, done)
+	assert.Equal(map[int32]bool{1: true}, failed)
 }
 
 func TestDownloadManager_ServePeer(t *testing.T) {
@@ -221,3 +390,192 @@ func TestDownloadManager_ServePeer(t *testing.T) {
 		assert.Equal(tc.responsePieceSize, len(response.PieceInfos))
 	}
 }
+
+func TestDownloadManager_ServeTaskCache(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "import-test")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	assert.Nil(err)
+	file := filepath.Join(dir, "test")
+	assert.Nil(ioutil.WriteFile(file, []byte("test"), 0644))
+
+	var (
+		url    = "d7y://cache/test"
+		taskID = idgen.GenerateTaskID(url, "", nil, "")
+		reuse  = &storage.ReusePeerTask{
+			PeerTaskMetaData: storage.PeerTaskMetaData{
+				PeerID: "peer-0",
+				TaskID: taskID,
+			},
+			ContentLength: 100,
+			TotalPieces:   1,
+		}
+	)
+	mockPeerTaskManager := mock_peer.NewMockPeerTaskManager(ctrl)
+	mockPeerTaskManager.EXPECT().ImportFile(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req *peer.ImportFileRequest) (*storage.ReusePeerTask, error) {
+			assert.Equal(url, req.Url)
+			assert.Equal(file, req.Path)
+			return reuse, nil
+		})
+	mockPeerTaskManager.EXPECT().RunningPeerTasks().Return(nil).AnyTimes()
+	stat := &storage.TaskStat{
+		PeerTaskMetaData: reuse.PeerTaskMetaData,
+		URL:              url,
+		ContentLength:    reuse.ContentLength,
+		TotalPieces:      reuse.TotalPieces,
+		CompletedLength:  reuse.ContentLength,
+		Done:             true,
+	}
+	mockStorageManger := mock_storage.NewMockManager(ctrl)
+	gomock.InOrder(
+		mockStorageManger.EXPECT().ListTasks().Return([]*storage.TaskStat{stat}),
+		mockStorageManger.EXPECT().ListTasks().Return([]*storage.TaskStat{stat}),
+		mockStorageManger.EXPECT().UnregisterTask(gomock.Any(), storage.CommonTaskRequest{
+			PeerID: reuse.PeerID,
+			TaskID: taskID,
+		}).Return(nil),
+		mockStorageManger.EXPECT().ListTasks().Return(nil),
+	)
+	m := &manager{
+		KeepAlive:       clientutil.NewKeepAlive("test"),
+		peerHost:        &scheduler.PeerHost{},
+		peerTaskManager: mockPeerTaskManager,
+		storageManager:  mockStorageManger,
+	}
+	m.downloadServer = rpc.NewServer(m)
+	sock := filepath.Join(dir, "dfdaemon.sock")
+	ln, err := net.Listen("unix", sock)
+	assert.Nil(err, "listen unix socket should be ok")
+	go func() {
+		m.ServeDownload(ln)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := grpc.Dial(sock, grpc.WithInsecure(), grpc.WithContextDialer(
+		func(ctx context.Context, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", addr)
+		}))
+	assert.Nil(err, "grpc dial should be ok")
+	defer conn.Close()
+	client := dfdaemongrpc.NewDaemonClient(conn)
+
+	_, err = client.ImportTask(context.Background(), &dfdaemongrpc.ImportTaskRequest{Url: url, Path: "test"})
+	assert.NotNil(err, "relative path should be rejected")
+	_, err = client.ImportTask(context.Background(), &dfdaemongrpc.ImportTaskRequest{Url: url, Path: filepath.Join(dir, "missing")})
+	assert.NotNil(err, "missing file should be rejected")
+	info, err := client.ImportTask(context.Background(), &dfdaemongrpc.ImportTaskRequest{Url: url, Path: file})
+	assert.Nil(err)
+	assert.Equal(taskID, info.TaskId)
+	assert.Equal(int64(100), info.ContentLength)
+
+	info, err = client.StatTask(context.Background(), &dfdaemongrpc.TaskRequest{Url: url})
+	assert.Nil(err)
+	assert.Equal("peer-0", info.PeerId)
+	assert.True(info.Done)
+
+	_, err = client.DeleteTask(context.Background(), &dfdaemongrpc.TaskRequest{Url: url})
+	assert.Nil(err)
+	_, err = client.StatTask(context.Background(), &dfdaemongrpc.TaskRequest{Url: url})
+	assert.NotNil(err, "deleted task should not be found")
+}
+
+func TestDownloadManager_ServeTaskManagement(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		lastAccess = time.Now()
+		// task-0 is completed, task-1 is downloading, task-2 is waiting for scheduling
+		stats = []*storage.TaskStat{
+			{
+				PeerTaskMetaData: storage.PeerTaskMetaData{PeerID: "peer-0", TaskID: "task-0"},
+				URL:              "http://example.com/0",
+				ContentLength:    100,
+				TotalPieces:      1,
+				CompletedLength:  100,
+				Done:             true,
+				LastAccess:       lastAccess,
+				UploadedLength:   200,
+				ServedPeers:      2,
+			},
+			{
+				PeerTaskMetaData: storage.PeerTaskMetaData{PeerID: "peer-1", TaskID: "task-1"},
+				URL:              "http://example.com/1",
+				ContentLength:    100,
+				TotalPieces:      2,
+				CompletedLength:  50,
+				LastAccess:       lastAccess,
+			},
+		}
+	)
+	runningTask := mock_peer.NewMockPeerTask(ctrl)
+	runningTask.EXPECT().GetPeerID().Return("peer-1").AnyTimes()
+	waitingTask := mock_peer.NewMockPeerTask(ctrl)
+	waitingTask.EXPECT().GetPeerID().Return("peer-2").AnyTimes()
+	waitingTask.EXPECT().GetTaskID().Return("task-2").AnyTimes()
+	waitingTask.EXPECT().GetURL().Return("http://example.com/2").AnyTimes()
+	waitingTask.EXPECT().GetContentLength().Return(int64(-1)).AnyTimes()
+	waitingTask.EXPECT().GetTotalPieces().Return(int32(-1)).AnyTimes()
+	waitingTask.EXPECT().GetCompletedLength().Return(int64(0)).AnyTimes()
+
+	mockPeerTaskManager := mock_peer.NewMockPeerTaskManager(ctrl)
+	mockPeerTaskManager.EXPECT().RunningPeerTasks().Return([]peer.PeerTask{runningTask, waitingTask}).AnyTimes()
+	mockPeerTaskManager.EXPECT().CancelPeerTask("peer-1").Return(true)
+	mockStorageManger := mock_storage.NewMockManager(ctrl)
+	mockStorageManger.EXPECT().ListTasks().Return(stats).AnyTimes()
+
+	m := &manager{
+		KeepAlive:       clientutil.NewKeepAlive("test"),
+		peerHost:        &scheduler.PeerHost{},
+		peerTaskManager: mockPeerTaskManager,
+		storageManager:  mockStorageManger,
+	}
+	m.downloadServer = rpc.NewServer(m)
+	port, err := freeport.GetFreePort()
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	assert.Nil(err, "get free port should be ok")
+	go func() {
+		m.ServeDownload(ln)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := grpc.Dial(fmt.Sprintf(":%d", port), grpc.WithInsecure())
+	assert.Nil(err, "grpc dial should be ok")
+	defer conn.Close()
+	client := dfdaemongrpc.NewDaemonClient(conn)
+
+	list, err := client.ListTasks(context.Background(), new(empty.Empty))
+	assert.Nil(err)
+	assert.Len(list.Tasks, 3)
+	assert.Equal("task-0", list.Tasks[0].TaskId)
+	assert.Equal("http://example.com/0", list.Tasks[0].Url)
+	assert.True(list.Tasks[0].Done)
+	assert.False(list.Tasks[0].Running)
+	assert.Equal(lastAccess.UnixNano(), list.Tasks[0].LastAccess)
+	assert.Equal(int64(200), list.Tasks[0].UploadLength)
+	assert.Equal(int32(2), list.Tasks[0].ServedPeers)
+	assert.Equal(int64(50), list.Tasks[1].CompletedLength)
+	assert.True(list.Tasks[1].Running)
+	assert.Equal("peer-2", list.Tasks[2].PeerId)
+	assert.Equal("http://example.com/2", list.Tasks[2].Url)
+	assert.True(list.Tasks[2].Running)
+	assert.Equal(int64(0), list.Tasks[2].LastAccess)
+
+	info, err := client.StatTask(context.Background(), &dfdaemongrpc.TaskRequest{TaskId: "task-1"})
+	assert.Nil(err)
+	assert.True(info.Running)
+
+	_, err = client.DeleteTask(context.Background(), &dfdaemongrpc.TaskRequest{TaskId: "task-1"})
+	assert.NotNil(err, "running task should not be deleted")
+	_, err = client.CancelTask(context.Background(), &dfdaemongrpc.TaskRequest{TaskId: "task-1"})
+	assert.Nil(err)
+	_, err = client.CancelTask(context.Background(), &dfdaemongrpc.TaskRequest{TaskId: "task-0"})
+	assert.NotNil(err, "completed task should not be canceled")
+}
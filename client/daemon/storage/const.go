@@ -26,6 +26,9 @@ const (
 	taskData     = "data"
 	taskMetaData = "metadata"
 
+	// taskMetaURL is the key of the task url in TaskMeta
+	taskMetaURL = "url"
+
 	defaultFileMode      = os.FileMode(0644)
 	defaultDirectoryMode = os.FileMode(0755)
 )
@@ -24,8 +24,11 @@ import (
 	"os"
 	"path"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/pkg/dferrors"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
@@ -48,6 +51,41 @@ type localTaskStore struct {
 	lastAccess    time.Time
 	reclaimMarked bool
 	gcCallback    func(CommonTaskRequest)
+
+	// readers stands the count of running readers of task data, like uploading to other peers
+	readers int32
+
+	// uploadedLength and servedPeers stand the data requested by other peers, they are not persisted
+	uploadedLength int64
+	servedPeers    map[string]struct{}
+}
+
+// dataFile decreases the readers of task store when closed
+type dataFile struct {
+	*os.File
+	store  *localTaskStore
+	closed int32
+}
+
+func (f *dataFile) Close() error {
+	if atomic.CompareAndSwapInt32(&f.closed, 0, 1) {
+		atomic.AddInt32(&f.store.readers, -1)
+	}
+	return f.File.Close()
+}
+
+func (t *localTaskStore) openDataFile() (*dataFile, error) {
+	file, err := os.Open(t.DataFilePath)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt32(&t.readers, 1)
+	return &dataFile{File: file, store: t}, nil
+}
+
+// reading returns whether there are running readers of task data
+func (t *localTaskStore) reading() bool {
+	return atomic.LoadInt32(&t.readers) > 0
 }
 
 func (t *localTaskStore) touch() {
@@ -133,16 +171,15 @@ func (t *localTaskStore) UpdateTask(ctx context.Context, req *UpdateTaskRequest)
 	if t.TotalPieces == 0 {
 		t.TotalPieces = req.TotalPieces
 	}
+	if req.PieceSize > 0 {
+		t.PieceSize = req.PieceSize
+	}
 	return nil
 }
 
 // GetPiece get a LimitReadCloser from task data with seeked, caller should read bytes and close it.
 func (t *localTaskStore) ReadPiece(ctx context.Context, req *ReadPieceRequest) (io.Reader, io.Closer, error) {
 	t.touch()
-	file, err := os.Open(t.DataFilePath)
-	if err != nil {
-		return nil, nil, err
-	}
 	if req.Num != -1 {
 		t.RLock()
 		if piece, ok := t.persistentMetadata.Pieces[req.Num]; ok {
@@ -153,17 +190,47 @@ func (t *localTaskStore) ReadPiece(ctx context.Context, req *ReadPieceRequest) (
 			return nil, nil, ErrPieceNotFound
 		}
 	}
+	file, err := t.openDataFile()
+	if err != nil {
+		return nil, nil, err
+	}
 	// who call ReadPiece, who close the io.ReadCloser
 	if _, err = file.Seek(req.Range.Start, io.SeekStart); err != nil {
+		file.Close()
 		return nil, nil, err
 	}
-	return io.LimitReader(file, req.Range.Length), file, nil
+	if req.SrcPeerID != "" {
+		t.addUpload(req.SrcPeerID, req.Range.Length)
+	}
+	return io.LimitReader(file.File, req.Range.Length), file, nil
+}
+
+// addUpload records the data length requested by the peer, it is counted before transferring,
+// so the data file can still be sent with zero copy
+func (t *localTaskStore) addUpload(peerID string, length int64) {
+	atomic.AddInt64(&t.uploadedLength, length)
+	t.Lock()
+	if t.servedPeers == nil {
+		t.servedPeers = map[string]struct{}{}
+	}
+	t.servedPeers[peerID] = struct{}{}
+	t.Unlock()
 }
 
 func (t *localTaskStore) Store(ctx context.Context, req *StoreRequest) error {
+	t.touch()
 	if req.TotalPieces > 0 {
 		t.TotalPieces = req.TotalPieces
 	}
+	if req.Digest != "" {
+		if err := t.verifyDigest(req.Digest); err != nil {
+			t.Errorf("verify task data digest error: %s", err)
+			return err
+		}
+	}
+	t.Lock()
+	t.Done = true
+	t.Unlock()
 	err := t.saveMetadata()
 	if err != nil {
 		t.Warnf("save task metadata error: %s", err)
@@ -211,6 +278,42 @@ func (t *localTaskStore) Store(ctx context.Context, req *StoreRequest) error {
 	return err
 }
 
+// ReadAllPieces returns a reader of the whole task data, it is used for completed tasks only
+func (t *localTaskStore) ReadAllPieces(ctx context.Context, req *PeerTaskMetaData) (io.ReadCloser, error) {
+	t.touch()
+	file, err := t.openDataFile()
+	if err != nil {
+		return nil, err
+	}
+	t.Debugf("read all pieces of task data")
+	return file, nil
+}
+
+// completed returns whether the task data is completed and not going to be reclaimed
+func (t *localTaskStore) completed() bool {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Done && !t.reclaimMarked
+}
+
+// verifyDigest checks the whole task data with the digest in the form of algorithm:hex
+func (t *localTaskStore) verifyDigest(digest string) error {
+	algorithm, encoded, err := digestutils.Parse(digest)
+	if err != nil {
+		return err
+	}
+	actual, err := digestutils.HashFile(algorithm, t.DataFilePath)
+	if err != nil {
+		return err
+	}
+	if actual != encoded {
+		return errors.Wrapf(digestutils.ErrDigestNotMatch, "desired: %s, actual: %s",
+			digest, digestutils.Format(algorithm, actual))
+	}
+	t.Debugf("task data digest match: %s", digest)
+	return nil
+}
+
 func (t *localTaskStore) GetPieces(ctx context.Context, req *base.PieceTaskRequest) (*base.PiecePacket, error) {
 	var pieces []*base.PieceInfo
 	t.RLock()
@@ -239,11 +342,54 @@ func (t *localTaskStore) GetPieces(ctx context.Context, req *base.PieceTaskReque
 		TotalPiece:    t.TotalPieces,
 		ContentLength: t.ContentLength,
 		PieceMd5Sign:  t.PieceMd5Sign,
+		PieceSize:     t.PieceSize,
 	}, nil
 }
 
+// dataSize returns the size of task data which is already written
+func (t *localTaskStore) dataSize() int64 {
+	t.RLock()
+	defer t.RUnlock()
+	if t.Done && t.ContentLength > 0 {
+		return t.ContentLength
+	}
+	var size int64
+	for _, piece := range t.Pieces {
+		size += piece.Range.Length
+	}
+	return size
+}
+
+// stat returns the snapshot of the task
+func (t *localTaskStore) stat() *TaskStat {
+	completedLength := t.dataSize()
+	t.RLock()
+	defer t.RUnlock()
+	return &TaskStat{
+		PeerTaskMetaData: PeerTaskMetaData{
+			PeerID: t.PeerID,
+			TaskID: t.TaskID,
+		},
+		URL:             t.TaskMeta[taskMetaURL],
+		ContentLength:   t.ContentLength,
+		TotalPieces:     t.TotalPieces,
+		CompletedLength: completedLength,
+		Done:            t.Done,
+		LastAccess:      t.lastAccess,
+		UploadedLength:  atomic.LoadInt64(&t.uploadedLength),
+		ServedPeers:     len(t.servedPeers),
+	}
+}
+
+// pinned returns whether the task is imported, the pinned task is only reclaimed when unregistered
+func (t *localTaskStore) pinned() bool {
+	t.RLock()
+	defer t.RUnlock()
+	return t.Seed != nil
+}
+
 func (t *localTaskStore) CanReclaim() bool {
-	return t.lastAccess.Add(t.expireTime).Before(time.Now())
+	return !t.pinned() && t.lastAccess.Add(t.expireTime).Before(time.Now())
 }
 
 // MarkReclaim will try to invoke gcCallback (normal leave peer task)
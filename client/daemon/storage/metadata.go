@@ -18,6 +18,7 @@ package storage
 
 import (
 	"io"
+	"time"
 
 	"d7y.io/dragonfly/v2/client/clientutil"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
@@ -29,10 +30,25 @@ type persistentMetadata struct {
 	TaskMeta      map[string]string       `json:"taskMeta"`
 	ContentLength int64                   `json:"contentLength"`
 	TotalPieces   int32                   `json:"totalPieces"`
+	PieceSize     int32                   `json:"pieceSize,omitempty"`
 	PeerID        string                  `json:"peerID"`
 	Pieces        map[int32]PieceMetaData `json:"pieces"`
 	PieceMd5Sign  string                  `json:"pieceMd5Sign"`
 	DataFilePath  string                  `json:"dataFilePath"`
+	// Done indicates the task data is completed and verified, it can be reused by other requests
+	Done bool `json:"done,omitempty"`
+	// Seed is set for the imported tasks, they are pinned in storage until unregistered
+	// and registered to scheduler as seeds again after reloaded
+	Seed *SeedRequest `json:"seed,omitempty"`
+}
+
+// SeedRequest holds the fields of the imported task to register it to scheduler as a seed,
+// the task id is generated with them
+type SeedRequest struct {
+	URL     string        `json:"url"`
+	Filter  string        `json:"filter,omitempty"`
+	BizID   string        `json:"bizID,omitempty"`
+	URLMeta *base.UrlMeta `json:"urlMeta,omitempty"`
 }
 
 type PeerTaskMetaData struct {
@@ -40,6 +56,36 @@ type PeerTaskMetaData struct {
 	TaskID string `json:"taskID,omitempty"`
 }
 
+// ReusePeerTask is a completed task in storage which can be reused without downloading again
+type ReusePeerTask struct {
+	PeerTaskMetaData
+	ContentLength int64
+	TotalPieces   int32
+}
+
+// SeedTask is a completed imported task in storage
+type SeedTask struct {
+	PeerTaskMetaData
+	SeedRequest
+	ContentLength int64
+	TotalPieces   int32
+	PieceSize     int32
+}
+
+// TaskStat is a snapshot of a task in storage
+type TaskStat struct {
+	PeerTaskMetaData
+	URL             string
+	ContentLength   int64
+	TotalPieces     int32
+	CompletedLength int64
+	Done            bool
+	LastAccess      time.Time
+	// UploadedLength and ServedPeers stand the data requested by other peers since the task is loaded
+	UploadedLength int64
+	ServedPeers    int
+}
+
 type PieceMetaData struct {
 	Num    int32            `json:"num,omitempty"`
 	Md5    string           `json:"md5,omitempty"`
@@ -56,9 +102,14 @@ type CommonTaskRequest struct {
 
 type RegisterTaskRequest struct {
 	CommonTaskRequest
+	// URL is the url of the task, it is only used for displaying
+	URL           string
 	ContentLength int64
 	TotalPieces   int32
-	GCCallback    func(CommonTaskRequest)
+	PieceSize     int32
+	// Seed pins the task in storage, it is only set for the imported tasks
+	Seed       *SeedRequest
+	GCCallback func(CommonTaskRequest)
 }
 
 type WritePieceRequest struct {
@@ -72,15 +123,20 @@ type StoreRequest struct {
 	CommonTaskRequest
 	MetadataOnly bool
 	TotalPieces  int32
+	// Digest is the expected digest of task data in the form of algorithm:hex, verified before storing when it is set
+	Digest string
 }
 
 type ReadPieceRequest struct {
 	PeerTaskMetaData
 	PieceMetaData
+	// SrcPeerID is the peer which downloads the piece, it is set when uploading to other peers
+	SrcPeerID string
 }
 
 type UpdateTaskRequest struct {
 	PeerTaskMetaData
 	ContentLength int64
 	TotalPieces   int32
+	PieceSize     int32
 }
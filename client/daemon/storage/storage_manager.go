@@ -25,6 +25,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -39,6 +40,8 @@ import (
 	"d7y.io/dragonfly/v2/client/daemon/gc"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/unit"
+	"d7y.io/dragonfly/v2/pkg/util/fileutils"
 )
 
 type TaskStorageDriver interface {
@@ -53,6 +56,9 @@ type TaskStorageDriver interface {
 
 	UpdateTask(ctx context.Context, req *UpdateTaskRequest) error
 
+	// ReadAllPieces returns a reader of the whole data of a completed task, caller should close it.
+	ReadAllPieces(ctx context.Context, req *PeerTaskMetaData) (io.ReadCloser, error)
+
 	// Store stores task data to the target path
 	Store(ctx context.Context, req *StoreRequest) error
 }
@@ -75,6 +81,14 @@ type Manager interface {
 	clientutil.KeepAlive
 	// RegisterTask registers a task in storage driver
 	RegisterTask(ctx context.Context, req RegisterTaskRequest) error
+	// UnregisterTask leaves the task and reclaims its storage data immediately
+	UnregisterTask(ctx context.Context, req CommonTaskRequest) error
+	// FindCompletedTask returns a completed task stored by any peer, nil if not found
+	FindCompletedTask(taskID string) *ReusePeerTask
+	// ListTasks returns the stats of all tasks in storage
+	ListTasks() []*TaskStat
+	// ListSeedTasks returns the completed imported tasks in storage
+	ListSeedTasks() []*SeedTask
 	// CleanUp cleans all storage data
 	CleanUp()
 }
@@ -187,6 +201,26 @@ func (s *storageManager) RegisterTask(ctx context.Context, req RegisterTaskReque
 	return nil
 }
 
+func (s *storageManager) UnregisterTask(ctx context.Context, req CommonTaskRequest) error {
+	key := PeerTaskMetaData{
+		PeerID: req.PeerID,
+		TaskID: req.TaskID,
+	}
+	t, ok := s.tasks.Load(key)
+	if !ok {
+		return ErrTaskNotFound
+	}
+	// mark first, so the task will not be reused
+	t.(*localTaskStore).MarkReclaim()
+	s.tasks.Delete(key)
+	if err := t.(*localTaskStore).Reclaim(); err != nil {
+		logger.Errorf("unregister task %s/%s error: %s", req.TaskID, req.PeerID, err)
+		return err
+	}
+	logger.Infof("task %s/%s unregistered", req.TaskID, req.PeerID)
+	return nil
+}
+
 func (s *storageManager) WritePiece(ctx context.Context, req *WritePieceRequest) (int64, error) {
 	t, ok := s.LoadTask(
 		PeerTaskMetaData{
@@ -225,6 +259,69 @@ func (s *storageManager) Store(ctx context.Context, req *StoreRequest) error {
 	return t.(TaskStorageDriver).Store(ctx, req)
 }
 
+func (s *storageManager) ReadAllPieces(ctx context.Context, req *PeerTaskMetaData) (io.ReadCloser, error) {
+	t, ok := s.LoadTask(*req)
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return t.(TaskStorageDriver).ReadAllPieces(ctx, req)
+}
+
+func (s *storageManager) FindCompletedTask(taskID string) *ReusePeerTask {
+	s.Keep()
+	var reuse *ReusePeerTask
+	s.tasks.Range(func(key, value interface{}) bool {
+		if key.(PeerTaskMetaData).TaskID != taskID {
+			return true
+		}
+		t := value.(*localTaskStore)
+		if !t.completed() {
+			return true
+		}
+		t.RLock()
+		reuse = &ReusePeerTask{
+			PeerTaskMetaData: key.(PeerTaskMetaData),
+			ContentLength:    t.ContentLength,
+			TotalPieces:      t.TotalPieces,
+		}
+		t.RUnlock()
+		return false
+	})
+	return reuse
+}
+
+func (s *storageManager) ListTasks() []*TaskStat {
+	var stats []*TaskStat
+	s.tasks.Range(func(key, value interface{}) bool {
+		stats = append(stats, value.(*localTaskStore).stat())
+		return true
+	})
+	return stats
+}
+
+func (s *storageManager) ListSeedTasks() []*SeedTask {
+	var seeds []*SeedTask
+	s.tasks.Range(func(key, value interface{}) bool {
+		t := value.(*localTaskStore)
+		if !t.completed() {
+			return true
+		}
+		t.RLock()
+		if t.Seed != nil {
+			seeds = append(seeds, &SeedTask{
+				PeerTaskMetaData: key.(PeerTaskMetaData),
+				SeedRequest:      *t.Seed,
+				ContentLength:    t.ContentLength,
+				TotalPieces:      t.TotalPieces,
+				PieceSize:        t.PieceSize,
+			})
+		}
+		t.RUnlock()
+		return true
+	})
+	return seeds
+}
+
 func (s *storageManager) GetPieces(ctx context.Context, req *base.PieceTaskRequest) (*base.PiecePacket, error) {
 	t, ok := s.LoadTask(
 		PeerTaskMetaData{
@@ -267,11 +364,13 @@ func (s *storageManager) CreateTask(req RegisterTaskRequest) error {
 		persistentMetadata: persistentMetadata{
 			StoreStrategy: string(s.storeStrategy),
 			TaskID:        req.TaskID,
-			TaskMeta:      map[string]string{},
+			TaskMeta:      map[string]string{taskMetaURL: req.URL},
 			ContentLength: req.ContentLength,
 			TotalPieces:   req.TotalPieces,
+			PieceSize:     req.PieceSize,
 			PeerID:        req.PeerID,
 			Pieces:        map[int32]PieceMetaData{},
+			Seed:          req.Seed,
 		},
 		gcCallback:       s.gcCallback,
 		RWMutex:          &sync.RWMutex{},
@@ -471,6 +570,12 @@ func (s *storageManager) TryGC() (bool, error) {
 			logger.Warnf("task %s/%s marked, but not found", key.TaskID, key.PeerID)
 			continue
 		}
+		// never reclaim tasks with running uploads, try again in next gc
+		if task.(*localTaskStore).reading() {
+			logger.Infof("task %s/%s marked, but it is uploading, reclaim later", key.TaskID, key.PeerID)
+			markedTasks = append(markedTasks, key)
+			continue
+		}
 		_, span := tracer.Start(context.Background(), config.SpanPeerGC)
 		span.SetAttributes(config.AttributePeerId.String(task.(*localTaskStore).PeerID))
 		span.SetAttributes(config.AttributeTaskId.String(task.(*localTaskStore).TaskID))
@@ -487,9 +592,117 @@ func (s *storageManager) TryGC() (bool, error) {
 	}
 	logger.Infof("marked %d task(s), reclaimed %d task(s)", len(markedTasks), len(s.markedReclaimTasks))
 	s.markedReclaimTasks = markedTasks
+	s.tryGCBySpace()
 	return true, nil
 }
 
+// tryGCBySpace reclaims least recently used tasks when the usage of DataPath exceeds the high watermark,
+// until the usage falls below the low watermark. Only the completed tasks are reclaimed, and when the usage
+// counts the whole disk, the task data is never reclaimed below the low watermark for the data of other programs
+func (s *storageManager) tryGCBySpace() {
+	if s.storeOption.HighWatermark <= 0 {
+		return
+	}
+	var (
+		capacity, usage int64
+		// taskUsage is the size of task data in DataPath
+		taskUsage int64
+		tasks     []*localTaskStore
+	)
+	s.tasks.Range(func(key, task interface{}) bool {
+		tasks = append(tasks, task.(*localTaskStore))
+		return true
+	})
+	for _, t := range tasks {
+		taskUsage += t.dataSize()
+	}
+	if s.storeOption.Quota.Bytes > 0 {
+		capacity = s.storeOption.Quota.ToNumber()
+		usage = taskUsage
+	} else {
+		total, free, err := fileutils.GetTotalAndFreeSpace(s.storeOption.DataPath)
+		if err != nil {
+			logger.Errorf("get disk usage of %s error: %s", s.storeOption.DataPath, err)
+			return
+		}
+		capacity, usage = total.ToNumber(), (total - free).ToNumber()
+	}
+	high := capacity * int64(s.storeOption.HighWatermark) / 100
+	if usage <= high {
+		logger.Debugf("storage usage %s not reach high watermark %s", unit.ToBytes(usage), unit.ToBytes(high))
+		return
+	}
+
+	low := capacity * int64(s.storeOption.LowWatermark) / 100
+	if taskUsage <= low {
+		logger.Warnf("storage usage %s exceeds high watermark %s, but task data %s is below low watermark %s, skip reclaiming",
+			unit.ToBytes(usage), unit.ToBytes(high), unit.ToBytes(taskUsage), unit.ToBytes(low))
+		return
+	}
+	logger.Infof("storage usage %s exceeds high watermark %s, capacity %s, start to reclaim tasks until usage falls below %s",
+		unit.ToBytes(usage), unit.ToBytes(high), unit.ToBytes(capacity), unit.ToBytes(low))
+	_, span := tracer.Start(context.Background(), config.SpanPeerSpaceGC)
+	span.SetAttributes(config.AttributeStorageCapacity.Int64(capacity))
+	span.SetAttributes(config.AttributeStorageUsage.Int64(usage))
+	defer span.End()
+
+	// least recently used first
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].lastAccess.Before(tasks[j].lastAccess)
+	})
+	var reclaimed int
+	for _, t := range tasks {
+		if usage <= low || taskUsage <= low {
+			break
+		}
+		key := PeerTaskMetaData{
+			TaskID: t.TaskID,
+			PeerID: t.PeerID,
+		}
+		if !t.completed() {
+			logger.Debugf("task %s/%s is not completed, skip reclaiming", key.TaskID, key.PeerID)
+			continue
+		}
+		if t.pinned() {
+			logger.Debugf("task %s/%s is pinned, skip reclaiming", key.TaskID, key.PeerID)
+			continue
+		}
+		if t.reading() {
+			logger.Infof("task %s/%s is uploading, skip reclaiming", key.TaskID, key.PeerID)
+			continue
+		}
+		size := t.dataSize()
+		t.MarkReclaim()
+		s.tasks.Delete(key)
+		if err := t.Reclaim(); err != nil {
+			logger.Errorf("gc task %s/%s error: %s", key.TaskID, key.PeerID, err)
+			span.RecordError(err)
+			continue
+		}
+		usage -= size
+		taskUsage -= size
+		reclaimed++
+		logger.Infof("task %s/%s reclaimed due to storage usage, size: %s, last access: %s",
+			key.TaskID, key.PeerID, unit.ToBytes(size), t.lastAccess.Format(time.RFC3339))
+		span.AddEvent("task reclaimed", trace.WithAttributes(
+			config.AttributeTaskId.String(key.TaskID),
+			config.AttributePeerId.String(key.PeerID)))
+	}
+	span.SetAttributes(config.AttributeStorageReclaimed.Int(reclaimed))
+	// remove the reclaimed tasks from marked tasks
+	var markedTasks []PeerTaskMetaData
+	for _, key := range s.markedReclaimTasks {
+		if _, ok := s.tasks.Load(key); ok {
+			markedTasks = append(markedTasks, key)
+		}
+	}
+	s.markedReclaimTasks = markedTasks
+	logger.Infof("reclaimed %d task(s) due to storage usage, usage is %s now", reclaimed, unit.ToBytes(usage))
+	if usage > low {
+		logger.Warnf("storage usage %s is still above low watermark %s", unit.ToBytes(usage), unit.ToBytes(low))
+	}
+}
+
 func (s *storageManager) CleanUp() {
 	_, _ = s.forceGC()
 }
@@ -0,0 +1,377 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/clientutil"
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/unit"
+)
+
+func TestStorageManager_TryGCBySpace(t *testing.T) {
+	assert := testifyassert.New(t)
+	dataDir, err := ioutil.TempDir("", "d7y-storage-gc-")
+	assert.Nil(err)
+	defer os.RemoveAll(dataDir)
+
+	var (
+		pieceSize = 1024
+		taskCount = 4
+		left      []string
+	)
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: dataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Hour,
+			},
+			// the completed tasks and an incomplete task
+			Quota:         clientutil.Size{Bytes: unit.ToBytes(int64((taskCount + 1) * pieceSize))},
+			HighWatermark: 90,
+			LowWatermark:  60,
+		}, func(request CommonTaskRequest) {
+		})
+	assert.Nil(err, "create storage manager")
+	var s = sm.(*storageManager)
+
+	var keys []PeerTaskMetaData
+	for i := 0; i <= taskCount; i++ {
+		key := PeerTaskMetaData{
+			TaskID: fmt.Sprintf("task-%d", i),
+			PeerID: fmt.Sprintf("peer-%d", i),
+		}
+		keys = append(keys, key)
+		assert.Nil(s.CreateTask(RegisterTaskRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: key.PeerID,
+				TaskID: key.TaskID,
+			},
+			ContentLength: int64(pieceSize),
+		}), "create task storage")
+		_, err = s.WritePiece(context.Background(), &WritePieceRequest{
+			PeerTaskMetaData: key,
+			PieceMetaData: PieceMetaData{
+				Num: 0,
+				Range: clientutil.Range{
+					Start:  0,
+					Length: int64(pieceSize),
+				},
+				Style: base.PieceStyle_PLAIN,
+			},
+			Reader: bytes.NewBuffer(make([]byte, pieceSize)),
+		})
+		assert.Nil(err, "put piece")
+		ts, _ := s.LoadTask(key)
+		if i < taskCount {
+			assert.Nil(ts.Store(context.Background(), &StoreRequest{
+				CommonTaskRequest: CommonTaskRequest{
+					PeerID: key.PeerID,
+					TaskID: key.TaskID,
+				},
+				MetadataOnly: true,
+				TotalPieces:  1,
+			}), "store task")
+		}
+		// the first task is the least recently used one
+		ts.(*localTaskStore).lastAccess = time.Now().Add(time.Duration(i-taskCount) * time.Minute)
+	}
+	// the incomplete task is used least recently
+	ts, _ := s.LoadTask(keys[taskCount])
+	ts.(*localTaskStore).lastAccess = time.Now().Add(-time.Hour)
+
+	// the least recently used completed task is uploading
+	ts, _ = s.LoadTask(keys[0])
+	_, closer, err := ts.ReadPiece(context.Background(), &ReadPieceRequest{
+		PeerTaskMetaData: keys[0],
+		PieceMetaData: PieceMetaData{
+			Num: 0,
+		},
+	})
+	assert.Nil(err, "read piece")
+	ts.(*localTaskStore).lastAccess = time.Now().Add(-time.Duration(taskCount) * time.Minute)
+
+	_, err = s.TryGC()
+	assert.Nil(err)
+
+	s.tasks.Range(func(key, value interface{}) bool {
+		left = append(left, key.(PeerTaskMetaData).TaskID)
+		return true
+	})
+	assert.ElementsMatch([]string{"task-0", "task-3", "task-4"}, left, "uploading, incomplete and recently used tasks should be kept")
+	for _, key := range keys[1:3] {
+		_, err = os.Stat(fmt.Sprintf("%s/%s/%s", dataDir, key.TaskID, key.PeerID))
+		assert.True(os.IsNotExist(err), "reclaimed task data should be removed")
+	}
+
+	assert.Nil(closer.Close())
+	assert.False(ts.(*localTaskStore).reading())
+}
+
+func TestStorageManager_TryGCByDiskSpace(t *testing.T) {
+	assert := testifyassert.New(t)
+	dataDir, err := ioutil.TempDir("", "d7y-storage-gc-")
+	assert.Nil(err)
+	defer os.RemoveAll(dataDir)
+
+	// without quota, the usage counts the whole disk, which is usually above 1%
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: dataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Hour,
+			},
+			HighWatermark: 1,
+			LowWatermark:  1,
+		}, func(request CommonTaskRequest) {
+		})
+	assert.Nil(err, "create storage manager")
+	var s = sm.(*storageManager)
+
+	key := PeerTaskMetaData{
+		TaskID: "task-0",
+		PeerID: "peer-0",
+	}
+	assert.Nil(s.CreateTask(RegisterTaskRequest{
+		CommonTaskRequest: CommonTaskRequest{
+			PeerID: key.PeerID,
+			TaskID: key.TaskID,
+		},
+		ContentLength: 1024,
+	}), "create task storage")
+	_, err = s.WritePiece(context.Background(), &WritePieceRequest{
+		PeerTaskMetaData: key,
+		PieceMetaData: PieceMetaData{
+			Num: 0,
+			Range: clientutil.Range{
+				Start:  0,
+				Length: 1024,
+			},
+			Style: base.PieceStyle_PLAIN,
+		},
+		Reader: bytes.NewBuffer(make([]byte, 1024)),
+	})
+	assert.Nil(err, "put piece")
+	ts, _ := s.LoadTask(key)
+	assert.Nil(ts.Store(context.Background(), &StoreRequest{
+		CommonTaskRequest: CommonTaskRequest{
+			PeerID: key.PeerID,
+			TaskID: key.TaskID,
+		},
+		MetadataOnly: true,
+		TotalPieces:  1,
+	}), "store task")
+
+	_, err = s.TryGC()
+	assert.Nil(err)
+	_, ok := s.LoadTask(key)
+	assert.True(ok, "task data below low watermark should not be reclaimed for the data of other programs")
+}
+
+func TestStorageManager_ListTasks(t *testing.T) {
+	assert := testifyassert.New(t)
+	dataDir, err := ioutil.TempDir("", "d7y-storage-list-")
+	assert.Nil(err)
+	defer os.RemoveAll(dataDir)
+
+	var (
+		pieceSize = 1024
+		key       = PeerTaskMetaData{
+			TaskID: "task-0",
+			PeerID: "peer-0",
+		}
+	)
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy,
+		&config.StorageOption{
+			DataPath: dataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Hour,
+			},
+		}, func(request CommonTaskRequest) {
+		})
+	assert.Nil(err, "create storage manager")
+	assert.Nil(sm.RegisterTask(context.Background(), RegisterTaskRequest{
+		CommonTaskRequest: CommonTaskRequest{
+			PeerID: key.PeerID,
+			TaskID: key.TaskID,
+		},
+		URL:           "http://example.com/a",
+		ContentLength: int64(pieceSize * 2),
+		TotalPieces:   2,
+	}), "register task")
+	_, err = sm.WritePiece(context.Background(), &WritePieceRequest{
+		PeerTaskMetaData: key,
+		PieceMetaData: PieceMetaData{
+			Num: 0,
+			Range: clientutil.Range{
+				Start:  0,
+				Length: int64(pieceSize),
+			},
+			Style: base.PieceStyle_PLAIN,
+		},
+		Reader: bytes.NewBuffer(make([]byte, pieceSize)),
+	})
+	assert.Nil(err, "put piece")
+
+	// only reading by other peers is counted as upload
+	for _, src := range []string{"peer-1", "peer-2", "peer-1", ""} {
+		_, closer, err := sm.ReadPiece(context.Background(), &ReadPieceRequest{
+			PeerTaskMetaData: key,
+			PieceMetaData: PieceMetaData{
+				Num: 0,
+			},
+			SrcPeerID: src,
+		})
+		assert.Nil(err, "read piece")
+		closer.Close()
+	}
+
+	stats := sm.ListTasks()
+	assert.Len(stats, 1)
+	assert.Equal(key, stats[0].PeerTaskMetaData)
+	assert.Equal("http://example.com/a", stats[0].URL)
+	assert.Equal(int64(pieceSize*2), stats[0].ContentLength)
+	assert.Equal(int64(pieceSize), stats[0].CompletedLength)
+	assert.False(stats[0].Done)
+	assert.False(stats[0].LastAccess.IsZero())
+	assert.Equal(int64(pieceSize*3), stats[0].UploadedLength)
+	assert.Equal(2, stats[0].ServedPeers)
+}
+
+func TestStorageManager_SeedTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	dataDir, err := ioutil.TempDir("", "d7y-storage-seed-")
+	assert.Nil(err)
+	defer os.RemoveAll(dataDir)
+
+	var (
+		pieceSize = 1024
+		option    = &config.StorageOption{
+			DataPath: dataDir,
+			TaskExpireTime: clientutil.Duration{
+				Duration: time.Minute,
+			},
+			// only the seed task fits in the quota
+			Quota:         clientutil.Size{Bytes: unit.ToBytes(int64(pieceSize))},
+			HighWatermark: 90,
+			LowWatermark:  60,
+		}
+		seed = &SeedRequest{
+			URL:    "d7y://cache/seed",
+			Filter: "token",
+			BizID:  "biz",
+			URLMeta: &base.UrlMeta{
+				Digest: "sha256:0",
+			},
+		}
+		seedKey = PeerTaskMetaData{
+			TaskID: "task-seed",
+			PeerID: "peer-0",
+		}
+		normalKey = PeerTaskMetaData{
+			TaskID: "task-normal",
+			PeerID: "peer-1",
+		}
+	)
+	sm, err := NewStorageManager(config.SimpleLocalTaskStoreStrategy, option, func(request CommonTaskRequest) {})
+	assert.Nil(err, "create storage manager")
+	var s = sm.(*storageManager)
+
+	for _, key := range []PeerTaskMetaData{seedKey, normalKey} {
+		req := RegisterTaskRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: key.PeerID,
+				TaskID: key.TaskID,
+			},
+			ContentLength: int64(pieceSize),
+			TotalPieces:   1,
+			PieceSize:     int32(pieceSize),
+		}
+		if key == seedKey {
+			req.Seed = seed
+		}
+		assert.Nil(s.RegisterTask(context.Background(), req), "register task")
+		_, err = s.WritePiece(context.Background(), &WritePieceRequest{
+			PeerTaskMetaData: key,
+			PieceMetaData: PieceMetaData{
+				Num: 0,
+				Range: clientutil.Range{
+					Start:  0,
+					Length: int64(pieceSize),
+				},
+				Style: base.PieceStyle_PLAIN,
+			},
+			Reader: bytes.NewBuffer(make([]byte, pieceSize)),
+		})
+		assert.Nil(err, "put piece")
+		assert.Nil(s.Store(context.Background(), &StoreRequest{
+			CommonTaskRequest: CommonTaskRequest{
+				PeerID: key.PeerID,
+				TaskID: key.TaskID,
+			},
+			MetadataOnly: true,
+			TotalPieces:  1,
+		}), "store task")
+	}
+
+	seeds := sm.ListSeedTasks()
+	assert.Len(seeds, 1)
+	assert.Equal(seedKey, seeds[0].PeerTaskMetaData)
+	assert.Equal(*seed, seeds[0].SeedRequest)
+	assert.Equal(int64(pieceSize), seeds[0].ContentLength)
+	assert.Equal(int32(1), seeds[0].TotalPieces)
+	assert.Equal(int32(pieceSize), seeds[0].PieceSize)
+
+	// the seed task is pinned against both expire time and watermark
+	ts, _ := s.LoadTask(seedKey)
+	ts.(*localTaskStore).lastAccess = time.Now().Add(-time.Hour)
+	assert.False(ts.(*localTaskStore).CanReclaim())
+	_, err = s.TryGC()
+	assert.Nil(err)
+	_, ok := s.LoadTask(seedKey)
+	assert.True(ok, "seed task should not be reclaimed")
+	_, ok = s.LoadTask(normalKey)
+	assert.False(ok, "normal task should be reclaimed by watermark")
+
+	// the seed task is kept after reloaded
+	sm, err = NewStorageManager(config.SimpleLocalTaskStoreStrategy, option, func(request CommonTaskRequest) {})
+	assert.Nil(err, "reload storage manager")
+	seeds = sm.ListSeedTasks()
+	assert.Len(seeds, 1)
+	assert.Equal(seedKey, seeds[0].PeerTaskMetaData)
+	assert.Equal(seed.URLMeta.Digest, seeds[0].URLMeta.Digest)
+	assert.Equal(seed.Filter, seeds[0].Filter)
+
+	// unregister reclaims the seed task
+	assert.Nil(sm.UnregisterTask(context.Background(), CommonTaskRequest{
+		PeerID: seedKey.PeerID,
+		TaskID: seedKey.TaskID,
+	}))
+	assert.Empty(sm.ListSeedTasks())
+	_, err = os.Stat(fmt.Sprintf("%s/%s/%s", dataDir, seedKey.TaskID, seedKey.PeerID))
+	assert.True(os.IsNotExist(err), "unregistered seed task data should be removed")
+}
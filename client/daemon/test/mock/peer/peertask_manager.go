@@ -13,6 +13,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 
 	peer "d7y.io/dragonfly/v2/client/daemon/peer"
+	storage "d7y.io/dragonfly/v2/client/daemon/storage"
 	logger "d7y.io/dragonfly/v2/pkg/dflog"
 	base "d7y.io/dragonfly/v2/pkg/rpc/base"
 	scheduler "d7y.io/dragonfly/v2/pkg/rpc/scheduler"
@@ -41,6 +42,35 @@ func (m *MockPeerTaskManager) EXPECT() *MockPeerTaskManagerMockRecorder {
 	return m.recorder
 }
 
+// CancelPeerTask mocks base method.
+func (m *MockPeerTaskManager) CancelPeerTask(pid string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPeerTask", pid)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// CancelPeerTask indicates an expected call of CancelPeerTask.
+func (mr *MockPeerTaskManagerMockRecorder) CancelPeerTask(pid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPeerTask", reflect.TypeOf((*MockPeerTaskManager)(nil).CancelPeerTask), pid)
+}
+
+// ImportFile mocks base method.
+func (m *MockPeerTaskManager) ImportFile(ctx context.Context, req *peer.ImportFileRequest) (*storage.ReusePeerTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportFile", ctx, req)
+	ret0, _ := ret[0].(*storage.ReusePeerTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportFile indicates an expected call of ImportFile.
+func (mr *MockPeerTaskManagerMockRecorder) ImportFile(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportFile", reflect.TypeOf((*MockPeerTaskManager)(nil).ImportFile), ctx, req)
+}
+
 // IsPeerTaskRunning mocks base method.
 func (m *MockPeerTaskManager) IsPeerTaskRunning(pid string) bool {
 	m.ctrl.T.Helper()
@@ -55,6 +85,34 @@ func (mr *MockPeerTaskManagerMockRecorder) IsPeerTaskRunning(pid interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsPeerTaskRunning", reflect.TypeOf((*MockPeerTaskManager)(nil).IsPeerTaskRunning), pid)
 }
 
+// RegisterSeeds mocks base method.
+func (m *MockPeerTaskManager) RegisterSeeds(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterSeeds", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterSeeds indicates an expected call of RegisterSeeds.
+func (mr *MockPeerTaskManagerMockRecorder) RegisterSeeds(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterSeeds", reflect.TypeOf((*MockPeerTaskManager)(nil).RegisterSeeds), ctx)
+}
+
+// RunningPeerTasks mocks base method.
+func (m *MockPeerTaskManager) RunningPeerTasks() []peer.PeerTask {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunningPeerTasks")
+	ret0, _ := ret[0].([]peer.PeerTask)
+	return ret0
+}
+
+// RunningPeerTasks indicates an expected call of RunningPeerTasks.
+func (mr *MockPeerTaskManagerMockRecorder) RunningPeerTasks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunningPeerTasks", reflect.TypeOf((*MockPeerTaskManager)(nil).RunningPeerTasks))
+}
+
 // StartFilePeerTask mocks base method.
 func (m *MockPeerTaskManager) StartFilePeerTask(ctx context.Context, req *peer.FilePeerTaskRequest) (chan *peer.FilePeerTaskProgress, *peer.TinyData, error) {
 	m.ctrl.T.Helper()
@@ -136,6 +194,18 @@ func (mr *MockPeerTaskMockRecorder) AddTraffic(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTraffic", reflect.TypeOf((*MockPeerTask)(nil).AddTraffic), arg0)
 }
 
+// Cancel mocks base method.
+func (m *MockPeerTask) Cancel(reason string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Cancel", reason)
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockPeerTaskMockRecorder) Cancel(reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockPeerTask)(nil).Cancel), reason)
+}
+
 // Context mocks base method.
 func (m *MockPeerTask) Context() context.Context {
 	m.ctrl.T.Helper()
@@ -150,6 +220,20 @@ func (mr *MockPeerTaskMockRecorder) Context() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockPeerTask)(nil).Context))
 }
 
+// GetCompletedLength mocks base method.
+func (m *MockPeerTask) GetCompletedLength() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompletedLength")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// GetCompletedLength indicates an expected call of GetCompletedLength.
+func (mr *MockPeerTaskMockRecorder) GetCompletedLength() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompletedLength", reflect.TypeOf((*MockPeerTask)(nil).GetCompletedLength))
+}
+
 // GetContentLength mocks base method.
 func (m *MockPeerTask) GetContentLength() int64 {
 	m.ctrl.T.Helper()
@@ -206,6 +290,20 @@ func (mr *MockPeerTaskMockRecorder) GetTotalPieces() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotalPieces", reflect.TypeOf((*MockPeerTask)(nil).GetTotalPieces))
 }
 
+// GetPieceSize mocks base method.
+func (m *MockPeerTask) GetPieceSize() int32 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPieceSize")
+	ret0, _ := ret[0].(int32)
+	return ret0
+}
+
+// GetPieceSize indicates an expected call of GetPieceSize.
+func (mr *MockPeerTaskMockRecorder) GetPieceSize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPieceSize", reflect.TypeOf((*MockPeerTask)(nil).GetPieceSize))
+}
+
 // GetTraffic mocks base method.
 func (m *MockPeerTask) GetTraffic() int64 {
 	m.ctrl.T.Helper()
@@ -220,6 +318,20 @@ func (mr *MockPeerTaskMockRecorder) GetTraffic() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTraffic", reflect.TypeOf((*MockPeerTask)(nil).GetTraffic))
 }
 
+// GetURL mocks base method.
+func (m *MockPeerTask) GetURL() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURL")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// GetURL indicates an expected call of GetURL.
+func (mr *MockPeerTaskMockRecorder) GetURL() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURL", reflect.TypeOf((*MockPeerTask)(nil).GetURL))
+}
+
 // Log mocks base method.
 func (m *MockPeerTask) Log() *logger.SugaredLoggerOnWith {
 	m.ctrl.T.Helper()
@@ -56,6 +56,26 @@ func (mr *MockSchedulerClientMockRecorder) LeaveTask(ctx, pt interface{}, opts .
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaveTask", reflect.TypeOf((*MockSchedulerClient)(nil).LeaveTask), varargs...)
 }
 
+// MigratePeerTask mocks base method.
+func (m *MockSchedulerClient) MigratePeerTask(ctx context.Context, taskId string, ptr *scheduler.PeerTaskRequest, stream client.PeerPacketStream, cause error, opts ...grpc.CallOption) (client.PeerPacketStream, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, taskId, ptr, stream, cause}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "MigratePeerTask", varargs...)
+	ret0, _ := ret[0].(client.PeerPacketStream)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigratePeerTask indicates an expected call of MigratePeerTask.
+func (mr *MockSchedulerClientMockRecorder) MigratePeerTask(ctx, taskId, ptr, stream, cause interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, taskId, ptr, stream, cause}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigratePeerTask", reflect.TypeOf((*MockSchedulerClient)(nil).MigratePeerTask), varargs...)
+}
+
 // RegisterPeerTask mocks base method.
 func (m *MockSchedulerClient) RegisterPeerTask(ctx context.Context, ptr *scheduler.PeerTaskRequest, opts ...grpc.CallOption) (*scheduler.RegisterResult, error) {
 	m.ctrl.T.Helper()
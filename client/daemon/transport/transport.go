@@ -18,6 +18,7 @@ package transport
 
 import (
 	"crypto/tls"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -202,9 +203,14 @@ func (rt *transport) download(req *http.Request) (*http.Response, error) {
 	}
 	logger.Infof("download stream attribute: %v", hdr)
 
+	// close the reader with the response body, the reader of a reused task holds the task data file
+	body, ok := r.(io.ReadCloser)
+	if !ok {
+		body = ioutil.NopCloser(r)
+	}
 	resp := &http.Response{
 		StatusCode: 200,
-		Body:       ioutil.NopCloser(r),
+		Body:       body,
 		Header:     hdr,
 	}
 	return resp, nil
@@ -86,9 +86,15 @@ func (um *uploadManager) handleUpload(w http.ResponseWriter, r *http.Request) {
 	var (
 		task = mux.Vars(r)["task"]
 		peer = r.FormValue("peerId")
+		// srcPeer is the peer which downloads the piece, older peers do not send it, use the remote ip instead
+		srcPeer = r.FormValue("srcPeerId")
 		//cdnSource = r.Header.Get("X-Dragonfly-CDN-Source")
 	)
 
+	if srcPeer == "" {
+		srcPeer, _, _ = net.SplitHostPort(r.RemoteAddr)
+	}
+
 	log := logger.With("peer", peer, "task", task, "component", "uploadManager")
 	log.Debugf("upload piece for task %s/%s to %s, request header: %#v", task, peer, r.RemoteAddr, r.Header)
 	rg, err := clientutil.ParseRange(r.Header.Get(headers.Range), math.MaxInt64)
@@ -115,6 +121,7 @@ func (um *uploadManager) handleUpload(w http.ResponseWriter, r *http.Request) {
 				Num:   -1,
 				Range: rg[0],
 			},
+			SrcPeerID: srcPeer,
 		})
 	if err != nil {
 		log.Errorf("get task data failed: %s", err)
@@ -0,0 +1,189 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	dfdaemongrpc "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
+	dfclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
+)
+
+// batchResult is the result of one entry in batch mode
+type batchResult struct {
+	taskID string
+	length uint64
+	err    error
+	// done means the entry is downloaded by daemon successfully
+	done bool
+}
+
+// batchDownload downloads all entries of the input file over one stream of daemon,
+// the failed entries are downloaded from source like single file mode
+func batchDownload(ctx context.Context, daemonClient dfclient.DaemonClient, hdr map[string]string) error {
+	start := time.Now()
+	entries, err := config.LoadBatchEntries(dfgetConfig.InputFile)
+	if err != nil {
+		logger.Errorf("load input file error: %s", err)
+		return err
+	}
+	logger.Infof("loaded %d file(s) from %s", len(entries), dfgetConfig.InputFile)
+
+	requests := make([]*dfdaemongrpc.DownRequest, len(entries))
+	results := make([]*batchResult, len(entries))
+	for i, entry := range entries {
+		if err := os.MkdirAll(filepath.Dir(entry.Output), 0755); err != nil {
+			return err
+		}
+		requests[i] = newBatchDownRequest(entry, hdr)
+		results[i] = &batchResult{}
+	}
+
+	if daemonClient == nil {
+		err = noDaemonError()
+	} else {
+		err = batchDownloadByDaemon(ctx, daemonClient, requests, results)
+	}
+	if err != nil {
+		logger.Errorf("batch download by dragonfly error: %s", err)
+	}
+
+	// download the entries which are not done by daemon from source
+	var fallback []int
+	for i, result := range results {
+		if !result.done {
+			fallback = append(fallback, i)
+			if result.err == nil {
+				result.err = err
+			}
+		}
+	}
+	runParallel(ctx, dfgetConfig.Parallel, len(fallback), func(i int) {
+		result := results[fallback[i]]
+		result.err = downloadFromSource(requests[fallback[i]], result.err)
+		result.done = true
+	})
+	// the entries not started are skipped since ctx is done, keep the error of dragonfly if any
+	for _, i := range fallback {
+		if result := results[i]; !result.done {
+			result.err = skipError(ctx.Err(), result.err)
+		}
+	}
+
+	return printBatchResults(entries, results, start)
+}
+
+// batchDownloadByDaemon sends all requests to daemon and updates results until the stream ends
+func batchDownloadByDaemon(ctx context.Context, daemonClient dfclient.DaemonClient,
+	requests []*dfdaemongrpc.DownRequest, results []*batchResult) error {
+	stream, err := daemonClient.BatchDownload(ctx, &dfdaemongrpc.BatchDownRequest{
+		Requests: requests,
+		Parallel: int32(dfgetConfig.Parallel),
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		r, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if r.Index < 0 || int(r.Index) >= len(results) {
+			logger.Warnf("receive batch download result with invalid index %d", r.Index)
+			continue
+		}
+		result := results[r.Index]
+		if r.Error != nil {
+			logger.Errorf("download %s by dragonfly error code %d/%s",
+				requests[r.Index].Url, r.Error.Code, r.Error.Message)
+			// daemon may send a done result before the error when the download is canceled
+			result.err, result.done = dferrors.New(r.Error.Code, r.Error.Message), false
+			continue
+		}
+		if r.Result != nil && r.Result.Done {
+			result.taskID, result.length, result.done = r.Result.TaskId, r.Result.CompletedLength, true
+			logger.Infof("download %s to %s success, task: %s, peer: %s, length: %d",
+				requests[r.Index].Url, requests[r.Index].Output, r.Result.TaskId, r.Result.PeerId, r.Result.CompletedLength)
+		}
+	}
+}
+
+// newBatchDownRequest generates the download request of entry, options of entry override the command line ones
+func newBatchDownRequest(entry *config.BatchEntry, hdr map[string]string) *dfdaemongrpc.DownRequest {
+	entryHdr := map[string]string{}
+	for k, v := range hdr {
+		entryHdr[k] = v
+	}
+	for k, v := range parseHeader(entry.Header) {
+		entryHdr[k] = v
+	}
+
+	request := newDownRequest(entry.URL, entry.Output, entryHdr)
+	request.UrlMeta.Digest = entry.Digest
+	if entry.Filter != "" {
+		request.Filter = entry.Filter
+	}
+	if entry.BizID != "" {
+		request.BizId = entry.BizID
+	}
+	return request
+}
+
+// skipError is the cause of an entry skipped before downloading from source, err is the error of dragonfly
+func skipError(cause error, err error) error {
+	if err == nil {
+		return errors.Wrap(cause, "skip downloading from source")
+	}
+	return errors.Wrapf(err, "skip downloading from source for %v, dragonfly error", cause)
+}
+
+func printBatchResults(entries []*config.BatchEntry, results []*batchResult, start time.Time) error {
+	var failed int
+	for i, result := range results {
+		switch {
+		case !result.done:
+			failed++
+			fmt.Printf("Skip %s -> %s: %s\n", entries[i].URL, entries[i].Output, result.err)
+		case result.err != nil:
+			failed++
+			fmt.Printf("Failed %s -> %s: %s\n", entries[i].URL, entries[i].Output, result.err)
+		case result.taskID == "":
+			fmt.Printf("Success %s -> %s, from source\n", entries[i].URL, entries[i].Output)
+		default:
+			fmt.Printf("Success %s -> %s, task: %s, length: %d\n", entries[i].URL, entries[i].Output, result.taskID, result.length)
+		}
+	}
+	fmt.Printf("Download %d file(s), %d failed, time cost: %dms\n",
+		len(entries), failed, time.Now().Sub(start).Milliseconds())
+	if failed > 0 {
+		return errors.Errorf("%d file(s) failed to download", failed)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/config"
+)
+
+// withBatchConfig writes the lines to an input file in dir and uses it in a copy of the default option
+func withBatchConfig(t *testing.T, dir string, lines []string) {
+	input := filepath.Join(dir, "input.txt")
+	if err := ioutil.WriteFile(input, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	origin := dfgetConfig
+	t.Cleanup(func() { dfgetConfig = origin })
+	option := *config.NewClientOption()
+	dfgetConfig = &option
+	dfgetConfig.InputFile = input
+	dfgetConfig.Parallel = 2
+}
+
+// captureStdout returns what f prints to stdout
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		output <- string(data)
+	}()
+	f()
+	w.Close()
+	return <-output
+}
+
+func TestBatchDownload(t *testing.T) {
+	assert := testifyassert.New(t)
+	files := map[string]string{
+		"a.txt": "content of a",
+		"b.txt": "content of b",
+	}
+	server := newIndexServer(files)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "dfget-batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	withBatchConfig(t, dir, []string{
+		fmt.Sprintf("%s/dataset/a.txt %s/a.txt", server.URL, dir),
+		fmt.Sprintf(`{"url": "%s/dataset/b.txt", "output": "%s/sub/b.txt"}`, server.URL, dir),
+	})
+	// without daemon, every entry is downloaded from source
+	output := captureStdout(t, func() {
+		assert.Nil(batchDownload(context.Background(), nil, nil))
+	})
+	for name, out := range map[string]string{"a.txt": "a.txt", "b.txt": "sub/b.txt"} {
+		content, err := ioutil.ReadFile(filepath.Join(dir, out))
+		assert.Nil(err)
+		assert.Equal(files[name], string(content))
+		assert.Contains(output, fmt.Sprintf("Success %s/dataset/%s -> %s/%s, from source", server.URL, name, dir, out))
+	}
+}
+
+func TestBatchDownload_Canceled(t *testing.T) {
+	assert := testifyassert.New(t)
+	dir, err := ioutil.TempDir("", "dfget-batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	withBatchConfig(t, dir, []string{
+		fmt.Sprintf("http://127.0.0.1:1/a.txt %s/a.txt", dir),
+		fmt.Sprintf("http://127.0.0.1:1/b.txt %s/b.txt", dir),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	output := captureStdout(t, func() {
+		assert.NotNil(batchDownload(ctx, nil, nil))
+	})
+	// every skipped entry reports its own cause instead of a bare context error
+	for _, name := range []string{"a.txt", "b.txt"} {
+		assert.Contains(output, fmt.Sprintf("Skip http://127.0.0.1:1/%s -> %s/%s: skip downloading from source for context canceled, dragonfly error: daemon is not available",
+			name, dir, name))
+	}
+	assert.NotContains(output, "<nil>")
+}
+
+func TestSkipError(t *testing.T) {
+	assert := testifyassert.New(t)
+	err := skipError(context.DeadlineExceeded, nil)
+	assert.Equal("skip downloading from source: context deadline exceeded", err.Error())
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+
+	cause := errors.New("peer task failed")
+	err = skipError(context.Canceled, cause)
+	assert.Equal("skip downloading from source for context canceled, dragonfly error: peer task failed", err.Error())
+	assert.True(errors.Is(err, cause))
+}
@@ -0,0 +1,171 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"d7y.io/dragonfly/v2/pkg/dflog/logcore"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	dfdaemongrpc "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
+	dfclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+	"d7y.io/dragonfly/v2/pkg/util/net/urlutils"
+)
+
+// cacheOption holds the flags shared by cache commands, they generate the task id of the key like dfget
+var cacheOption struct {
+	path    string
+	digest  string
+	bizID   string
+	sock    string
+	console bool
+}
+
+// cacheExample shows examples in dfget cache command, and is used in auto-generated cli docs.
+var cacheExample = `
+$ dfget cache import d7y://artifacts/build.tar --path /tmp/build.tar
+Task: 8c1d8c2d3e6ed1dbd7df5db1b28a2d7efd7a5f2c86f4d4c6e0b1c7fd5a5d1d04
+Peer: 10.0.0.1-30-59c54ceb-868a-4897-9832-577d2b347cce
+Length: 1073741824, pieces: 256, done: true
+
+# on other nodes
+$ dfget -u d7y://artifacts/build.tar -o /tmp/build.tar
+`
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "manage local files imported into the P2P cache without an origin",
+	Long: `Import a local file into the P2P cache under a url style key, other nodes can download
+it with dfget by the key even though there is no origin for the key.
+The imported file is pinned by the daemon, it is never reclaimed by --expiretime or the storage watermark,
+and it is registered to the scheduler as a seed again after the daemon restarts, until it is deleted with dfget cache delete.`,
+	Example:           cacheExample,
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+}
+
+var cacheImportCmd = &cobra.Command{
+	Use:   "import <key>",
+	Short: "import a local file into the P2P cache with the key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cacheOption.path == "" {
+			return errors.New("path is required")
+		}
+		path, err := filepath.Abs(cacheOption.path)
+		if err != nil {
+			return err
+		}
+		return runCache(args[0], true, func(ctx context.Context, dc dfclient.DaemonClient, req *dfdaemongrpc.TaskRequest) error {
+			info, err := dc.ImportTask(ctx, &dfdaemongrpc.ImportTaskRequest{
+				Url:     req.Url,
+				UrlMeta: req.UrlMeta,
+				BizId:   req.BizId,
+				Path:    path,
+			})
+			if err != nil {
+				return err
+			}
+			printTaskInfo(info)
+			return nil
+		})
+	},
+}
+
+var cacheStatCmd = &cobra.Command{
+	Use:   "stat <key>",
+	Short: "show the cached task of the key in local",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCache(args[0], false, func(ctx context.Context, dc dfclient.DaemonClient, req *dfdaemongrpc.TaskRequest) error {
+			info, err := dc.StatTask(ctx, req)
+			if err != nil {
+				return err
+			}
+			printTaskInfo(info)
+			return nil
+		})
+	},
+}
+
+var cacheDeleteCmd = &cobra.Command{
+	Use:   "delete <key>",
+	Short: "delete the cached task of the key in local",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCache(args[0], false, func(ctx context.Context, dc dfclient.DaemonClient, req *dfdaemongrpc.TaskRequest) error {
+			if err := dc.DeleteTask(ctx, req); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted: %s\n", req.Url)
+			return nil
+		})
+	},
+}
+
+func init() {
+	flagSet := cacheCmd.PersistentFlags()
+	flagSet.StringVar(&cacheOption.digest, "digest", "",
+		"digest of the cached file in the form of algorithm:hex, eg: sha256:xxx, the same digest is required when downloading with the key")
+	flagSet.StringVar(&cacheOption.bizID, "callsystem", "", "the system name of the cached file, it is a part of the task id like dfget")
+	flagSet.BoolVar(&cacheOption.console, "console", false, "show log on console")
+	flagSet.StringVar(&cacheOption.sock, "daemon-sock", "",
+		"the unix domain socket address for grpc with daemon, default is the one in daemon config")
+	cacheImportCmd.Flags().StringVarP(&cacheOption.path, "path", "p", "", "the local file to import")
+
+	cacheCmd.AddCommand(cacheImportCmd, cacheStatCmd, cacheDeleteCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// runCache connects daemon and calls fn with the task request of the key,
+// the daemon is spawned when spawn is true, the imported file is served by it
+func runCache(key string, spawn bool, fn func(context.Context, dfclient.DaemonClient, *dfdaemongrpc.TaskRequest) error) error {
+	logcore.InitDfget(cacheOption.console)
+
+	if !urlutils.IsValidURL(key) {
+		return fmt.Errorf("invalid key %q, a url style key like d7y://bucket/file is required", key)
+	}
+	if cacheOption.digest != "" {
+		if _, _, err := digestutils.Parse(cacheOption.digest); err != nil {
+			return err
+		}
+	}
+
+	dc, _, err := connectDaemon(cacheOption.sock, spawn)
+	if err != nil {
+		return err
+	}
+
+	return fn(context.Background(), dc, &dfdaemongrpc.TaskRequest{
+		Url: key,
+		UrlMeta: &base.UrlMeta{
+			Digest: cacheOption.digest,
+		},
+		BizId: cacheOption.bizID,
+	})
+}
+
+func printTaskInfo(info *dfdaemongrpc.TaskInfo) {
+	fmt.Printf("Task: %s\nPeer: %s\n", info.TaskId, info.PeerId)
+	fmt.Printf("Length: %d, pieces: %d, done: %t\n", info.ContentLength, info.TotalPiece, info.Done)
+}
@@ -0,0 +1,126 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"d7y.io/dragonfly/v2/client/clientutil/progressbar"
+	dfdaemongrpc "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
+)
+
+// downloadProgress shows the progress of a downloading file from daemon
+type downloadProgress interface {
+	update(result *dfdaemongrpc.DownResult)
+	finish(result *dfdaemongrpc.DownResult, cost time.Duration)
+}
+
+// newDownloadProgress returns the json progress when asJSON is true, otherwise the progress bar
+func newDownloadProgress(asJSON bool, w io.Writer) downloadProgress {
+	if asJSON {
+		return &jsonProgress{encoder: json.NewEncoder(w)}
+	}
+	return &barProgress{
+		pb: progressbar.DefaultBytes(-1, "Downloading"),
+		w:  w,
+	}
+}
+
+// barProgress shows the progress with a progress bar, it changes from a spinner to a bar when the content length is known
+type barProgress struct {
+	pb *progressbar.ProgressBar
+	w  io.Writer
+}
+
+func (b *barProgress) update(result *dfdaemongrpc.DownResult) {
+	if result.ContentLength > 0 && b.pb.GetMax64() != result.ContentLength {
+		b.pb.ChangeMax64(result.ContentLength)
+	}
+	if result.CompletedLength > 0 {
+		b.pb.Set64(int64(result.CompletedLength))
+	}
+}
+
+func (b *barProgress) finish(result *dfdaemongrpc.DownResult, cost time.Duration) {
+	b.update(result)
+	b.pb.Describe("Downloaded")
+	b.pb.Finish()
+	fmt.Fprintf(b.w, "Task: %s\nPeer: %s\n", result.TaskId, result.PeerId)
+	fmt.Fprintf(b.w, "Download success, time cost: %dms, length: %d\n", cost.Milliseconds(), result.CompletedLength)
+	fmt.Fprintf(b.w, "Download from peers: %d, cdn: %d, source: %d\n", result.PeerLength, result.CdnLength, result.SourceLength)
+}
+
+// progressRecord is one line of the json progress
+type progressRecord struct {
+	TaskID          string `json:"task_id"`
+	PeerID          string `json:"peer_id"`
+	ContentLength   int64  `json:"content_length"`
+	CompletedLength uint64 `json:"completed_length"`
+	TotalPiece      int32  `json:"total_piece"`
+	// Rate is the current download rate in bytes per second
+	Rate uint64 `json:"rate"`
+	// ETA is the estimated remaining time in seconds, -1 when it is unknown
+	ETA          int64  `json:"eta"`
+	PeerLength   uint64 `json:"peer_length"`
+	CdnLength    uint64 `json:"cdn_length"`
+	SourceLength uint64 `json:"source_length"`
+	Done         bool   `json:"done"`
+	// Cost is the time cost of the whole download in milliseconds, it is set in the last record only
+	Cost int64 `json:"cost,omitempty"`
+}
+
+func newProgressRecord(result *dfdaemongrpc.DownResult) *progressRecord {
+	record := &progressRecord{
+		TaskID:          result.TaskId,
+		PeerID:          result.PeerId,
+		ContentLength:   result.ContentLength,
+		CompletedLength: result.CompletedLength,
+		TotalPiece:      result.TotalPiece,
+		Rate:            result.Rate,
+		ETA:             -1,
+		PeerLength:      result.PeerLength,
+		CdnLength:       result.CdnLength,
+		SourceLength:    result.SourceLength,
+		Done:            result.Done,
+	}
+	switch {
+	case result.Done:
+		record.ETA = 0
+	case result.ContentLength > 0 && result.Rate > 0 && uint64(result.ContentLength) >= result.CompletedLength:
+		remaining := uint64(result.ContentLength) - result.CompletedLength
+		record.ETA = int64((remaining + result.Rate - 1) / result.Rate)
+	}
+	return record
+}
+
+// jsonProgress prints every progress from daemon as a json line
+type jsonProgress struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonProgress) update(result *dfdaemongrpc.DownResult) {
+	j.encoder.Encode(newProgressRecord(result))
+}
+
+func (j *jsonProgress) finish(result *dfdaemongrpc.DownResult, cost time.Duration) {
+	record := newProgressRecord(result)
+	record.Cost = cost.Milliseconds()
+	j.encoder.Encode(record)
+}
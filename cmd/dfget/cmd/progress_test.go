@@ -0,0 +1,119 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"d7y.io/dragonfly/v2/client/config"
+	dfdaemongrpc "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestNewProgressRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *dfdaemongrpc.DownResult
+		rate   uint64
+		eta    int64
+	}{
+		{
+			name:   "remaining time is rounded up",
+			result: &dfdaemongrpc.DownResult{ContentLength: 1000, CompletedLength: 100, Rate: 200},
+			rate:   200,
+			eta:    5,
+		},
+		{
+			name:   "unknown content length",
+			result: &dfdaemongrpc.DownResult{ContentLength: -1, CompletedLength: 100, Rate: 200},
+			rate:   200,
+			eta:    -1,
+		},
+		{
+			name:   "no rate yet",
+			result: &dfdaemongrpc.DownResult{ContentLength: 1000, CompletedLength: 0},
+			eta:    -1,
+		},
+		{
+			name:   "completed length exceeds content length",
+			result: &dfdaemongrpc.DownResult{ContentLength: 100, CompletedLength: 200, Rate: 200},
+			rate:   200,
+			eta:    -1,
+		},
+		{
+			name:   "done",
+			result: &dfdaemongrpc.DownResult{ContentLength: 1000, CompletedLength: 1000, Rate: 300, Done: true},
+			rate:   300,
+			eta:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			record := newProgressRecord(tt.result)
+			assert.Equal(tt.rate, record.Rate)
+			assert.Equal(tt.eta, record.ETA)
+			assert.Equal(tt.result.Done, record.Done)
+		})
+	}
+}
+
+func TestNewDownloadProgress(t *testing.T) {
+	assert := testifyassert.New(t)
+	var buf bytes.Buffer
+	_, ok := newDownloadProgress(false, &buf).(*barProgress)
+	assert.True(ok)
+
+	progress, ok := newDownloadProgress(true, &buf).(*jsonProgress)
+	assert.True(ok)
+	progress.update(&dfdaemongrpc.DownResult{TaskId: "task", ContentLength: 10, CompletedLength: 5, Rate: 5})
+	progress.finish(&dfdaemongrpc.DownResult{TaskId: "task", ContentLength: 10, CompletedLength: 10, Done: true}, 1500*time.Millisecond)
+
+	// every progress is a json line
+	decoder := json.NewDecoder(&buf)
+	var records []*progressRecord
+	for decoder.More() {
+		record := &progressRecord{}
+		assert.Nil(decoder.Decode(record))
+		records = append(records, record)
+	}
+	assert.Len(records, 2)
+	assert.Equal(int64(1), records[0].ETA)
+	assert.Equal(int64(0), records[0].Cost)
+	assert.True(records[1].Done)
+	assert.Equal(int64(1500), records[1].Cost)
+}
+
+func TestProgressWriter(t *testing.T) {
+	assert := testifyassert.New(t)
+	origin := dfgetConfig
+	defer func() { dfgetConfig = origin }()
+
+	dfgetConfig = &config.ClientOption{Output: "/tmp/file"}
+	assert.Equal(os.Stdout, progressWriter())
+	dfgetConfig = &config.ClientOption{Output: config.OutputStdout}
+	assert.Equal(os.Stderr, progressWriter())
+
+	// the json progress is printed to stdout, the other messages to stderr
+	dfgetConfig = &config.ClientOption{Output: "/tmp/file", JSONProgress: true}
+	assert.Equal(os.Stdout, progressWriter())
+	assert.Equal(os.Stderr, messageWriter())
+}
@@ -0,0 +1,185 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"d7y.io/dragonfly/v2/cdnsystem/source"
+	_ "d7y.io/dragonfly/v2/cdnsystem/source/httpprotocol"
+	_ "d7y.io/dragonfly/v2/cdnsystem/source/ossprotocol"
+	_ "d7y.io/dragonfly/v2/cdnsystem/source/s3protocol"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	dfclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
+)
+
+// recursiveEntry is a file to download in recursive mode
+type recursiveEntry struct {
+	url string
+	// name is the path relative to the url of dfget, separated by "/"
+	name string
+}
+
+// recursiveDownload downloads all files under the url into the output directory,
+// each file is a separate task and at most dfgetConfig.Parallel files are downloaded at the same time
+func recursiveDownload(ctx context.Context, daemonClient dfclient.DaemonClient, output string, hdr map[string]string) error {
+	start := time.Now()
+	entries, err := listRecursively(dfgetConfig.URL, hdr)
+	if err != nil {
+		logger.Errorf("list %s error: %s", dfgetConfig.URL, err)
+		return err
+	}
+	logger.Infof("listed %d file(s) under %s", len(entries), dfgetConfig.URL)
+
+	var (
+		lock   sync.Mutex
+		failed []string
+	)
+	runParallel(ctx, dfgetConfig.Parallel, len(entries), func(i int) {
+		entry := entries[i]
+		target := filepath.Join(output, filepath.FromSlash(entry.name))
+		err := downloadEntry(ctx, daemonClient, entry.url, target, hdr)
+		lock.Lock()
+		defer lock.Unlock()
+		if err != nil {
+			logger.Errorf("download %s to %s error: %s", entry.url, target, err)
+			failed = append(failed, entry.name)
+			fmt.Printf("Download %s failed: %s\n", entry.name, err)
+			return
+		}
+		fmt.Printf("Download %s success\n", entry.name)
+	})
+
+	if ctx.Err() != nil {
+		return errors.Wrapf(ctx.Err(), "recursive download of %s is interrupted", dfgetConfig.URL)
+	}
+	fmt.Printf("Download %d file(s), %d failed, time cost: %dms\n",
+		len(entries), len(failed), time.Now().Sub(start).Milliseconds())
+	if len(failed) > 0 {
+		return errors.Errorf("%d file(s) failed to download: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// listRecursively lists all files under rawURL which match the include and exclude patterns,
+// the schemes of rawURL are limited to config.RecursiveSchemes.
+func listRecursively(rawURL string, hdr map[string]string) ([]*recursiveEntry, error) {
+	client, err := source.NewSourceClient()
+	if err != nil {
+		return nil, err
+	}
+	lister, ok := client.(source.ResourceLister)
+	if !ok {
+		return nil, source.ErrListNotSupported
+	}
+
+	var (
+		entries []*recursiveEntry
+		dirs    = []*recursiveEntry{{url: rawURL}}
+	)
+	for len(dirs) > 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+		children, err := lister.List(dir.url, hdr)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			name := path.Join(dir.name, child.Name)
+			// never write outside of the output directory
+			if name == ".." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+				logger.Warnf("skip entry %s with invalid name %q", child.URL, child.Name)
+				continue
+			}
+			if child.IsDir {
+				dirs = append(dirs, &recursiveEntry{url: child.URL, name: name})
+				continue
+			}
+			if !dfgetConfig.Match(name) {
+				logger.Debugf("skip entry %s which does not match patterns", name)
+				continue
+			}
+			entries = append(entries, &recursiveEntry{url: child.URL, name: name})
+		}
+	}
+	return entries, nil
+}
+
+// downloadEntry downloads one file of recursive mode by daemon, and falls back to source when failed
+func downloadEntry(ctx context.Context, daemonClient dfclient.DaemonClient, url, output string, hdr map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return err
+	}
+	request := newDownRequest(url, output, hdr)
+	if daemonClient == nil {
+		return downloadFromSource(request, noDaemonError())
+	}
+
+	down, err := daemonClient.Download(ctx, request)
+	if err == nil {
+		for {
+			result, recvErr := down.Recv()
+			if recvErr != nil {
+				err = recvErr
+				break
+			}
+			if result.Done {
+				logger.Infof("download %s to %s success, task: %s, peer: %s, length: %d",
+					url, output, result.TaskId, result.PeerId, result.CompletedLength)
+				return nil
+			}
+		}
+	}
+	logger.Errorf("download %s by dragonfly error: %s", url, err)
+	return downloadFromSource(request, err)
+}
+
+// runParallel calls f with 0 to n-1, at most parallel calls run at the same time,
+// no more calls start after ctx is done
+func runParallel(ctx context.Context, parallel, n int, f func(i int)) {
+	var (
+		wg     sync.WaitGroup
+		tokens = make(chan struct{}, parallel)
+	)
+	for i := 0; i < n; i++ {
+		select {
+		case tokens <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,375 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/cdnsystem/source"
+	"d7y.io/dragonfly/v2/client/config"
+)
+
+// newIndexServer serves files as a http server with directory index pages,
+// the keys of files are the paths of the files under /dataset/
+func newIndexServer(files map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/dataset/")
+		if content, ok := files[name]; ok {
+			io.WriteString(w, content)
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// list the direct children of the directory
+		children := map[string]bool{}
+		for file := range files {
+			if !strings.HasPrefix(file, name) {
+				continue
+			}
+			child := strings.TrimPrefix(file, name)
+			if i := strings.Index(child, "/"); i >= 0 {
+				child = child[:i+1]
+			}
+			children[child] = true
+		}
+		if len(children) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "<html><body>\n<a href=\"../\">../</a>\n")
+		for child := range children {
+			fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", child, child)
+		}
+		fmt.Fprintf(w, "</body></html>")
+	}))
+}
+
+// newS3Server serves files as the bucket of a S3 compatible service, the keys of files are the keys of objects
+func newS3Server(bucket string, files map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/"+bucket+"/")
+		if content, ok := files[key]; ok {
+			io.WriteString(w, content)
+			return
+		}
+		query := r.URL.Query()
+		if key != "" || query.Get("list-type") != "2" || query.Get("delimiter") != "/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// list the direct objects and prefixes under the prefix
+		var (
+			prefix   = query.Get("prefix")
+			objects  []string
+			prefixes = map[string]bool{}
+		)
+		for file := range files {
+			if !strings.HasPrefix(file, prefix) {
+				continue
+			}
+			if i := strings.Index(file[len(prefix):], "/"); i >= 0 {
+				prefixes[file[:len(prefix)+i+1]] = true
+				continue
+			}
+			objects = append(objects, file)
+		}
+		fmt.Fprint(w, "<ListBucketResult><IsTruncated>false</IsTruncated>")
+		for _, object := range objects {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key><Size>%d</Size></Contents>", object, len(files[object]))
+		}
+		for p := range prefixes {
+			fmt.Fprintf(w, "<CommonPrefixes><Prefix>%s</Prefix></CommonPrefixes>", p)
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+	}))
+}
+
+// listSource is a source client lists the entries in the map by url, it does not download anything
+type listSource struct {
+	source.ResourceClient
+	entries map[string][]*source.URLEntry
+}
+
+func (s *listSource) List(url string, header map[string]string) ([]*source.URLEntry, error) {
+	entries, ok := s.entries[url]
+	if !ok {
+		return nil, errors.Errorf("%s not found", url)
+	}
+	return entries, nil
+}
+
+func withRecursiveConfig(t *testing.T, include, exclude []string) {
+	origin := dfgetConfig
+	t.Cleanup(func() { dfgetConfig = origin })
+	// the default option is shared, modify a copy only
+	option := *config.NewClientOption()
+	dfgetConfig = &option
+	dfgetConfig.Recursive = true
+	dfgetConfig.Include = include
+	dfgetConfig.Exclude = exclude
+}
+
+func TestListRecursively(t *testing.T) {
+	server := newIndexServer(map[string]string{
+		"a.txt":            "a",
+		"skip.tmp":         "skip",
+		"sub/b.txt":        "b",
+		"sub/deeper/c.tmp": "c",
+		"sub/deeper/d.txt": "d",
+	})
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		names   []string
+	}{
+		{
+			name:  "all files",
+			names: []string{"a.txt", "skip.tmp", "sub/b.txt", "sub/deeper/c.tmp", "sub/deeper/d.txt"},
+		},
+		{
+			name:    "exclude base name",
+			exclude: []string{"*.tmp"},
+			names:   []string{"a.txt", "sub/b.txt", "sub/deeper/d.txt"},
+		},
+		{
+			name:    "include relative path",
+			include: []string{"sub/*"},
+			names:   []string{"sub/b.txt"},
+		},
+		{
+			name:    "include and exclude",
+			include: []string{"*.txt", "*.tmp"},
+			exclude: []string{"sub/deeper/*"},
+			names:   []string{"a.txt", "skip.tmp", "sub/b.txt"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			withRecursiveConfig(t, tc.include, tc.exclude)
+
+			entries, err := listRecursively(server.URL+"/dataset/", nil)
+			assert.Nil(err)
+			var names []string
+			for _, entry := range entries {
+				assert.Equal(server.URL+"/dataset/"+entry.name, entry.url)
+				names = append(names, entry.name)
+			}
+			sort.Strings(names)
+			assert.Equal(tc.names, names)
+		})
+	}
+}
+
+func TestListRecursively_InvalidNames(t *testing.T) {
+	assert := testifyassert.New(t)
+	withRecursiveConfig(t, nil, nil)
+	source.Register("list", &listSource{
+		entries: map[string][]*source.URLEntry{
+			"list://dataset/": {
+				{URL: "list://escape", Name: "../escape"},
+				{URL: "list://root", Name: "/root"},
+				{URL: "list://dataset/ok", Name: "ok"},
+				{URL: "list://dataset/dir/", Name: "dir/", IsDir: true},
+				{URL: "list://parent/", Name: "../", IsDir: true},
+			},
+			"list://dataset/dir/": {
+				{URL: "list://dataset/escape", Name: "../../escape"},
+				{URL: "list://dataset/dir/ok", Name: "ok"},
+			},
+		},
+	})
+
+	entries, err := listRecursively("list://dataset/", nil)
+	assert.Nil(err)
+	assert.Equal([]*recursiveEntry{
+		{url: "list://dataset/ok", name: "ok"},
+		{url: "list://dataset/dir/ok", name: "dir/ok"},
+	}, entries)
+
+	_, err = listRecursively("list://not-found/", nil)
+	assert.NotNil(err)
+}
+
+func TestListRecursively_NotSupported(t *testing.T) {
+	assert := testifyassert.New(t)
+	withRecursiveConfig(t, nil, nil)
+	source.Register("nolist", &struct{ source.ResourceClient }{})
+
+	_, err := listRecursively("nolist://dataset/", nil)
+	assert.True(errors.Is(err, source.ErrListNotSupported))
+}
+
+func TestRecursiveDownload(t *testing.T) {
+	assert := testifyassert.New(t)
+	files := map[string]string{
+		"a.txt":            "content of a",
+		"sub/b.txt":        "content of b",
+		"sub/deeper/c.txt": "content of c",
+		"sub/deeper/d.tmp": "content of d",
+	}
+	server := newIndexServer(files)
+	defer server.Close()
+
+	output, err := ioutil.TempDir("", "dfget-recursive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(output)
+
+	withRecursiveConfig(t, nil, []string{"*.tmp"})
+	dfgetConfig.URL = server.URL + "/dataset/"
+	dfgetConfig.Parallel = 2
+	// without daemon, every file is downloaded from source
+	assert.Nil(recursiveDownload(context.Background(), nil, output, nil))
+
+	var downloaded []string
+	assert.Nil(filepath.Walk(output, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(output, p)
+		downloaded = append(downloaded, filepath.ToSlash(rel))
+		content, _ := ioutil.ReadFile(p)
+		assert.Equal(files[filepath.ToSlash(rel)], string(content))
+		return nil
+	}))
+	sort.Strings(downloaded)
+	assert.Equal([]string{"a.txt", "sub/b.txt", "sub/deeper/c.txt"}, downloaded)
+}
+
+func TestRecursiveDownload_S3(t *testing.T) {
+	assert := testifyassert.New(t)
+	files := map[string]string{
+		"dataset/a.txt":            "content of a",
+		"dataset/sub/b.txt":        "content of b",
+		"dataset/sub/deeper/c.tmp": "content of c",
+		"other/d.txt":              "content of d",
+	}
+	server := newS3Server("bucket", files)
+	defer server.Close()
+
+	output, err := ioutil.TempDir("", "dfget-recursive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(output)
+
+	withRecursiveConfig(t, nil, []string{"*.tmp"})
+	dfgetConfig.URL = "s3://bucket/dataset"
+	dfgetConfig.Parallel = 2
+	hdr := map[string]string{
+		"endpoint":        server.URL,
+		"accessKeyID":     "id",
+		"accessKeySecret": "secret",
+	}
+	// without daemon, every object is downloaded from source
+	assert.Nil(recursiveDownload(context.Background(), nil, output, hdr))
+
+	var downloaded []string
+	assert.Nil(filepath.Walk(output, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(output, p)
+		downloaded = append(downloaded, filepath.ToSlash(rel))
+		content, _ := ioutil.ReadFile(p)
+		assert.Equal(files["dataset/"+filepath.ToSlash(rel)], string(content))
+		return nil
+	}))
+	sort.Strings(downloaded)
+	assert.Equal([]string{"a.txt", "sub/b.txt"}, downloaded)
+}
+
+func TestRecursiveDownload_Failed(t *testing.T) {
+	assert := testifyassert.New(t)
+	server := newIndexServer(map[string]string{"a.txt": "a", "broken.txt": "b"})
+	defer server.Close()
+	// the listed broken file can not be downloaded
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "broken.txt") {
+			// close the connection to fail the download
+			hj, _ := w.(http.Hijacker)
+			conn, _, _ := hj.Hijack()
+			conn.Close()
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+	output, err := ioutil.TempDir("", "dfget-recursive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(output)
+
+	withRecursiveConfig(t, nil, nil)
+	dfgetConfig.URL = server.URL + "/dataset/"
+	err = recursiveDownload(context.Background(), nil, output, nil)
+	assert.NotNil(err)
+	assert.Contains(fmt.Sprint(err), "broken.txt")
+	_, err = os.Stat(filepath.Join(output, "a.txt"))
+	assert.Nil(err)
+}
+
+func TestRunParallel(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	var running, maxRunning, calls int32
+	runParallel(context.Background(), 3, 10, func(i int) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&calls, 1)
+	})
+	assert.Equal(int32(10), calls)
+	assert.True(maxRunning <= 3)
+
+	// no more calls start after ctx is done
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	runParallel(ctx, 1, 10, func(i int) {
+		atomic.AddInt32(&calls, 1)
+		cancel()
+	})
+	assert.Equal(int32(1), calls)
+}
@@ -39,7 +39,6 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"d7y.io/dragonfly/v2/cdnsystem/source"
-	"d7y.io/dragonfly/v2/client/clientutil/progressbar"
 	"d7y.io/dragonfly/v2/client/config"
 	"d7y.io/dragonfly/v2/client/pidfile"
 	"d7y.io/dragonfly/v2/pkg/basic"
@@ -51,10 +50,13 @@ import (
 	dfdaemongrpc "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
 	_ "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
 	dfclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
 )
 
 var filter string
 
+var digest string
+
 var dfgetConfig *config.ClientOption
 
 // dfgetDescription is used to describe dfget command in details.
@@ -72,6 +74,7 @@ $ dfget -u https://example.com/1G -o /tmp/d7y.test
 Task: 4d07b1df273af9c830296903f0ba0cc2290dc630b26f634d6ac95cddfce6a0ef
 Peer: 10.0.0.1-30-59c54ceb-868a-4897-9832-577d2b347cce
 Download success, time cost: 2008ms, length: 1073741824
+Download from peers: 805306368, cdn: 268435456, source: 0
 `
 
 var deprecatedFlags struct {
@@ -98,6 +101,14 @@ var rootCmd = &cobra.Command{
 		// Convent deprecated flags
 		convertDeprecatedFlags()
 
+		if digest != "" {
+			algorithm, encoded, err := digestutils.Parse(digest)
+			if err != nil {
+				return err
+			}
+			dfgetConfig.DigestMethod, dfgetConfig.DigestValue = algorithm, encoded
+		}
+
 		// Dfget config validate
 		if err := dfgetConfig.Validate(); err != nil {
 			return err
@@ -127,9 +138,10 @@ func init() {
 	flagSet := rootCmd.Flags()
 	persistentflagSet := rootCmd.PersistentFlags()
 
-	flagSet.StringVarP(&dfgetConfig.URL, "url", "u", "", "URL of user requested downloading file(only HTTP/HTTPs supported)")
+	flagSet.StringVarP(&dfgetConfig.URL, "url", "u", "", "URL of user requested downloading file(HTTP/HTTPs and OSS supported)")
 	flagSet.StringVarP(&dfgetConfig.Output, "output", "o", "",
-		"destination path which is used to store the requested downloading file. It must contain detailed directory and specific filename, for example, '/tmp/file.mp4'")
+		"destination path which is used to store the requested downloading file. It must contain detailed directory and specific filename, for example, '/tmp/file.mp4', "+
+			"or '-' to stream the content to stdout while downloading, for example, 'dfget -u https://example.com/image.tar -O - | docker load'")
 	flagSet.StringVarP(&dfgetConfig.Output, "", "O", "", "Deprecated, keep for backward compatibility, use --output or -o instead")
 	flagSet.Var(config.NewLimitRateValue(&daemonConfig.Download.TotalRateLimit), "totallimit",
 		"network bandwidth rate limit for the whole host, in format of G(B)/g/M(B)/m/K(B)/k/B, pure number will also be parsed as Byte")
@@ -139,6 +151,9 @@ func init() {
 		"timeout for file downloading task. If dfget has not finished downloading all pieces of file before --timeout, the dfget will throw an error and exit")
 	flagSet.StringVarP(&dfgetConfig.Md5, "md5", "m", "",
 		"md5 value input from user for the requested downloading file to enhance security")
+	flagSet.StringVar(&digest, "digest", "",
+		"digest input from user for the requested downloading file in the form of algorithm:hex, eg: sha256:xxx, supported algorithms are md5, sha256 and sha512."+
+			" the file is verified with it and the downloading task is shared by urls with the same digest")
 	flagSet.StringVarP(&dfgetConfig.Identifier, "identifier", "i", "",
 		"the usage of identifier is making different downloading tasks generate different downloading task IDs even if they have the same URLs. conflict with --md5.")
 	flagSet.StringVar(&dfgetConfig.CallSystem, "callsystem", "",
@@ -167,10 +182,25 @@ func init() {
 		"specify the size of client queue which controls the number of pieces that can be processed simultaneously")
 	flagSet.BoolVarP(&dfgetConfig.ShowBar, "showbar", "b", false,
 		"show progress bar, it is conflict with '--console'")
+	flagSet.BoolVar(&dfgetConfig.JSONProgress, "json", false,
+		"print the download progress to stdout as json lines instead of the progress bar, each line has the content length, completed length, "+
+			"total piece, rate(bytes per second), eta(seconds) and the length downloaded from peers, cdn and source, other messages are printed to stderr")
 	flagSet.BoolVar(&dfgetConfig.Console, "console", false,
 		"show log on console, it's conflict with '--showbar'")
 	flagSet.BoolVar(&dfgetConfig.Verbose, "verbose", true,
 		"enable verbose mode, all debug log will be display")
+	flagSet.BoolVarP(&dfgetConfig.Recursive, "recursive", "r", false,
+		"download all files under the url recursively, the url must be a http(s) directory index, an oss prefix or a s3 prefix, and the output must be a directory")
+	flagSet.StringSliceVar(&dfgetConfig.Include, "include", nil,
+		"only download files whose relative path or name matches one of the glob patterns in recursive mode, eg: --include '*.tar.gz'")
+	flagSet.StringSliceVar(&dfgetConfig.Exclude, "exclude", nil,
+		"skip files whose relative path or name matches one of the glob patterns in recursive mode, eg: --exclude 'tmp/*'")
+	flagSet.IntVar(&dfgetConfig.Parallel, "parallel", dfgetConfig.Parallel,
+		"the number of files downloaded at the same time in recursive and batch mode")
+	flagSet.StringVar(&dfgetConfig.InputFile, "input-file", "",
+		"download all files in the input file over one connection of daemon, each line is a url with an optional output path separated by blanks, "+
+			"or a json record with url, output, digest, header, filter and biz_id, eg: {\"url\": \"https://example.com/a\", \"output\": \"/tmp/a\", \"digest\": \"sha256:xxx\"}, "+
+			"the output paths must be different")
 	persistentflagSet.StringVar(&daemonConfig.WorkHome, "home", daemonConfig.WorkHome,
 		"the work home directory")
 	persistentflagSet.StringVar(&daemonConfig.Host.ListenIP, "ip", daemonConfig.Host.ListenIP,
@@ -240,16 +270,20 @@ func runDfget() error {
 	// Initialize verbose mode
 	initVerboseMode(dfgetConfig.Verbose)
 
-	// Check df daemon state, start a new daemon if necessary
-	daemonClient, err := checkAndSpawnDaemon(addr)
+	daemonClient, err := newDaemonClient(addr)
 	if err != nil {
 		logger.Errorf("connect daemon error: %s", err)
-		return downloadFromSource(hdr, err)
+	}
+	// without daemon, every file will be downloaded from source in recursive and batch mode
+	if daemonClient == nil && !dfgetConfig.Recursive && dfgetConfig.InputFile == "" {
+		return downloadFromSource(newDownRequest(dfgetConfig.URL, dfgetConfig.Output, hdr), err)
 	}
 
-	output, err := filepath.Abs(dfgetConfig.Output)
-	if err != nil {
-		return err
+	output := dfgetConfig.Output
+	if output != config.OutputStdout {
+		if output, err = filepath.Abs(output); err != nil {
+			return err
+		}
 	}
 
 	if dfgetConfig.Timeout > 0 {
@@ -260,19 +294,17 @@ func runDfget() error {
 		defer cancel()
 	}
 
-	request := &dfdaemongrpc.DownRequest{
-		Url: dfgetConfig.URL,
-		UrlMeta: &base.UrlMeta{
-			Md5:    dfgetConfig.Md5,
-			Range:  hdr[headers.Range],
-			Header: hdr,
-		},
-		Output: output,
-		BizId:  dfgetConfig.CallSystem,
-		Filter: filter,
-		Uid:    int64(basic.UserId),
-		Gid:    int64(basic.UserGroup),
+	if dfgetConfig.Recursive {
+		return recursiveDownload(ctx, daemonClient, output, hdr)
+	}
+	if dfgetConfig.InputFile != "" {
+		return batchDownload(ctx, daemonClient, hdr)
 	}
+	if output == config.OutputStdout {
+		return streamDownload(ctx, daemonClient, hdr)
+	}
+
+	request := newDownRequest(dfgetConfig.URL, output, hdr)
 	var (
 		start = time.Now()
 		end   time.Time
@@ -284,7 +316,7 @@ func runDfget() error {
 	var (
 		result *dfdaemongrpc.DownResult
 	)
-	pb := progressbar.DefaultBytes(-1, "Downloading")
+	progress := newDownloadProgress(dfgetConfig.JSONProgress, progressWriter())
 	for {
 		result, err = down.Recv()
 		if err != nil {
@@ -295,25 +327,38 @@ func runDfget() error {
 			}
 			break
 		}
-		if result.CompletedLength > 0 {
-			pb.Set64(int64(result.CompletedLength))
-		}
 		if result.Done {
-			pb.Describe("Downloaded")
-			pb.Finish()
 			end = time.Now()
-			fmt.Printf("Task: %s\nPeer: %s\n", result.TaskId, result.PeerId)
-			fmt.Printf("Download success, time cost: %dms, length: %d\n", end.Sub(start).Milliseconds(), result.CompletedLength)
+			progress.finish(result, end.Sub(start))
 			break
 		}
+		progress.update(result)
 	}
 	if err != nil {
 		logger.Errorf("download by dragonfly error: %s", err)
-		return downloadFromSource(hdr, err)
+		return downloadFromSource(request, err)
 	}
 	return err
 }
 
+func newDownRequest(url, output string, hdr map[string]string) *dfdaemongrpc.DownRequest {
+	return &dfdaemongrpc.DownRequest{
+		Url: url,
+		UrlMeta: &base.UrlMeta{
+			Md5:    dfgetConfig.Md5,
+			Digest: dfgetConfig.Digest(),
+			Range:  hdr[headers.Range],
+			Header: hdr,
+		},
+		Output:  output,
+		BizId:   dfgetConfig.CallSystem,
+		Filter:  filter,
+		Uid:     int64(basic.UserId),
+		Gid:     int64(basic.UserGroup),
+		CdnOnly: dfgetConfig.Pattern == config.PatternCDN,
+	}
+}
+
 func initVerboseMode(verbose bool) {
 	if !verbose {
 		return
@@ -342,8 +387,35 @@ func initVerboseMode(verbose bool) {
 	}()
 }
 
-func downloadFromSource(hdr map[string]string, dferr error) (err error) {
-	if dfgetConfig.NotBackSource {
+// messageWriter returns the writer for messages to user,
+// it is stderr when the content is streamed to stdout or the progress is printed as json
+func messageWriter() io.Writer {
+	if dfgetConfig.Output == config.OutputStdout || dfgetConfig.JSONProgress {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// progressWriter returns the writer of the download progress, the json progress is always printed to stdout
+func progressWriter() io.Writer {
+	if dfgetConfig.JSONProgress {
+		return os.Stdout
+	}
+	return messageWriter()
+}
+
+// noDaemonError returns the cause of downloading from source without daemon, it is nil in source pattern
+func noDaemonError() error {
+	if dfgetConfig.Pattern == config.PatternSource {
+		return nil
+	}
+	return fmt.Errorf("daemon is not available")
+}
+
+// downloadFromSource downloads the file of request from source directly when dragonfly failed with dferr,
+// dferr is nil in source pattern
+func downloadFromSource(request *dfdaemongrpc.DownRequest, dferr error) (err error) {
+	if dferr != nil && dfgetConfig.NotBackSource {
 		err = fmt.Errorf("dfget download error: %s, and back source disabled", dferr)
 		logger.Warnf("%s", err)
 		return err
@@ -354,7 +426,9 @@ func downloadFromSource(hdr map[string]string, dferr error) (err error) {
 		end   time.Time
 	)
 
-	fmt.Printf("dfget download error: %s, try to download from source", dferr)
+	if dferr != nil {
+		fmt.Fprintf(messageWriter(), "dfget download error: %s, try to download from source\n", dferr)
+	}
 	var (
 		resourceClient source.ResourceClient
 		target         *os.File
@@ -369,28 +443,38 @@ func downloadFromSource(hdr map[string]string, dferr error) (err error) {
 		return err
 	}
 
-	response, _, err = resourceClient.Download(dfgetConfig.URL, hdr)
+	response, _, err = resourceClient.Download(request.Url, request.UrlMeta.Header)
 	if err != nil {
 		logger.Errorf("download from source error: %s", err)
 		return err
 	}
 	defer response.Close()
 
-	target, err = os.OpenFile(dfgetConfig.Output, os.O_RDWR|os.O_CREATE, 0644)
-	if err != nil {
-		logger.Errorf("open %s error: %s", dfgetConfig.Output, err)
-		return err
+	if request.Output == config.OutputStdout {
+		target = os.Stdout
+	} else {
+		target, err = os.OpenFile(request.Output, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			logger.Errorf("open %s error: %s", request.Output, err)
+			return err
+		}
+		defer target.Close()
 	}
 
-	written, err = io.Copy(target, response)
+	var reader io.Reader = response
+	if d := request.UrlMeta.Digest; d != "" {
+		reader = digestutils.NewDigestReader(response, d)
+	}
+
+	written, err = io.Copy(target, reader)
 	if err == nil {
-		logger.Infof("copied %d bytes to %s", written, dfgetConfig.Output)
+		logger.Infof("copied %d bytes to %s", written, request.Output)
 		end = time.Now()
-		fmt.Printf("Download from source success, time cost: %dms\n", end.Sub(start).Milliseconds())
+		fmt.Fprintf(messageWriter(), "Download from source success, time cost: %dms\n", end.Sub(start).Milliseconds())
 		return nil
 	}
 	logger.Errorf("copied %d bytes to %s, with error: %s",
-		written, dfgetConfig.Output, err)
+		written, request.Output, err)
 	return err
 }
 
@@ -418,6 +502,16 @@ func checkAndSpawnDaemon(addr dfnet.NetAddr) (dfclient.DaemonClient, error) {
 	return probeDaemon(addr)
 }
 
+// newDaemonClient checks df daemon state and starts a new daemon if necessary,
+// the client is nil in source pattern, which downloads from source directly without daemon
+func newDaemonClient(addr dfnet.NetAddr) (dfclient.DaemonClient, error) {
+	if dfgetConfig.Pattern == config.PatternSource {
+		logger.Infof("download from source directly in %s pattern", dfgetConfig.Pattern)
+		return nil, nil
+	}
+	return checkAndSpawnDaemon(addr)
+}
+
 func probeDaemon(addr dfnet.NetAddr) (dfclient.DaemonClient, error) {
 	dc, err := dfclient.GetClientByAddr([]dfnet.NetAddr{addr})
 	if err != nil {
@@ -432,6 +526,31 @@ func probeDaemon(addr dfnet.NetAddr) (dfclient.DaemonClient, error) {
 	return dc, nil
 }
 
+// connectDaemon connects the daemon with the unix socket, sock overrides the one in daemon config when it is set,
+// the daemon is spawned if necessary when spawn is true
+func connectDaemon(sock string, spawn bool) (dfclient.DaemonClient, dfnet.NetAddr, error) {
+	if sock != "" {
+		daemonConfig.Download.DownloadGRPC.UnixListen.Socket = sock
+	}
+	var (
+		addr = dfnet.NetAddr{
+			Type: dfnet.UNIX,
+			Addr: daemonConfig.Download.DownloadGRPC.UnixListen.Socket,
+		}
+		dc  dfclient.DaemonClient
+		err error
+	)
+	if spawn {
+		dc, err = checkAndSpawnDaemon(addr)
+	} else {
+		dc, err = probeDaemon(addr)
+	}
+	if err != nil {
+		return nil, addr, fmt.Errorf("connect daemon error: %s", err)
+	}
+	return dc, addr, nil
+}
+
 func spawnDaemon() error {
 	// Initialize lock file
 	lock := flock.New(dfgetConfig.LockFile)
@@ -0,0 +1,69 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+)
+
+func TestRunDfget_SourcePattern(t *testing.T) {
+	assert := testifyassert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "content from source")
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "dfget-source-pattern")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	originDfget, originDaemon := dfgetConfig, daemonConfig
+	defer func() { dfgetConfig, daemonConfig = originDfget, originDaemon }()
+	option := *config.NewClientOption()
+	dfgetConfig = &option
+	dfgetConfig.URL = server.URL + "/file"
+	dfgetConfig.Output = filepath.Join(dir, "file")
+	dfgetConfig.Pattern = config.PatternSource
+	dfgetConfig.LockFile = filepath.Join(dir, "dfget.lock")
+	// neither the daemon is running nor its socket exists, the daemon is spawned if it is checked
+	daemonOption := *config.NewPeerHostOption()
+	daemonConfig = &daemonOption
+	daemonConfig.PidFile = filepath.Join(dir, "daemon.pid")
+	daemonConfig.Download.DownloadGRPC.UnixListen.Socket = filepath.Join(dir, "daemon.sock")
+
+	client, err := newDaemonClient(dfnet.NetAddr{Type: dfnet.UNIX, Addr: daemonConfig.Download.DownloadGRPC.UnixListen.Socket})
+	assert.Nil(client)
+	assert.Nil(err, "daemon is not checked in source pattern")
+
+	assert.Nil(runDfget())
+	content, err := ioutil.ReadFile(dfgetConfig.Output)
+	assert.Nil(err)
+	assert.Equal("content from source", string(content))
+}
@@ -0,0 +1,109 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"d7y.io/dragonfly/v2/client/clientutil/progressbar"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
+	logger "d7y.io/dragonfly/v2/pkg/dflog"
+	dfdaemongrpc "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
+	dfclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+)
+
+// chunkReader reads the content from the stream of daemon
+type chunkReader struct {
+	stream        dfdaemongrpc.Daemon_StreamDownloadClient
+	buf           []byte
+	taskID        string
+	peerID        string
+	contentLength int64
+	// received is the length of content received from daemon
+	received int64
+	// onFirstChunk is called when the first chunk is received
+	onFirstChunk func(*chunkReader)
+}
+
+func (cr *chunkReader) Read(p []byte) (int, error) {
+	for len(cr.buf) == 0 {
+		chunk, err := cr.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		if cr.taskID == "" && chunk.TaskId != "" {
+			cr.taskID, cr.peerID, cr.contentLength = chunk.TaskId, chunk.PeerId, chunk.ContentLength
+			if cr.onFirstChunk != nil {
+				cr.onFirstChunk(cr)
+			}
+		}
+		cr.buf = chunk.Data
+	}
+	n := copy(p, cr.buf)
+	cr.buf = cr.buf[n:]
+	cr.received += int64(n)
+	return n, nil
+}
+
+// streamDownload writes the content to stdout while downloading, messages are written to stderr
+func streamDownload(ctx context.Context, daemonClient dfclient.DaemonClient, hdr map[string]string) error {
+	request := newDownRequest(dfgetConfig.URL, dfgetConfig.Output, hdr)
+	start := time.Now()
+	stream, err := daemonClient.StreamDownload(ctx, request)
+	if err != nil {
+		logger.Errorf("stream download by dragonfly error: %s", err)
+		return downloadFromSource(request, err)
+	}
+
+	pb := progressbar.DefaultBytes(-1, "Downloading")
+	cr := &chunkReader{
+		stream: stream,
+		onFirstChunk: func(cr *chunkReader) {
+			if cr.contentLength >= 0 {
+				pb.ChangeMax64(cr.contentLength)
+			}
+		},
+	}
+	var reader io.Reader = cr
+	if d := request.UrlMeta.Digest; d != "" {
+		reader = digestutils.NewDigestReader(reader, d)
+	}
+
+	written, err := io.Copy(io.MultiWriter(os.Stdout, pb), reader)
+	if err != nil {
+		if de, ok := err.(*dferrors.DfError); ok {
+			logger.Errorf("dragonfly daemon returns error code %d/%s", de.Code, de.Message)
+		} else {
+			logger.Errorf("stream download error after %d bytes: %s", written, err)
+		}
+		// the content can not be taken back once it is written to stdout
+		if written == 0 && cr.received == 0 {
+			return downloadFromSource(request, err)
+		}
+		return err
+	}
+	pb.Describe("Downloaded")
+	pb.Finish()
+	fmt.Fprintf(os.Stderr, "Task: %s\nPeer: %s\n", cr.taskID, cr.peerID)
+	fmt.Fprintf(os.Stderr, "Download success, time cost: %dms, length: %d\n", time.Now().Sub(start).Milliseconds(), written)
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"d7y.io/dragonfly/v2/pkg/dflog/logcore"
+	dfdaemongrpc "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon"
+	dfclient "d7y.io/dragonfly/v2/pkg/rpc/dfdaemon/client"
+	"d7y.io/dragonfly/v2/pkg/unit"
+	"d7y.io/dragonfly/v2/pkg/util/net/urlutils"
+)
+
+// taskOption holds the flags shared by task commands
+var taskOption struct {
+	peerID  string
+	sock    string
+	json    bool
+	console bool
+}
+
+// taskExample shows examples in dfget task command, and is used in auto-generated cli docs.
+var taskExample = `
+$ dfget task list
+TASK ID                                                           PEER ID                                           URL                     SIZE   COMPLETION  STATUS   LAST ACCESS          UPLOAD  PEERS
+4d07b1df273af9c830296903f0ba0cc2290dc630b26f634d6ac95cddfce6a0ef  10.0.0.1-30-59c54ceb-868a-4897-9832-577d2b347cce  https://example.com/1G  1.0GB  100%        done     2021-07-01 10:00:00  3.0GB   3
+9c7ab8f2ed9d01a35b0a8f1e4e7f5c3d2b1a09f8e7d6c5b4a39281706f5e4d3c  10.0.0.1-31-6a4b9c7e-2d0f-4a85-b3c1-8f6e5d4c3b2a  https://example.com/2G  2.0GB  37%         running  2021-07-01 10:01:00  0.0B    0
+
+$ dfget task cancel https://example.com/2G
+$ dfget task delete 4d07b1df273af9c830296903f0ba0cc2290dc630b26f634d6ac95cddfce6a0ef
+`
+
+var taskCmd = &cobra.Command{
+	Use:   "task",
+	Short: "manage the tasks of the local daemon",
+	Long: `Show the tasks in local storage and the running peer tasks of the daemon,
+cancel the running peer tasks, or delete the cached tasks on demand.
+A task is specified by the task id or the url used for downloading.`,
+	Example:           taskExample,
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+}
+
+var taskListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the tasks in local storage and the running peer tasks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logcore.InitDfget(taskOption.console)
+		dc, addr, err := connectDaemon(taskOption.sock, false)
+		if err != nil {
+			return err
+		}
+		list, err := dc.ListTasks(context.Background(), addr)
+		if err != nil {
+			return err
+		}
+		return printTasks(os.Stdout, list.Tasks)
+	},
+}
+
+var taskStatCmd = &cobra.Command{
+	Use:   "stat <task id | url>",
+	Short: "show the task in local storage or the running peer task",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTask(args[0], func(ctx context.Context, dc dfclient.DaemonClient, req *dfdaemongrpc.TaskRequest) error {
+			info, err := dc.StatTask(ctx, req)
+			if err != nil {
+				return err
+			}
+			return printTasks(os.Stdout, []*dfdaemongrpc.TaskInfo{info})
+		})
+	},
+}
+
+var taskCancelCmd = &cobra.Command{
+	Use:   "cancel <task id | url>",
+	Short: "cancel the running peer tasks of the task",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTask(args[0], func(ctx context.Context, dc dfclient.DaemonClient, req *dfdaemongrpc.TaskRequest) error {
+			if err := dc.CancelTask(ctx, req); err != nil {
+				return err
+			}
+			fmt.Printf("Canceled: %s\n", args[0])
+			return nil
+		})
+	},
+}
+
+var taskDeleteCmd = &cobra.Command{
+	Use:   "delete <task id | url>",
+	Short: "delete the task in local storage, running peer tasks must be canceled first",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTask(args[0], func(ctx context.Context, dc dfclient.DaemonClient, req *dfdaemongrpc.TaskRequest) error {
+			if err := dc.DeleteTask(ctx, req); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted: %s\n", args[0])
+			return nil
+		})
+	},
+}
+
+func init() {
+	flagSet := taskCmd.PersistentFlags()
+	flagSet.StringVar(&taskOption.sock, "daemon-sock", "",
+		"the unix domain socket address for grpc with daemon, default is the one in daemon config")
+	flagSet.BoolVar(&taskOption.json, "json", false, "print tasks as json lines")
+	flagSet.BoolVar(&taskOption.console, "console", false, "show log on console")
+	for _, cmd := range []*cobra.Command{taskStatCmd, taskCancelCmd, taskDeleteCmd} {
+		cmd.Flags().StringVar(&taskOption.peerID, "peer", "", "only operate the peer task of the peer id")
+	}
+
+	taskCmd.AddCommand(taskListCmd, taskStatCmd, taskCancelCmd, taskDeleteCmd)
+	rootCmd.AddCommand(taskCmd)
+}
+
+// runTask connects daemon and calls fn with the task request of the task id or url
+func runTask(task string, fn func(context.Context, dfclient.DaemonClient, *dfdaemongrpc.TaskRequest) error) error {
+	logcore.InitDfget(taskOption.console)
+
+	dc, _, err := connectDaemon(taskOption.sock, false)
+	if err != nil {
+		return err
+	}
+	req := &dfdaemongrpc.TaskRequest{
+		PeerId: taskOption.peerID,
+	}
+	if urlutils.IsValidURL(task) {
+		req.Url = task
+	} else {
+		req.TaskId = task
+	}
+	return fn(context.Background(), dc, req)
+}
+
+// printTasks prints tasks as a table, or json lines with --json
+func printTasks(w io.Writer, tasks []*dfdaemongrpc.TaskInfo) error {
+	if taskOption.json {
+		encoder := json.NewEncoder(w)
+		for _, task := range tasks {
+			if err := encoder.Encode(task); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TASK ID\tPEER ID\tURL\tSIZE\tCOMPLETION\tSTATUS\tLAST ACCESS\tUPLOAD\tPEERS")
+	for _, task := range tasks {
+		size, completion := "-", "-"
+		if task.ContentLength >= 0 {
+			size = unit.ToBytes(task.ContentLength).String()
+			completion = "100%"
+			if task.ContentLength > 0 {
+				completion = fmt.Sprintf("%d%%", task.CompletedLength*100/task.ContentLength)
+			}
+		}
+		lastAccess := "-"
+		if task.LastAccess > 0 {
+			lastAccess = time.Unix(0, task.LastAccess).Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\n", task.TaskId, task.PeerId, task.Url, size, completion,
+			taskStatus(task), lastAccess, unit.ToBytes(task.UploadLength), task.ServedPeers)
+	}
+	return tw.Flush()
+}
+
+func taskStatus(task *dfdaemongrpc.TaskInfo) string {
+	switch {
+	case task.Done:
+		return "done"
+	case task.Running:
+		return "running"
+	default:
+		return "partial"
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"d7y.io/dragonfly/v2/manager/config"
+	"d7y.io/dragonfly/v2/manager/configsvc"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateFrom string
+	migrateTo   string
+)
+
+// migrateCmd copies all configs from one configured store into another
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "migrate configs between stores",
+	Long: `migrate copies all configs from one store defined in the manager
+configuration into another one, e.g. from memory or mysql into bolt.`,
+	Args:              cobra.NoArgs,
+	DisableAutoGenTag: true,
+	SilenceUsage:      true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigrate()
+	},
+}
+
+func init() {
+	flagSet := migrateCmd.Flags()
+	flagSet.StringVarP(&cfgFile, "config", "f", "", "the path of configuration file")
+	flagSet.StringVar(&migrateFrom, "from", "", "name of the source store")
+	flagSet.StringVar(&migrateTo, "to", "", "name of the destination store")
+
+	rootCmd.AddCommand(migrateCmd)
+}
+
+func runMigrate() error {
+	if migrateFrom == "" || migrateTo == "" {
+		return errors.New("both --from and --to are required")
+	}
+
+	if migrateFrom == migrateTo {
+		return errors.Errorf("source and destination store are the same: %s", migrateFrom)
+	}
+
+	src, err := openStore(migrateFrom)
+	if err != nil {
+		return err
+	}
+
+	dst, err := openStore(migrateTo)
+	if err != nil {
+		return err
+	}
+
+	count, err := configsvc.Migrate(context.Background(), src, dst)
+	if err != nil {
+		return errors.Wrapf(err, "migrate configs from %s to %s, %d migrated", migrateFrom, migrateTo, count)
+	}
+
+	fmt.Printf("migrated %d configs from %s to %s\n", count, migrateFrom, migrateTo)
+	return nil
+}
+
+func openStore(name string) (configsvc.Store, error) {
+	var storeCfg *config.StoreConfig
+	for _, store := range cfg.Stores {
+		if store.Name == name {
+			storeCfg = store
+			break
+		}
+	}
+
+	if storeCfg == nil {
+		return nil, errors.Errorf("store %s not found in configuration", name)
+	}
+
+	store, err := configsvc.NewStore(storeCfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open store %s", name)
+	}
+
+	return store, nil
+}
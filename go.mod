@@ -19,6 +19,7 @@ require (
 	github.com/golang/protobuf v1.4.3
 	github.com/google/uuid v1.1.5
 	github.com/gorilla/mux v1.7.3
+	github.com/klauspost/compress v1.11.8
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/mattn/go-runewidth v0.0.9
 	github.com/mitchellh/colorstring v0.0.0-20190213212951-d06e56a500db
@@ -41,6 +42,7 @@ require (
 	github.com/swaggo/swag v1.7.0
 	github.com/valyala/fasthttp v1.22.0
 	github.com/willf/bitset v1.1.11
+	go.etcd.io/bbolt v1.3.5
 	go.opentelemetry.io/otel v0.19.0
 	go.opentelemetry.io/otel/exporters/trace/jaeger v0.19.0
 	go.opentelemetry.io/otel/sdk v0.19.0
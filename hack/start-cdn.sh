@@ -1,9 +0,0 @@
-#!/usr/bin/env bash
-
-set -o nounset
-set -o errexit
-set -o pipefail
-
-nginx
-
-/opt/dragonfly/df-cdn/cdn "$@"
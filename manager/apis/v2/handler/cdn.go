@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
+	"github.com/gin-gonic/gin"
+)
+
+// cdnAdminTasksPath is the path of the tasks api served on the admin port of cdn
+const cdnAdminTasksPath = "/admin/tasks"
+
+// cdnAdminClient never follows redirects, so the requests are only sent to the admin api of registered cdns
+var cdnAdminClient = &http.Client{
+	Timeout: 30 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// ListCdnTasks godoc
+// @Summary List cdn tasks
+// @Description list the tasks cached by a cdn
+// @Tags cdns
+// @Accept  json
+// @Produce  json
+// @Param cdn path string true "Object of cdn, the cdn must be registered with a config enabling the admin api"
+// @Success 200 {array} types.CdnTask
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Failure 500 {object} HTTPError
+// @Failure 502 {object} HTTPError
+// @Router /cdns/{cdn}/tasks [get]
+func (handler *Handler) ListCdnTasks(ctx *gin.Context) {
+	handler.proxyCdnAdmin(ctx, http.MethodGet, "", nil)
+}
+
+// GetCdnTask godoc
+// @Summary Get a cdn task
+// @Description get a task cached by a cdn with its pieces
+// @Tags cdns
+// @Accept  json
+// @Produce  json
+// @Param cdn path string true "Object of cdn, the cdn must be registered with a config enabling the admin api"
+// @Param id path string true "Task ID"
+// @Success 200 {object} types.CdnTaskDetail
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Failure 500 {object} HTTPError
+// @Failure 502 {object} HTTPError
+// @Router /cdns/{cdn}/tasks/{id} [get]
+func (handler *Handler) GetCdnTask(ctx *gin.Context) {
+	handler.proxyCdnAdmin(ctx, http.MethodGet, "/"+ctx.Param("id"), nil)
+}
+
+// PurgeCdnTask godoc
+// @Summary Purge a cdn task
+// @Description purge a task cached by a cdn from both memory and storage
+// @Tags cdns
+// @Accept  json
+// @Produce  json
+// @Param cdn path string true "Object of cdn, the cdn must be registered with a config enabling the admin api"
+// @Param id path string true "Task ID"
+// @Success 200 {object} types.PurgeCdnTaskResponse
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Failure 409 {object} HTTPError
+// @Failure 500 {object} HTTPError
+// @Failure 502 {object} HTTPError
+// @Router /cdns/{cdn}/tasks/{id} [delete]
+func (handler *Handler) PurgeCdnTask(ctx *gin.Context) {
+	handler.proxyCdnAdmin(ctx, http.MethodDelete, "/"+ctx.Param("id"), nil)
+}
+
+// PurgeCdnTaskByURL godoc
+// @Summary Purge a cdn task by url
+// @Description purge a task cached by a cdn, the task id is computed from the url the same way as downloading
+// @Tags cdns
+// @Accept  json
+// @Produce  json
+// @Param cdn path string true "Object of cdn, the cdn must be registered with a config enabling the admin api"
+// @Param url query string true "URL of task"
+// @Param filter query string false "Filter of url query parameters, separated by &"
+// @Param md5 query string false "Md5 of task"
+// @Param range query string false "Range of task"
+// @Param bizId query string false "Biz ID of task"
+// @Success 200 {object} types.PurgeCdnTaskResponse
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Failure 409 {object} HTTPError
+// @Failure 500 {object} HTTPError
+// @Failure 502 {object} HTTPError
+// @Router /cdns/{cdn}/tasks [delete]
+func (handler *Handler) PurgeCdnTaskByURL(ctx *gin.Context) {
+	if ctx.Query("url") == "" {
+		NewError(ctx, http.StatusBadRequest, errors.New("must set url of task you want purge in query of http protocol"))
+		return
+	}
+	query := url.Values{}
+	for _, key := range []string{"url", "filter", "md5", "range", "bizId"} {
+		if value := ctx.Query(key); value != "" {
+			query.Set(key, value)
+		}
+	}
+	handler.proxyCdnAdmin(ctx, http.MethodDelete, "", query)
+}
+
+// PinCdnTask godoc
+// @Summary Pin a cdn task
+// @Description pin a task cached by a cdn, so that it is never removed by gc
+// @Tags cdns
+// @Accept  json
+// @Produce  json
+// @Param cdn path string true "Object of cdn, the cdn must be registered with a config enabling the admin api"
+// @Param id path string true "Task ID"
+// @Success 200 {object} types.CdnTask
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Failure 500 {object} HTTPError
+// @Failure 502 {object} HTTPError
+// @Router /cdns/{cdn}/tasks/{id}/pin [put]
+func (handler *Handler) PinCdnTask(ctx *gin.Context) {
+	handler.proxyCdnAdmin(ctx, http.MethodPut, "/"+ctx.Param("id")+"/pin", nil)
+}
+
+// UnpinCdnTask godoc
+// @Summary Unpin a cdn task
+// @Description unpin a task cached by a cdn, so that it can be removed by gc again
+// @Tags cdns
+// @Accept  json
+// @Produce  json
+// @Param cdn path string true "Object of cdn, the cdn must be registered with a config enabling the admin api"
+// @Param id path string true "Task ID"
+// @Success 200 {object} types.CdnTask
+// @Failure 400 {object} HTTPError
+// @Failure 404 {object} HTTPError
+// @Failure 500 {object} HTTPError
+// @Failure 502 {object} HTTPError
+// @Router /cdns/{cdn}/tasks/{id}/pin [delete]
+func (handler *Handler) UnpinCdnTask(ctx *gin.Context) {
+	handler.proxyCdnAdmin(ctx, http.MethodDelete, "/"+ctx.Param("id")+"/pin", nil)
+}
+
+// proxyCdnAdmin forwards the request to the admin api of the cdn in path,
+// the json body of cdn is returned as it is and the errors are converted to HTTPError.
+// The address of admin api is taken from the config of the cdn, so only the registered cdns can be requested.
+func (handler *Handler) proxyCdnAdmin(ctx *gin.Context, method string, path string, query url.Values) {
+	object := ctx.Param("cdn")
+	if object == "" {
+		NewError(ctx, http.StatusBadRequest, errors.New("must set cdn in path of http protocol"))
+		return
+	}
+
+	admin, err := handler.server.GetCdnAdmin(ctx.Request.Context(), object)
+	if err != nil {
+		if dferrors.CheckError(err, dfcodes.ManagerConfigNotFound) {
+			NewError(ctx, http.StatusNotFound, err)
+		} else if dferrors.CheckError(err, dfcodes.ManagerConfigInvalid) {
+			NewError(ctx, http.StatusBadRequest, err)
+		} else {
+			NewError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	target := url.URL{Scheme: "http", Host: admin.Addr, Path: cdnAdminTasksPath + path, RawQuery: query.Encode()}
+	req, err := http.NewRequestWithContext(ctx.Request.Context(), method, target.String(), nil)
+	if err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if admin.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+admin.Token)
+	}
+	resp, err := cdnAdminClient.Do(req)
+	if err != nil {
+		NewError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		NewError(ctx, http.StatusBadGateway, err)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		NewError(ctx, resp.StatusCode, fmt.Errorf("cdn %s: %s", object, strings.TrimSpace(string(body))))
+		return
+	}
+	ctx.Data(http.StatusOK, "application/json", body)
+}
@@ -0,0 +1,120 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/manager/config"
+	"d7y.io/dragonfly/v2/manager/server/service"
+	"d7y.io/dragonfly/v2/pkg/rpc/manager"
+)
+
+func newTestCdnRouter(t *testing.T, configs ...*manager.Config) *gin.Engine {
+	server := service.NewManagerServer(&config.Config{
+		Server:        &config.ServerConfig{Port: 8004},
+		ConfigService: &config.ConfigServiceConfig{StoreName: "memory"},
+		Stores: []*config.StoreConfig{
+			{Name: "memory", Type: "memory", Memory: &config.MemoryConfig{}},
+		},
+	})
+	for _, cfg := range configs {
+		if _, err := server.AddConfig(context.Background(), &manager.AddConfigRequest{Config: cfg}); err != nil {
+			t.Fatalf("add config error: %v", err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewHandler(server)
+	cdns := router.Group("/api/v2/cdns/:cdn")
+	{
+		cdns.GET("/tasks", handler.ListCdnTasks)
+		cdns.DELETE("/tasks", handler.PurgeCdnTaskByURL)
+		cdns.GET("/tasks/:id", handler.GetCdnTask)
+		cdns.DELETE("/tasks/:id", handler.PurgeCdnTask)
+	}
+	return router
+}
+
+func TestProxyCdnAdmin(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	var requests []*http.Request
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		if r.URL.Path == cdnAdminTasksPath+"/missing" {
+			http.Error(w, "task missing not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[]`)
+	}))
+	defer admin.Close()
+	_, port, _ := net.SplitHostPort(admin.Listener.Addr().String())
+
+	router := newTestCdnRouter(t,
+		&manager.Config{Object: "cdn-0", Type: manager.ObjType_Cdn.String(), Version: 1,
+			Data: []byte("base:\n  adminPort: 1\n")},
+		// the latest config is used
+		&manager.Config{Object: "cdn-0", Type: manager.ObjType_Cdn.String(), Version: 2,
+			Data: []byte(fmt.Sprintf("base:\n  adminIP: 127.0.0.1\n  adminPort: %s\n  adminToken: secret\n", port))},
+		&manager.Config{Object: "cdn-1", Type: manager.ObjType_Cdn.String(), Version: 1,
+			Data: []byte("base:\n  listenPort: 8003\n")},
+		&manager.Config{Object: "scheduler-0", Type: manager.ObjType_Scheduler.String(), Version: 1,
+			Data: []byte("")},
+	)
+	do := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	w := do(http.MethodGet, "/api/v2/cdns/cdn-0/tasks")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("[]", w.Body.String())
+	assert.Len(requests, 1)
+	assert.Equal(cdnAdminTasksPath, requests[0].URL.Path)
+	assert.Equal("Bearer secret", requests[0].Header.Get("Authorization"))
+
+	w = do(http.MethodDelete, "/api/v2/cdns/cdn-0/tasks?url=http%3A%2F%2Fexample.com%2Fa&filter=sign")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Len(requests, 2)
+	assert.Equal(http.MethodDelete, requests[1].Method)
+	assert.Equal("http://example.com/a", requests[1].URL.Query().Get("url"))
+	assert.Equal("sign", requests[1].URL.Query().Get("filter"))
+
+	w = do(http.MethodGet, "/api/v2/cdns/cdn-0/tasks/missing")
+	assert.Equal(http.StatusNotFound, w.Code)
+	assert.Contains(w.Body.String(), "task missing not found")
+
+	// the cdns not registered with configs are never requested
+	requests = nil
+	assert.Equal(http.StatusNotFound, do(http.MethodGet, "/api/v2/cdns/cdn-x/tasks").Code)
+	assert.Equal(http.StatusNotFound, do(http.MethodGet, "/api/v2/cdns/"+admin.Listener.Addr().String()+"/tasks").Code)
+	assert.Equal(http.StatusNotFound, do(http.MethodGet, "/api/v2/cdns/scheduler-0/tasks").Code)
+	assert.Equal(http.StatusBadRequest, do(http.MethodGet, "/api/v2/cdns/cdn-1/tasks").Code, "admin api of cdn-1 is disabled")
+	assert.Empty(requests)
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"d7y.io/dragonfly/v2/manager/apis/v2/types"
+	"d7y.io/dragonfly/v2/manager/configsvc"
 	"d7y.io/dragonfly/v2/pkg/dfcodes"
 	"d7y.io/dragonfly/v2/pkg/dferrors"
 	proto "d7y.io/dragonfly/v2/pkg/rpc/manager"
@@ -47,7 +48,7 @@ func (handler *Handler) AddConfig(ctx *gin.Context) {
 	rep, err := handler.server.AddConfig(context.TODO(), req)
 	if err == nil {
 		ctx.JSON(http.StatusOK, &types.AddConfigResponse{Id: rep.GetId()})
-	} else if dferrors.CheckError(err, dfcodes.InvalidObjType) {
+	} else if dferrors.CheckError(err, dfcodes.InvalidObjType) || dferrors.CheckError(err, dfcodes.ManagerConfigInvalid) {
 		NewError(ctx, http.StatusBadRequest, err)
 	} else if dferrors.CheckError(err, dfcodes.ManagerStoreError) || dferrors.CheckError(err, dfcodes.ManagerError) {
 		NewError(ctx, http.StatusInternalServerError, err)
@@ -131,7 +132,7 @@ func (handler *Handler) UpdateConfig(ctx *gin.Context) {
 	_, err := handler.server.UpdateConfig(context.TODO(), req)
 	if err == nil {
 		ctx.JSON(http.StatusOK, "success")
-	} else if dferrors.CheckError(err, dfcodes.InvalidObjType) {
+	} else if dferrors.CheckError(err, dfcodes.InvalidObjType) || dferrors.CheckError(err, dfcodes.ManagerConfigInvalid) {
 		NewError(ctx, http.StatusBadRequest, err)
 	} else if dferrors.CheckError(err, dfcodes.ManagerStoreError) || dferrors.CheckError(err, dfcodes.ManagerError) {
 		NewError(ctx, http.StatusInternalServerError, err)
@@ -214,6 +215,37 @@ func (handler *Handler) ListConfigs(ctx *gin.Context) {
 	}
 }
 
+// ValidateConfig godoc
+// @Summary Validate a config
+// @Description validate by json config without saving it, and return the effective config merged with defaults
+// @Tags configs
+// @Accept  json
+// @Produce  json
+// @Param config body types.Config true "Validate config"
+// @Success 200 {object} types.ValidateConfigResponse
+// @Failure 400 {object} HTTPError
+// @Router /dry-run/configs [post]
+func (handler *Handler) ValidateConfig(ctx *gin.Context) {
+	var cfg types.Config
+	if err := ctx.ShouldBindJSON(&cfg); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := checkTypeConfigValidate(&cfg); err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	effective, err := configsvc.ValidateConfigData(cfg.Type, cfg.Data)
+	if err != nil {
+		NewError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &types.ValidateConfigResponse{Config: effective})
+}
+
 func protoConfig2TypeConfig(config *proto.Config) *types.Config {
 	return &types.Config{
 		Id:       config.Id,
@@ -244,8 +276,8 @@ func checkTypeConfigValidate(config *types.Config) (err error) {
 		return
 	}
 
-	if config.Type != proto.ObjType_Scheduler.String() && config.Type != proto.ObjType_Cdn.String() {
-		err = errors.New(`type in config must be one of "Cdn" or "Scheduler"`)
+	if config.Type != proto.ObjType_Scheduler.String() && config.Type != proto.ObjType_Cdn.String() && config.Type != configsvc.ClientObjType {
+		err = errors.New(`type in config must be one of "Cdn", "Scheduler" or "Client"`)
 		return
 	}
 
@@ -0,0 +1,43 @@
+package types
+
+import "time"
+
+type CdnTask struct {
+	TaskId           string    `json:"taskId"`
+	URL              string    `json:"url"`
+	TaskURL          string    `json:"taskUrl"`
+	Status           string    `json:"status"`
+	Size             int64     `json:"size"`
+	SourceFileLength int64     `json:"sourceFileLength"`
+	PieceSize        int32     `json:"pieceSize"`
+	PieceTotal       int32     `json:"pieceTotal"`
+	AccessTime       time.Time `json:"accessTime"`
+	HitCount         int64     `json:"hitCount"`
+	Pinned           bool      `json:"pinned"`
+	Registered       bool      `json:"registered"`
+}
+
+type CdnPiece struct {
+	PieceNum    int32       `json:"pieceNum"`
+	PieceLen    int32       `json:"pieceLen"`
+	Md5         string      `json:"md5"`
+	Range       *PieceRange `json:"range"`
+	OriginRange *PieceRange `json:"originRange"`
+	PieceStyle  int32       `json:"pieceStyle"`
+}
+
+type PieceRange struct {
+	StartIndex uint64 `json:"StartIndex"`
+	EndIndex   uint64 `json:"EndIndex"`
+}
+
+type CdnTaskDetail struct {
+	CdnTask
+	SourceRealMd5 string      `json:"sourceRealMd5"`
+	PieceMd5Sign  string      `json:"pieceMd5Sign"`
+	Pieces        []*CdnPiece `json:"pieces"`
+}
+
+type PurgeCdnTaskResponse struct {
+	TaskId string `json:"taskId"`
+}
@@ -47,3 +47,7 @@ type GetConfigResponse struct {
 type ListConfigsResponse struct {
 	Configs []*Config `json:"configs"`
 }
+
+type ValidateConfigResponse struct {
+	Config interface{} `json:"config"`
+}
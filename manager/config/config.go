@@ -37,12 +37,17 @@ type OssConfig struct {
 type MemoryConfig struct {
 }
 
+type BoltConfig struct {
+	Path string `yaml:"path"`
+}
+
 type StoreConfig struct {
 	Name   string        `yaml:"name"`
 	Type   string        `yaml:"type"`
 	Mysql  *MysqlConfig  `yaml:"mysql",omitempty`
 	Oss    *OssConfig    `yaml:"oss",omitempty`
 	Memory *MemoryConfig `yaml:"memory", omitempty`
+	Bolt   *BoltConfig   `yaml:"bolt",omitempty`
 }
 
 func New() *Config {
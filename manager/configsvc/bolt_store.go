@@ -0,0 +1,240 @@
+package configsvc
+
+import (
+	"context"
+	"d7y.io/dragonfly/v2/manager/config"
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
+	"encoding/json"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var configBucket = []byte("configs")
+
+type boltStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltStore opens or creates a bolt database file as the config store.
+// Every write is committed in its own transaction and synced to disk before
+// returning, so a crash never leaves a partially written config behind.
+func NewBoltStore(cfg *config.StoreConfig) (Store, error) {
+	if cfg.Bolt == nil || cfg.Bolt.Path == "" {
+		return nil, dferrors.Newf(dfcodes.ManagerConfigError, "config error: bolt path nil")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Bolt.Path), 0755); err != nil {
+		return nil, dferrors.Newf(dfcodes.ManagerStoreError, "create bolt dir error: %s", err.Error())
+	}
+
+	db, err := bbolt.Open(cfg.Bolt.Path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, dferrors.Newf(dfcodes.ManagerStoreError, "open bolt error: %s", err.Error())
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(configBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, dferrors.Newf(dfcodes.ManagerStoreError, "create bolt bucket error: %s", err.Error())
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (bolt *boltStore) AddConfig(ctx context.Context, id string, config *Config) (*Config, error) {
+	err := bolt.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(configBucket)
+		if bucket.Get([]byte(id)) != nil {
+			return dferrors.Newf(dfcodes.ManagerStoreError, "add config error: id %s", id)
+		}
+
+		now := time.Now()
+		config.ID = id
+		config.CreateAt = now
+		config.UpdateAt = now
+		return putConfig(bucket, config)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (bolt *boltStore) DeleteConfig(ctx context.Context, id string) (*Config, error) {
+	var config *Config
+	err := bolt.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(configBucket)
+		cfg, err := getConfig(bucket, id)
+		if err != nil || cfg == nil {
+			return err
+		}
+
+		if err := bucket.Delete([]byte(id)); err != nil {
+			return dferrors.Newf(dfcodes.ManagerStoreError, "delete config error: %s", err.Error())
+		}
+
+		config = cfg
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (bolt *boltStore) UpdateConfig(ctx context.Context, id string, config *Config) (*Config, error) {
+	err := bolt.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(configBucket)
+		cur, err := getConfig(bucket, id)
+		if err != nil {
+			return err
+		} else if cur == nil {
+			return dferrors.Newf(dfcodes.ManagerConfigNotFound, "update config error: id %s", id)
+		}
+
+		config.ID = id
+		config.CreateAt = cur.CreateAt
+		config.UpdateAt = time.Now()
+		return putConfig(bucket, config)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (bolt *boltStore) PutConfig(ctx context.Context, config *Config) (*Config, error) {
+	err := bolt.db.Update(func(tx *bbolt.Tx) error {
+		return putConfig(tx.Bucket(configBucket), config)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (bolt *boltStore) GetConfig(ctx context.Context, id string) (*Config, error) {
+	var config *Config
+	err := bolt.db.View(func(tx *bbolt.Tx) error {
+		cfg, err := getConfig(tx.Bucket(configBucket), id)
+		if err != nil {
+			return err
+		} else if cfg == nil {
+			return dferrors.Newf(dfcodes.ManagerConfigNotFound, "get config error: id %s", id)
+		}
+
+		config = cfg
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (bolt *boltStore) ListConfigs(ctx context.Context, object string) ([]*Config, error) {
+	return bolt.listSortedConfig(ctx, object, 0)
+}
+
+func (bolt *boltStore) ListAllConfigs(ctx context.Context) ([]*Config, error) {
+	configs := make([]*Config, 0)
+	err := bolt.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(configBucket).ForEach(func(k, v []byte) error {
+			config := &Config{}
+			if err := json.Unmarshal(v, config); err != nil {
+				return dferrors.Newf(dfcodes.ManagerStoreError, "list all configs error: id %s, %s", string(k), err.Error())
+			}
+
+			configs = append(configs, config)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+func (bolt *boltStore) LatestConfig(ctx context.Context, object string, objType string) (*Config, error) {
+	configs, err := bolt.listSortedConfig(ctx, object, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if configs[0].Type != objType {
+		return nil, dferrors.Newf(dfcodes.ManagerStoreError, "latest config error: object %s, objType %s", object, objType)
+	}
+
+	return configs[0], nil
+}
+
+func (bolt *boltStore) listSortedConfig(ctx context.Context, object string, maxLen uint32) ([]*Config, error) {
+	configs := make([]*Config, 0)
+	err := bolt.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(configBucket).ForEach(func(k, v []byte) error {
+			config := &Config{}
+			if err := json.Unmarshal(v, config); err != nil {
+				return dferrors.Newf(dfcodes.ManagerStoreError, "list sorted config error: id %s, %s", string(k), err.Error())
+			}
+
+			if config.Object == object {
+				configs = append(configs, config)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(configs) <= 0 {
+		return nil, dferrors.Newf(dfcodes.ManagerConfigNotFound, "list sorted config error: object %s, maxLen %d", object, maxLen)
+	}
+
+	sort.Sort(SortConfig(configs))
+	len := uint32(len(configs))
+	if maxLen == 0 || len <= maxLen {
+		return configs, nil
+	} else {
+		return configs[0:maxLen], nil
+	}
+}
+
+func getConfig(bucket *bbolt.Bucket, id string) (*Config, error) {
+	data := bucket.Get([]byte(id))
+	if data == nil {
+		return nil, nil
+	}
+
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, dferrors.Newf(dfcodes.ManagerStoreError, "unmarshal config error: id %s, %s", id, err.Error())
+	}
+
+	return config, nil
+}
+
+func putConfig(bucket *bbolt.Bucket, config *Config) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return dferrors.Newf(dfcodes.ManagerStoreError, "marshal config error: id %s, %s", config.ID, err.Error())
+	}
+
+	if err := bucket.Put([]byte(config.ID), data); err != nil {
+		return dferrors.Newf(dfcodes.ManagerStoreError, "put config error: id %s, %s", config.ID, err.Error())
+	}
+
+	return nil
+}
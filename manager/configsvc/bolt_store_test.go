@@ -0,0 +1,135 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configsvc
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/manager/config"
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
+)
+
+func newTestBoltStore(t *testing.T, dir string) Store {
+	store, err := NewBoltStore(&config.StoreConfig{
+		Name: "bolt",
+		Type: "bolt",
+		Bolt: &config.BoltConfig{Path: filepath.Join(dir, "db", "manager.db")},
+	})
+	if err != nil {
+		t.Fatalf("open bolt store error: %v", err)
+	}
+	return store
+}
+
+func TestBoltStore_CRUD(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "bolt-store")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	store := newTestBoltStore(t, dir)
+
+	added, err := store.AddConfig(ctx, "id-1", &Config{Object: "cdn-0", Type: "Cdn", Version: 1, Data: []byte("base: {}")})
+	assert.Nil(err)
+	assert.Equal("id-1", added.ID)
+	assert.False(added.CreateAt.IsZero())
+	assert.Equal(added.CreateAt, added.UpdateAt)
+
+	_, err = store.AddConfig(ctx, "id-1", &Config{Object: "cdn-0", Type: "Cdn", Version: 1})
+	assert.True(dferrors.CheckError(err, dfcodes.ManagerStoreError), "duplicated id should be rejected")
+
+	_, err = store.AddConfig(ctx, "id-2", &Config{Object: "cdn-0", Type: "Cdn", Version: 2})
+	assert.Nil(err)
+	_, err = store.AddConfig(ctx, "id-3", &Config{Object: "scheduler-0", Type: "Scheduler", Version: 1})
+	assert.Nil(err)
+
+	got, err := store.GetConfig(ctx, "id-1")
+	assert.Nil(err)
+	assert.Equal("cdn-0", got.Object)
+	assert.Equal([]byte("base: {}"), got.Data)
+	assert.True(added.CreateAt.Equal(got.CreateAt))
+
+	time.Sleep(10 * time.Millisecond)
+	updated, err := store.UpdateConfig(ctx, "id-1", &Config{Object: "cdn-0", Type: "Cdn", Version: 3})
+	assert.Nil(err)
+	assert.True(added.CreateAt.Equal(updated.CreateAt), "update should keep create time")
+	assert.True(updated.UpdateAt.After(added.UpdateAt))
+	_, err = store.UpdateConfig(ctx, "id-x", &Config{Object: "cdn-0", Type: "Cdn", Version: 1})
+	assert.True(dferrors.CheckError(err, dfcodes.ManagerConfigNotFound))
+
+	configs, err := store.ListConfigs(ctx, "cdn-0")
+	assert.Nil(err)
+	assert.Len(configs, 2)
+	assert.Equal("id-1", configs[0].ID, "configs are sorted by version from new to old")
+	assert.Equal("id-2", configs[1].ID)
+
+	latest, err := store.LatestConfig(ctx, "cdn-0", "Cdn")
+	assert.Nil(err)
+	assert.Equal("id-1", latest.ID)
+	_, err = store.LatestConfig(ctx, "cdn-0", "Scheduler")
+	assert.NotNil(err, "latest config of another type should not be returned")
+	_, err = store.LatestConfig(ctx, "cdn-x", "Cdn")
+	assert.True(dferrors.CheckError(err, dfcodes.ManagerConfigNotFound))
+
+	all, err := store.ListAllConfigs(ctx)
+	assert.Nil(err)
+	assert.Len(all, 3)
+
+	deleted, err := store.DeleteConfig(ctx, "id-2")
+	assert.Nil(err)
+	assert.Equal("id-2", deleted.ID)
+	deleted, err = store.DeleteConfig(ctx, "id-2")
+	assert.Nil(err)
+	assert.Nil(deleted, "deleting a missing config is not an error")
+	_, err = store.GetConfig(ctx, "id-2")
+	assert.True(dferrors.CheckError(err, dfcodes.ManagerConfigNotFound))
+}
+
+func TestBoltStore_Reopen(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "bolt-store")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	store := newTestBoltStore(t, dir)
+	added, err := store.AddConfig(ctx, "id-1", &Config{Object: "cdn-0", Type: "Cdn", Version: 1, Data: []byte("base: {}")})
+	assert.Nil(err)
+	assert.Nil(store.(*boltStore).db.Close())
+
+	store = newTestBoltStore(t, dir)
+	defer store.(*boltStore).db.Close()
+	got, err := store.GetConfig(ctx, "id-1")
+	assert.Nil(err)
+	assert.Equal(added.Object, got.Object)
+	assert.Equal(added.Data, got.Data)
+	assert.True(added.CreateAt.Equal(got.CreateAt))
+}
+
+func TestNewBoltStore_InvalidConfig(t *testing.T) {
+	assert := testifyassert.New(t)
+	_, err := NewBoltStore(&config.StoreConfig{Name: "bolt", Type: "bolt", Bolt: &config.BoltConfig{}})
+	assert.True(dferrors.CheckError(err, dfcodes.ManagerConfigError))
+}
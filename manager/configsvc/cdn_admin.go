@@ -0,0 +1,69 @@
+package configsvc
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	cdnconfig "d7y.io/dragonfly/v2/cdnsystem/config"
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
+	"d7y.io/dragonfly/v2/pkg/rpc/manager"
+	"gopkg.in/yaml.v3"
+)
+
+// CdnAdmin is the admin api of a cdn.
+type CdnAdmin struct {
+	// Addr is ip:port of the admin api
+	Addr  string
+	Token string
+}
+
+// cdnAdminConfig is the part of cdn config data about the admin api,
+// the fields are not defaulted so that the defaults of manager host are never used as the cdn address.
+type cdnAdminConfig struct {
+	Base struct {
+		AdvertiseIP string `yaml:"advertiseIP"`
+		AdminPort   int    `yaml:"adminPort"`
+		AdminIP     string `yaml:"adminIP"`
+		AdminToken  string `yaml:"adminToken"`
+	} `yaml:"base"`
+}
+
+// GetCdnAdmin returns the admin api of the cdn object from its latest config,
+// only the cdns registered with configs can be managed.
+func (svc *ConfigSvc) GetCdnAdmin(ctx context.Context, object string) (*CdnAdmin, error) {
+	configs, err := svc.configs.ListConfigs(ctx, object)
+	if err != nil {
+		return nil, err
+	}
+
+	config := configs[0]
+	if config.Type != manager.ObjType_Cdn.String() {
+		return nil, dferrors.Newf(dfcodes.ManagerConfigNotFound, "object %s is not a cdn", object)
+	}
+
+	var cfg cdnAdminConfig
+	if err := yaml.Unmarshal(config.Data, &cfg); err != nil {
+		return nil, dferrors.Newf(dfcodes.ManagerConfigInvalid, "invalid cdn config data of %s: %s", object, err.Error())
+	}
+
+	if cfg.Base.AdminPort <= 0 {
+		return nil, dferrors.Newf(dfcodes.ManagerConfigInvalid, "admin api of cdn %s is disabled", object)
+	}
+
+	host := cfg.Base.AdminIP
+	if host == "" {
+		host = cdnconfig.DefaultAdminIP
+	} else if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		host = cfg.Base.AdvertiseIP
+	}
+	if net.ParseIP(host) == nil {
+		return nil, dferrors.Newf(dfcodes.ManagerConfigInvalid, "unknown admin ip of cdn %s", object)
+	}
+
+	return &CdnAdmin{
+		Addr:  net.JoinHostPort(host, strconv.Itoa(cfg.Base.AdminPort)),
+		Token: cfg.Base.AdminToken,
+	}, nil
+}
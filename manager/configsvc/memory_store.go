@@ -83,6 +83,18 @@ func (memory *memoryStore) UpdateConfig(ctx context.Context, id string, config *
 	}
 }
 
+func (memory *memoryStore) PutConfig(ctx context.Context, config *Config) (*Config, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	memory.configs[config.ID] = config
+	if objConfig, exist := memory.objects[config.Object]; !exist || objConfig.ID == config.ID || objConfig.Version <= config.Version {
+		memory.objects[config.Object] = config
+	}
+
+	return config, nil
+}
+
 func (memory *memoryStore) GetConfig(ctx context.Context, id string) (*Config, error) {
 	memory.mu.Lock()
 	defer memory.mu.Unlock()
@@ -101,6 +113,18 @@ func (memory *memoryStore) ListConfigs(ctx context.Context, object string) ([]*C
 	return memory.listSortedConfig(ctx, object, 0)
 }
 
+func (memory *memoryStore) ListAllConfigs(ctx context.Context) ([]*Config, error) {
+	memory.mu.Lock()
+	defer memory.mu.Unlock()
+
+	configs := make([]*Config, 0, len(memory.configs))
+	for _, config := range memory.configs {
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
 func (memory *memoryStore) LatestConfig(ctx context.Context, object string, objType string) (*Config, error) {
 	memory.mu.Lock()
 	defer memory.mu.Unlock()
@@ -0,0 +1,32 @@
+package configsvc
+
+import (
+	"context"
+	"sort"
+)
+
+// Migrate copies every config in src into dst verbatim, including ids and timestamps,
+// and returns the number of configs copied. Configs already present in dst with the same id are overwritten.
+func Migrate(ctx context.Context, src Store, dst Store) (int, error) {
+	configs, err := src.ListAllConfigs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	// keep the relative order of versions so that dst caches the latest one per object
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Version < configs[j].Version
+	})
+
+	count := 0
+	for _, config := range configs {
+		cfg := *config
+		if _, err := dst.PutConfig(ctx, &cfg); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
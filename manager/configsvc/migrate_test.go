@@ -0,0 +1,85 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configsvc
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	assert := testifyassert.New(t)
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "migrate")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	createAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	updateAt := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	src := NewMemoryStore()
+	for _, config := range []*Config{
+		{ID: "id-1", Object: "cdn-0", Type: "Cdn", Version: 1, Data: []byte("v1"), CreateAt: createAt, UpdateAt: updateAt},
+		{ID: "id-2", Object: "cdn-0", Type: "Cdn", Version: 2, Data: []byte("v2"), CreateAt: createAt, UpdateAt: updateAt},
+		{ID: "id-3", Object: "scheduler-0", Type: "Scheduler", Version: 1, Data: []byte("v1"), CreateAt: createAt, UpdateAt: updateAt},
+	} {
+		_, err := src.AddConfig(ctx, config.ID, config)
+		assert.Nil(err)
+	}
+
+	dst := newTestBoltStore(t, dir)
+	defer dst.(*boltStore).db.Close()
+	// the stale config in dst is overwritten
+	_, err = dst.AddConfig(ctx, "id-1", &Config{Object: "cdn-0", Type: "Cdn", Version: 1, Data: []byte("stale")})
+	assert.Nil(err)
+
+	count, err := Migrate(ctx, src, dst)
+	assert.Nil(err)
+	assert.Equal(3, count)
+
+	all, err := dst.ListAllConfigs(ctx)
+	assert.Nil(err)
+	assert.Len(all, 3)
+	for _, id := range []string{"id-1", "id-2", "id-3"} {
+		want, err := src.GetConfig(ctx, id)
+		assert.Nil(err)
+		got, err := dst.GetConfig(ctx, id)
+		assert.Nil(err)
+		assert.Equal(want.Object, got.Object)
+		assert.Equal(want.Type, got.Type)
+		assert.Equal(want.Version, got.Version)
+		assert.Equal(want.Data, got.Data)
+		assert.True(createAt.Equal(got.CreateAt), "create time of %s should be kept", id)
+		assert.True(updateAt.Equal(got.UpdateAt), "update time of %s should be kept", id)
+	}
+
+	latest, err := dst.LatestConfig(ctx, "cdn-0", "Cdn")
+	assert.Nil(err)
+	assert.Equal("id-2", latest.ID)
+
+	// migrating again is idempotent
+	count, err = Migrate(ctx, src, dst)
+	assert.Nil(err)
+	assert.Equal(3, count)
+	all, err = dst.ListAllConfigs(ctx)
+	assert.Nil(err)
+	assert.Len(all, 3)
+}
@@ -123,6 +123,26 @@ func (orm *ormStore) UpdateConfig(ctx context.Context, id string, config *Config
 	}
 }
 
+func (orm *ormStore) PutConfig(ctx context.Context, config *Config) (*Config, error) {
+	cfg := &OrmConfig{
+		ID:        config.ID,
+		Object:    config.Object,
+		Type:      config.Type,
+		Version:   config.Version,
+		Data:      config.Data,
+		CreatedAt: config.CreateAt,
+		UpdatedAt: config.UpdateAt,
+	}
+
+	// skip hooks to keep the timestamps of config
+	tx := orm.withTable().Session(&gorm.Session{SkipHooks: true}).Save(cfg)
+	if err := tx.Error; err != nil {
+		return nil, dferrors.Newf(dfcodes.ManagerStoreError, "put config error: %s", err.Error())
+	} else {
+		return ormConfig2InnerConfig(cfg), nil
+	}
+}
+
 func (orm *ormStore) GetConfig(ctx context.Context, id string) (*Config, error) {
 	cfg := &OrmConfig{
 		ID: id,
@@ -142,6 +162,22 @@ func (orm *ormStore) ListConfigs(ctx context.Context, object string) ([]*Config,
 	return orm.listSortedConfig(ctx, object, 0)
 }
 
+func (orm *ormStore) ListAllConfigs(ctx context.Context) ([]*Config, error) {
+	var ormConfigs []OrmConfig
+	configs := make([]*Config, 0)
+
+	tx := orm.withTable().Find(&ormConfigs)
+	if err := tx.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, dferrors.Newf(dfcodes.ManagerStoreError, "list all configs error: %s", err.Error())
+	}
+
+	for i := range ormConfigs {
+		configs = append(configs, ormConfig2InnerConfig(&ormConfigs[i]))
+	}
+
+	return configs, nil
+}
+
 func (orm *ormStore) LatestConfig(ctx context.Context, object string, objType string) (*Config, error) {
 	configs, err := orm.listSortedConfig(ctx, object, 1)
 	if err != nil {
@@ -157,9 +193,9 @@ func (orm *ormStore) listSortedConfig(ctx context.Context, object string, maxLen
 
 	tx := orm.withTable().Where("object = ?", object).Find(&ormConfigs)
 	if err := tx.Error; errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, dferrors.Newf(dfcodes.ManagerConfigNotFound, "list sorted config error %s, object %s, maxLen %d%s", object, maxLen, err.Error())
+		return nil, dferrors.Newf(dfcodes.ManagerConfigNotFound, "list sorted config error: object %s, maxLen %d, %s", object, maxLen, err.Error())
 	} else if err != nil {
-		return nil, dferrors.Newf(dfcodes.ManagerStoreError, "list sorted config error %s, object %s, maxLen %d", object, maxLen, err.Error())
+		return nil, dferrors.Newf(dfcodes.ManagerStoreError, "list sorted config error: object %s, maxLen %d, %s", object, maxLen, err.Error())
 	} else {
 		for _, cfg := range ormConfigs {
 			configs = append(configs, ormConfig2InnerConfig(&cfg))
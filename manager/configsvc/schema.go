@@ -0,0 +1,106 @@
+package configsvc
+
+import (
+	"bytes"
+	cdnconfig "d7y.io/dragonfly/v2/cdnsystem/config"
+	clientconfig "d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
+	"d7y.io/dragonfly/v2/pkg/rpc/manager"
+	schedulerconfig "d7y.io/dragonfly/v2/scheduler/config"
+	"errors"
+	"gopkg.in/yaml.v3"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// ClientObjType is the type of configs pushed to dfget daemons,
+// the others are defined by manager.ObjType.
+const ClientObjType = "Client"
+
+type validator interface {
+	Validate() error
+}
+
+// schemas maps an object type to the constructor of its default config.
+// The config data of an object type is decoded on top of a copy of the default config,
+// so the result is the effective config the component will run with.
+var schemas = map[string]func() interface{}{
+	manager.ObjType_Scheduler.String(): func() interface{} { return schedulerconfig.New() },
+	manager.ObjType_Cdn.String():       func() interface{} { return cdnconfig.New() },
+	ClientObjType:                      func() interface{} { return clientconfig.NewPeerHostOption() },
+}
+
+// ValidateConfigData checks data against the schema of objType and returns the effective config.
+// Unknown fields and mistyped values are reported one per line with their position in data.
+func ValidateConfigData(objType string, data []byte) (interface{}, error) {
+	newDefault, ok := schemas[objType]
+	if !ok {
+		return nil, dferrors.Newf(dfcodes.InvalidObjType, "invalid object type %s", objType)
+	}
+
+	// the default configs may be shared package variables, never decode into them
+	effective := deepCopy(reflect.ValueOf(newDefault())).Interface()
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(effective); err != nil && err != io.EOF {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return nil, dferrors.Newf(dfcodes.ManagerConfigInvalid, "invalid %s config data:\n%s", objType, strings.Join(typeErr.Errors, "\n"))
+		}
+		return nil, dferrors.Newf(dfcodes.ManagerConfigInvalid, "invalid %s config data: %s", objType, err.Error())
+	}
+
+	if v, ok := effective.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, dferrors.Newf(dfcodes.ManagerConfigInvalid, "invalid %s config data: %s", objType, err.Error())
+		}
+	}
+
+	return effective, nil
+}
+
+// deepCopy copies exported fields recursively, unexported fields are copied shallowly.
+func deepCopy(in reflect.Value) reflect.Value {
+	switch in.Kind() {
+	case reflect.Ptr:
+		if in.IsNil() {
+			return in
+		}
+		out := reflect.New(in.Type().Elem())
+		out.Elem().Set(deepCopy(in.Elem()))
+		return out
+	case reflect.Struct:
+		out := reflect.New(in.Type()).Elem()
+		out.Set(in)
+		for i := 0; i < in.NumField(); i++ {
+			if out.Field(i).CanSet() {
+				out.Field(i).Set(deepCopy(in.Field(i)))
+			}
+		}
+		return out
+	case reflect.Slice:
+		if in.IsNil() {
+			return in
+		}
+		out := reflect.MakeSlice(in.Type(), in.Len(), in.Len())
+		for i := 0; i < in.Len(); i++ {
+			out.Index(i).Set(deepCopy(in.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if in.IsNil() {
+			return in
+		}
+		out := reflect.MakeMapWithSize(in.Type(), in.Len())
+		iter := in.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return out
+	default:
+		return in
+	}
+}
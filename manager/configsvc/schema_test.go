@@ -0,0 +1,150 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configsvc
+
+import (
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	cdnconfig "d7y.io/dragonfly/v2/cdnsystem/config"
+	clientconfig "d7y.io/dragonfly/v2/client/config"
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/rpc/manager"
+	"d7y.io/dragonfly/v2/pkg/unit"
+	schedulerconfig "d7y.io/dragonfly/v2/scheduler/config"
+)
+
+func TestValidateConfigData(t *testing.T) {
+	testCases := []struct {
+		name    string
+		objType string
+		data    string
+		code    base.Code
+		errMsg  string
+		check   func(assert *testifyassert.Assertions, effective interface{})
+	}{
+		{
+			name:    "scheduler empty data uses defaults",
+			objType: manager.ObjType_Scheduler.String(),
+			data:    "",
+			check: func(assert *testifyassert.Assertions, effective interface{}) {
+				cfg := effective.(*schedulerconfig.Config)
+				assert.Equal(schedulerconfig.New().Server.Port, cfg.Server.Port)
+			},
+		},
+		{
+			name:    "scheduler valid data",
+			objType: manager.ObjType_Scheduler.String(),
+			data:    "server:\n  port: 8102\nworker:\n  workerNum: 8\n",
+			check: func(assert *testifyassert.Assertions, effective interface{}) {
+				cfg := effective.(*schedulerconfig.Config)
+				assert.Equal(8102, cfg.Server.Port)
+				assert.Equal(8, cfg.Worker.WorkerNum)
+				assert.NotEqual(8102, schedulerconfig.New().Server.Port, "default config should not be changed")
+			},
+		},
+		{
+			name:    "scheduler unknown field",
+			objType: manager.ObjType_Scheduler.String(),
+			data:    "server:\n  prot: 8102\n",
+			code:    dfcodes.ManagerConfigInvalid,
+			errMsg:  "line 2: field prot not found",
+		},
+		{
+			name:    "scheduler mistyped value",
+			objType: manager.ObjType_Scheduler.String(),
+			data:    "server:\n  port: abc\n",
+			code:    dfcodes.ManagerConfigInvalid,
+			errMsg:  "line 2",
+		},
+		{
+			name:    "cdn valid data",
+			objType: manager.ObjType_Cdn.String(),
+			data:    "base:\n  listenPort: 8103\n  maxBandwidth: 2G\n",
+			check: func(assert *testifyassert.Assertions, effective interface{}) {
+				cfg := effective.(*cdnconfig.Config)
+				assert.Equal(8103, cfg.ListenPort)
+				assert.Equal(2*unit.GB, cfg.MaxBandwidth)
+				assert.Equal(cdnconfig.DefaultDownloadPort, cfg.DownloadPort)
+			},
+		},
+		{
+			name:    "cdn unknown field",
+			objType: manager.ObjType_Cdn.String(),
+			data:    "base:\n  listenport: 8103\n",
+			code:    dfcodes.ManagerConfigInvalid,
+			errMsg:  "field listenport not found",
+		},
+		{
+			name:    "cdn mistyped value",
+			objType: manager.ObjType_Cdn.String(),
+			data:    "base:\n  listenPort: [8103]\n",
+			code:    dfcodes.ManagerConfigInvalid,
+			errMsg:  "line 2",
+		},
+		{
+			name:    "client valid data",
+			objType: ClientObjType,
+			data:    "scheduler:\n  net_addrs:\n  - type: tcp\n    addr: 127.0.0.1:8002\nstorage:\n  high_watermark: 90\n  low_watermark: 60\n",
+			check: func(assert *testifyassert.Assertions, effective interface{}) {
+				cfg := effective.(*clientconfig.PeerHostOption)
+				assert.Len(cfg.Scheduler.NetAddrs, 1)
+				assert.Equal("127.0.0.1:8002", cfg.Scheduler.NetAddrs[0].Addr)
+				assert.Equal(90, cfg.Storage.HighWatermark)
+				assert.Equal(60, cfg.Storage.LowWatermark)
+			},
+		},
+		{
+			name:    "client unknown field",
+			objType: ClientObjType,
+			data:    "storage:\n  highWatermark: 90\n",
+			code:    dfcodes.ManagerConfigInvalid,
+			errMsg:  "field highWatermark not found",
+		},
+		{
+			name:    "client invalid value",
+			objType: ClientObjType,
+			data:    "scheduler:\n  net_addrs:\n  - type: tcp\n    addr: 127.0.0.1:8002\nstorage:\n  high_watermark: 50\n  low_watermark: 60\n",
+			code:    dfcodes.ManagerConfigInvalid,
+			errMsg:  "low watermark 60",
+		},
+		{
+			name:    "unknown object type",
+			objType: "Proxy",
+			data:    "",
+			code:    dfcodes.InvalidObjType,
+			errMsg:  "invalid object type Proxy",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			effective, err := ValidateConfigData(tc.objType, []byte(tc.data))
+			if tc.code != 0 {
+				assert.True(dferrors.CheckError(err, tc.code), "unexpected error: %v", err)
+				assert.Contains(err.Error(), tc.errMsg)
+				return
+			}
+			assert.Nil(err)
+			tc.check(assert, effective)
+		})
+	}
+}
@@ -46,7 +46,11 @@ func InnerConfig2ProtoConfig(config *Config) *manager.Config {
 
 func (svc *ConfigSvc) AddConfig(ctx context.Context, req *manager.AddConfigRequest) (*manager.AddConfigResponse, error) {
 	switch req.Config.GetType() {
-	case manager.ObjType_Scheduler.String(), manager.ObjType_Cdn.String():
+	case manager.ObjType_Scheduler.String(), manager.ObjType_Cdn.String(), ClientObjType:
+		if _, err := ValidateConfigData(req.Config.GetType(), req.Config.GetData()); err != nil {
+			return nil, err
+		}
+
 		if config, err := svc.configs.AddConfig(ctx, NewConfigID(), protoConfig2InnerConfig(req.GetConfig())); err != nil {
 			return nil, err
 		} else {
@@ -70,7 +74,11 @@ func (svc *ConfigSvc) DeleteConfig(ctx context.Context, req *manager.DeleteConfi
 
 func (svc *ConfigSvc) UpdateConfig(ctx context.Context, req *manager.UpdateConfigRequest) (*manager.UpdateConfigResponse, error) {
 	switch req.Config.GetType() {
-	case manager.ObjType_Scheduler.String(), manager.ObjType_Cdn.String():
+	case manager.ObjType_Scheduler.String(), manager.ObjType_Cdn.String(), ClientObjType:
+		if _, err := ValidateConfigData(req.Config.GetType(), req.Config.GetData()); err != nil {
+			return nil, err
+		}
+
 		if _, err := svc.configs.UpdateConfig(ctx, req.GetId(), protoConfig2InnerConfig(req.GetConfig())); err != nil {
 			return nil, err
 		} else {
@@ -88,7 +96,7 @@ func (svc *ConfigSvc) GetConfig(ctx context.Context, req *manager.GetConfigReque
 		return nil, err
 	} else {
 		switch config.Type {
-		case manager.ObjType_Scheduler.String(), manager.ObjType_Cdn.String():
+		case manager.ObjType_Scheduler.String(), manager.ObjType_Cdn.String(), ClientObjType:
 			return &manager.GetConfigResponse{State: common.NewState(dfcodes.Success, "success"), Config: InnerConfig2ProtoConfig(config)}, nil
 		default:
 			return nil, dferrors.Newf(dfcodes.InvalidObjType, "failed to get Config, req=%+v", req)
@@ -105,7 +113,7 @@ func (svc *ConfigSvc) ListConfigs(ctx context.Context, req *manager.ListConfigsR
 	var protoConfigs []*manager.Config
 	for _, config := range configs {
 		switch config.Type {
-		case manager.ObjType_Scheduler.String(), manager.ObjType_Cdn.String():
+		case manager.ObjType_Scheduler.String(), manager.ObjType_Cdn.String(), ClientObjType:
 			protoConfigs = append(protoConfigs, InnerConfig2ProtoConfig(config))
 		default:
 			return nil, dferrors.Newf(dfcodes.ManagerError, "failed to list configs, req=%+v", req)
@@ -125,7 +133,7 @@ func (svc *ConfigSvc) KeepAlive(ctx context.Context, req *manager.KeepAliveReque
 	}
 
 	switch config.Type {
-	case manager.ObjType_Scheduler.String(), manager.ObjType_Cdn.String():
+	case manager.ObjType_Scheduler.String(), manager.ObjType_Cdn.String(), ClientObjType:
 		return &manager.KeepAliveResponse{
 			State:  common.NewState(dfcodes.Success, "success"),
 			Config: InnerConfig2ProtoConfig(config),
@@ -2,6 +2,9 @@ package configsvc
 
 import (
 	"context"
+	"d7y.io/dragonfly/v2/manager/config"
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
 	"time"
 )
 
@@ -19,12 +22,44 @@ type Store interface {
 	AddConfig(ctx context.Context, id string, config *Config) (*Config, error)
 	DeleteConfig(ctx context.Context, id string) (*Config, error)
 	UpdateConfig(ctx context.Context, id string, config *Config) (*Config, error)
+	// PutConfig stores the config as it is, including its id and timestamps,
+	// the config with the same id is replaced.
+	PutConfig(ctx context.Context, config *Config) (*Config, error)
 	GetConfig(ctx context.Context, id string) (*Config, error)
 	ListConfigs(ctx context.Context, object string) ([]*Config, error)
+	ListAllConfigs(ctx context.Context) ([]*Config, error)
 
 	LatestConfig(ctx context.Context, object string, objType string) (*Config, error)
 }
 
+// NewStore creates the config store described by cfg.
+func NewStore(cfg *config.StoreConfig) (Store, error) {
+	switch cfg.Type {
+	case "memory":
+		if cfg.Memory != nil {
+			return NewMemoryStore(), nil
+		} else {
+			return nil, dferrors.Newf(dfcodes.ManagerConfigError, "config error: memory nil")
+		}
+	case "mysql":
+		if cfg.Mysql != nil {
+			return NewOrmStore(cfg)
+		} else {
+			return nil, dferrors.Newf(dfcodes.ManagerConfigError, "config error: mysql nil")
+		}
+	case "bolt":
+		if cfg.Bolt != nil {
+			return NewBoltStore(cfg)
+		} else {
+			return nil, dferrors.Newf(dfcodes.ManagerConfigError, "config error: bolt nil")
+		}
+	case "oss":
+		return nil, dferrors.Newf(dfcodes.ManagerConfigError, "config error: oss not support")
+	default:
+		return nil, dferrors.Newf(dfcodes.ManagerConfigError, "config error: unknown store type %s", cfg.Type)
+	}
+}
+
 type SortConfig []*Config
 
 func (s SortConfig) Len() int {
@@ -23,6 +23,21 @@ func InitRouter(server *service.ManagerServer) (*gin.Engine, error) {
 			configs.GET(":id", handler.GetConfig)
 			configs.GET("", handler.ListConfigs)
 		}
+
+		cdns := api.Group("/cdns/:cdn")
+		{
+			cdns.GET("/tasks", handler.ListCdnTasks)
+			cdns.DELETE("/tasks", handler.PurgeCdnTaskByURL)
+			cdns.GET("/tasks/:id", handler.GetCdnTask)
+			cdns.DELETE("/tasks/:id", handler.PurgeCdnTask)
+			cdns.PUT("/tasks/:id/pin", handler.PinCdnTask)
+			cdns.DELETE("/tasks/:id/pin", handler.UnpinCdnTask)
+		}
+
+		dryRun := api.Group("/dry-run")
+		{
+			dryRun.POST("/configs", handler.ValidateConfig)
+		}
 	}
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -21,27 +21,7 @@ func createConfigStore(cfg *config.Config) (configsvc.Store, error) {
 
 	for _, store := range cfg.Stores {
 		if cfg.ConfigService.StoreName == store.Name {
-			switch store.Type {
-			case "memory":
-				if store.Memory != nil {
-					return configsvc.NewMemoryStore(), nil
-				} else {
-					return nil, dferrors.Newf(dfcodes.ManagerConfigError, "config error: memory nil")
-				}
-			case "mysql":
-				if store.Mysql != nil {
-					if orm, err := configsvc.NewOrmStore(store); err != nil {
-						return nil, err
-					} else {
-						return orm, nil
-					}
-				} else {
-					return nil, dferrors.Newf(dfcodes.ManagerConfigError, "config error: mysql nil")
-				}
-			case "oss":
-				return nil, dferrors.Newf(dfcodes.ManagerConfigError, "config error: oss not support")
-			default:
-			}
+			return configsvc.NewStore(store)
 		}
 	}
 
@@ -97,3 +77,7 @@ func (ms *ManagerServer) ListConfigs(ctx context.Context, req *manager.ListConfi
 	rep, err := ms.configSvc.ListConfigs(ctx, req)
 	return rep, err
 }
+
+func (ms *ManagerServer) GetCdnAdmin(ctx context.Context, object string) (*configsvc.CdnAdmin, error) {
+	return ms.configSvc.GetCdnAdmin(ctx, object)
+}
@@ -27,6 +27,7 @@ const (
 	// common response error 1000-1999
 	ResourceLacked   base.Code = 1000 // client can be migrated to another scheduler
 	BadRequest       base.Code = 1400
+	Forbidden        base.Code = 1403
 	PeerTaskNotFound base.Code = 1404
 	UnknownError     base.Code = 1500
 	RequestTimeOut   base.Code = 1504
@@ -46,10 +47,13 @@ const (
 	SchedPeerGone       base.Code = 5002 // client should disconnect from scheduler
 
 	// cdnsystem response error 6000-6999
-	CdnError            base.Code = 6000
-	CdnTaskRegistryFail base.Code = 6001
-	CdnTaskDownloadFail base.Code = 6002
-	CdnTaskNotFound     base.Code = 6404
+	CdnError             base.Code = 6000
+	CdnTaskRegistryFail  base.Code = 6001
+	CdnTaskDownloadFail  base.Code = 6002
+	CdnSourceError       base.Code = 6003 // source is not reachable
+	CdnSourceClientError base.Code = 6004 // source responds with status 4xx
+	CdnSourceServerError base.Code = 6005 // source responds with status 5xx
+	CdnTaskNotFound      base.Code = 6404
 
 	// manager response error 7000-7999
 	ManagerError          base.Code = 7000
@@ -57,4 +61,5 @@ const (
 	ManagerStoreError     base.Code = 7002
 	ManagerConfigError    base.Code = 7003
 	ManagerConfigNotFound base.Code = 7004
+	ManagerConfigInvalid  base.Code = 7005
 )
@@ -32,19 +32,27 @@ const (
 
 // GenerateTaskID generates a taskId.
 // filter is separated by & character.
+// When meta carries a digest, the task is content addressed and the url is ignored,
+// so the same content from different urls shares one task.
 func GenerateTaskID(url string, filter string, meta *base.UrlMeta, bizID string) string {
 	taskIdSource := url
 	if filter != "" {
 		taskIdSource = urlutils.FilterURLParam(url, strings.Split(filter, "&"))
 	}
 
-	var md5String, rangeString string
+	var md5String, digestString, rangeString string
 	if meta != nil {
 		md5String = meta.Md5
+		digestString = meta.Digest
 		rangeString = meta.Range
 	}
 
-	if md5String != "" {
+	if digestString != "" {
+		if algorithm, encoded, err := digestutils.Parse(digestString); err == nil {
+			digestString = digestutils.Format(algorithm, encoded)
+		}
+		taskIdSource = digestString
+	} else if md5String != "" {
 		taskIdSource += "|" + md5String
 	} else if bizID != "" {
 		taskIdSource += "|" + bizID
@@ -0,0 +1,185 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package piecesize decides how a task is split into pieces.
+// The cdn and the client share the same policy, so that a task has the same piece size everywhere.
+package piecesize
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"d7y.io/dragonfly/v2/pkg/unit"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPieceSize is the piece size of files not larger than 200MB or with unknown length.
+	DefaultPieceSize = 4 * unit.MB
+
+	// DefaultMinPieceSize is the lower bound of the adaptive piece size.
+	DefaultMinPieceSize = 1 * unit.MB
+
+	// DefaultMaxPieceSize is the upper bound of the adaptive piece size.
+	DefaultMaxPieceSize = 15 * unit.MB
+
+	// alignment keeps the adaptive piece size a multiple of it.
+	alignment = 64 * unit.KB
+)
+
+// Policy decides the piece size of a task.
+// Rules are checked in order and the first matched one wins, a task matches no rule uses the adaptive size,
+// which grows with the file length and shrinks with the number of concurrent peers.
+type Policy struct {
+	// Min is the lower bound of the adaptive piece size.
+	// default: 1MB
+	Min unit.Bytes `yaml:"min" json:"min"`
+
+	// Max is the upper bound of the adaptive piece size.
+	// default: 15MB
+	Max unit.Bytes `yaml:"max" json:"max"`
+
+	// Rules fix the piece size of tasks by task type or url pattern.
+	Rules []*Rule `yaml:"rules" json:"rules"`
+}
+
+// Rule fixes the piece size of the matched tasks.
+type Rule struct {
+	// Type matches the scheme of the task url, like http or oss, empty matches all.
+	Type string `yaml:"type" json:"type"`
+
+	// URLPattern is a regular expression matched against the task url, empty matches all.
+	URLPattern string `yaml:"urlPattern" json:"url_pattern"`
+
+	// PieceSize is the piece size of the matched tasks.
+	PieceSize unit.Bytes `yaml:"pieceSize" json:"piece_size"`
+
+	regexp *regexp.Regexp
+}
+
+// NewDefaultPolicy creates a policy without rules.
+func NewDefaultPolicy() *Policy {
+	return &Policy{
+		Min: DefaultMinPieceSize,
+		Max: DefaultMaxPieceSize,
+	}
+}
+
+// Validate checks the bounds and compiles the url patterns of rules, it must be called before Compute.
+func (p *Policy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Min <= 0 {
+		p.Min = DefaultMinPieceSize
+	}
+	if p.Max <= 0 {
+		p.Max = DefaultMaxPieceSize
+	}
+	if p.Min > p.Max {
+		return errors.Errorf("min piece size %s is larger than max piece size %s", p.Min, p.Max)
+	}
+	for i, rule := range p.Rules {
+		if rule.PieceSize <= 0 {
+			return errors.Errorf("piece size of rule %d must be positive", i)
+		}
+		if rule.URLPattern == "" {
+			continue
+		}
+		r, err := regexp.Compile(rule.URLPattern)
+		if err != nil {
+			return errors.Wrapf(err, "invalid url pattern of rule %d", i)
+		}
+		rule.regexp = r
+	}
+	return nil
+}
+
+// Compute returns the piece size of the task with rawURL, length is the content length of the task,
+// and peers is the number of peers downloading the task concurrently, both are ignored when not positive.
+// A nil policy falls back to the package level Compute.
+func (p *Policy) Compute(rawURL string, length int64, peers int32) int32 {
+	if p == nil {
+		return Compute(length)
+	}
+	if rule := p.match(rawURL); rule != nil {
+		return int32(rule.PieceSize)
+	}
+
+	size := adaptive(length)
+	// make sure every concurrent peer has a distinct piece to download from the beginning
+	if peers > 1 && length > 0 && length/int64(peers) < size {
+		size = length / int64(peers) / int64(alignment) * int64(alignment)
+	}
+	if size < int64(p.Min) {
+		size = int64(p.Min)
+	}
+	if size > int64(p.Max) {
+		size = int64(p.Max)
+	}
+	return int32(size)
+}
+
+func (p *Policy) match(rawURL string) *Rule {
+	if len(p.Rules) == 0 {
+		return nil
+	}
+	var scheme string
+	if u, err := url.Parse(rawURL); err == nil {
+		scheme = u.Scheme
+	}
+	for _, rule := range p.Rules {
+		if rule.Type != "" && !strings.EqualFold(rule.Type, scheme) {
+			continue
+		}
+		if rule.URLPattern != "" && !rule.matchURL(rawURL) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+func (r *Rule) matchURL(rawURL string) bool {
+	if r.regexp != nil {
+		return r.regexp.MatchString(rawURL)
+	}
+	// the policy is not validated, compile the pattern every time
+	matched, err := regexp.MatchString(r.URLPattern, rawURL)
+	return err == nil && matched
+}
+
+// Compute computes the piece size with the default policy.
+//
+// If the length<=0, which means failed to get the length
+// and then use the DefaultPieceSize.
+func Compute(length int64) int32 {
+	size := adaptive(length)
+	if size > int64(DefaultMaxPieceSize) {
+		size = int64(DefaultMaxPieceSize)
+	}
+	return int32(size)
+}
+
+// adaptive grows the piece size by 1MB every 100MB over 200MB.
+func adaptive(length int64) int64 {
+	if length <= 200*int64(unit.MB) {
+		return int64(DefaultPieceSize)
+	}
+	gapCount := length / (100 * int64(unit.MB))
+	return (gapCount-2)*int64(unit.MB) + int64(DefaultPieceSize)
+}
@@ -0,0 +1,74 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package piecesize
+
+import (
+	"testing"
+
+	"d7y.io/dragonfly/v2/pkg/unit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompute(t *testing.T) {
+	assert.Equal(t, int32(DefaultPieceSize), Compute(-1))
+	assert.Equal(t, int32(DefaultPieceSize), Compute(int64(200*unit.MB)))
+	assert.Equal(t, int32(5*unit.MB), Compute(int64(300*unit.MB)))
+	assert.Equal(t, int32(DefaultMaxPieceSize), Compute(int64(100*unit.GB)))
+}
+
+func TestPolicy_Compute(t *testing.T) {
+	p := NewDefaultPolicy()
+	p.Rules = []*Rule{
+		{Type: "oss", PieceSize: 8 * unit.MB},
+		{URLPattern: `^https?://images\.example\.com/`, PieceSize: 2 * unit.MB},
+	}
+	assert.Nil(t, p.Validate())
+
+	tests := []struct {
+		name   string
+		url    string
+		length int64
+		peers  int32
+		expect int32
+	}{
+		{"type rule", "oss://bucket/file", int64(unit.GB), 1, int32(8 * unit.MB)},
+		{"pattern rule", "https://images.example.com/a.tar", int64(unit.GB), 100, int32(2 * unit.MB)},
+		{"unknown length", "http://example.com/a", -1, 100, int32(DefaultPieceSize)},
+		{"single peer", "http://example.com/a", int64(300 * unit.MB), 1, int32(5 * unit.MB)},
+		{"many peers", "http://example.com/a", int64(20 * unit.MB), 10, int32(2 * unit.MB)},
+		{"too many peers", "http://example.com/a", int64(20 * unit.MB), 1000, int32(DefaultMinPieceSize)},
+		{"max", "http://example.com/a", int64(100 * unit.GB), 1, int32(DefaultMaxPieceSize)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, p.Compute(tt.url, tt.length, tt.peers))
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	p := &Policy{Min: 2 * unit.MB, Max: 1 * unit.MB}
+	assert.NotNil(t, p.Validate())
+
+	p = &Policy{Rules: []*Rule{{URLPattern: "(", PieceSize: unit.MB}}}
+	assert.NotNil(t, p.Validate())
+
+	p = &Policy{}
+	assert.Nil(t, p.Validate())
+	assert.Equal(t, DefaultMinPieceSize, p.Min)
+	assert.Equal(t, DefaultMaxPieceSize, p.Max)
+}
@@ -37,6 +37,14 @@ func NewGrpcDfError(code base.Code, msg string) *base.GrpcDfError {
 	}
 }
 
+func NewState(code base.Code, msg string) *base.ResponseState {
+	return &base.ResponseState{
+		Success: code == dfcodes.Success,
+		Code:    code,
+		Msg:     msg,
+	}
+}
+
 // NewResWithCodeAndMsg returns a response ptr with code and msg,
 // ptr is a expected type ptr.
 func NewResWithCodeAndMsg(ptr interface{}, code base.Code, msg string) interface{} {
@@ -40,16 +40,21 @@ type PieceSeedStream struct {
 	stream cdnsystem.Seeder_ObtainSeedsClient
 	// server list which cannot serve
 	failedServers []string
+	// nextPieceNum is the smallest piece number not received yet, the new stream resumes from it
+	nextPieceNum   int32
+	receivedPieces map[int32]struct{}
 	rpc.RetryMeta
 }
 
 func newPieceSeedStream(sc *cdnClient, ctx context.Context, hashKey string, sr *cdnsystem.SeedRequest, opts []grpc.CallOption) (*PieceSeedStream, error) {
 	pss := &PieceSeedStream{
-		sc:      sc,
-		ctx:     ctx,
-		hashKey: hashKey,
-		sr:      sr,
-		opts:    opts,
+		sc:             sc,
+		ctx:            ctx,
+		hashKey:        hashKey,
+		sr:             sr,
+		opts:           opts,
+		nextPieceNum:   sr.StartPieceNum,
+		receivedPieces: make(map[int32]struct{}),
 		RetryMeta: rpc.RetryMeta{
 			MaxAttempts: 5,
 			InitBackoff: 0.5,
@@ -88,9 +93,39 @@ func (pss *PieceSeedStream) Recv() (ps *cdnsystem.PieceSeed, err error) {
 	if ps, err = pss.stream.Recv(); err != nil && err != io.EOF {
 		ps, err = pss.retryRecv(err)
 	}
+	if err == nil && ps != nil && ps.PieceInfo != nil {
+		pss.markReceived(ps.PieceInfo.PieceNum)
+	}
 	return
 }
 
+// markReceived advances nextPieceNum over the continuous received pieces
+func (pss *PieceSeedStream) markReceived(pieceNum int32) {
+	if pieceNum < pss.nextPieceNum {
+		return
+	}
+	pss.receivedPieces[pieceNum] = struct{}{}
+	for {
+		if _, ok := pss.receivedPieces[pss.nextPieceNum]; !ok {
+			break
+		}
+		delete(pss.receivedPieces, pss.nextPieceNum)
+		pss.nextPieceNum++
+	}
+}
+
+// resumeRequest returns the seed request resuming from the first piece not received
+func (pss *PieceSeedStream) resumeRequest() *cdnsystem.SeedRequest {
+	return &cdnsystem.SeedRequest{
+		TaskId:        pss.sr.TaskId,
+		Url:           pss.sr.Url,
+		Filter:        pss.sr.Filter,
+		UrlMeta:       pss.sr.UrlMeta,
+		PeerCount:     pss.sr.PeerCount,
+		StartPieceNum: pss.nextPieceNum,
+	}
+}
+
 func (pss *PieceSeedStream) retryRecv(cause error) (*cdnsystem.PieceSeed, error) {
 	if status.Code(cause) == codes.DeadlineExceeded {
 		return nil, cause
@@ -115,7 +150,7 @@ func (pss *PieceSeedStream) replaceStream(key string, cause error) error {
 		if err != nil {
 			return nil, err
 		}
-		return client.ObtainSeeds(pss.ctx, pss.sr, pss.opts...)
+		return client.ObtainSeeds(pss.ctx, pss.resumeRequest(), pss.opts...)
 	}, pss.InitBackoff, pss.MaxBackOff, pss.MaxAttempts, cause)
 	if err == nil {
 		pss.stream = stream.(cdnsystem.Seeder_ObtainSeedsClient)
@@ -136,7 +171,7 @@ func (pss *PieceSeedStream) replaceClient(key string, cause error) error {
 		if err != nil {
 			return nil, err
 		}
-		return client.ObtainSeeds(pss.ctx, pss.sr, pss.opts...)
+		return client.ObtainSeeds(pss.ctx, pss.resumeRequest(), pss.opts...)
 	}, pss.InitBackoff, pss.MaxBackOff, pss.MaxAttempts, cause)
 	if err == nil {
 		pss.stream = stream.(cdnsystem.Seeder_ObtainSeedsClient)
@@ -64,9 +64,23 @@ func GetClientByAddr(addrs []dfnet.NetAddr, opts ...grpc.DialOption) (DaemonClie
 type DaemonClient interface {
 	Download(ctx context.Context, req *dfdaemon.DownRequest, opts ...grpc.CallOption) (*DownResultStream, error)
 
+	StreamDownload(ctx context.Context, req *dfdaemon.DownRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_StreamDownloadClient, error)
+
+	BatchDownload(ctx context.Context, req *dfdaemon.BatchDownRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_BatchDownloadClient, error)
+
 	GetPieceTasks(ctx context.Context, addr dfnet.NetAddr, ptr *base.PieceTaskRequest, opts ...grpc.CallOption) (*base.PiecePacket, error)
 
 	CheckHealth(ctx context.Context, target dfnet.NetAddr, opts ...grpc.CallOption) error
+
+	ImportTask(ctx context.Context, req *dfdaemon.ImportTaskRequest, opts ...grpc.CallOption) (*dfdaemon.TaskInfo, error)
+
+	StatTask(ctx context.Context, req *dfdaemon.TaskRequest, opts ...grpc.CallOption) (*dfdaemon.TaskInfo, error)
+
+	DeleteTask(ctx context.Context, req *dfdaemon.TaskRequest, opts ...grpc.CallOption) error
+
+	ListTasks(ctx context.Context, target dfnet.NetAddr, opts ...grpc.CallOption) (*dfdaemon.TaskList, error)
+
+	CancelTask(ctx context.Context, req *dfdaemon.TaskRequest, opts ...grpc.CallOption) error
 }
 
 type daemonClient struct {
@@ -96,6 +110,43 @@ func (dc *daemonClient) Download(ctx context.Context, req *dfdaemon.DownRequest,
 	return newDownResultStream(dc, ctx, taskId, req, opts)
 }
 
+// StreamDownload does not replace the broken stream like Download, because the received content can not be taken back
+func (dc *daemonClient) StreamDownload(ctx context.Context, req *dfdaemon.DownRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_StreamDownloadClient, error) {
+	req.Uuid = uuid.New().String()
+	taskId := idgen.GenerateTaskID(req.Url, req.Filter, req.UrlMeta, req.BizId)
+	stream, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
+		client, _, err := dc.getDaemonClient(taskId, false)
+		if err != nil {
+			return nil, err
+		}
+		return client.StreamDownload(ctx, req, opts...)
+	}, 0.2, 2.0, 3, nil)
+	if err != nil {
+		return nil, err
+	}
+	return stream.(dfdaemon.Daemon_StreamDownloadClient), nil
+}
+
+// BatchDownload does not replace the broken stream like Download,
+// the caller should retry the unfinished requests, and completed tasks will be reused by daemon
+func (dc *daemonClient) BatchDownload(ctx context.Context, req *dfdaemon.BatchDownRequest, opts ...grpc.CallOption) (dfdaemon.Daemon_BatchDownloadClient, error) {
+	for _, r := range req.Requests {
+		r.Uuid = uuid.New().String()
+	}
+	hashKey := uuid.New().String()
+	stream, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
+		client, _, err := dc.getDaemonClient(hashKey, false)
+		if err != nil {
+			return nil, err
+		}
+		return client.BatchDownload(ctx, req, opts...)
+	}, 0.2, 2.0, 3, nil)
+	if err != nil {
+		return nil, err
+	}
+	return stream.(dfdaemon.Daemon_BatchDownloadClient), nil
+}
+
 func (dc *daemonClient) GetPieceTasks(ctx context.Context, target dfnet.NetAddr, ptr *base.PieceTaskRequest, opts ...grpc.CallOption) (*base.PiecePacket,
 	error) {
 	res, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
@@ -124,3 +175,79 @@ func (dc *daemonClient) CheckHealth(ctx context.Context, target dfnet.NetAddr, o
 
 	return
 }
+
+func (dc *daemonClient) ImportTask(ctx context.Context, req *dfdaemon.ImportTaskRequest, opts ...grpc.CallOption) (*dfdaemon.TaskInfo, error) {
+	taskId := idgen.GenerateTaskID(req.Url, req.Filter, req.UrlMeta, req.BizId)
+	res, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
+		client, _, err := dc.getDaemonClient(taskId, false)
+		if err != nil {
+			return nil, err
+		}
+		return client.ImportTask(ctx, req, opts...)
+	}, 0.2, 2.0, 3, nil)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*dfdaemon.TaskInfo), nil
+}
+
+func (dc *daemonClient) StatTask(ctx context.Context, req *dfdaemon.TaskRequest, opts ...grpc.CallOption) (*dfdaemon.TaskInfo, error) {
+	taskId := taskRequestKey(req)
+	res, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
+		client, _, err := dc.getDaemonClient(taskId, false)
+		if err != nil {
+			return nil, err
+		}
+		return client.StatTask(ctx, req, opts...)
+	}, 0.2, 2.0, 3, nil)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*dfdaemon.TaskInfo), nil
+}
+
+func (dc *daemonClient) DeleteTask(ctx context.Context, req *dfdaemon.TaskRequest, opts ...grpc.CallOption) (err error) {
+	taskId := taskRequestKey(req)
+	_, err = rpc.ExecuteWithRetry(func() (interface{}, error) {
+		client, _, err := dc.getDaemonClient(taskId, false)
+		if err != nil {
+			return nil, err
+		}
+		return client.DeleteTask(ctx, req, opts...)
+	}, 0.2, 2.0, 3, nil)
+	return
+}
+
+func (dc *daemonClient) ListTasks(ctx context.Context, target dfnet.NetAddr, opts ...grpc.CallOption) (*dfdaemon.TaskList, error) {
+	res, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
+		client, err := dc.getDaemonClientWithTarget(target.GetEndpoint())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to connect server %s", target.GetEndpoint())
+		}
+		return client.ListTasks(ctx, new(empty.Empty), opts...)
+	}, 0.2, 2.0, 3, nil)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*dfdaemon.TaskList), nil
+}
+
+func (dc *daemonClient) CancelTask(ctx context.Context, req *dfdaemon.TaskRequest, opts ...grpc.CallOption) (err error) {
+	taskId := taskRequestKey(req)
+	_, err = rpc.ExecuteWithRetry(func() (interface{}, error) {
+		client, _, err := dc.getDaemonClient(taskId, false)
+		if err != nil {
+			return nil, err
+		}
+		return client.CancelTask(ctx, req, opts...)
+	}, 0.2, 2.0, 3, nil)
+	return
+}
+
+// taskRequestKey returns the task id of the request, it is generated with the url when task id is not set
+func taskRequestKey(req *dfdaemon.TaskRequest) string {
+	if req.TaskId != "" {
+		return req.TaskId
+	}
+	return idgen.GenerateTaskID(req.Url, req.Filter, req.UrlMeta, req.BizId)
+}
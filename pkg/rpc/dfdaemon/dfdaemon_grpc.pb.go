@@ -21,10 +21,24 @@ const _ = grpc.SupportPackageIsVersion7
 type DaemonClient interface {
 	// trigger client to download file
 	Download(ctx context.Context, in *DownRequest, opts ...grpc.CallOption) (Daemon_DownloadClient, error)
+	// trigger client to download file and stream the content back in order, output of request is ignored
+	StreamDownload(ctx context.Context, in *DownRequest, opts ...grpc.CallOption) (Daemon_StreamDownloadClient, error)
+	// trigger client to download files in batch over one stream
+	BatchDownload(ctx context.Context, in *BatchDownRequest, opts ...grpc.CallOption) (Daemon_BatchDownloadClient, error)
 	// get piece tasks from other peers
 	GetPieceTasks(ctx context.Context, in *base.PieceTaskRequest, opts ...grpc.CallOption) (*base.PiecePacket, error)
 	// check daemon health
 	CheckHealth(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// import a local file as a completed task, and register the daemon as a seed peer of the task to scheduler
+	ImportTask(ctx context.Context, in *ImportTaskRequest, opts ...grpc.CallOption) (*TaskInfo, error)
+	// get the completed task in local storage
+	StatTask(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*TaskInfo, error)
+	// delete the completed task in local storage, and leave the task in scheduler
+	DeleteTask(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// list all tasks in local storage and running peer tasks
+	ListTasks(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TaskList, error)
+	// cancel the running peer tasks of the task
+	CancelTask(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type daemonClient struct {
@@ -67,6 +81,70 @@ func (x *daemonDownloadClient) Recv() (*DownResult, error) {
 	return m, nil
 }
 
+func (c *daemonClient) StreamDownload(ctx context.Context, in *DownRequest, opts ...grpc.CallOption) (Daemon_StreamDownloadClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Daemon_serviceDesc.Streams[1], "/dfdaemon.Daemon/StreamDownload", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonStreamDownloadClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_StreamDownloadClient interface {
+	Recv() (*DownChunk, error)
+	grpc.ClientStream
+}
+
+type daemonStreamDownloadClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonStreamDownloadClient) Recv() (*DownChunk, error) {
+	m := new(DownChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *daemonClient) BatchDownload(ctx context.Context, in *BatchDownRequest, opts ...grpc.CallOption) (Daemon_BatchDownloadClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Daemon_serviceDesc.Streams[2], "/dfdaemon.Daemon/BatchDownload", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonBatchDownloadClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_BatchDownloadClient interface {
+	Recv() (*BatchDownResult, error)
+	grpc.ClientStream
+}
+
+type daemonBatchDownloadClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonBatchDownloadClient) Recv() (*BatchDownResult, error) {
+	m := new(BatchDownResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *daemonClient) GetPieceTasks(ctx context.Context, in *base.PieceTaskRequest, opts ...grpc.CallOption) (*base.PiecePacket, error) {
 	out := new(base.PiecePacket)
 	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/GetPieceTasks", in, out, opts...)
@@ -85,16 +163,75 @@ func (c *daemonClient) CheckHealth(ctx context.Context, in *emptypb.Empty, opts
 	return out, nil
 }
 
+func (c *daemonClient) ImportTask(ctx context.Context, in *ImportTaskRequest, opts ...grpc.CallOption) (*TaskInfo, error) {
+	out := new(TaskInfo)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/ImportTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) StatTask(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*TaskInfo, error) {
+	out := new(TaskInfo)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/StatTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) DeleteTask(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/DeleteTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) ListTasks(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*TaskList, error) {
+	out := new(TaskList)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/ListTasks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonClient) CancelTask(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dfdaemon.Daemon/CancelTask", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DaemonServer is the server API for Daemon service.
 // All implementations must embed UnimplementedDaemonServer
 // for forward compatibility
 type DaemonServer interface {
 	// trigger client to download file
 	Download(*DownRequest, Daemon_DownloadServer) error
+	// trigger client to download file and stream the content back in order, output of request is ignored
+	StreamDownload(*DownRequest, Daemon_StreamDownloadServer) error
+	// trigger client to download files in batch over one stream
+	BatchDownload(*BatchDownRequest, Daemon_BatchDownloadServer) error
 	// get piece tasks from other peers
 	GetPieceTasks(context.Context, *base.PieceTaskRequest) (*base.PiecePacket, error)
 	// check daemon health
 	CheckHealth(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	// import a local file as a completed task, and register the daemon as a seed peer of the task to scheduler
+	ImportTask(context.Context, *ImportTaskRequest) (*TaskInfo, error)
+	// get the completed task in local storage
+	StatTask(context.Context, *TaskRequest) (*TaskInfo, error)
+	// delete the completed task in local storage, and leave the task in scheduler
+	DeleteTask(context.Context, *TaskRequest) (*emptypb.Empty, error)
+	// list all tasks in local storage and running peer tasks
+	ListTasks(context.Context, *emptypb.Empty) (*TaskList, error)
+	// cancel the running peer tasks of the task
+	CancelTask(context.Context, *TaskRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedDaemonServer()
 }
 
@@ -105,12 +242,33 @@ type UnimplementedDaemonServer struct {
 func (UnimplementedDaemonServer) Download(*DownRequest, Daemon_DownloadServer) error {
 	return status.Errorf(codes.Unimplemented, "method Download not implemented")
 }
+func (UnimplementedDaemonServer) StreamDownload(*DownRequest, Daemon_StreamDownloadServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDownload not implemented")
+}
+func (UnimplementedDaemonServer) BatchDownload(*BatchDownRequest, Daemon_BatchDownloadServer) error {
+	return status.Errorf(codes.Unimplemented, "method BatchDownload not implemented")
+}
 func (UnimplementedDaemonServer) GetPieceTasks(context.Context, *base.PieceTaskRequest) (*base.PiecePacket, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPieceTasks not implemented")
 }
 func (UnimplementedDaemonServer) CheckHealth(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CheckHealth not implemented")
 }
+func (UnimplementedDaemonServer) ImportTask(context.Context, *ImportTaskRequest) (*TaskInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportTask not implemented")
+}
+func (UnimplementedDaemonServer) StatTask(context.Context, *TaskRequest) (*TaskInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StatTask not implemented")
+}
+func (UnimplementedDaemonServer) DeleteTask(context.Context, *TaskRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTask not implemented")
+}
+func (UnimplementedDaemonServer) ListTasks(context.Context, *emptypb.Empty) (*TaskList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTasks not implemented")
+}
+func (UnimplementedDaemonServer) CancelTask(context.Context, *TaskRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelTask not implemented")
+}
 func (UnimplementedDaemonServer) mustEmbedUnimplementedDaemonServer() {}
 
 // UnsafeDaemonServer may be embedded to opt out of forward compatibility for this service.
@@ -145,6 +303,48 @@ func (x *daemonDownloadServer) Send(m *DownResult) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Daemon_StreamDownload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).StreamDownload(m, &daemonStreamDownloadServer{stream})
+}
+
+type Daemon_StreamDownloadServer interface {
+	Send(*DownChunk) error
+	grpc.ServerStream
+}
+
+type daemonStreamDownloadServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonStreamDownloadServer) Send(m *DownChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Daemon_BatchDownload_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BatchDownRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).BatchDownload(m, &daemonBatchDownloadServer{stream})
+}
+
+type Daemon_BatchDownloadServer interface {
+	Send(*BatchDownResult) error
+	grpc.ServerStream
+}
+
+type daemonBatchDownloadServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonBatchDownloadServer) Send(m *BatchDownResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Daemon_GetPieceTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(base.PieceTaskRequest)
 	if err := dec(in); err != nil {
@@ -181,6 +381,96 @@ func _Daemon_CheckHealth_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Daemon_ImportTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).ImportTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/ImportTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).ImportTask(ctx, req.(*ImportTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_StatTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).StatTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/StatTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).StatTask(ctx, req.(*TaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_DeleteTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).DeleteTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/DeleteTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).DeleteTask(ctx, req.(*TaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_ListTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).ListTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/ListTasks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).ListTasks(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daemon_CancelTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServer).CancelTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dfdaemon.Daemon/CancelTask",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServer).CancelTask(ctx, req.(*TaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Daemon_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "dfdaemon.Daemon",
 	HandlerType: (*DaemonServer)(nil),
@@ -193,6 +483,26 @@ var _Daemon_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CheckHealth",
 			Handler:    _Daemon_CheckHealth_Handler,
 		},
+		{
+			MethodName: "ImportTask",
+			Handler:    _Daemon_ImportTask_Handler,
+		},
+		{
+			MethodName: "StatTask",
+			Handler:    _Daemon_StatTask_Handler,
+		},
+		{
+			MethodName: "DeleteTask",
+			Handler:    _Daemon_DeleteTask_Handler,
+		},
+		{
+			MethodName: "ListTasks",
+			Handler:    _Daemon_ListTasks_Handler,
+		},
+		{
+			MethodName: "CancelTask",
+			Handler:    _Daemon_CancelTask_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -200,6 +510,16 @@ var _Daemon_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Daemon_Download_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamDownload",
+			Handler:       _Daemon_StreamDownload_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "BatchDownload",
+			Handler:       _Daemon_BatchDownload_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "pkg/rpc/dfdaemon/dfdaemon.proto",
 }
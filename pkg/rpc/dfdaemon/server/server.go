@@ -45,8 +45,22 @@ type proxy struct {
 // see dfdaemon.DaemonServer
 type DaemonServer interface {
 	Download(context.Context, *dfdaemon.DownRequest, chan<- *dfdaemon.DownResult) error
+	// StreamDownload sends the file content into the channel in order
+	StreamDownload(context.Context, *dfdaemon.DownRequest, chan<- *dfdaemon.DownChunk) error
+	// BatchDownload sends results of all requests into the channel, and returns after all requests are finished
+	BatchDownload(context.Context, *dfdaemon.BatchDownRequest, chan<- *dfdaemon.BatchDownResult) error
 	GetPieceTasks(context.Context, *base.PieceTaskRequest) (*base.PiecePacket, error)
 	CheckHealth(context.Context) error
+	// ImportTask imports a local file as a completed task, and registers the daemon as a seed peer to scheduler
+	ImportTask(context.Context, *dfdaemon.ImportTaskRequest) (*dfdaemon.TaskInfo, error)
+	// StatTask returns the task in local storage or the running peer task, the completed one is preferred
+	StatTask(context.Context, *dfdaemon.TaskRequest) (*dfdaemon.TaskInfo, error)
+	// DeleteTask deletes the task in local storage which is not running
+	DeleteTask(context.Context, *dfdaemon.TaskRequest) error
+	// ListTasks returns all tasks in local storage and running peer tasks
+	ListTasks(context.Context) ([]*dfdaemon.TaskInfo, error)
+	// CancelTask cancels the running peer tasks of the task
+	CancelTask(context.Context, *dfdaemon.TaskRequest) error
 }
 
 func (p *proxy) Download(req *dfdaemon.DownRequest, stream dfdaemon.Daemon_DownloadServer) (err error) {
@@ -81,6 +95,80 @@ func (p *proxy) Download(req *dfdaemon.DownRequest, stream dfdaemon.Daemon_Downl
 	return
 }
 
+func (p *proxy) StreamDownload(req *dfdaemon.DownRequest, stream dfdaemon.Daemon_StreamDownloadServer) (err error) {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	peerAddr := "unknown"
+	if pe, ok := peer.FromContext(ctx); ok {
+		peerAddr = pe.Addr.String()
+	}
+	logger.Infof("trigger stream download for url:%s,from:%s,uuid:%s", req.Url, peerAddr, req.Uuid)
+
+	errChan := make(chan error, 2)
+	dcc := make(chan *dfdaemon.DownChunk, 4)
+
+	go func() {
+		defer close(dcc)
+		if err := safe.Call(func() {
+			errChan <- p.server.StreamDownload(ctx, req, dcc)
+		}); err != nil {
+			errChan <- err
+		}
+	}()
+
+	// keep draining chunks after sending failed, the server stops soon when ctx is canceled
+	for v := range dcc {
+		if err != nil {
+			continue
+		}
+		if err = stream.Send(v); err != nil {
+			cancel()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return <-errChan
+}
+
+func (p *proxy) BatchDownload(req *dfdaemon.BatchDownRequest, stream dfdaemon.Daemon_BatchDownloadServer) (err error) {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	peerAddr := "unknown"
+	if pe, ok := peer.FromContext(ctx); ok {
+		peerAddr = pe.Addr.String()
+	}
+	logger.Infof("trigger batch download for %d urls,from:%s", len(req.Requests), peerAddr)
+
+	errChan := make(chan error, 2)
+	brc := make(chan *dfdaemon.BatchDownResult, 4)
+
+	go func() {
+		defer close(brc)
+		if err := safe.Call(func() {
+			errChan <- p.server.BatchDownload(ctx, req, brc)
+		}); err != nil {
+			errChan <- err
+		}
+	}()
+
+	// keep draining results after sending failed, the server stops soon when ctx is canceled
+	for v := range brc {
+		if err != nil {
+			continue
+		}
+		if err = stream.Send(v); err != nil {
+			cancel()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return <-errChan
+}
+
 func (p *proxy) GetPieceTasks(ctx context.Context, ptr *base.PieceTaskRequest) (*base.PiecePacket, error) {
 	return p.server.GetPieceTasks(ctx, ptr)
 }
@@ -90,6 +178,33 @@ func (p *proxy) CheckHealth(ctx context.Context, req *empty.Empty) (*empty.Empty
 	return new(empty.Empty), p.server.CheckHealth(ctx)
 }
 
+func (p *proxy) ImportTask(ctx context.Context, req *dfdaemon.ImportTaskRequest) (*dfdaemon.TaskInfo, error) {
+	logger.Infof("trigger import task for url:%s,path:%s", req.Url, req.Path)
+	return p.server.ImportTask(ctx, req)
+}
+
+func (p *proxy) StatTask(ctx context.Context, req *dfdaemon.TaskRequest) (*dfdaemon.TaskInfo, error) {
+	return p.server.StatTask(ctx, req)
+}
+
+func (p *proxy) DeleteTask(ctx context.Context, req *dfdaemon.TaskRequest) (*empty.Empty, error) {
+	logger.Infof("trigger delete task for url:%s,task:%s", req.Url, req.TaskId)
+	return new(empty.Empty), p.server.DeleteTask(ctx, req)
+}
+
+func (p *proxy) ListTasks(ctx context.Context, req *empty.Empty) (*dfdaemon.TaskList, error) {
+	tasks, err := p.server.ListTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &dfdaemon.TaskList{Tasks: tasks}, nil
+}
+
+func (p *proxy) CancelTask(ctx context.Context, req *dfdaemon.TaskRequest) (*empty.Empty, error) {
+	logger.Infof("trigger cancel task for url:%s,task:%s,peer:%s", req.Url, req.TaskId, req.PeerId)
+	return new(empty.Empty), p.server.CancelTask(ctx, req)
+}
+
 func send(drc chan *dfdaemon.DownResult, closeDrc func(), stream dfdaemon.Daemon_DownloadServer, errChan chan error) {
 	err := safe.Call(func() {
 		defer closeDrc()
@@ -33,6 +33,7 @@ import (
 	"d7y.io/dragonfly/v2/pkg/rpc"
 	"d7y.io/dragonfly/v2/pkg/rpc/base"
 	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	"d7y.io/dragonfly/v2/pkg/util/stringutils"
 )
 
 func GetClient() (SchedulerClient, error) {
@@ -69,6 +70,10 @@ type SchedulerClient interface {
 	RegisterPeerTask(ctx context.Context, ptr *scheduler.PeerTaskRequest, opts ...grpc.CallOption) (*scheduler.RegisterResult, error)
 	// IsMigrating of ptr will be set to true
 	ReportPieceResult(ctx context.Context, taskId string, ptr *scheduler.PeerTaskRequest, opts ...grpc.CallOption) (PeerPacketStream, error)
+	// MigratePeerTask migrates the task to another scheduler except the ones stream has used, registers ptr there
+	// with IsMigrating set and returns a new PeerPacketStream, stream is closed, cause is the error which broke it
+	MigratePeerTask(ctx context.Context, taskId string, ptr *scheduler.PeerTaskRequest, stream PeerPacketStream, cause error,
+		opts ...grpc.CallOption) (PeerPacketStream, error)
 
 	ReportPeerResult(ctx context.Context, pr *scheduler.PeerResult, opts ...grpc.CallOption) error
 
@@ -143,10 +148,60 @@ func (sc *schedulerClient) ReportPieceResult(ctx context.Context, taskId string,
 	pps, err := newPeerPacketStream(sc, ctx, taskId, ptr, opts)
 
 	logger.With("peerId", ptr.PeerId, "errMsg", err).Infof("start to report piece result for taskId:%s", taskId)
+	if err != nil {
+		return nil, err
+	}
 
 	// trigger scheduling
 	pps.Send(scheduler.NewZeroPieceResult(taskId, ptr.PeerId))
-	return pps, err
+	return pps, nil
+}
+
+func (sc *schedulerClient) MigratePeerTask(ctx context.Context, taskId string, ptr *scheduler.PeerTaskRequest, stream PeerPacketStream,
+	cause error, opts ...grpc.CallOption) (PeerPacketStream, error) {
+	var exclusiveNodes []string
+	if pps, ok := stream.(*peerPacketStream); ok {
+		exclusiveNodes = append(exclusiveNodes, pps.failedServers...)
+		if pps.node != "" {
+			exclusiveNodes = append(exclusiveNodes, pps.node)
+		}
+		pps.close()
+	}
+	preNode, err := sc.TryMigrate(taskId, cause, exclusiveNodes)
+	if err != nil {
+		logger.With("peerId", ptr.PeerId, "errMsg", err).Warnf("migrate peer task failed for taskId:%s", taskId)
+		return nil, err
+	}
+
+	ptr.IsMigrating = true
+	var schedulerNode string
+	_, err = rpc.ExecuteWithRetry(func() (interface{}, error) {
+		var client scheduler.SchedulerClient
+		client, schedulerNode, err = sc.getSchedulerClient(taskId, true)
+		if err != nil {
+			return nil, err
+		}
+		return client.RegisterPeerTask(ctx, ptr, opts...)
+	}, 0.5, 5.0, 3, nil)
+
+	logger.With("peerId", ptr.PeerId, "errMsg", err).
+		Infof("migrate peer task result:%t for taskId:%s,from scheduler:%s,to scheduler:%s", err == nil, taskId, preNode, schedulerNode)
+	if err != nil {
+		return nil, err
+	}
+
+	pps, err := sc.ReportPieceResult(ctx, taskId, ptr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// the new stream never goes back to the failed schedulers
+	if pps, ok := pps.(*peerPacketStream); ok {
+		if preNode != "" && !stringutils.Contains(exclusiveNodes, preNode) {
+			exclusiveNodes = append(exclusiveNodes, preNode)
+		}
+		pps.failedServers = exclusiveNodes
+	}
+	return pps, nil
 }
 
 func (sc *schedulerClient) ReportPeerResult(ctx context.Context, pr *scheduler.PeerResult, opts ...grpc.CallOption) error {
@@ -0,0 +1,146 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	testifyassert "github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"d7y.io/dragonfly/v2/pkg/basic/dfnet"
+	"d7y.io/dragonfly/v2/pkg/rpc"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+)
+
+// testScheduler records the registered peers and notifies when a piece result stream ends
+type testScheduler struct {
+	scheduler.UnimplementedSchedulerServer
+	registered chan *scheduler.PeerTaskRequest
+	streamDone chan struct{}
+}
+
+func (s *testScheduler) RegisterPeerTask(ctx context.Context, ptr *scheduler.PeerTaskRequest) (*scheduler.RegisterResult, error) {
+	s.registered <- ptr
+	return &scheduler.RegisterResult{TaskId: "task-0"}, nil
+}
+
+func (s *testScheduler) ReportPieceResult(stream scheduler.Scheduler_ReportPieceResultServer) error {
+	defer func() {
+		s.streamDone <- struct{}{}
+	}()
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return nil
+		}
+	}
+}
+
+func startTestSchedulers(t *testing.T, count int) (map[string]*testScheduler, []dfnet.NetAddr) {
+	var (
+		schedulers = map[string]*testScheduler{}
+		addrs      []dfnet.NetAddr
+	)
+	for i := 0; i < count; i++ {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &testScheduler{
+			registered: make(chan *scheduler.PeerTaskRequest, 10),
+			streamDone: make(chan struct{}, 10),
+		}
+		server := grpc.NewServer()
+		scheduler.RegisterSchedulerServer(server, s)
+		go server.Serve(ln)
+		t.Cleanup(server.Stop)
+
+		addr := dfnet.NetAddr{Type: dfnet.TCP, Addr: ln.Addr().String()}
+		schedulers[addr.GetEndpoint()] = s
+		addrs = append(addrs, addr)
+	}
+	return schedulers, addrs
+}
+
+func TestSchedulerClient_MigratePeerTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	schedulers, addrs := startTestSchedulers(t, 3)
+	sc := &schedulerClient{
+		rpc.NewConnection(context.Background(), "scheduler", addrs, nil),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		taskID = "task-0"
+		ptr    = &scheduler.PeerTaskRequest{
+			PeerId:   "peer-0",
+			PeerHost: &scheduler.PeerHost{},
+		}
+	)
+	_, err := sc.RegisterPeerTask(ctx, ptr)
+	assert.Nil(err)
+	current, ok := sc.GetServerNode(taskID)
+	assert.True(ok)
+	<-schedulers[current].registered
+
+	stream, err := sc.ReportPieceResult(ctx, taskID, ptr)
+	assert.Nil(err)
+	old := stream.(*peerPacketStream)
+	assert.Equal(current, old.node)
+	// one of the other schedulers failed before in the old stream
+	var failed, candidate string
+	for node := range schedulers {
+		if node == current {
+			continue
+		}
+		if failed == "" {
+			failed = node
+		} else {
+			candidate = node
+		}
+	}
+	old.failedServers = []string{failed}
+
+	stream, err = sc.MigratePeerTask(ctx, taskID, ptr, old, status.Error(codes.Unavailable, "scheduler gone"))
+	assert.Nil(err)
+	node, _ := sc.GetServerNode(taskID)
+	assert.Equal(candidate, node, "both the current and failed schedulers should be excluded")
+	select {
+	case req := <-schedulers[candidate].registered:
+		assert.True(req.IsMigrating)
+	case <-time.After(5 * time.Second):
+		assert.Fail("peer is not registered to the candidate scheduler")
+	}
+	assert.ElementsMatch([]string{current, failed}, stream.(*peerPacketStream).failedServers)
+
+	// the old stream is closed
+	select {
+	case <-schedulers[current].streamDone:
+	case <-time.After(5 * time.Second):
+		assert.Fail("old piece result stream is not closed")
+	}
+
+	// no scheduler is left
+	_, err = sc.MigratePeerTask(ctx, taskID, ptr, stream, status.Error(codes.Unavailable, "scheduler gone"))
+	assert.NotNil(err)
+}
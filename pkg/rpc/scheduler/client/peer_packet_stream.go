@@ -41,12 +41,15 @@ type PeerPacketStream interface {
 type peerPacketStream struct {
 	sc      *schedulerClient
 	ctx     context.Context
+	cancel  context.CancelFunc
 	hashKey string
 	ptr     *scheduler.PeerTaskRequest
 	opts    []grpc.CallOption
 
 	// stream for one client
-	stream          scheduler.Scheduler_ReportPieceResultClient
+	stream scheduler.Scheduler_ReportPieceResultClient
+	// node is the scheduler node of stream
+	node            string
 	failedServers   []string
 	lastPieceResult *scheduler.PieceResult
 
@@ -56,9 +59,11 @@ type peerPacketStream struct {
 func newPeerPacketStream(sc *schedulerClient, ctx context.Context, hashKey string, ptr *scheduler.PeerTaskRequest, opts []grpc.CallOption) (PeerPacketStream, error) {
 	ptr.IsMigrating = true
 
+	ctx, cancel := context.WithCancel(ctx)
 	pps := &peerPacketStream{
 		sc:      sc,
 		ctx:     ctx,
+		cancel:  cancel,
 		hashKey: hashKey,
 		ptr:     ptr,
 		opts:    opts,
@@ -70,6 +75,7 @@ func newPeerPacketStream(sc *schedulerClient, ctx context.Context, hashKey strin
 	}
 
 	if err := pps.initStream(); err != nil {
+		cancel()
 		return nil, err
 	} else {
 		return pps, nil
@@ -98,6 +104,12 @@ func (pps *peerPacketStream) closeSend() error {
 	return pps.stream.CloseSend()
 }
 
+// close closes the stream which is replaced by migrating to another scheduler
+func (pps *peerPacketStream) close() {
+	_ = pps.closeSend()
+	pps.cancel()
+}
+
 func (pps *peerPacketStream) Recv() (pp *scheduler.PeerPacket, err error) {
 	pps.sc.UpdateAccessNodeMap(pps.hashKey)
 	if pp, err = pps.stream.Recv(); err != nil && err != io.EOF {
@@ -131,7 +143,7 @@ func (pps *peerPacketStream) retryRecv(cause error) (*scheduler.PeerPacket, erro
 		return nil, cause
 	}
 	_, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
-		client, _, err := pps.sc.getSchedulerClient(pps.hashKey, false)
+		client, node, err := pps.sc.getSchedulerClient(pps.hashKey, false)
 		if err != nil {
 			return nil, err
 		}
@@ -146,6 +158,7 @@ func (pps *peerPacketStream) retryRecv(cause error) (*scheduler.PeerPacket, erro
 			return nil, err
 		}
 		pps.stream = stream.(scheduler.Scheduler_ReportPieceResultClient)
+		pps.node = node
 		pps.retryMeta.StreamTimes = 1
 		err = pps.Send(pps.lastPieceResult)
 		if err != nil {
@@ -161,10 +174,11 @@ func (pps *peerPacketStream) retryRecv(cause error) (*scheduler.PeerPacket, erro
 
 func (pps *peerPacketStream) initStream() error {
 	stream, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
-		client, _, err := pps.sc.getSchedulerClient(pps.hashKey, true)
+		client, node, err := pps.sc.getSchedulerClient(pps.hashKey, true)
 		if err != nil {
 			return nil, err
 		}
+		pps.node = node
 		return client.ReportPieceResult(pps.ctx, pps.opts...)
 	}, pps.retryMeta.InitBackoff, pps.retryMeta.MaxBackOff, pps.retryMeta.MaxAttempts, nil)
 	if err == nil {
@@ -182,10 +196,11 @@ func (pps *peerPacketStream) replaceStream(cause error) error {
 		return errors.New("times of replacing stream reaches limit")
 	}
 	res, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
-		client, _, err := pps.sc.getSchedulerClient(pps.hashKey, true)
+		client, node, err := pps.sc.getSchedulerClient(pps.hashKey, true)
 		if err != nil {
 			return nil, err
 		}
+		pps.node = node
 		return client.ReportPieceResult(pps.ctx, pps.opts...)
 	}, pps.retryMeta.InitBackoff, pps.retryMeta.MaxBackOff, pps.retryMeta.MaxAttempts, cause)
 	if err == nil {
@@ -203,7 +218,7 @@ func (pps *peerPacketStream) replaceClient(cause error) error {
 	}
 
 	stream, err := rpc.ExecuteWithRetry(func() (interface{}, error) {
-		client, _, err := pps.sc.getSchedulerClient(pps.hashKey, true)
+		client, node, err := pps.sc.getSchedulerClient(pps.hashKey, true)
 		if err != nil {
 			return nil, err
 		}
@@ -213,6 +228,7 @@ func (pps *peerPacketStream) replaceClient(cause error) error {
 		if err != nil {
 			return nil, err
 		}
+		pps.node = node
 		return client.ReportPieceResult(pps.ctx, pps.opts...)
 	}, pps.retryMeta.InitBackoff, pps.retryMeta.MaxBackOff, pps.retryMeta.MaxAttempts, cause)
 	if err == nil {
@@ -65,6 +65,11 @@ type PeerTaskRequest struct {
 	HostLoad *base.HostLoad `protobuf:"bytes,7,opt,name=host_load,json=hostLoad,proto3" json:"host_load,omitempty"`
 	// whether this request is caused by migration
 	IsMigrating bool `protobuf:"varint,8,opt,name=is_migrating,json=isMigrating,proto3" json:"is_migrating,omitempty"`
+	// only schedule cdn peers as parents, it is used when other peers are untrusted
+	CdnOnly bool `protobuf:"varint,9,opt,name=cdn_only,json=cdnOnly,proto3" json:"cdn_only,omitempty"`
+	// set when the peer already has the whole content of the task, eg: a local file imported without origin,
+	// the peer is taken as a finished parent and cdn is not triggered for the task
+	SeedInfo *SeedInfo `protobuf:"bytes,10,opt,name=seed_info,json=seedInfo,proto3" json:"seed_info,omitempty"`
 }
 
 func (x *PeerTaskRequest) Reset() {
@@ -155,6 +160,86 @@ func (x *PeerTaskRequest) GetIsMigrating() bool {
 	return false
 }
 
+func (x *PeerTaskRequest) GetCdnOnly() bool {
+	if x != nil {
+		return x.CdnOnly
+	}
+	return false
+}
+
+func (x *PeerTaskRequest) GetSeedInfo() *SeedInfo {
+	if x != nil {
+		return x.SeedInfo
+	}
+	return nil
+}
+
+type SeedInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// content length of the task
+	ContentLength int64 `protobuf:"varint,1,opt,name=content_length,json=contentLength,proto3" json:"content_length,omitempty"`
+	// piece size of the task
+	PieceSize int32 `protobuf:"varint,2,opt,name=piece_size,json=pieceSize,proto3" json:"piece_size,omitempty"`
+	// total piece count of the task
+	TotalPiece int32 `protobuf:"varint,3,opt,name=total_piece,json=totalPiece,proto3" json:"total_piece,omitempty"`
+}
+
+func (x *SeedInfo) Reset() {
+	*x = SeedInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SeedInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeedInfo) ProtoMessage() {}
+
+func (x *SeedInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeedInfo.ProtoReflect.Descriptor instead.
+func (*SeedInfo) Descriptor() ([]byte, []int) {
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SeedInfo) GetContentLength() int64 {
+	if x != nil {
+		return x.ContentLength
+	}
+	return 0
+}
+
+func (x *SeedInfo) GetPieceSize() int32 {
+	if x != nil {
+		return x.PieceSize
+	}
+	return 0
+}
+
+func (x *SeedInfo) GetTotalPiece() int32 {
+	if x != nil {
+		return x.TotalPiece
+	}
+	return 0
+}
+
 type RegisterResult struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -175,7 +260,7 @@ type RegisterResult struct {
 func (x *RegisterResult) Reset() {
 	*x = RegisterResult{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[1]
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -188,7 +273,7 @@ func (x *RegisterResult) String() string {
 func (*RegisterResult) ProtoMessage() {}
 
 func (x *RegisterResult) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[1]
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -201,7 +286,7 @@ func (x *RegisterResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RegisterResult.ProtoReflect.Descriptor instead.
 func (*RegisterResult) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{1}
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *RegisterResult) GetTaskId() string {
@@ -273,7 +358,7 @@ type SinglePiece struct {
 func (x *SinglePiece) Reset() {
 	*x = SinglePiece{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[2]
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -286,7 +371,7 @@ func (x *SinglePiece) String() string {
 func (*SinglePiece) ProtoMessage() {}
 
 func (x *SinglePiece) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[2]
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -299,7 +384,7 @@ func (x *SinglePiece) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SinglePiece.ProtoReflect.Descriptor instead.
 func (*SinglePiece) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{2}
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *SinglePiece) GetDstPid() string {
@@ -351,7 +436,7 @@ type PeerHost struct {
 func (x *PeerHost) Reset() {
 	*x = PeerHost{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[3]
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -364,7 +449,7 @@ func (x *PeerHost) String() string {
 func (*PeerHost) ProtoMessage() {}
 
 func (x *PeerHost) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[3]
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -377,7 +462,7 @@ func (x *PeerHost) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeerHost.ProtoReflect.Descriptor instead.
 func (*PeerHost) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{3}
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *PeerHost) GetUuid() string {
@@ -473,7 +558,7 @@ type PieceResult struct {
 func (x *PieceResult) Reset() {
 	*x = PieceResult{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[4]
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -486,7 +571,7 @@ func (x *PieceResult) String() string {
 func (*PieceResult) ProtoMessage() {}
 
 func (x *PieceResult) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[4]
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -499,7 +584,7 @@ func (x *PieceResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PieceResult.ProtoReflect.Descriptor instead.
 func (*PieceResult) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{4}
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *PieceResult) GetTaskId() string {
@@ -591,7 +676,7 @@ type PeerPacket struct {
 func (x *PeerPacket) Reset() {
 	*x = PeerPacket{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[5]
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -604,7 +689,7 @@ func (x *PeerPacket) String() string {
 func (*PeerPacket) ProtoMessage() {}
 
 func (x *PeerPacket) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[5]
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -617,7 +702,7 @@ func (x *PeerPacket) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeerPacket.ProtoReflect.Descriptor instead.
 func (*PeerPacket) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{5}
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *PeerPacket) GetTaskId() string {
@@ -688,7 +773,7 @@ type PeerResult struct {
 func (x *PeerResult) Reset() {
 	*x = PeerResult{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[6]
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -701,7 +786,7 @@ func (x *PeerResult) String() string {
 func (*PeerResult) ProtoMessage() {}
 
 func (x *PeerResult) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[6]
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -714,7 +799,7 @@ func (x *PeerResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeerResult.ProtoReflect.Descriptor instead.
 func (*PeerResult) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{6}
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *PeerResult) GetTaskId() string {
@@ -806,7 +891,7 @@ type PeerTarget struct {
 func (x *PeerTarget) Reset() {
 	*x = PeerTarget{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[7]
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -819,7 +904,7 @@ func (x *PeerTarget) String() string {
 func (*PeerTarget) ProtoMessage() {}
 
 func (x *PeerTarget) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[7]
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -832,7 +917,7 @@ func (x *PeerTarget) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeerTarget.ProtoReflect.Descriptor instead.
 func (*PeerTarget) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{7}
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *PeerTarget) GetTaskId() string {
@@ -865,7 +950,7 @@ type PeerPacket_DestPeer struct {
 func (x *PeerPacket_DestPeer) Reset() {
 	*x = PeerPacket_DestPeer{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[8]
+		mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -878,7 +963,7 @@ func (x *PeerPacket_DestPeer) String() string {
 func (*PeerPacket_DestPeer) ProtoMessage() {}
 
 func (x *PeerPacket_DestPeer) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[8]
+	mi := &file_pkg_rpc_scheduler_scheduler_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -891,7 +976,7 @@ func (x *PeerPacket_DestPeer) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PeerPacket_DestPeer.ProtoReflect.Descriptor instead.
 func (*PeerPacket_DestPeer) Descriptor() ([]byte, []int) {
-	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{5, 0}
+	return file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP(), []int{6, 0}
 }
 
 func (x *PeerPacket_DestPeer) GetIp() string {
@@ -924,7 +1009,7 @@ var file_pkg_rpc_scheduler_scheduler_proto_rawDesc = []byte{
 	0x70, 0x6b, 0x67, 0x2f, 0x72, 0x70, 0x63, 0x2f, 0x62, 0x61, 0x73, 0x65, 0x2f, 0x62, 0x61, 0x73,
 	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x97, 0x02, 0x0a, 0x0f, 0x50, 0x65, 0x65, 0x72, 0x54, 0x61, 0x73,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0xe4, 0x02, 0x0a, 0x0f, 0x50, 0x65, 0x65, 0x72, 0x54, 0x61, 0x73,
 	0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x69,
 	0x6c, 0x74, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x69, 0x6c, 0x74,
@@ -941,7 +1026,19 @@ var file_pkg_rpc_scheduler_scheduler_proto_rawDesc = []byte{
 	0x0b, 0x32, 0x0e, 0x2e, 0x62, 0x61, 0x73, 0x65, 0x2e, 0x48, 0x6f, 0x73, 0x74, 0x4c, 0x6f, 0x61,
 	0x64, 0x52, 0x08, 0x68, 0x6f, 0x73, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x69,
 	0x73, 0x5f, 0x6d, 0x69, 0x67, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x08, 0x20, 0x01, 0x28,
-	0x08, 0x52, 0x0b, 0x69, 0x73, 0x4d, 0x69, 0x67, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x22, 0xcd,
+	0x08, 0x52, 0x0b, 0x69, 0x73, 0x4d, 0x69, 0x67, 0x72, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x19,
+	0x0a, 0x08, 0x63, 0x64, 0x6e, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x07, 0x63, 0x64, 0x6e, 0x4f, 0x6e, 0x6c, 0x79, 0x12, 0x30, 0x0a, 0x09, 0x73, 0x65, 0x65,
+	0x64, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73,
+	0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x72, 0x2e, 0x53, 0x65, 0x65, 0x64, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x08, 0x73, 0x65, 0x65, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x71, 0x0a, 0x08, 0x53,
+	0x65, 0x65, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x6f, 0x6e, 0x74, 0x65,
+	0x6e, 0x74, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0d, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x1d,
+	0x0a, 0x0a, 0x70, 0x69, 0x65, 0x63, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x09, 0x70, 0x69, 0x65, 0x63, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1f, 0x0a,
+	0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x70, 0x69, 0x65, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x0a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x50, 0x69, 0x65, 0x63, 0x65, 0x22, 0xcd,
 	0x01, 0x0a, 0x0e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x73, 0x75, 0x6c,
 	0x74, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
 	0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x73, 0x6b, 0x49, 0x64, 0x12, 0x2e, 0x0a, 0x0a, 0x73, 0x69,
@@ -1078,50 +1175,52 @@ func file_pkg_rpc_scheduler_scheduler_proto_rawDescGZIP() []byte {
 	return file_pkg_rpc_scheduler_scheduler_proto_rawDescData
 }
 
-var file_pkg_rpc_scheduler_scheduler_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_pkg_rpc_scheduler_scheduler_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_pkg_rpc_scheduler_scheduler_proto_goTypes = []interface{}{
 	(*PeerTaskRequest)(nil),     // 0: scheduler.PeerTaskRequest
-	(*RegisterResult)(nil),      // 1: scheduler.RegisterResult
-	(*SinglePiece)(nil),         // 2: scheduler.SinglePiece
-	(*PeerHost)(nil),            // 3: scheduler.PeerHost
-	(*PieceResult)(nil),         // 4: scheduler.PieceResult
-	(*PeerPacket)(nil),          // 5: scheduler.PeerPacket
-	(*PeerResult)(nil),          // 6: scheduler.PeerResult
-	(*PeerTarget)(nil),          // 7: scheduler.PeerTarget
-	(*PeerPacket_DestPeer)(nil), // 8: scheduler.PeerPacket.DestPeer
-	(*base.UrlMeta)(nil),        // 9: base.UrlMeta
-	(*base.HostLoad)(nil),       // 10: base.HostLoad
-	(base.SizeScope)(0),         // 11: base.SizeScope
-	(*base.PieceInfo)(nil),      // 12: base.PieceInfo
-	(base.Code)(0),              // 13: base.Code
-	(*emptypb.Empty)(nil),       // 14: google.protobuf.Empty
+	(*SeedInfo)(nil),            // 1: scheduler.SeedInfo
+	(*RegisterResult)(nil),      // 2: scheduler.RegisterResult
+	(*SinglePiece)(nil),         // 3: scheduler.SinglePiece
+	(*PeerHost)(nil),            // 4: scheduler.PeerHost
+	(*PieceResult)(nil),         // 5: scheduler.PieceResult
+	(*PeerPacket)(nil),          // 6: scheduler.PeerPacket
+	(*PeerResult)(nil),          // 7: scheduler.PeerResult
+	(*PeerTarget)(nil),          // 8: scheduler.PeerTarget
+	(*PeerPacket_DestPeer)(nil), // 9: scheduler.PeerPacket.DestPeer
+	(*base.UrlMeta)(nil),        // 10: base.UrlMeta
+	(*base.HostLoad)(nil),       // 11: base.HostLoad
+	(base.SizeScope)(0),         // 12: base.SizeScope
+	(*base.PieceInfo)(nil),      // 13: base.PieceInfo
+	(base.Code)(0),              // 14: base.Code
+	(*emptypb.Empty)(nil),       // 15: google.protobuf.Empty
 }
 var file_pkg_rpc_scheduler_scheduler_proto_depIdxs = []int32{
-	9,  // 0: scheduler.PeerTaskRequest.url_mata:type_name -> base.UrlMeta
-	3,  // 1: scheduler.PeerTaskRequest.peer_host:type_name -> scheduler.PeerHost
-	10, // 2: scheduler.PeerTaskRequest.host_load:type_name -> base.HostLoad
-	11, // 3: scheduler.RegisterResult.size_scope:type_name -> base.SizeScope
-	2,  // 4: scheduler.RegisterResult.single_piece:type_name -> scheduler.SinglePiece
-	12, // 5: scheduler.SinglePiece.piece_info:type_name -> base.PieceInfo
-	13, // 6: scheduler.PieceResult.code:type_name -> base.Code
-	10, // 7: scheduler.PieceResult.host_load:type_name -> base.HostLoad
-	8,  // 8: scheduler.PeerPacket.main_peer:type_name -> scheduler.PeerPacket.DestPeer
-	8,  // 9: scheduler.PeerPacket.steal_peers:type_name -> scheduler.PeerPacket.DestPeer
-	13, // 10: scheduler.PeerPacket.code:type_name -> base.Code
-	13, // 11: scheduler.PeerResult.code:type_name -> base.Code
-	0,  // 12: scheduler.Scheduler.RegisterPeerTask:input_type -> scheduler.PeerTaskRequest
-	4,  // 13: scheduler.Scheduler.ReportPieceResult:input_type -> scheduler.PieceResult
-	6,  // 14: scheduler.Scheduler.ReportPeerResult:input_type -> scheduler.PeerResult
-	7,  // 15: scheduler.Scheduler.LeaveTask:input_type -> scheduler.PeerTarget
-	1,  // 16: scheduler.Scheduler.RegisterPeerTask:output_type -> scheduler.RegisterResult
-	5,  // 17: scheduler.Scheduler.ReportPieceResult:output_type -> scheduler.PeerPacket
-	14, // 18: scheduler.Scheduler.ReportPeerResult:output_type -> google.protobuf.Empty
-	14, // 19: scheduler.Scheduler.LeaveTask:output_type -> google.protobuf.Empty
-	16, // [16:20] is the sub-list for method output_type
-	12, // [12:16] is the sub-list for method input_type
-	12, // [12:12] is the sub-list for extension type_name
-	12, // [12:12] is the sub-list for extension extendee
-	0,  // [0:12] is the sub-list for field type_name
+	10, // 0: scheduler.PeerTaskRequest.url_mata:type_name -> base.UrlMeta
+	4,  // 1: scheduler.PeerTaskRequest.peer_host:type_name -> scheduler.PeerHost
+	11, // 2: scheduler.PeerTaskRequest.host_load:type_name -> base.HostLoad
+	1,  // 3: scheduler.PeerTaskRequest.seed_info:type_name -> scheduler.SeedInfo
+	12, // 4: scheduler.RegisterResult.size_scope:type_name -> base.SizeScope
+	3,  // 5: scheduler.RegisterResult.single_piece:type_name -> scheduler.SinglePiece
+	13, // 6: scheduler.SinglePiece.piece_info:type_name -> base.PieceInfo
+	14, // 7: scheduler.PieceResult.code:type_name -> base.Code
+	11, // 8: scheduler.PieceResult.host_load:type_name -> base.HostLoad
+	9,  // 9: scheduler.PeerPacket.main_peer:type_name -> scheduler.PeerPacket.DestPeer
+	9,  // 10: scheduler.PeerPacket.steal_peers:type_name -> scheduler.PeerPacket.DestPeer
+	14, // 11: scheduler.PeerPacket.code:type_name -> base.Code
+	14, // 12: scheduler.PeerResult.code:type_name -> base.Code
+	0,  // 13: scheduler.Scheduler.RegisterPeerTask:input_type -> scheduler.PeerTaskRequest
+	5,  // 14: scheduler.Scheduler.ReportPieceResult:input_type -> scheduler.PieceResult
+	7,  // 15: scheduler.Scheduler.ReportPeerResult:input_type -> scheduler.PeerResult
+	8,  // 16: scheduler.Scheduler.LeaveTask:input_type -> scheduler.PeerTarget
+	2,  // 17: scheduler.Scheduler.RegisterPeerTask:output_type -> scheduler.RegisterResult
+	6,  // 18: scheduler.Scheduler.ReportPieceResult:output_type -> scheduler.PeerPacket
+	15, // 19: scheduler.Scheduler.ReportPeerResult:output_type -> google.protobuf.Empty
+	15, // 20: scheduler.Scheduler.LeaveTask:output_type -> google.protobuf.Empty
+	17, // [17:21] is the sub-list for method output_type
+	13, // [13:17] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_pkg_rpc_scheduler_scheduler_proto_init() }
@@ -1143,7 +1242,7 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			}
 		}
 		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RegisterResult); i {
+			switch v := v.(*SeedInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1155,7 +1254,7 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			}
 		}
 		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SinglePiece); i {
+			switch v := v.(*RegisterResult); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1167,7 +1266,7 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			}
 		}
 		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeerHost); i {
+			switch v := v.(*SinglePiece); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1179,7 +1278,7 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			}
 		}
 		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PieceResult); i {
+			switch v := v.(*PeerHost); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1191,7 +1290,7 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			}
 		}
 		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeerPacket); i {
+			switch v := v.(*PieceResult); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1203,7 +1302,7 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			}
 		}
 		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeerResult); i {
+			switch v := v.(*PeerPacket); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1215,7 +1314,7 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			}
 		}
 		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PeerTarget); i {
+			switch v := v.(*PeerResult); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1227,6 +1326,18 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			}
 		}
 		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PeerTarget); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_rpc_scheduler_scheduler_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*PeerPacket_DestPeer); i {
 			case 0:
 				return &v.state
@@ -1239,7 +1350,7 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			}
 		}
 	}
-	file_pkg_rpc_scheduler_scheduler_proto_msgTypes[1].OneofWrappers = []interface{}{
+	file_pkg_rpc_scheduler_scheduler_proto_msgTypes[2].OneofWrappers = []interface{}{
 		(*RegisterResult_SinglePiece)(nil),
 		(*RegisterResult_PieceContent)(nil),
 	}
@@ -1249,7 +1360,7 @@ func file_pkg_rpc_scheduler_scheduler_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pkg_rpc_scheduler_scheduler_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,107 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package compressutils compresses and decompresses piece contents by piece style.
+package compressutils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+)
+
+// encoder is safe for concurrent EncodeAll calls
+var encoder, _ = zstd.NewWriter(nil)
+
+// ParsePieceStyle parses the piece style from its name, empty name means plain.
+func ParsePieceStyle(name string) (base.PieceStyle, error) {
+	if name == "" {
+		return base.PieceStyle_PLAIN, nil
+	}
+	style, ok := base.PieceStyle_value[strings.ToUpper(name)]
+	if !ok {
+		return base.PieceStyle_PLAIN, errors.Errorf("unknown piece style %s", name)
+	}
+	return base.PieceStyle(style), nil
+}
+
+// Compress compresses data with the piece style.
+func Compress(style base.PieceStyle, data []byte) ([]byte, error) {
+	switch style {
+	case base.PieceStyle_PLAIN:
+		return data, nil
+	case base.PieceStyle_ZSTD:
+		return encoder.EncodeAll(data, make([]byte, 0, len(data))), nil
+	case base.PieceStyle_GZIP:
+		buf := bytes.NewBuffer(make([]byte, 0, len(data)))
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, errors.Errorf("unsupported piece style %s", style)
+	}
+}
+
+// NewReader returns a reader decompressing r with the piece style.
+// The remaining data of r is drained after the decompressed content ends,
+// so the errors of r, like digest mismatch, are always returned.
+func NewReader(style base.PieceStyle, r io.Reader) (io.ReadCloser, error) {
+	switch style {
+	case base.PieceStyle_PLAIN:
+		return ioutil.NopCloser(r), nil
+	case base.PieceStyle_ZSTD:
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return &drainReader{ReadCloser: d.IOReadCloser(), src: r}, nil
+	case base.PieceStyle_GZIP:
+		d, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return &drainReader{ReadCloser: d, src: r}, nil
+	default:
+		return nil, errors.Errorf("unsupported piece style %s", style)
+	}
+}
+
+type drainReader struct {
+	io.ReadCloser
+	src io.Reader
+}
+
+func (d *drainReader) Read(p []byte) (int, error) {
+	n, err := d.ReadCloser.Read(p)
+	if err == io.EOF {
+		if _, derr := io.Copy(ioutil.Discard, d.src); derr != nil {
+			return n, derr
+		}
+	}
+	return n, err
+}
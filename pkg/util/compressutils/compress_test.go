@@ -0,0 +1,85 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compressutils
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	testifyassert "github.com/stretchr/testify/assert"
+
+	"d7y.io/dragonfly/v2/pkg/rpc/base"
+	"d7y.io/dragonfly/v2/pkg/util/digestutils"
+)
+
+func TestParsePieceStyle(t *testing.T) {
+	assert := testifyassert.New(t)
+	tests := []struct {
+		name    string
+		style   base.PieceStyle
+		wantErr bool
+	}{
+		{name: "", style: base.PieceStyle_PLAIN},
+		{name: "plain", style: base.PieceStyle_PLAIN},
+		{name: "zstd", style: base.PieceStyle_ZSTD},
+		{name: "GZIP", style: base.PieceStyle_GZIP},
+		{name: "lz4", wantErr: true},
+	}
+	for _, tt := range tests {
+		style, err := ParsePieceStyle(tt.name)
+		assert.Equal(tt.wantErr, err != nil, tt.name)
+		assert.Equal(tt.style, style, tt.name)
+	}
+}
+
+func TestCompressAndNewReader(t *testing.T) {
+	assert := testifyassert.New(t)
+	data := []byte(strings.Repeat("dragonfly compressed piece\n", 4096))
+	for _, style := range []base.PieceStyle{base.PieceStyle_PLAIN, base.PieceStyle_ZSTD, base.PieceStyle_GZIP} {
+		compressed, err := Compress(style, data)
+		assert.Nil(err, style.String())
+		if style != base.PieceStyle_PLAIN {
+			assert.Less(len(compressed), len(data), style.String())
+		}
+
+		r, err := NewReader(style, bytes.NewReader(compressed))
+		assert.Nil(err, style.String())
+		content, err := ioutil.ReadAll(r)
+		assert.Nil(err, style.String())
+		assert.Equal(data, content, style.String())
+		assert.Nil(r.Close())
+	}
+}
+
+func TestNewReaderReturnsSourceError(t *testing.T) {
+	assert := testifyassert.New(t)
+	data := []byte(strings.Repeat("dragonfly", 1024))
+	compressed, err := Compress(base.PieceStyle_ZSTD, data)
+	assert.Nil(err)
+
+	hash := md5.New()
+	hash.Write([]byte("other content"))
+	r, err := NewReader(base.PieceStyle_ZSTD,
+		digestutils.NewDigestReader(bytes.NewReader(compressed), hex.EncodeToString(hash.Sum(nil))))
+	assert.Nil(err)
+	_, err = ioutil.ReadAll(r)
+	assert.Equal(digestutils.ErrDigestNotMatch, err)
+}
@@ -20,15 +20,87 @@ import (
 	"bufio"
 	"crypto/md5"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
+	"fmt"
 	"hash"
 	"hash/crc32"
 	"io"
+	"strings"
 
 	"d7y.io/dragonfly/v2/pkg/unit"
 	"d7y.io/dragonfly/v2/pkg/util/fileutils"
 )
 
+const (
+	AlgorithmMD5    = "md5"
+	AlgorithmSHA256 = "sha256"
+	AlgorithmSHA512 = "sha512"
+)
+
+// Parse splits a digest in the form of algorithm:hex into its algorithm and encoded value.
+// A digest without algorithm is treated as md5 for compatibility.
+func Parse(digest string) (algorithm string, encoded string, err error) {
+	algorithm, encoded = AlgorithmMD5, digest
+	if i := strings.Index(digest, ":"); i >= 0 {
+		algorithm, encoded = strings.ToLower(digest[:i]), digest[i+1:]
+	}
+
+	h, err := NewHash(algorithm)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(encoded) != hex.EncodedLen(h.Size()) {
+		return "", "", fmt.Errorf("invalid %s digest length: %q", algorithm, digest)
+	}
+
+	if _, err := hex.DecodeString(encoded); err != nil {
+		return "", "", fmt.Errorf("invalid %s digest: %q", algorithm, digest)
+	}
+
+	return algorithm, strings.ToLower(encoded), nil
+}
+
+// Format returns the digest in the form of algorithm:hex.
+func Format(algorithm, encoded string) string {
+	return algorithm + ":" + encoded
+}
+
+// NewHash returns a hash for the given algorithm.
+func NewHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case AlgorithmMD5:
+		return md5.New(), nil
+	case AlgorithmSHA256:
+		return sha256.New(), nil
+	case AlgorithmSHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported digest algorithm: %q", algorithm)
+	}
+}
+
+// HashFile returns the hex digest of the file with the given algorithm.
+func HashFile(algorithm, name string) (string, error) {
+	h, err := NewHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := fileutils.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, bufio.NewReaderSize(f, int(4*unit.MB))); err != nil {
+		return "", err
+	}
+
+	return ToHashString(h), nil
+}
+
 func Sha256(values ...string) string {
 	if len(values) == 0 {
 		return ""
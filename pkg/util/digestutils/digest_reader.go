@@ -45,16 +45,29 @@ type DigestReader interface {
 
 // TODO add AF_ALG digest https://github.com/golang/sys/commit/e24f485414aeafb646f6fca458b0bf869c0880a1
 
+// NewDigestReader returns a reader which calculates the digest of contents read and,
+// when a digest is given, verifies it at EOF. The digest is a md5 hex or in the form
+// of algorithm:hex, see Parse.
 func NewDigestReader(reader io.Reader, digest ...string) io.Reader {
-	var d string
-	if len(digest) > 0 {
-		d = digest[0]
+	var (
+		d string
+		h hash.Hash = md5.New()
+	)
+	if len(digest) > 0 && digest[0] != "" {
+		algorithm, encoded, err := Parse(digest[0])
+		if err != nil {
+			// keep the raw digest, it never matches and the reader reports ErrDigestNotMatch
+			logger.Warnf("parse digest error: %s", err)
+			d = digest[0]
+		} else {
+			d = encoded
+			h, _ = NewHash(algorithm)
+		}
 	}
 	return &digestReader{
 		digest: d,
-		// TODO support more digest method like sha1, sha256
-		hash: md5.New(),
-		r:    reader,
+		hash:   h,
+		r:      reader,
 	}
 }
 
@@ -79,5 +92,5 @@ func (dr *digestReader) Read(p []byte) (int, error) {
 
 // GetDigest returns the digest of contents read.
 func (dr *digestReader) Digest() string {
-	return hex.EncodeToString(dr.hash.Sum(nil)[:16])
+	return hex.EncodeToString(dr.hash.Sum(nil))
 }
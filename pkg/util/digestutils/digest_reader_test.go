@@ -19,6 +19,7 @@ package digestutils
 import (
 	"bytes"
 	"crypto/md5"
+	"crypto/sha512"
 	"encoding/hex"
 	"io/ioutil"
 	"testing"
@@ -45,3 +46,21 @@ func TestNewDigestReader(t *testing.T) {
 	assert.Nil(err)
 	assert.Equal(testBytes, data)
 }
+
+func TestNewDigestReader_Algorithm(t *testing.T) {
+	assert := testifyassert.New(t)
+
+	testBytes := []byte("hello world")
+	hash := sha512.Sum512(testBytes)
+	digest := hex.EncodeToString(hash[:])
+
+	reader := NewDigestReader(bytes.NewBuffer(testBytes), Format(AlgorithmSHA512, digest))
+	data, err := ioutil.ReadAll(reader)
+	assert.Nil(err)
+	assert.Equal(testBytes, data)
+	assert.Equal(digest, reader.(DigestReader).Digest())
+
+	reader = NewDigestReader(bytes.NewBuffer(testBytes), Format(AlgorithmSHA256, digest[:64]))
+	_, err = ioutil.ReadAll(reader)
+	assert.Equal(ErrDigestNotMatch, err)
+}
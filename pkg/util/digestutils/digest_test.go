@@ -62,3 +62,47 @@ func TestMd5File(t *testing.T) {
 
 	assert.Equal(t, expected, Md5File(path))
 }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		digest    string
+		algorithm string
+		encoded   string
+		ok        bool
+	}{
+		{"5d41402abc4b2a76b9719d911017c592", AlgorithmMD5, "5d41402abc4b2a76b9719d911017c592", true},
+		{"md5:5d41402abc4b2a76b9719d911017c592", AlgorithmMD5, "5d41402abc4b2a76b9719d911017c592", true},
+		{"SHA256:2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824", AlgorithmSHA256, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", true},
+		{"sha256:5d41402abc4b2a76b9719d911017c592", "", "", false},
+		{"sha512:" + strings.Repeat("zz", 64), "", "", false},
+		{"sha1:aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tc := range tests {
+		algorithm, encoded, err := Parse(tc.digest)
+		if !tc.ok {
+			assert.NotNil(t, err, tc.digest)
+			continue
+		}
+		assert.Nil(t, err, tc.digest)
+		assert.Equal(t, tc.algorithm, algorithm)
+		assert.Equal(t, tc.encoded, encoded)
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	path := basic.TmpDir + "/" + uuid.New().String()
+	f, err := fileutils.OpenFile(path, syscall.O_CREAT|syscall.O_TRUNC|syscall.O_RDWR, 0644)
+	assert.Nil(t, err)
+
+	f.Write([]byte("hello"))
+	f.Close()
+
+	digest, err := HashFile(AlgorithmSHA256, path)
+	assert.Nil(t, err)
+	assert.Equal(t, Sha256("hello"), digest)
+
+	_, err = HashFile("sha1", path)
+	assert.NotNil(t, err)
+}
@@ -96,10 +96,11 @@ func (cm *CDNManager) TriggerTask(task *types.Task, callback func(peerTask *type
 
 	go safe.Call(func() {
 		stream, err := cm.client.ObtainSeeds(context.TODO(), &cdnsystem.SeedRequest{
-			TaskId:  task.TaskId,
-			Url:     task.Url,
-			Filter:  task.Filter,
-			UrlMeta: task.UrlMata,
+			TaskId:    task.TaskId,
+			Url:       task.Url,
+			Filter:    task.Filter,
+			UrlMeta:   task.UrlMata,
+			PeerCount: int32(cm.taskManager.PeerTask.Count(task)),
 		})
 		if err != nil {
 			logger.Warnf("receive a failure state from cdn: taskId[%s] error:%v", task.TaskId, err)
@@ -225,6 +226,10 @@ func (cm *CDNManager) processPieceSeed(task *types.Task, ps *cdnsystem.PieceSeed
 		}
 		host = cm.hostManager.Add(host)
 	}
+	cm.hostManager.UpdateCDNUploadLoad(host, ps.TotalUploadLoad, ps.FreeUploadLoad)
+	if ps.PieceSize > 0 {
+		task.PieceSize = ps.PieceSize
+	}
 	pid := ps.PeerId
 	peerTask, _ := cm.taskManager.PeerTask.Get(pid)
 	if peerTask == nil {
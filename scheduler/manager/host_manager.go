@@ -77,3 +77,20 @@ func (m *HostManager) CalculateLoad(host *types.Host) {
 		host.SetTotalDownloadLoad(HostLoadCDN)
 	}
 }
+
+// UpdateCDNUploadLoad sizes the upload load of a cdn host with the capacity reported by the cdn itself.
+// The free load is shared with other schedulers, so the total is capped by the load in use here plus the free load.
+func (m *HostManager) UpdateCDNUploadLoad(host *types.Host, total int32, free int32) {
+	if total <= 0 {
+		return
+	}
+	load := host.GetUploadLoad() + free
+	if load > total {
+		load = total
+	}
+	// keep one slot at least, the cdn may be the only parent of a new task
+	if load < 1 {
+		load = 1
+	}
+	host.SetTotalUploadLoad(load)
+}
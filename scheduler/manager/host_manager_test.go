@@ -0,0 +1,98 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package manager
+
+import (
+	"testing"
+
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	"d7y.io/dragonfly/v2/scheduler/types"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestHostManager_UpdateCDNUploadLoad(t *testing.T) {
+	tests := []struct {
+		name      string
+		inUse     int32
+		total     int32
+		free      int32
+		wantTotal int32
+		wantFree  int32
+	}{
+		{
+			name:      "not reported",
+			total:     0,
+			free:      0,
+			wantTotal: HostLoadCDN,
+			wantFree:  HostLoadCDN,
+		},
+		{
+			name:      "idle",
+			total:     20,
+			free:      20,
+			wantTotal: 20,
+			wantFree:  20,
+		},
+		{
+			name:      "shared with other schedulers",
+			inUse:     2,
+			total:     20,
+			free:      5,
+			wantTotal: 7,
+			wantFree:  5,
+		},
+		{
+			name:      "capped by total",
+			inUse:     2,
+			total:     20,
+			free:      20,
+			wantTotal: 20,
+			wantFree:  18,
+		},
+		{
+			name:      "busy in other schedulers",
+			total:     20,
+			free:      0,
+			wantTotal: 1,
+			wantFree:  1,
+		},
+		{
+			name:      "busy in this scheduler",
+			inUse:     3,
+			total:     20,
+			free:      0,
+			wantTotal: 3,
+			wantFree:  0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := testifyassert.New(t)
+			m := newHostManager()
+			host := m.Add(&types.Host{
+				Type:     types.HostTypeCdn,
+				PeerHost: scheduler.PeerHost{Uuid: "cdn"},
+			})
+			host.AddUploadLoad(tc.inUse)
+
+			m.UpdateCDNUploadLoad(host, tc.total, tc.free)
+			assert.Equal(tc.wantFree, host.GetFreeUploadLoad())
+			assert.Equal(float64(tc.inUse)/float64(tc.wantTotal), host.GetUploadLoadPercent())
+		})
+	}
+}
@@ -207,6 +207,22 @@ func (m *PeerTask) Walker(task *types.Task, limit int, walker func(pt *types.Pee
 	})
 }
 
+// Count returns the number of peer tasks downloading the task
+func (m *PeerTask) Count(task *types.Task) int {
+	if m.dataRanger == nil {
+		return 0
+	}
+	v, ok := m.dataRanger.Load(task)
+	if !ok {
+		return 0
+	}
+	ranger, ok := v.(*sortedlist.SortedList)
+	if !ok {
+		return 0
+	}
+	return ranger.Size()
+}
+
 func (m *PeerTask) WalkerReverse(task *types.Task, limit int, walker func(pt *types.PeerTask) bool) {
 	if walker == nil || m.dataRanger == nil {
 		return
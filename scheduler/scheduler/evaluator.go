@@ -155,6 +155,8 @@ func (e *evaluator) selectChildCandidates(peer *types.PeerTask) (list []*types.P
 			return true
 		} else if pt.Host.Type == types.HostTypeCdn {
 			return true
+		} else if pt.CdnOnly && (peer.Host == nil || peer.Host.Type != types.HostTypeCdn) {
+			return true
 		} else if peer.GetParent() != nil && peer.GetParent().DstPeerTask == pt {
 			return true
 		} else if peer.GetFreeLoad() < 1 {
@@ -196,6 +198,9 @@ func (e *evaluator) selectParentCandidates(peer *types.PeerTask) (list []*types.
 		} else if pt.GetFreeLoad() < 1 {
 			msg = append(msg, fmt.Sprintf("%s no load", pt.Pid))
 			return true
+		} else if peer.CdnOnly && (pt.Host == nil || pt.Host.Type != types.HostTypeCdn) {
+			msg = append(msg, fmt.Sprintf("%s is not cdn", pt.Pid))
+			return true
 		}
 		if pt.Success {
 			list = append(list, pt)
@@ -0,0 +1,67 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scheduler
+
+import (
+	"sort"
+	"testing"
+
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/manager"
+	"d7y.io/dragonfly/v2/scheduler/types"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestEvaluator_CdnOnly(t *testing.T) {
+	assert := testifyassert.New(t)
+	mgr := manager.New(config.New())
+	e := newEvaluator(withTaskManager(mgr.TaskManager))
+
+	task, _ := mgr.TaskManager.Add(&types.Task{TaskId: "cdn-only", Url: "http://example.com/blob"})
+	mgr.TaskManager.PeerTask.AddTask(task)
+	addPeerTask := func(pid string, hostType types.HostType) *types.PeerTask {
+		host := mgr.HostManager.Add(&types.Host{
+			Type:     hostType,
+			PeerHost: scheduler.PeerHost{Uuid: pid + "-host"},
+		})
+		return mgr.TaskManager.PeerTask.Add(pid, task, host)
+	}
+	pids := func(peerTasks []*types.PeerTask) []string {
+		var list []string
+		for _, pt := range peerTasks {
+			list = append(list, pt.Pid)
+		}
+		sort.Strings(list)
+		return list
+	}
+
+	cdn := addPeerTask("cdn", types.HostTypeCdn)
+	seed := addPeerTask("seed", types.HostTypePeer)
+	seed.Success = true
+	normal := addPeerTask("normal", types.HostTypePeer)
+	cdnOnly := addPeerTask("cdn-only", types.HostTypePeer)
+	cdnOnly.CdnOnly = true
+
+	// only cdn peers can be the parent of the cdn only peer
+	assert.Equal([]string{"cdn"}, pids(e.selectParentCandidates(cdnOnly)))
+	assert.Equal([]string{"cdn", "seed"}, pids(e.selectParentCandidates(normal)))
+
+	// the cdn only peer is never scheduled as the child of other peers
+	assert.Equal([]string{"cdn-only", "normal"}, pids(e.selectChildCandidates(cdn)))
+	assert.Equal([]string{"normal"}, pids(e.selectChildCandidates(seed)))
+}
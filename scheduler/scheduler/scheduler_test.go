@@ -0,0 +1,62 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scheduler
+
+import (
+	"testing"
+
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/manager"
+	"d7y.io/dragonfly/v2/scheduler/types"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_ScheduleParentByCDNUploadLoad(t *testing.T) {
+	assert := testifyassert.New(t)
+	mgr := manager.New(config.New())
+	s := New(config.SchedulerConfig{}, mgr.TaskManager)
+
+	task, _ := mgr.TaskManager.Add(&types.Task{TaskId: "cdn-upload-load", Url: "http://example.com/blob"})
+	mgr.TaskManager.PeerTask.AddTask(task)
+	addPeerTask := func(pid string, hostType types.HostType) *types.PeerTask {
+		host := mgr.HostManager.Add(&types.Host{
+			Type:     hostType,
+			PeerHost: scheduler.PeerHost{Uuid: pid + "-host"},
+		})
+		return mgr.TaskManager.PeerTask.Add(pid, task, host)
+	}
+	cdnA := addPeerTask("cdn-a", types.HostTypeCdn)
+	cdnB := addPeerTask("cdn-b", types.HostTypeCdn)
+	// both cdns are uploading to one peer of this scheduler
+	cdnA.Host.AddUploadLoad(1)
+	cdnB.Host.AddUploadLoad(1)
+
+	// cdn a is saturated by other schedulers
+	mgr.HostManager.UpdateCDNUploadLoad(cdnA.Host, 10, 0)
+	mgr.HostManager.UpdateCDNUploadLoad(cdnB.Host, 10, 5)
+	peer := addPeerTask("peer-1", types.HostTypePeer)
+	primary, _, _ := s.ScheduleParent(peer)
+	assert.Equal(cdnB, primary)
+
+	// the load of cdn a is released, and cdn b becomes busier
+	mgr.HostManager.UpdateCDNUploadLoad(cdnA.Host, 10, 8)
+	mgr.HostManager.UpdateCDNUploadLoad(cdnB.Host, 10, 1)
+	peer = addPeerTask("peer-2", types.HostTypePeer)
+	primary, _, _ = s.ScheduleParent(peer)
+	assert.Equal(cdnA, primary)
+}
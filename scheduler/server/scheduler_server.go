@@ -98,6 +98,10 @@ func (s *SchedulerServer) RegisterPeerTask(ctx context.Context, request *schedul
 	// get or create task
 	var isCdn = false
 	pkg.TaskId = s.service.GenerateTaskID(request.Url, request.Filter, request.UrlMata, request.BizId, request.PeerId)
+	if request.SeedInfo != nil {
+		err = s.registerSeedPeerTask(request, pkg)
+		return
+	}
 	task, _ := s.service.GetTask(pkg.TaskId)
 	if task == nil {
 		task = &types.Task{
@@ -136,10 +140,7 @@ func (s *SchedulerServer) RegisterPeerTask(ctx context.Context, request *schedul
 	hostId := request.PeerHost.Uuid
 	host, _ := s.service.GetHost(hostId)
 	if host == nil {
-		host = &types.Host{
-			Type:     types.HostTypePeer,
-			PeerHost: *request.PeerHost,
-		}
+		host = newHost(request.PeerHost)
 		if isCdn {
 			host.Type = types.HostTypeCdn
 		}
@@ -160,6 +161,7 @@ func (s *SchedulerServer) RegisterPeerTask(ctx context.Context, request *schedul
 	} else if peerTask.Host == nil {
 		peerTask.Host = host
 	}
+	peerTask.CdnOnly = request.CdnOnly
 
 	if isCdn {
 		peerTask.SetDown()
@@ -199,6 +201,88 @@ func (s *SchedulerServer) RegisterPeerTask(ctx context.Context, request *schedul
 	return
 }
 
+// registerSeedPeerTask registers a peer which already has the whole content of the task,
+// the peer is marked as finished, so it can be scheduled as a parent without cdn
+func (s *SchedulerServer) registerSeedPeerTask(request *scheduler.PeerTaskRequest, pkg *scheduler.RegisterResult) (err error) {
+	seed := request.SeedInfo
+	if seed.TotalPiece <= 0 || seed.PieceSize <= 0 {
+		return dferrors.New(dfcodes.BadRequest, fmt.Sprintf("invalid seed info, piece size: %d, total piece: %d", seed.PieceSize, seed.TotalPiece))
+	}
+	task, _ := s.service.GetTask(pkg.TaskId)
+	if task == nil {
+		task = s.service.AddSeedTask(&types.Task{
+			TaskId:  pkg.TaskId,
+			Url:     request.Url,
+			Filter:  request.Filter,
+			BizId:   request.BizId,
+			UrlMata: request.UrlMata,
+		})
+	}
+	if task.PieceTotal <= 0 {
+		task.PieceSize = seed.PieceSize
+		task.PieceTotal = seed.TotalPiece
+		task.ContentLength = seed.ContentLength
+	}
+	pkg.TaskId = task.TaskId
+	pkg.SizeScope = base.SizeScope_NORMAL
+
+	host, _ := s.service.GetHost(request.PeerHost.Uuid)
+	if host == nil {
+		host, err = s.service.AddHost(newHost(request.PeerHost))
+		if err != nil {
+			return
+		}
+	}
+
+	// the seed is registered again with a new host after the daemon restarts
+	peerTask, _ := s.service.GetPeerTask(request.PeerId)
+	if peerTask == nil {
+		peerTask, err = s.service.AddPeerTask(request.PeerId, task, host)
+		if err != nil {
+			return
+		}
+	} else if peerTask.Host != host {
+		peerTask.Host.DeletePeerTask(peerTask.Pid)
+		peerTask.Host = host
+		host.AddPeerTask(peerTask)
+	}
+	if peerTask.IsDown() {
+		peerTask.SetUp()
+	}
+	for pieceNum := int32(0); pieceNum < seed.TotalPiece; pieceNum++ {
+		peerTask.AddPieceStatus(&scheduler.PieceResult{
+			TaskId:        task.TaskId,
+			SrcPid:        request.PeerId,
+			PieceNum:      pieceNum,
+			Success:       true,
+			FinishedCount: pieceNum + 1,
+		})
+	}
+	peerTask.SetStatus(0, 0, true, dfcodes.Success)
+	peerTask.SetNodeStatus(types.PeerTaskStatusDone)
+	logger.Infof("[%s][%s]: register seed peer, content length %d, total piece %d",
+		task.TaskId, request.PeerId, seed.ContentLength, seed.TotalPiece)
+	return
+}
+
+// newHost creates a peer host, the fields are copied one by one since the proto message must not be copied
+func newHost(peerHost *scheduler.PeerHost) *types.Host {
+	return &types.Host{
+		Type: types.HostTypePeer,
+		PeerHost: scheduler.PeerHost{
+			Uuid:           peerHost.Uuid,
+			Ip:             peerHost.Ip,
+			RpcPort:        peerHost.RpcPort,
+			DownPort:       peerHost.DownPort,
+			HostName:       peerHost.HostName,
+			SecurityDomain: peerHost.SecurityDomain,
+			Location:       peerHost.Location,
+			Idc:            peerHost.Idc,
+			NetTopology:    peerHost.NetTopology,
+		},
+	}
+}
+
 func (s *SchedulerServer) ReportPieceResult(stream scheduler.Scheduler_ReportPieceResultServer) (err error) {
 	defer func() {
 		e := recover()
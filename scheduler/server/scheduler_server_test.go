@@ -0,0 +1,105 @@
+/*
+ *     Copyright 2020 The Dragonfly Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"d7y.io/dragonfly/v2/pkg/dfcodes"
+	"d7y.io/dragonfly/v2/pkg/dferrors"
+	"d7y.io/dragonfly/v2/pkg/rpc/scheduler"
+	"d7y.io/dragonfly/v2/scheduler/config"
+	"d7y.io/dragonfly/v2/scheduler/service"
+	"d7y.io/dragonfly/v2/scheduler/types"
+	testifyassert "github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerServer_RegisterSeedPeerTask(t *testing.T) {
+	assert := testifyassert.New(t)
+	cfg := config.New()
+	svc := service.NewSchedulerService(cfg)
+	s := NewSchedulerServer(cfg, WithSchedulerService(svc))
+
+	seedRequest := func(hostID string) *scheduler.PeerTaskRequest {
+		return &scheduler.PeerTaskRequest{
+			Url:    "d7y://cache/seed",
+			PeerId: "seed-peer",
+			PeerHost: &scheduler.PeerHost{
+				Uuid:     hostID,
+				Ip:       "127.0.0.1",
+				RpcPort:  65000,
+				DownPort: 65002,
+			},
+			SeedInfo: &scheduler.SeedInfo{
+				ContentLength: 10 << 20,
+				PieceSize:     4 << 20,
+				TotalPiece:    3,
+			},
+		}
+	}
+	assertSeed := func(taskID string, hostID string) *types.PeerTask {
+		task, _ := svc.GetTask(taskID)
+		assert.NotNil(task)
+		assert.Equal(int64(10<<20), task.ContentLength)
+		assert.Equal(int32(4<<20), task.PieceSize)
+		assert.Equal(int32(3), task.PieceTotal)
+		peerTask, _ := svc.GetPeerTask("seed-peer")
+		assert.NotNil(peerTask)
+		assert.True(peerTask.Success)
+		assert.Equal(types.PeerTaskStatusDone, peerTask.GetNodeStatus())
+		assert.Equal(int32(3), peerTask.GetFinishedNum())
+		assert.False(peerTask.IsDown())
+		assert.Equal(hostID, peerTask.Host.Uuid)
+		assert.Equal(int32(65002), peerTask.Host.DownPort)
+		host, _ := svc.GetHost(hostID)
+		assert.Equal(peerTask, host.GetPeerTask("seed-peer"))
+		return peerTask
+	}
+
+	// invalid seed info
+	req := seedRequest("host-0")
+	req.SeedInfo.PieceSize = 0
+	_, err := s.RegisterPeerTask(context.Background(), req)
+	assert.NotNil(err)
+	if de, ok := err.(*dferrors.DfError); assert.True(ok) {
+		assert.Equal(dfcodes.BadRequest, de.Code)
+	}
+
+	result, err := s.RegisterPeerTask(context.Background(), seedRequest("host-0"))
+	assert.Nil(err)
+	peerTask := assertSeed(result.TaskId, "host-0")
+
+	// registering again is idempotent
+	_, err = s.RegisterPeerTask(context.Background(), seedRequest("host-0"))
+	assert.Nil(err)
+	assert.Equal(peerTask, assertSeed(result.TaskId, "host-0"))
+
+	// the daemon restarts with a new host
+	_, err = s.RegisterPeerTask(context.Background(), seedRequest("host-1"))
+	assert.Nil(err)
+	assert.Equal(peerTask, assertSeed(result.TaskId, "host-1"))
+	oldHost, _ := svc.GetHost("host-0")
+	assert.Nil(oldHost.GetPeerTask("seed-peer"))
+
+	// the seed is registered again after the scheduler reclaims it
+	assert.Nil(svc.DeletePeerTask("seed-peer"))
+	svc.TaskManager.Delete(result.TaskId)
+	_, err = s.RegisterPeerTask(context.Background(), seedRequest("host-1"))
+	assert.Nil(err)
+	assert.NotEqual(peerTask, assertSeed(result.TaskId, "host-1"))
+}
@@ -71,6 +71,13 @@ func (s *SchedulerService) AddTask(task *types.Task) (ret *types.Task, err error
 	return
 }
 
+// AddSeedTask adds the task whose whole content is held by a seed peer, cdn is never triggered for it
+func (s *SchedulerService) AddSeedTask(task *types.Task) (ret *types.Task) {
+	ret, _ = s.TaskManager.Add(task)
+	s.TaskManager.PeerTask.AddTask(ret)
+	return
+}
+
 func (s *SchedulerService) ScheduleParent(task *types.PeerTask) (primary *types.PeerTask,
 	secondary []*types.PeerTask, err error) {
 	return s.Scheduler.ScheduleParent(task)
@@ -65,6 +65,8 @@ type PeerTask struct {
 	Cost    uint32
 	Success bool
 	Code    base.Code
+	// CdnOnly indicates only cdn peers can be the parent
+	CdnOnly bool
 
 	status  PeerTaskStatus
 	jobData interface{}
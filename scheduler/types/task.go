@@ -43,6 +43,7 @@ type Task struct {
 	rwLock        *sync.RWMutex
 	PieceList     map[int32]*Piece // Piece list
 	PieceTotal    int32            // the total number of Pieces, set > 0 when cdn finished
+	PieceSize     int32            // the piece size decided by cdn, set > 0 when cdn started
 	ContentLength int64
 	Statistic     *metrics.TaskStatistic
 	Removed       bool